@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"ttanalytic/internal/config"
+	pgprovider "ttanalytic/internal/infrastructure"
+	"ttanalytic/internal/infrastructure/dbtx"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/repo"
+	"ttanalytic/internal/service"
+	"ttanalytic/internal/tenant"
+
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+)
+
+const usage = `usage:
+  apikey create [-workspace default] -name <name> -scopes read,track,admin
+  apikey list   [-workspace default]
+  apikey revoke [-workspace default] <key_id>`
+
+// apikey manages API keys directly in the database, used to bootstrap the first admin key
+// of a workspace; create also creates the workspace if it does not exist
+func main() {
+	_ = godotenv.Load(".env")
+
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	cfg, err := config.ParseConfig()
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	logger := zap.NewNop().Sugar()
+
+	db := pgprovider.NewProvider(
+		logger,
+		cfg.SQLDataBase.Server,
+		cfg.SQLDataBase.Database,
+		cfg.SQLDataBase.Username,
+		cfg.SQLDataBase.Password,
+		cfg.SQLDataBase.Port,
+		cfg.SQLDataBase.MaxIdleConns,
+		cfg.SQLDataBase.MaxOpenConns,
+		cfg.SQLDataBase.ConnMaxLifetimeMin,
+	)
+	if err := db.Open(ctx); err != nil {
+		log.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+
+	r := repo.NewRepository(db.DB(), logger, cfg.SQLDataBase.QueryTimeoutSec)
+	keys := service.NewAPIKeyService(r, logger, dbtx.NewTransactor(db.DB()), r)
+
+	// changes made here are audited as the CLI, there is no API key in the context
+	ctx = service.WithActor(ctx, models.ActorCLI)
+
+	if err := run(ctx, r, keys, os.Args[1], os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, r *repo.Repository, keys *service.APIKeyService, cmd string, args []string) error {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	workspace := fs.String("workspace", "default", "workspace name")
+	name := fs.String("name", "", "key name (create)")
+	scopes := fs.String("scopes", models.ScopeRead, "comma separated scopes: read, track, admin (create)")
+	_ = fs.Parse(args)
+
+	var (
+		ws  *models.Workspace
+		err error
+	)
+	if cmd == "create" {
+		ws, err = r.EnsureWorkspace(ctx, *workspace)
+	} else {
+		ws, err = r.FindWorkspaceByName(ctx, *workspace)
+	}
+	if errors.Is(err, models.ErrNotFound) {
+		return fmt.Errorf("workspace %q does not exist", *workspace)
+	}
+	if err != nil {
+		return fmt.Errorf("resolve workspace %q: %w", *workspace, err)
+	}
+
+	ctx = tenant.NewContext(ctx, ws.ID)
+
+	switch cmd {
+	case "create":
+		req := models.CreateAPIKeyRequest{Name: *name, Scopes: strings.Split(*scopes, ",")}
+		if err := req.Validate(); err != nil {
+			return err
+		}
+
+		resp, err := keys.CreateAPIKey(ctx, req)
+		if err != nil {
+			return fmt.Errorf("create api key: %w", err)
+		}
+
+		fmt.Printf("key_id=%d workspace=%s name=%s scopes=%s\n", resp.KeyID, ws.Name, resp.Name, strings.Join(resp.Scopes, ","))
+		fmt.Println("store this key now, it is not shown again:")
+		fmt.Println(resp.Key)
+
+	case "list":
+		list, err := keys.ListAPIKeys(ctx)
+		if err != nil {
+			return fmt.Errorf("list api keys: %w", err)
+		}
+
+		for _, k := range list {
+			status := "active"
+			if k.RevokedAt != nil {
+				status = "revoked"
+			}
+			fmt.Printf("%d\t%s\t%s\t%s\t%s\n", k.KeyID, k.Prefix, k.Name, strings.Join(k.Scopes, ","), status)
+		}
+
+	case "revoke":
+		if fs.NArg() != 1 {
+			return errors.New(usage)
+		}
+
+		keyID, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid key_id %q", fs.Arg(0))
+		}
+
+		if err := keys.RevokeAPIKey(ctx, keyID); err != nil {
+			return fmt.Errorf("revoke api key %d: %w", keyID, err)
+		}
+
+		fmt.Printf("key %d revoked\n", keyID)
+
+	default:
+		return fmt.Errorf("unknown command %q\n%s", cmd, usage)
+	}
+
+	return nil
+}
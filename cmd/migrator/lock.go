@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// lockKey is the advisory lock held for a whole migrator run. golang-migrate takes its own
+// lock per migration call only, this one also covers the version read and plan in between.
+const lockKey int64 = 0x7474_6d69_6772 // "ttmigr"
+
+// acquireLock waits up to timeout for the migrator lock on a dedicated session.
+// The returned func releases it; it is also released if the process dies.
+func acquireLock(ctx context.Context, dsn string, timeout time.Duration) (func(), error) {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("lock connection: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for waited := false; ; waited = true {
+		var ok bool
+		if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, lockKey).Scan(&ok); err != nil {
+			_ = conn.Close(ctx)
+			return nil, fmt.Errorf("take migrator lock: %w", err)
+		}
+		if ok {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			_ = conn.Close(ctx)
+			return nil, fmt.Errorf("another migrator still holds the lock after %s", timeout)
+		}
+		if !waited {
+			log.Printf("another migrator is running, waiting up to %s", timeout)
+		}
+		time.Sleep(time.Second)
+	}
+
+	return func() {
+		_, _ = conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey)
+		_ = conn.Close(context.Background())
+	}, nil
+}
@@ -1,52 +1,288 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"log"
 	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
 
 	"ttanalytic/internal/config"
+	"ttanalytic/migrations"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/joho/godotenv"
 )
 
+const usage = `usage: migrator [-dir migrations] [-dry-run] [-lock-timeout 1m] <command>
+
+  up [N]        apply all pending migrations, or the next N (the default command)
+  down N        roll back the last N migrations
+  goto V        migrate up or down to version V
+  force V       set the version without running anything, to recover a dirty schema; -1 for none
+  version       print the current version
+  status        list applied and pending migrations
+  create NAME   add empty NNN_NAME.up.sql and .down.sql files to -dir
+
+Migrations are read from the binary unless -dir is set. -dry-run prints what up, down and goto would run.`
+
+var namePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+type options struct {
+	dir         string
+	dryRun      bool
+	lockTimeout time.Duration
+}
+
 func main() {
 	_ = godotenv.Load(".env")
 
+	var opts options
+	flag.StringVar(&opts.dir, "dir", "", "read migrations from this directory instead of the binary; create writes here (default migrations)")
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "print the migrations up, down and goto would run")
+	flag.DurationVar(&opts.lockTimeout, "lock-timeout", time.Minute, "how long to wait for another migrator to finish")
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+
+	cmd, args := "up", flag.Args()
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
+
+	if cmd == "create" {
+		if err := create(opts.dir, args); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	cfg, err := config.ParseConfig()
 	if err != nil {
 		log.Fatalf("load config: %v", err)
 	}
 
-	user := url.QueryEscape(cfg.SQLDataBase.Username)
-	pass := url.QueryEscape(cfg.SQLDataBase.Password)
+	if err := run(context.Background(), dsn(cfg), opts, cmd, args); err != nil {
+		log.Fatal(err)
+	}
+}
 
-	dsn := fmt.Sprintf(
+func dsn(cfg *config.Config) string {
+	return fmt.Sprintf(
 		"postgres://%s:%s@%s:%d/%s?sslmode=disable",
-		user,
-		pass,
+		url.QueryEscape(cfg.SQLDataBase.Username),
+		url.QueryEscape(cfg.SQLDataBase.Password),
 		cfg.SQLDataBase.Server,
 		cfg.SQLDataBase.Port,
 		cfg.SQLDataBase.Database,
 	)
+}
+
+func run(ctx context.Context, dsn string, opts options, cmd string, args []string) error {
+	fsys := fs.FS(migrations.FS)
+	if opts.dir != "" {
+		fsys = os.DirFS(opts.dir)
+	}
 
-	m, err := migrate.New("file://migrations", dsn)
+	all, err := migrations.List(fsys)
 	if err != nil {
-		log.Fatalf("migrate new: %v", err)
+		return fmt.Errorf("list migrations: %w", err)
+	}
+
+	source, err := iofs.New(fsys, ".")
+	if err != nil {
+		return fmt.Errorf("open migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", source, dsn)
+	if err != nil {
+		return fmt.Errorf("migrate new: %w", err)
 	}
 	defer m.Close()
+	m.Log = logger{}
+
+	// read-only commands do not wait for a running migration
+	if cmd != "version" && cmd != "status" && !opts.dryRun {
+		unlock, err := acquireLock(ctx, dsn, opts.lockTimeout)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	current, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		current, dirty, err = 0, false, nil
+	}
+	if err != nil {
+		return fmt.Errorf("read version: %w", err)
+	}
+
+	switch cmd {
+	case "version":
+		fmt.Println(versionString(current, dirty))
+		return nil
 
-	if err := m.Up(); err != nil {
-		if errors.Is(err, migrate.ErrNoChange) {
-			log.Println("no migrations to apply")
-			return
+	case "status":
+		fmt.Printf("version %s\n", versionString(current, dirty))
+		for _, mig := range all {
+			state := "pending"
+			switch {
+			case mig.Version == current && dirty:
+				state = "dirty"
+			case mig.Version <= current:
+				state = "applied"
+			}
+			fmt.Printf("  %-8s %s\n", state, mig)
 		}
-		log.Fatalf("migrate up: %v", err)
+		return nil
+
+	case "force":
+		v, err := argInt(args, "force V")
+		if err != nil {
+			return err
+		}
+		if opts.dryRun {
+			fmt.Printf("would force version %d\n", v)
+			return nil
+		}
+		if err := m.Force(v); err != nil {
+			return fmt.Errorf("force %d: %w", v, err)
+		}
+		log.Printf("version forced to %d", v)
+		return nil
+	}
+
+	if dirty {
+		return fmt.Errorf("schema version %d is dirty: fix it by hand, then run force", current)
 	}
 
-	log.Println("migrations applied")
+	var target uint
+	switch cmd {
+	case "up":
+		n := len(all)
+		if len(args) > 0 {
+			if n, err = argInt(args, "up [N]"); err != nil || n <= 0 {
+				return fmt.Errorf("up N: N must be a positive number")
+			}
+		}
+		target = migrations.Target(all, current, n)
+
+	case "down":
+		n, err := argInt(args, "down N")
+		if err != nil || n <= 0 {
+			return fmt.Errorf("down N: N must be a positive number")
+		}
+		target = migrations.Target(all, current, -n)
+
+	case "goto":
+		v, err := argInt(args, "goto V")
+		if err != nil || v < 0 {
+			return fmt.Errorf("goto V: V must be a version")
+		}
+		target = uint(v)
+
+	default:
+		return fmt.Errorf("unknown command %q\n%s", cmd, usage)
+	}
+
+	steps := migrations.Plan(all, current, target)
+	if len(steps) == 0 {
+		log.Printf("no migrations to apply, version %d", current)
+		return nil
+	}
+
+	if opts.dryRun {
+		fmt.Printf("version %d -> %d, would run:\n", current, target)
+		for _, s := range steps {
+			fmt.Printf("  %s\n", s)
+		}
+		return nil
+	}
+
+	if target == 0 {
+		err = m.Down()
+	} else {
+		err = m.Migrate(target)
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrate %d -> %d: %w", current, target, err)
+	}
+
+	log.Printf("migrated %d -> %d (%d migrations)", current, target, len(steps))
+	return nil
+}
+
+// create adds the next version after the highest one found in dir
+func create(dir string, args []string) error {
+	if len(args) != 1 || !namePattern.MatchString(args[0]) {
+		return fmt.Errorf("create NAME: NAME must be lower case letters, digits and underscores")
+	}
+	if dir == "" {
+		dir = "migrations"
+	}
+
+	all, err := migrations.List(os.DirFS(dir))
+	if err != nil {
+		return fmt.Errorf("list migrations: %w", err)
+	}
+
+	next := migrations.Migration{Version: 1, Name: args[0]}
+	if len(all) > 0 {
+		next.Version = all[len(all)-1].Version + 1
+	}
+
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%s.%s.sql", next, direction))
+
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", path, err)
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		fmt.Println(path)
+	}
+
+	return nil
+}
+
+func argInt(args []string, form string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("usage: migrator %s", form)
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("%s: %q is not a number", form, args[0])
+	}
+
+	return n, nil
 }
+
+func versionString(v uint, dirty bool) string {
+	switch {
+	case v == 0:
+		return "0 (no migrations applied)"
+	case dirty:
+		return fmt.Sprintf("%d (dirty)", v)
+	default:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// logger prints every migration golang-migrate runs
+type logger struct{}
+
+func (logger) Printf(format string, v ...any) { log.Printf(format, v...) }
+func (logger) Verbose() bool                  { return false }
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"ttanalytic/internal/config"
+	pgprovider "ttanalytic/internal/infrastructure"
+	"ttanalytic/internal/infrastructure/dbtx"
+	tiktokprovider "ttanalytic/internal/infrastructure/tiktok_provider"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/repo"
+	"ttanalytic/internal/service"
+	"ttanalytic/internal/tenant"
+
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+)
+
+const usage = `usage: ttctl <command> [-workspace default] [-o table|json] [args]
+
+  track URL                      start tracking a video (polls Ensemble once)
+  stop VIDEO_ID                  stop polling a video
+  resume VIDEO_ID                poll a stopped or errored video again
+  errors                         list videos stopped by a provider error
+  refresh VIDEO_ID               poll a video through Ensemble now and store the snapshot
+  recompute-earnings [-history]  rewrite earnings from views at the configured rate
+  import FILE                    track every URL of FILE, one per line, # for comments, - for stdin
+  summary [-top 10]              totals of the workspace and its top videos by earnings`
+
+// ttctl runs operational tasks against the database and Ensemble with the service configuration
+func main() {
+	_ = godotenv.Load(".env")
+
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "help" {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.ParseConfig()
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger := zap.NewNop().Sugar()
+
+	db := pgprovider.NewProvider(
+		logger,
+		cfg.SQLDataBase.Server,
+		cfg.SQLDataBase.Database,
+		cfg.SQLDataBase.Username,
+		cfg.SQLDataBase.Password,
+		cfg.SQLDataBase.Port,
+		cfg.SQLDataBase.MaxIdleConns,
+		cfg.SQLDataBase.MaxOpenConns,
+		cfg.SQLDataBase.ConnMaxLifetimeMin,
+	)
+	if err := db.Open(ctx); err != nil {
+		log.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+
+	r := repo.NewRepository(db.DB(), logger, cfg.SQLDataBase.QueryTimeoutSec)
+	transactor := dbtx.NewTransactor(db.DB())
+
+	provider, err := tiktokprovider.NewClient(
+		&http.Client{Timeout: time.Duration(cfg.Provider.TimeoutSec) * time.Second},
+		tiktokprovider.Config{
+			BaseURL:         cfg.Provider.URL,
+			APIKey:          cfg.Provider.Token,
+			MaxRetriesCount: cfg.Provider.MaxRetriesCount,
+			RetryTimeout:    cfg.Provider.RetryTimeout,
+			MaxRetryDelay:   cfg.Provider.MaxRetryDelay,
+			CallTimeout:     cfg.Provider.CallTimeout,
+			MaxBatchSize:    cfg.Provider.MaxBatchSize,
+			MaxPostPages:    cfg.Provider.MaxPostPages,
+		},
+		logger,
+		nil,
+	)
+	if err != nil {
+		log.Fatalf("provider: %v", err)
+	}
+
+	earnings := service.EarningsConfig{Rate: cfg.Earnings.Rate, Per: cfg.Earnings.Per}
+
+	c := &ctl{
+		repo:    r,
+		videos:  service.NewService(r, provider, earnings, logger, transactor, r, r),
+		updater: service.NewUpdaterService(r, provider, logger, service.UpdaterConfig{}, earnings, transactor, r, r, nil),
+	}
+
+	// changes made here are audited as the CLI, there is no API key in the context
+	ctx = service.WithActor(ctx, models.ActorCLI)
+
+	if err := c.run(ctx, os.Args[1], os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type ctl struct {
+	repo    *repo.Repository
+	videos  *service.Service
+	updater *service.UpdaterService
+	json    bool
+}
+
+func (c *ctl) run(ctx context.Context, cmd string, args []string) error {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	workspace := fs.String("workspace", "default", "workspace name")
+	output := fs.String("o", "table", "output: table or json")
+	history := fs.Bool("history", false, "also rewrite the earnings of every snapshot (recompute-earnings)")
+	top := fs.Int("top", 10, "number of top videos (summary)")
+	_ = fs.Parse(args)
+	args = fs.Args()
+
+	switch *output {
+	case "table":
+	case "json":
+		c.json = true
+	default:
+		return fmt.Errorf("unknown output %q, use table or json", *output)
+	}
+
+	ws, err := c.repo.FindWorkspaceByName(ctx, *workspace)
+	if errors.Is(err, models.ErrNotFound) {
+		return fmt.Errorf("workspace %q does not exist", *workspace)
+	}
+	if err != nil {
+		return fmt.Errorf("resolve workspace %q: %w", *workspace, err)
+	}
+
+	ctx = tenant.NewContext(ctx, ws.ID)
+
+	switch cmd {
+	case "track":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: ttctl track URL")
+		}
+		video, err := c.track(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		return c.printVideos([]models.TrackVideoResponse{video})
+
+	case "stop", "resume":
+		videoID, err := videoIDArg(cmd, args)
+		if err != nil {
+			return err
+		}
+		if cmd == "stop" {
+			err = c.videos.StopTracking(ctx, videoID)
+		} else {
+			err = c.videos.ResumeTracking(ctx, videoID)
+		}
+		if err != nil {
+			return fmt.Errorf("%s video %d: %w", cmd, videoID, err)
+		}
+		return c.printResult(map[string]any{"video_id": videoID, "action": cmd})
+
+	case "errors":
+		return c.listErrors(ctx)
+
+	case "refresh":
+		videoID, err := videoIDArg(cmd, args)
+		if err != nil {
+			return err
+		}
+		video, result, err := c.updater.RefreshVideo(ctx, videoID)
+		if err != nil {
+			return fmt.Errorf("refresh video %d: %w", videoID, err)
+		}
+		return c.printResult(map[string]any{
+			"video_id": video.ID,
+			"result":   result,
+			"views":    video.CurrentViews,
+			"earnings": video.CurrentEarnings,
+		})
+
+	case "recompute-earnings":
+		n, err := c.videos.RecomputeEarnings(ctx, *history)
+		if err != nil {
+			return fmt.Errorf("recompute earnings: %w", err)
+		}
+		return c.printResult(map[string]any{"videos": n, "history": *history})
+
+	case "import":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: ttctl import FILE")
+		}
+		return c.importFile(ctx, args[0])
+
+	case "summary":
+		summary, err := c.videos.PortfolioSummary(ctx, *top)
+		if err != nil {
+			return fmt.Errorf("summary: %w", err)
+		}
+		return c.printSummary(summary)
+
+	default:
+		return fmt.Errorf("unknown command %q\n%s", cmd, usage)
+	}
+}
+
+func (c *ctl) track(ctx context.Context, videoURL string) (models.TrackVideoResponse, error) {
+	tikTokID, ok := tiktokprovider.VideoIDFromURL(videoURL)
+	if !ok {
+		return models.TrackVideoResponse{}, fmt.Errorf("%s: not a TikTok video URL", videoURL)
+	}
+
+	video, err := c.videos.TrackVideo(ctx, models.TrackVideoRequest{URL: videoURL, TikTokID: tikTokID})
+	if err != nil {
+		return models.TrackVideoResponse{}, fmt.Errorf("track %s: %w", videoURL, err)
+	}
+
+	return video, nil
+}
+
+// importFile tracks every URL and keeps going on failures; it fails if any URL failed
+func (c *ctl) importFile(ctx context.Context, path string) error {
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var (
+		tracked []models.TrackVideoResponse
+		failed  int
+	)
+
+	scanner := bufio.NewScanner(in)
+	for line := 1; scanner.Scan(); line++ {
+		videoURL := strings.TrimSpace(scanner.Text())
+		if videoURL == "" || strings.HasPrefix(videoURL, "#") {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		video, err := c.track(ctx, videoURL)
+		if err != nil {
+			failed++
+			log.Printf("line %d: %v", line, err)
+			continue
+		}
+		tracked = append(tracked, video)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+
+	if err := c.printVideos(tracked); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d URLs failed", failed, failed+len(tracked))
+	}
+
+	return nil
+}
+
+func (c *ctl) listErrors(ctx context.Context) error {
+	type erroredVideo struct {
+		VideoID     int64  `json:"video_id"`
+		TikTokID    string `json:"tiktok_id"`
+		URL         string `json:"url"`
+		LastError   string `json:"last_error"`
+		LastErrorAt string `json:"last_error_at"`
+	}
+
+	list := []erroredVideo{}
+	err := c.repo.StreamVideos(ctx, models.VideoListFilter{Status: models.VideoStatusError}, func(v models.Video) error {
+		e := erroredVideo{VideoID: v.ID, TikTokID: v.TikTokID, URL: v.URL}
+		if v.LastError != nil {
+			e.LastError = *v.LastError
+		}
+		if v.LastErrorAt != nil {
+			e.LastErrorAt = v.LastErrorAt.UTC().Format(time.RFC3339)
+		}
+		list = append(list, e)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("list errored videos: %w", err)
+	}
+
+	if c.json {
+		return printJSON(list)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VIDEO_ID\tTIKTOK_ID\tLAST_ERROR_AT\tLAST_ERROR")
+	for _, e := range list {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", e.VideoID, e.TikTokID, e.LastErrorAt, e.LastError)
+	}
+	return w.Flush()
+}
+
+func (c *ctl) printVideos(videos []models.TrackVideoResponse) error {
+	if c.json {
+		return printJSON(videos)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VIDEO_ID\tTIKTOK_ID\tSTATUS\tVIEWS\tEARNINGS\tUPDATED")
+	for _, v := range videos {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%.2f %s\t%s\n",
+			v.VideoID, v.TikTokID, v.Status, v.CurrentViews, v.CurrentEarnings, v.Currency, v.LastUpdatedAt)
+	}
+	return w.Flush()
+}
+
+func (c *ctl) printSummary(s models.PortfolioSummary) error {
+	if c.json {
+		return printJSON(s)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "videos\t%d\t(active %d, stopped %d, error %d)\n", s.Videos, s.Active, s.Stopped, s.Errored)
+	fmt.Fprintf(w, "views\t%d\n", s.TotalViews)
+	fmt.Fprintf(w, "earnings\t%.2f %s\n", s.TotalEarnings, s.Currency)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(s.Top) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	return c.printVideos(s.Top)
+}
+
+func (c *ctl) printResult(result map[string]any) error {
+	if c.json {
+		return printJSON(result)
+	}
+
+	keys := make([]string, 0, len(result))
+	for k := range result {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s\t%v\n", k, result[k])
+	}
+	return w.Flush()
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func videoIDArg(cmd string, args []string) (int64, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("usage: ttctl %s VIDEO_ID", cmd)
+	}
+
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%s: invalid video id %q", cmd, args[0])
+	}
+
+	return id, nil
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"ttanalytic/internal/api/handlers"
+	"ttanalytic/internal/auth"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+)
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, rawKey string) (*models.APIKey, error)
+}
+
+// authenticate resolves the API key from "Authorization: Bearer <key>" or "X-API-Key"
+// and scopes the request to the key's workspace
+func authenticate(authn Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, err := authn.Authenticate(r.Context(), apiKeyFromRequest(r))
+			if err != nil {
+				if errors.Is(err, models.ErrUnauthorized) {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+					writeError(w, http.StatusUnauthorized, "Unauthorized")
+					return
+				}
+				writeError(w, http.StatusInternalServerError, "Internal server error")
+				return
+			}
+
+			ctx := auth.NewContext(r.Context(), key)
+			ctx = tenant.NewContext(ctx, key.WorkspaceID)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// defaultWorkspace scopes every request to the default workspace when auth is disabled
+func defaultWorkspace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(tenant.NewContext(r.Context(), models.DefaultWorkspaceID)))
+	})
+}
+
+// requireScope rejects keys without the scope; it is a no-op when auth is disabled
+func requireScope(enabled bool, scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := auth.FromContext(r.Context())
+			if !ok {
+				writeError(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+
+			if !key.HasScope(scope) {
+				writeError(w, http.StatusForbidden, "Forbidden: missing scope "+scope)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+
+	const bearer = "Bearer "
+	if h := r.Header.Get("Authorization"); len(h) > len(bearer) && strings.EqualFold(h[:len(bearer)], bearer) {
+		return strings.TrimSpace(h[len(bearer):])
+	}
+
+	return ""
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(handlers.ErrorResponse{Error: message})
+}
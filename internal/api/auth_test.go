@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"ttanalytic/internal/models"
+)
+
+type stubAuthenticator map[string]*models.APIKey
+
+func (s stubAuthenticator) Authenticate(_ context.Context, rawKey string) (*models.APIKey, error) {
+	if rawKey == "broken" {
+		return nil, errors.New("db down")
+	}
+
+	key, ok := s[rawKey]
+	if !ok {
+		return nil, models.ErrUnauthorized
+	}
+
+	return key, nil
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	authn := stubAuthenticator{
+		"reader": {ID: 1, Scopes: []string{models.ScopeRead}},
+		"admin":  {ID: 2, Scopes: []string{models.ScopeAdmin}},
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		scope  string
+		want   int
+	}{
+		{name: "no key", scope: models.ScopeRead, want: http.StatusUnauthorized},
+		{name: "unknown key", header: "X-API-Key", value: "nope", scope: models.ScopeRead, want: http.StatusUnauthorized},
+		{name: "authenticator failure", header: "X-API-Key", value: "broken", scope: models.ScopeRead, want: http.StatusInternalServerError},
+		{name: "bearer with scope", header: "Authorization", value: "Bearer reader", scope: models.ScopeRead, want: http.StatusNoContent},
+		{name: "x-api-key with scope", header: "X-API-Key", value: "reader", scope: models.ScopeRead, want: http.StatusNoContent},
+		{name: "missing scope", header: "X-API-Key", value: "reader", scope: models.ScopeTrack, want: http.StatusForbidden},
+		{name: "admin grants every scope", header: "Authorization", value: "bearer admin", scope: models.ScopeTrack, want: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := authenticate(authn)(requireScope(true, tt.scope)(ok))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/videos/1", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("expected %d, got %d (%s)", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireScope_DisabledAuthPassesThrough(t *testing.T) {
+	h := requireScope(false, models.ScopeAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/videos/1/stop", nil))
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+}
@@ -15,9 +15,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/videos": {
+        "/api/admin/creators/purge": {
             "post": {
-                "description": "If the video is not yet tracked, the service:\n1) validates the URL/ID,\n2) fetches fresh stats from the provider,\n3) creates a new video record in the DB and writes the first stats snapshot.\nIf the video is already tracked, the service DOES NOT call the provider.\nIt returns the latest saved views and earnings from the ` + "`" + `videos` + "`" + ` table\nand also appends a new row to the hourly stats journal.",
+                "description": "Removes right away, without a retention window, the videos of the username in the workspace\n(linked to the creator account or with /@username/ in the URL, deleted ones included) with\ntheir stats, exports, events and webhook deliveries, the creator account, and the username in\nwatch filters. The audit entry holds the counts and a SHA-256 of the username only.\nRequires the ` + "`" + `admin` + "`" + ` scope.",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,17 +25,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "videos"
+                    "admin"
                 ],
-                "summary": "TrackVideo TikTok video for tracking",
+                "summary": "Erase everything about a TikTok author (GDPR)",
                 "parameters": [
                     {
-                        "description": "Video URL or TikTok ID",
+                        "description": "TikTok username",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.TrackVideoRequest"
+                            "$ref": "#/definitions/models.PurgeCreatorRequest"
                         }
                     }
                 ],
@@ -43,23 +43,23 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.TrackVideoResponse"
+                            "$ref": "#/definitions/models.PurgeResult"
                         }
                     },
                     "400": {
-                        "description": "Invalid URL/ID",
+                        "description": "Invalid username",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Video not found on TikTok",
+                    "401": {
+                        "description": "Missing or invalid API key",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "429": {
-                        "description": "Provider rate limit",
+                    "403": {
+                        "description": "Missing admin scope",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -73,9 +73,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/videos/{tiktok_id}": {
+        "/api/admin/keys": {
             "get": {
-                "description": "Returns the last saved views and earnings for a TikTok video\nfrom the ` + "`" + `videos` + "`" + ` table. Does NOT call external provider.",
+                "description": "Returns all keys including revoked ones. Raw keys are never returned. Requires the ` + "`" + `admin` + "`" + ` scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List API keys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.APIKeyResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a key with the given scopes (` + "`" + `read` + "`" + `, ` + "`" + `track` + "`" + `, ` + "`" + `admin` + "`" + `). Requires the ` + "`" + `admin` + "`" + ` scope.\nThe raw key is returned only once, the service keeps its hash.",
                 "consumes": [
                     "application/json"
                 ],
@@ -83,33 +122,94 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "videos"
+                    "admin"
                 ],
-                "summary": "Get latest saved TikTok video stats",
+                "summary": "Mint an API key",
+                "parameters": [
+                    {
+                        "description": "Key name and scopes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateAPIKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid name or scopes",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/keys/{key_id}": {
+            "delete": {
+                "description": "Revoked keys are rejected with 401 immediately. Requires the ` + "`" + `admin` + "`" + ` scope.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Revoke an API key",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "TikTok video ID",
-                        "name": "tiktok_id",
+                        "description": "API key ID",
+                        "name": "key_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid key_id",
                         "schema": {
-                            "$ref": "#/definitions/models.TrackVideoResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "400": {
-                        "description": "Invalid TikTok ID",
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing admin scope",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Video not found",
+                        "description": "Key not found or already revoked",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -123,57 +223,87 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/videos/{video_id}/history": {
+        "/api/audit": {
             "get": {
-                "description": "Returns saved history of views and earnings for a TikTok video from ` + "`" + `video_stats` + "`" + ` table.\nDoes NOT call external provider, uses only stored snapshots.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Changes made through the API, the CLIs and the background jobs, newest first. Each entry names\nthe actor (` + "`" + `api_key:\u003cid\u003e` + "`" + `, ` + "`" + `cli` + "`" + `, ` + "`" + `system` + "`" + ` or ` + "`" + `anonymous` + "`" + ` while auth is disabled), the action, the\ntarget and its state before and after the change. Pass next_before_id back as before_id for the\nnext page. Requires the ` + "`" + `admin` + "`" + ` scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "videos"
+                    "admin"
                 ],
-                "summary": "Get historical stats for a TikTok video",
+                "summary": "Audit log of the workspace",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "video_id video ID",
-                        "name": "video_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Actor, e.g. api_key:3",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Action, e.g. video.stopped",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Target type: video, creator, watch, api_key, webhook, export, workspace",
+                        "name": "target_type",
+                        "in": "query"
                     },
                     {
                         "type": "integer",
-                        "format": "int64",
-                        "description": "Start time (unix seconds), inclusive. Example: 1732060800",
+                        "description": "Target ID",
+                        "name": "target_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "From, unix seconds (inclusive)",
                         "name": "from",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "format": "int64",
-                        "description": "End time (unix seconds), exclusive. Example: 1732665600",
+                        "description": "To, unix seconds (exclusive)",
                         "name": "to",
                         "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Entries older than this id",
+                        "name": "before_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max entries, default 50, max 500",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.VideoHistoryResponse"
+                            "$ref": "#/definitions/models.AuditListResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid TikTok ID or invalid date params",
+                        "description": "Invalid parameter",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Video or history not found",
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing admin scope",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -187,34 +317,92 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/videos/{video_id}/stop": {
+        "/api/creators": {
             "post": {
-                "description": "Sets tracking status to \"stopped\" so updater no longer updates this video.",
+                "description": "Registers a TikTok username. A background discovery job lists the creator's posts\nand starts tracking every video created at or after ` + "`" + `start_date` + "`" + ` (defaults to now).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "videos"
+                    "creators"
                 ],
-                "summary": "Stop tracking for a video",
+                "summary": "Track all videos of a TikTok creator",
+                "parameters": [
+                    {
+                        "description": "TikTok username and start date",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RegisterCreatorRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.CreatorResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid username",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Creator already registered",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/creators/{creator_id}": {
+            "get": {
+                "description": "Returns the creator with total views, earnings and number of tracked videos.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "creators"
+                ],
+                "summary": "Get a tracked creator with aggregates",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "video ID",
-                        "name": "video_id",
+                        "description": "creator ID",
+                        "name": "creator_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CreatorResponse"
+                        }
                     },
                     "400": {
-                        "description": "Invalid video_id",
+                        "description": "Invalid creator_id",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Video not found",
+                        "description": "Creator not found",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -227,17 +415,1803 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string"
-                },
-                "message": {
-                    "type": "string"
+        },
+        "/api/exports": {
+            "post": {
+                "description": "Queues an export of the snapshots in [from, to) of one video, or of every video of the workspace.\nPoll GET /api/exports/{export_id}: once ` + "`" + `done` + "`" + ` it holds a signed ` + "`" + `download_url` + "`" + ` valid until ` + "`" + `expires_at` + "`" + `.\nColumns: video_id, tiktok_id, captured_at (timestamp millis, UTC), views, earnings.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exports"
+                ],
+                "summary": "Export stats snapshots to Parquet",
+                "parameters": [
+                    {
+                        "description": "Video and date range",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateExportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing track scope",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Video not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/exports/{export_id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exports"
+                ],
+                "summary": "Get an export job",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "export ID",
+                        "name": "export_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid export_id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Export not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stream": {
+            "get": {
+                "description": "Same as /api/videos/{video_id}/stream for every video of the workspace of the API key.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "stream"
+                ],
+                "summary": "Live stats of all videos of the workspace (Server-Sent Events)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "id of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "same as Last-Event-ID, for clients that cannot set headers",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "stream of events",
+                        "schema": {
+                            "$ref": "#/definitions/models.VideoStatsEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Last-Event-ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/videos": {
+            "get": {
+                "description": "Returns the videos of the workspace ordered by id, one page at a time: pass ` + "`" + `next_after_id` + "`" + `\nback as ` + "`" + `after_id` + "`" + ` for the next page. ` + "`" + `?format=csv|ndjson` + "`" + ` (or the matching Accept header)\nstreams every matching video row by row, ` + "`" + `limit` + "`" + ` is then optional.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "videos"
+                ],
+                "summary": "List tracked videos",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "active, stopped or error",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "return videos with a greater id",
+                        "name": "after_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, default 100, max 1000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), csv or ndjson",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.VideoListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid status, after_id, limit or format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "If the video is not yet tracked, the service:\n1) validates the URL/ID,\n2) fetches fresh stats from the provider,\n3) creates a new video record in the DB and writes the first stats snapshot.\nIf the video is already tracked, the service DOES NOT call the provider.\nIt returns the latest saved views and earnings from the ` + "`" + `videos` + "`" + ` table\nand also appends a new row to the hourly stats journal.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "videos"
+                ],
+                "summary": "TrackVideo TikTok video for tracking",
+                "parameters": [
+                    {
+                        "description": "Video URL or TikTok ID",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TrackVideoRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TrackVideoResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL/ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Video not found on TikTok",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Provider rate limit",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/videos/{id}": {
+            "get": {
+                "description": "Returns the last saved views and earnings. Does NOT call external provider.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Get a video by video_id or TikTok id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "video_id (42) or tt: + TikTok ID (tt:7301234567890)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TrackVideoResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Video not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "The video disappears at once from the API, the updater and exports; its stats are purged\nafter the retention window (` + "`" + `purge.retention` + "`" + `). Requires the ` + "`" + `admin` + "`" + ` scope.",
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Delete a video by video_id or TikTok id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "video_id (42) or tt: + TikTok ID (tt:7301234567890)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Video not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/videos/{id}/history": {
+            "get": {
+                "description": "Same as /api/videos/{video_id}/history.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Get historical stats of a video by video_id or TikTok id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "video_id (42) or tt: + TikTok ID (tt:7301234567890)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Start time (unix seconds), inclusive",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "End time (unix seconds), exclusive",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), csv or ndjson",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.VideoHistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid id or date params",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Video not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/videos/{id}/refresh": {
+            "post": {
+                "description": "Same as /api/videos/{video_id}/refresh.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Poll a video now, by video_id or TikTok id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "video_id (42) or tt: + TikTok ID (tt:7301234567890)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RefreshVideoResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Video not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Refreshed too recently, budget or provider rate limit exhausted",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Provider unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/videos/{id}/stop": {
+            "post": {
+                "description": "Sets tracking status to \"stopped\" so updater no longer updates this video.",
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Stop tracking a video by video_id or TikTok id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "video_id (42) or tt: + TikTok ID (tt:7301234567890)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Video not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/videos/{id}/stream": {
+            "get": {
+                "description": "Same as /api/videos/{video_id}/stream.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Live stats of a video by video_id or TikTok id (Server-Sent Events)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "video_id (42) or tt: + TikTok ID (tt:7301234567890)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "id of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "same as Last-Event-ID, for clients that cannot set headers",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "stream of events",
+                        "schema": {
+                            "$ref": "#/definitions/models.VideoStatsEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid id or Last-Event-ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Video not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/videos": {
+            "get": {
+                "description": "Returns the videos of the workspace ordered by id, one page at a time: pass ` + "`" + `next_after_id` + "`" + `\nback as ` + "`" + `after_id` + "`" + ` for the next page. ` + "`" + `?format=csv|ndjson` + "`" + ` (or the matching Accept header)\nstreams every matching video row by row, ` + "`" + `limit` + "`" + ` is then optional.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "videos"
+                ],
+                "summary": "List tracked videos",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "active, stopped or error",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "return videos with a greater id",
+                        "name": "after_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, default 100, max 1000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), csv or ndjson",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.VideoListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid status, after_id, limit or format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "If the video is not yet tracked, the service:\n1) validates the URL/ID,\n2) fetches fresh stats from the provider,\n3) creates a new video record in the DB and writes the first stats snapshot.\nIf the video is already tracked, the service DOES NOT call the provider.\nIt returns the latest saved views and earnings from the ` + "`" + `videos` + "`" + ` table\nand also appends a new row to the hourly stats journal.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "videos"
+                ],
+                "summary": "TrackVideo TikTok video for tracking",
+                "parameters": [
+                    {
+                        "description": "Video URL or TikTok ID",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TrackVideoRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TrackVideoResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL/ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Video not found on TikTok",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Provider rate limit",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/videos/{tiktok_id}": {
+            "get": {
+                "description": "Returns the last saved views and earnings for a TikTok video\nfrom the ` + "`" + `videos` + "`" + ` table. Does NOT call external provider.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "videos"
+                ],
+                "summary": "Get latest saved TikTok video stats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "TikTok video ID",
+                        "name": "tiktok_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TrackVideoResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid TikTok ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Video not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/videos/{video_id}/history": {
+            "get": {
+                "description": "Returns saved history of views and earnings for a TikTok video from ` + "`" + `video_stats` + "`" + ` table.\nDoes NOT call external provider, uses only stored snapshots.\nPast the raw retention the range is read from the daily, then weekly rollups: one point per\nbucket, its last snapshot, with ` + "`" + `granularity` + "`" + ` set to ` + "`" + `day` + "`" + ` or ` + "`" + `week` + "`" + ` instead of ` + "`" + `hour` + "`" + `.\n` + "`" + `?format=csv|ndjson` + "`" + ` (or the matching Accept header) streams the snapshots row by row.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "videos"
+                ],
+                "summary": "Get historical stats for a TikTok video",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "video_id video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Start time (unix seconds), inclusive. Example: 1732060800",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "End time (unix seconds), exclusive. Example: 1732665600",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), csv or ndjson",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.VideoHistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid TikTok ID or invalid date params",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Video or history not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/videos/{video_id}/refresh": {
+            "post": {
+                "description": "Fetches fresh stats from the provider right away and appends a snapshot the same way the hourly updater does.\nConcurrent refreshes of one video share a single provider call, ` + "`" + `shared` + "`" + ` is then true in every response.\nA video can be refreshed once per ` + "`" + `refresh.cooldown` + "`" + `, and all on-demand refreshes share the ` + "`" + `refresh.rate_per_minute` + "`" + ` budget;\nrefused calls get 429 with ` + "`" + `Retry-After` + "`" + `.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "videos"
+                ],
+                "summary": "Poll a video now",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RefreshVideoResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid video_id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Video not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Refreshed too recently, budget or provider rate limit exhausted",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Provider unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/videos/{video_id}/stop": {
+            "post": {
+                "description": "Sets tracking status to \"stopped\" so updater no longer updates this video.",
+                "tags": [
+                    "videos"
+                ],
+                "summary": "Stop tracking for a video",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid video_id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Video not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/videos/{video_id}/stream": {
+            "get": {
+                "description": "Emits an ` + "`" + `stats` + "`" + ` event with a models.VideoStatsEvent every time the updater stores a snapshot\nof the video; works across replicas. A comment line is sent as heartbeat.\nReconnect with ` + "`" + `Last-Event-ID` + "`" + ` (or ` + "`" + `?last_event_id=` + "`" + `) to replay the snapshots missed since that event;\nthe last minute before it is replayed again, so dedupe by id. A ` + "`" + `reset` + "`" + ` event follows a replay\ncut at the replay limit: the snapshots after it are lost and the history has to be reloaded.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "stream"
+                ],
+                "summary": "Live stats of a video (Server-Sent Events)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "id of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "same as Last-Event-ID, for clients that cannot set headers",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "stream of events",
+                        "schema": {
+                            "$ref": "#/definitions/models.VideoStatsEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid video_id or Last-Event-ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Video not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/watches": {
+            "post": {
+                "description": "Registers a hashtag (` + "`" + `kind=hashtag` + "`" + `, value without ` + "`" + `#` + "`" + `) or a sound (` + "`" + `kind=sound` + "`" + `, numeric music id).\nA background discovery job queries the provider for matching posts created at or after ` + "`" + `start_date` + "`" + `\nand starts tracking them. When ` + "`" + `allowed_creators` + "`" + ` is set, only posts by those usernames are tracked.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watches"
+                ],
+                "summary": "Track all videos using a hashtag or sound",
+                "parameters": [
+                    {
+                        "description": "Hashtag or sound to watch",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RegisterWatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.WatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid kind or value",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Hashtag or sound already watched",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/watches/{watch_id}": {
+            "get": {
+                "description": "Returns total views, earnings and number of tracked videos matched by the hashtag or sound.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watches"
+                ],
+                "summary": "Get a watched hashtag or sound with aggregates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "watch ID",
+                        "name": "watch_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.WatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid watch_id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Watch not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/webhooks": {
+            "get": {
+                "description": "Secrets are never returned. Requires the ` + "`" + `admin` + "`" + ` scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhook subscriptions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WebhookResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Events: ` + "`" + `video.tracked` + "`" + `, ` + "`" + `video.milestone_reached` + "`" + `, ` + "`" + `video.error` + "`" + `, ` + "`" + `video.stopped` + "`" + `, ` + "`" + `earnings.threshold` + "`" + `.\nEvery delivery is a JSON POST signed with the returned secret:\n` + "`" + `X-Webhook-Signature: t=\u003cunix\u003e,v1=\u003chex hmac-sha256(secret, \"\u003cunix\u003e.\u003cbody\u003e\")\u003e` + "`" + `.\nNon-2xx responses are retried with exponential backoff. Requires the ` + "`" + `admin` + "`" + ` scope.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Subscribe a URL to video lifecycle events",
+                "parameters": [
+                    {
+                        "description": "Receiver URL and events",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid url or events",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/webhooks/{webhook_id}": {
+            "delete": {
+                "description": "Pending deliveries and the delivery log are removed with it. Requires the ` + "`" + `admin` + "`" + ` scope.",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete a webhook subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid webhook_id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/webhooks/{webhook_id}/deliveries": {
+            "get": {
+                "description": "Latest deliveries first with status, attempts, last response code and next retry time.\nRequires the ` + "`" + `admin` + "`" + ` scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delivery log of a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max deliveries, default 50, max 500",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WebhookDeliveryResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook_id or limit",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/exports/{export_id}/download": {
+            "get": {
+                "description": "Authorised by the signature of the link returned in ` + "`" + `download_url` + "`" + `, no API key needed.\nSupports Range requests.",
+                "produces": [
+                    "application/vnd.apache.parquet"
+                ],
+                "tags": [
+                    "exports"
+                ],
+                "summary": "Download an export file",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "export ID",
+                        "name": "export_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "workspace ID",
+                        "name": "workspace",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "link expiry, unix seconds",
+                        "name": "expires",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "link signature",
+                        "name": "signature",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid export_id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Invalid or expired link",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Export not found or expired",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.APIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-11-24T01:30:00Z"
+                },
+                "key_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2025-11-24T01:30:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "dashboard"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "tta_1a2b3c4d"
+                },
+                "revoked_at": {
+                    "type": "string",
+                    "example": "2025-11-24T01:30:00Z"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read",
+                        "track"
+                    ]
+                },
+                "workspace_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.AuditEntryResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "video.stopped"
+                },
+                "actor": {
+                    "type": "string",
+                    "example": "api_key:3"
+                },
+                "after": {
+                    "type": "object"
+                },
+                "before": {
+                    "type": "object"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-11-24T01:30:00Z"
+                },
+                "details": {
+                    "type": "object"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "9f1c2a7b4e6d8a0c"
+                },
+                "target_id": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "target_type": {
+                    "type": "string",
+                    "example": "video"
+                }
+            }
+        },
+        "models.AuditListResponse": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditEntryResponse"
+                    }
+                },
+                "next_before_id": {
+                    "type": "integer",
+                    "example": 17
+                }
+            }
+        },
+        "models.CreateAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "dashboard"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read",
+                        "track"
+                    ]
+                }
+            }
+        },
+        "models.CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-11-24T01:30:00Z"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "tta_1a2b3c4d..."
+                },
+                "key_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2025-11-24T01:30:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "dashboard"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "tta_1a2b3c4d"
+                },
+                "revoked_at": {
+                    "type": "string",
+                    "example": "2025-11-24T01:30:00Z"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read",
+                        "track"
+                    ]
+                },
+                "workspace_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.CreateExportRequest": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "description": "unix seconds, inclusive",
+                    "type": "integer",
+                    "example": 1732060800
+                },
+                "to": {
+                    "description": "unix seconds, exclusive",
+                    "type": "integer",
+                    "example": 1732665600
+                },
+                "video_id": {
+                    "description": "all videos of the workspace when empty",
+                    "type": "integer",
+                    "example": 7
+                }
+            }
+        },
+        "models.CreateWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "video.tracked",
+                        "video.milestone_reached"
+                    ]
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/hooks/tiktok"
+                }
+            }
+        },
+        "models.CreatorResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-11-24T01:30:00Z"
+                },
+                "creator_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "last_synced_at": {
+                    "type": "string",
+                    "example": "2025-11-24T01:30:00Z"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "2025-11-24T00:00:00Z"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "active"
+                },
+                "total_earnings": {
+                    "type": "number",
+                    "example": 15
+                },
+                "total_views": {
+                    "type": "integer",
+                    "example": 150000
+                },
+                "username": {
+                    "type": "string",
+                    "example": "someuser"
+                },
+                "video_count": {
+                    "type": "integer",
+                    "example": 12
+                }
+            }
+        },
+        "models.ExportResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-11-27T10:00:00Z"
+                },
+                "download_url": {
+                    "type": "string",
+                    "example": "/exports/3/download?workspace=1\u0026expires=1732700000\u0026signature=..."
+                },
+                "error": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2025-11-28T00:00:00Z"
+                },
+                "export_id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "format": {
+                    "type": "string",
+                    "example": "parquet"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "2025-11-20T00:00:00Z"
+                },
+                "rows": {
+                    "type": "integer",
+                    "example": 16800
+                },
+                "size_bytes": {
+                    "type": "integer",
+                    "example": 540000
+                },
+                "status": {
+                    "type": "string",
+                    "example": "done"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2025-11-27T00:00:00Z"
+                },
+                "video_id": {
+                    "type": "integer",
+                    "example": 7
+                }
+            }
+        },
+        "models.PurgeCreatorRequest": {
+            "type": "object",
+            "properties": {
+                "username": {
+                    "type": "string",
+                    "example": "someuser"
+                }
+            }
+        },
+        "models.PurgeResult": {
+            "type": "object",
+            "properties": {
+                "audit_entries": {
+                    "description": "earlier audit entries whose snapshots were redacted",
+                    "type": "integer",
+                    "example": 9
+                },
+                "audit_entry_id": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "creators": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "events": {
+                    "description": "outbox events and webhook deliveries about the videos",
+                    "type": "integer",
+                    "example": 30
+                },
+                "stats": {
+                    "type": "integer",
+                    "example": 3400
+                },
+                "videos": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "watch_filters": {
+                    "description": "watches the username was removed from allowed_creators of",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.RefreshVideoResponse": {
+            "type": "object",
+            "properties": {
+                "result": {
+                    "type": "string",
+                    "example": "updated"
+                },
+                "shared": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "video": {
+                    "$ref": "#/definitions/models.TrackVideoResponse"
+                }
+            }
+        },
+        "models.RegisterCreatorRequest": {
+            "type": "object",
+            "properties": {
+                "start_date": {
+                    "type": "string",
+                    "example": "2025-11-24T00:00:00Z"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "someuser"
+                }
+            }
+        },
+        "models.RegisterWatchRequest": {
+            "type": "object",
+            "properties": {
+                "allowed_creators": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "someuser"
+                    ]
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "hashtag",
+                        "sound"
+                    ],
+                    "example": "hashtag"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "2025-11-24T00:00:00Z"
+                },
+                "value": {
+                    "type": "string",
+                    "example": "brandtag"
                 }
             }
         },
@@ -309,6 +2283,21 @@ const docTemplate = `{
                 }
             }
         },
+        "models.VideoListResponse": {
+            "type": "object",
+            "properties": {
+                "next_after_id": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "videos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TrackVideoResponse"
+                    }
+                }
+            }
+        },
         "models.VideoStatPoint": {
             "type": "object",
             "properties": {
@@ -318,10 +2307,183 @@ const docTemplate = `{
                 "earnings": {
                     "type": "number"
                 },
+                "granularity": {
+                    "description": "hour, or day / week past the raw retention",
+                    "type": "string",
+                    "example": "hour"
+                },
                 "views": {
                     "type": "integer"
                 }
             }
+        },
+        "models.VideoStatsEvent": {
+            "type": "object",
+            "properties": {
+                "captured_at": {
+                    "type": "string",
+                    "example": "2025-11-20T12:00:00Z"
+                },
+                "earnings": {
+                    "type": "number",
+                    "example": 1.5
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1042
+                },
+                "video_id": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "views": {
+                    "type": "integer",
+                    "example": 15000
+                },
+                "workspace_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.WatchResponse": {
+            "type": "object",
+            "properties": {
+                "allowed_creators": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "someuser"
+                    ]
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-11-24T01:30:00Z"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "kind": {
+                    "type": "string",
+                    "example": "hashtag"
+                },
+                "last_synced_at": {
+                    "type": "string",
+                    "example": "2025-11-24T01:30:00Z"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "2025-11-24T00:00:00Z"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "active"
+                },
+                "total_earnings": {
+                    "type": "number",
+                    "example": 15
+                },
+                "total_views": {
+                    "type": "integer",
+                    "example": 150000
+                },
+                "value": {
+                    "type": "string",
+                    "example": "brandtag"
+                },
+                "video_count": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "watch_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.WebhookDeliveryResponse": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-11-24T01:30:00Z"
+                },
+                "delivered_at": {
+                    "type": "string",
+                    "example": "2025-11-24T01:30:00Z"
+                },
+                "delivery_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "event_id": {
+                    "type": "string",
+                    "example": "evt_1a2b3c4d5e6f7a8b"
+                },
+                "event_type": {
+                    "type": "string",
+                    "example": "video.tracked"
+                },
+                "last_error": {
+                    "type": "string",
+                    "example": "receiver returned 500"
+                },
+                "last_status_code": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "next_attempt_at": {
+                    "type": "string",
+                    "example": "2025-11-24T01:30:00Z"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "delivered",
+                        "failed"
+                    ],
+                    "example": "delivered"
+                }
+            }
+        },
+        "models.WebhookResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-11-24T01:30:00Z"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "video.tracked"
+                    ]
+                },
+                "secret": {
+                    "description": "returned only on creation, used to verify the X-Webhook-Signature header",
+                    "type": "string",
+                    "example": "whsec_1a2b3c..."
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/hooks/tiktok"
+                },
+                "webhook_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"ttanalytic/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, req models.CreateAPIKeyRequest) (models.CreateAPIKeyResponse, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKeyResponse, error)
+	RevokeAPIKey(ctx context.Context, keyID int64) error
+}
+
+// CreateAPIKey handles POST
+// @Summary     Mint an API key
+// @Description Creates a key with the given scopes (`read`, `track`, `admin`). Requires the `admin` scope.
+// @Description The raw key is returned only once, the service keeps its hash.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Param       request body models.CreateAPIKeyRequest true "Key name and scopes"
+// @Success     201 {object} models.CreateAPIKeyResponse
+// @Failure     400 {object} ErrorResponse "Invalid name or scopes"
+// @Failure     401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure     403 {object} ErrorResponse "Missing admin scope"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/admin/keys [post]
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateAPIKeyRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	resp, err := h.keys.CreateAPIKey(r.Context(), req)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusCreated, resp)
+}
+
+// ListAPIKeys handles GET
+// @Summary     List API keys
+// @Description Returns all keys including revoked ones. Raw keys are never returned. Requires the `admin` scope.
+// @Tags        admin
+// @Produce     json
+// @Success     200 {array}  models.APIKeyResponse
+// @Failure     401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure     403 {object} ErrorResponse "Missing admin scope"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/admin/keys [get]
+func (h *Handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.keys.ListAPIKeys(r.Context())
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, resp)
+}
+
+// RevokeAPIKey handles DELETE
+// @Summary     Revoke an API key
+// @Description Revoked keys are rejected with 401 immediately. Requires the `admin` scope.
+// @Tags        admin
+// @Param       key_id path string true "API key ID"
+// @Success     204
+// @Failure     400 {object} ErrorResponse "Invalid key_id"
+// @Failure     401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure     403 {object} ErrorResponse "Missing admin scope"
+// @Failure     404 {object} ErrorResponse "Key not found or already revoked"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/admin/keys/{key_id} [delete]
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyID, err := strconv.ParseInt(chi.URLParam(r, "key_id"), 10, 64)
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "invalid key_id", err)
+		return
+	}
+
+	if err := h.keys.RevokeAPIKey(r.Context(), keyID); err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
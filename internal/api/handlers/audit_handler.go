@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"ttanalytic/internal/models"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+type AuditService interface {
+	ListAudit(ctx context.Context, filter models.AuditFilter) (models.AuditListResponse, error)
+}
+
+// ListAudit handles GET
+// @Summary     Audit log of the workspace
+// @Description Changes made through the API, the CLIs and the background jobs, newest first. Each entry names
+// @Description the actor (`api_key:<id>`, `cli`, `system` or `anonymous` while auth is disabled), the action, the
+// @Description target and its state before and after the change. Pass next_before_id back as before_id for the
+// @Description next page. Requires the `admin` scope.
+// @Tags        admin
+// @Produce     json
+// @Param       actor       query string false "Actor, e.g. api_key:3"
+// @Param       action      query string false "Action, e.g. video.stopped"
+// @Param       target_type query string false "Target type: video, creator, watch, api_key, webhook, export, workspace"
+// @Param       target_id   query int    false "Target ID"
+// @Param       from        query int    false "From, unix seconds (inclusive)"
+// @Param       to          query int    false "To, unix seconds (exclusive)"
+// @Param       before_id   query int    false "Entries older than this id"
+// @Param       limit       query int    false "Max entries, default 50, max 500"
+// @Success     200 {object} models.AuditListResponse
+// @Failure     400 {object} ErrorResponse "Invalid parameter"
+// @Failure     401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure     403 {object} ErrorResponse "Missing admin scope"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/audit [get]
+func (h *Handler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := models.AuditFilter{
+		Actor:      q.Get("actor"),
+		Action:     q.Get("action"),
+		TargetType: q.Get("target_type"),
+		Limit:      defaultAuditLimit,
+	}
+
+	var err error
+
+	if raw := q.Get("target_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			h.sendError(w, r, http.StatusBadRequest, "invalid 'target_id' parameter", err)
+			return
+		}
+		filter.TargetID = &id
+	}
+
+	if filter.From, err = parseTimeParam(q.Get("from")); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "invalid 'from' parameter", err)
+		return
+	}
+
+	if filter.To, err = parseTimeParam(q.Get("to")); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "invalid 'to' parameter", err)
+		return
+	}
+
+	if raw := q.Get("before_id"); raw != "" {
+		filter.BeforeID, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || filter.BeforeID < 1 {
+			h.sendError(w, r, http.StatusBadRequest, "invalid 'before_id' parameter", err)
+			return
+		}
+	}
+
+	if raw := q.Get("limit"); raw != "" {
+		filter.Limit, err = strconv.Atoi(raw)
+		if err != nil || filter.Limit < 1 || filter.Limit > maxAuditLimit {
+			h.sendError(w, r, http.StatusBadRequest, "invalid limit", err)
+			return
+		}
+	}
+
+	resp, err := h.audit.ListAudit(r.Context(), filter)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type CreatorService interface {
+	RegisterCreator(ctx context.Context, req models.RegisterCreatorRequest) (models.CreatorResponse, error)
+	GetCreator(ctx context.Context, creatorID int64) (models.CreatorResponse, error)
+}
+
+// RegisterCreator handles POST
+// @Summary     Track all videos of a TikTok creator
+// @Description Registers a TikTok username. A background discovery job lists the creator's posts
+// @Description and starts tracking every video created at or after `start_date` (defaults to now).
+// @Tags        creators
+// @Accept      json
+// @Produce     json
+// @Param       request body models.RegisterCreatorRequest true "TikTok username and start date"
+// @Success     201 {object} models.CreatorResponse
+// @Failure     400 {object} ErrorResponse "Invalid username"
+// @Failure     409 {object} ErrorResponse "Creator already registered"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/creators [post]
+func (h *Handler) RegisterCreator(w http.ResponseWriter, r *http.Request) {
+	var req models.RegisterCreatorRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	logging.From(r.Context(), h.logger).Infof("HTTP RegisterCreator: incoming username=%s", req.Username)
+
+	resp, err := h.creators.RegisterCreator(r.Context(), req)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusCreated, resp)
+}
+
+// GetCreator handles GET
+// @Summary     Get a tracked creator with aggregates
+// @Description Returns the creator with total views, earnings and number of tracked videos.
+// @Tags        creators
+// @Produce     json
+// @Param       creator_id path string true "creator ID"
+// @Success     200 {object} models.CreatorResponse
+// @Failure     400 {object} ErrorResponse "Invalid creator_id"
+// @Failure     404 {object} ErrorResponse "Creator not found"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/creators/{creator_id} [get]
+func (h *Handler) GetCreator(w http.ResponseWriter, r *http.Request) {
+	creatorID, err := strconv.ParseInt(chi.URLParam(r, "creator_id"), 10, 64)
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "invalid creator_id", err)
+		return
+	}
+
+	resp, err := h.creators.GetCreator(r.Context(), creatorID)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"ttanalytic/internal/models"
+)
+
+type DeletionService interface {
+	DeleteVideo(ctx context.Context, videoID int64) error
+	PurgeCreator(ctx context.Context, req models.PurgeCreatorRequest) (models.PurgeResult, error)
+}
+
+// PurgeCreator handles POST
+// @Summary     Erase everything about a TikTok author (GDPR)
+// @Description Removes right away, without a retention window, the videos of the username in the workspace
+// @Description (linked to the creator account or with /@username/ in the URL, deleted ones included) with
+// @Description their stats, exports, events and webhook deliveries, the creator account, and the username in
+// @Description watch filters. The audit entry holds the counts and a SHA-256 of the username only.
+// @Description Requires the `admin` scope.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Param       request body models.PurgeCreatorRequest true "TikTok username"
+// @Success     200 {object} models.PurgeResult
+// @Failure     400 {object} ErrorResponse "Invalid username"
+// @Failure     401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure     403 {object} ErrorResponse "Missing admin scope"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/admin/creators/purge [post]
+func (h *Handler) PurgeCreator(w http.ResponseWriter, r *http.Request) {
+	var req models.PurgeCreatorRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	resp, err := h.deletions.PurgeCreator(r.Context(), req)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+	"ttanalytic/internal/export"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultVideosLimit = 100
+	maxVideosLimit     = 1000
+
+	// rows written between flushes of a CSV/NDJSON stream
+	flushEvery = 500
+)
+
+var (
+	videoColumns = []string{
+		"video_id", "tiktok_id", "url", "current_views", "current_earnings",
+		"currency", "created_at", "last_updated_at", "status",
+	}
+	historyColumns = []string{"captured_at", "views", "earnings", "granularity"}
+)
+
+type ExportService interface {
+	CreateExport(ctx context.Context, req models.CreateExportRequest) (models.ExportResponse, error)
+	GetExport(ctx context.Context, exportID int64) (models.ExportResponse, error)
+	OpenExportFile(ctx context.Context, exportID int64, query url.Values) (*os.File, error)
+}
+
+// ListVideos handles GET
+// @Summary     List tracked videos
+// @Description Returns the videos of the workspace ordered by id, one page at a time: pass `next_after_id`
+// @Description back as `after_id` for the next page. `?format=csv|ndjson` (or the matching Accept header)
+// @Description streams every matching video row by row, `limit` is then optional.
+// @Tags        videos
+// @Produce     json,text/csv,application/x-ndjson
+// @Param       status   query string false "active, stopped or error"
+// @Param       after_id query int    false "return videos with a greater id"
+// @Param       limit    query int    false "page size, default 100, max 1000"
+// @Param       format   query string false "json (default), csv or ndjson"
+// @Success     200 {object} models.VideoListResponse
+// @Failure     400 {object} ErrorResponse "Invalid status, after_id, limit or format"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/videos [get]
+// @Router      /api/v2/videos [get]
+func (h *Handler) ListVideos(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	format, err := export.Negotiate(q.Get("format"), r.Header.Get("Accept"))
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	filter := models.VideoListFilter{Status: q.Get("status")}
+
+	switch filter.Status {
+	case "", models.VideoStatusActive, models.VideoStatusStopped, models.VideoStatusError:
+	default:
+		h.sendError(w, r, http.StatusBadRequest, "invalid status", nil)
+		return
+	}
+
+	if raw := q.Get("after_id"); raw != "" {
+		filter.AfterID, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || filter.AfterID < 0 {
+			h.sendError(w, r, http.StatusBadRequest, "invalid after_id", err)
+			return
+		}
+	}
+
+	if format == export.FormatJSON {
+		filter.Limit = defaultVideosLimit
+	}
+	if raw := q.Get("limit"); raw != "" {
+		filter.Limit, err = strconv.Atoi(raw)
+		if err != nil || filter.Limit < 1 || filter.Limit > maxVideosLimit {
+			h.sendError(w, r, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxVideosLimit), err)
+			return
+		}
+	}
+
+	if format != export.FormatJSON {
+		rs := newRowStream(w, format, "videos", videoColumns)
+		err := h.service.StreamVideos(r.Context(), filter, func(v models.TrackVideoResponse) error {
+			return rs.write(videoRecord(v), v)
+		})
+		h.finishRowStream(w, r, rs, err)
+		return
+	}
+
+	resp, err := h.service.ListVideos(r.Context(), filter)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) streamVideoHistory(w http.ResponseWriter, r *http.Request, format export.Format, videoID int64, from, to *time.Time) {
+	rs := newRowStream(w, format, fmt.Sprintf("video-%d-history", videoID), historyColumns)
+
+	err := h.service.StreamVideoHistory(r.Context(), videoID, from, to, func(p models.VideoStatPoint) error {
+		return rs.write(historyRecord(p), p)
+	})
+
+	h.finishRowStream(w, r, rs, err)
+}
+
+func (h *Handler) finishRowStream(w http.ResponseWriter, r *http.Request, rs *rowStream, err error) {
+	if err == nil {
+		err = rs.close()
+	}
+	if err == nil {
+		return
+	}
+
+	if !rs.started {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	// the status is already sent, the client gets a truncated body
+	logging.From(r.Context(), h.logger).Errorw("row stream aborted", "rows", rs.rows, "error", err)
+}
+
+// CreateExport handles POST
+// @Summary     Export stats snapshots to Parquet
+// @Description Queues an export of the snapshots in [from, to) of one video, or of every video of the workspace.
+// @Description Poll GET /api/exports/{export_id}: once `done` it holds a signed `download_url` valid until `expires_at`.
+// @Description Columns: video_id, tiktok_id, captured_at (timestamp millis, UTC), views, earnings.
+// @Tags        exports
+// @Accept      json
+// @Produce     json
+// @Param       request body models.CreateExportRequest true "Video and date range"
+// @Success     202 {object} models.ExportResponse
+// @Failure     400 {object} ErrorResponse "Invalid request body"
+// @Failure     401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure     403 {object} ErrorResponse "Missing track scope"
+// @Failure     404 {object} ErrorResponse "Video not found"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/exports [post]
+func (h *Handler) CreateExport(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateExportRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	resp, err := h.exports.CreateExport(r.Context(), req)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusAccepted, resp)
+}
+
+// GetExport handles GET
+// @Summary     Get an export job
+// @Tags        exports
+// @Produce     json
+// @Param       export_id path int true "export ID"
+// @Success     200 {object} models.ExportResponse
+// @Failure     400 {object} ErrorResponse "Invalid export_id"
+// @Failure     404 {object} ErrorResponse "Export not found"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/exports/{export_id} [get]
+func (h *Handler) GetExport(w http.ResponseWriter, r *http.Request) {
+	exportID, err := strconv.ParseInt(chi.URLParam(r, "export_id"), 10, 64)
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "invalid export_id", err)
+		return
+	}
+
+	resp, err := h.exports.GetExport(r.Context(), exportID)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, resp)
+}
+
+// DownloadExport handles GET
+// @Summary     Download an export file
+// @Description Authorised by the signature of the link returned in `download_url`, no API key needed.
+// @Description Supports Range requests.
+// @Tags        exports
+// @Produce     application/vnd.apache.parquet
+// @Param       export_id path  int    true "export ID"
+// @Param       workspace query int    true "workspace ID"
+// @Param       expires   query int    true "link expiry, unix seconds"
+// @Param       signature query string true "link signature"
+// @Success     200 {file} file
+// @Failure     400 {object} ErrorResponse "Invalid export_id"
+// @Failure     403 {object} ErrorResponse "Invalid or expired link"
+// @Failure     404 {object} ErrorResponse "Export not found or expired"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /exports/{export_id}/download [get]
+func (h *Handler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	exportID, err := strconv.ParseInt(chi.URLParam(r, "export_id"), 10, 64)
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "invalid export_id", err)
+		return
+	}
+
+	f, err := h.exports.OpenExportFile(r.Context(), exportID, r.URL.Query())
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	// a large file outlives the server write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "application/vnd.apache.parquet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(info.Name())))
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
+
+// rowStream writes CSV or NDJSON rows as they come; the status and headers go out with
+// the first row so an error before it can still be answered with JSON
+type rowStream struct {
+	w        http.ResponseWriter
+	rc       *http.ResponseController
+	format   export.Format
+	filename string
+	columns  []string
+
+	csv *csv.Writer
+	enc *json.Encoder
+
+	started bool
+	rows    int
+}
+
+func newRowStream(w http.ResponseWriter, format export.Format, filename string, columns []string) *rowStream {
+	return &rowStream{
+		w:        w,
+		rc:       http.NewResponseController(w),
+		format:   format,
+		filename: filename,
+		columns:  columns,
+	}
+}
+
+func (s *rowStream) start() error {
+	s.started = true
+
+	// a long stream outlives the server write timeout
+	_ = s.rc.SetWriteDeadline(time.Time{})
+
+	s.w.Header().Set("Content-Type", s.format.ContentType())
+	s.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", s.filename+"."+string(s.format)))
+	s.w.WriteHeader(http.StatusOK)
+
+	if s.format == export.FormatCSV {
+		s.csv = csv.NewWriter(s.w)
+		return s.csv.Write(s.columns)
+	}
+
+	s.enc = json.NewEncoder(s.w)
+	return nil
+}
+
+// write sends record as a CSV line or v as a JSON line
+func (s *rowStream) write(record []string, v any) error {
+	if !s.started {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if s.csv != nil {
+		err = s.csv.Write(record)
+	} else {
+		err = s.enc.Encode(v)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.rows++
+	if s.rows%flushEvery == 0 {
+		return s.flush()
+	}
+
+	return nil
+}
+
+func (s *rowStream) flush() error {
+	if s.csv != nil {
+		s.csv.Flush()
+		if err := s.csv.Error(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	return nil
+}
+
+// close sends what is buffered, an empty result still gets the CSV header line
+func (s *rowStream) close() error {
+	if !s.started {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+
+	if s.csv == nil {
+		return nil
+	}
+
+	s.csv.Flush()
+	return s.csv.Error()
+}
+
+func videoRecord(v models.TrackVideoResponse) []string {
+	return []string{
+		strconv.FormatInt(v.VideoID, 10),
+		v.TikTokID,
+		v.URL,
+		strconv.FormatInt(v.CurrentViews, 10),
+		strconv.FormatFloat(v.CurrentEarnings, 'f', -1, 64),
+		v.Currency,
+		v.CreatedAt,
+		v.LastUpdatedAt,
+		v.Status,
+	}
+}
+
+func historyRecord(p models.VideoStatPoint) []string {
+	return []string{
+		p.CapturedAt.UTC().Format(time.RFC3339),
+		strconv.FormatInt(p.Views, 10),
+		strconv.FormatFloat(p.Earnings, 'f', -1, 64),
+		p.Granularity,
+	}
+}
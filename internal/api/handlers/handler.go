@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
+	"ttanalytic/internal/export"
+	"ttanalytic/internal/logging"
 	"ttanalytic/internal/models"
 
 	"github.com/go-chi/chi/v5"
@@ -17,23 +20,62 @@ type Service interface {
 	TrackVideo(ctx context.Context, req models.TrackVideoRequest) (models.TrackVideoResponse, error)
 	GetVideo(ctx context.Context, tiktok string) (models.TrackVideoResponse, error)
 	GetVideoHistory(ctx context.Context, videoID int64, from, to *time.Time) (models.VideoHistoryResponse, error)
+	GetVideoByRef(ctx context.Context, ref models.VideoRef) (models.TrackVideoResponse, error)
 	StopTracking(ctx context.Context, videoID int64) error
+	ListVideos(ctx context.Context, filter models.VideoListFilter) (models.VideoListResponse, error)
+	StreamVideos(ctx context.Context, filter models.VideoListFilter, fn func(models.TrackVideoResponse) error) error
+	StreamVideoHistory(ctx context.Context, videoID int64, from, to *time.Time, fn func(models.VideoStatPoint) error) error
+}
+type RefreshService interface {
+	RefreshVideo(ctx context.Context, videoID int64) (models.RefreshVideoResponse, error)
 }
 type Logger interface {
 	Errorf(format string, args ...any)
 	Warnf(format string, args ...any)
 	Infof(format string, args ...any)
 	Info(args ...any)
+	Errorw(msg string, keysAndValues ...any)
+	Warnw(msg string, keysAndValues ...any)
 }
 type Handler struct {
-	service Service
-	logger  Logger
+	service   Service
+	creators  CreatorService
+	watches   WatchService
+	keys      APIKeyService
+	webhooks  WebhookService
+	streams   StreamService
+	exports   ExportService
+	refresh   RefreshService
+	deletions DeletionService
+	audit     AuditService
+	logger    Logger
 }
 
-func NewHandler(service Service, logger Logger) *Handler {
+func NewHandler(
+	service Service,
+	creators CreatorService,
+	watches WatchService,
+	keys APIKeyService,
+	webhooks WebhookService,
+	streams StreamService,
+	exports ExportService,
+	refresh RefreshService,
+	deletions DeletionService,
+	audit AuditService,
+	logger Logger,
+) *Handler {
 	return &Handler{
-		service: service,
-		logger:  logger,
+		service:   service,
+		creators:  creators,
+		watches:   watches,
+		keys:      keys,
+		webhooks:  webhooks,
+		streams:   streams,
+		exports:   exports,
+		refresh:   refresh,
+		deletions: deletions,
+		audit:     audit,
+		logger:    logger,
 	}
 }
 
@@ -61,23 +103,24 @@ type ErrorResponse struct {
 // @Failure     429 {object} ErrorResponse "Provider rate limit"
 // @Failure     500 {object} ErrorResponse "Internal server error"
 // @Router      /api/videos [post]
+// @Router      /api/v2/videos [post]
 func (h *Handler) TrackVideo(w http.ResponseWriter, r *http.Request) {
 	var req models.TrackVideoRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.sendError(w, http.StatusBadRequest, "Invalid request body", err)
+		h.sendError(w, r, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
 	if err := req.Validate(); err != nil {
-		h.sendError(w, http.StatusBadRequest, err.Error(), nil)
+		h.sendError(w, r, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
-	h.logger.Infof("HTTP TrackVideo: incoming url=%s", req.URL)
+	logging.From(r.Context(), h.logger).Infof("HTTP TrackVideo: incoming url=%s", req.URL)
 
 	resp, err := h.service.TrackVideo(r.Context(), req)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
@@ -100,15 +143,15 @@ func (h *Handler) TrackVideo(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetVideo(w http.ResponseWriter, r *http.Request) {
 	tikTokID := chi.URLParam(r, "tiktok_id")
 	if tikTokID == "" {
-		h.sendError(w, http.StatusBadRequest, "TikTok ID is required", nil)
+		h.sendError(w, r, http.StatusBadRequest, "TikTok ID is required", nil)
 		return
 	}
 
-	h.logger.Infof("HTTP GetVideo: incoming tiktok_id=%s", tikTokID)
+	logging.From(r.Context(), h.logger).Infof("HTTP GetVideo: incoming tiktok_id=%s", tikTokID)
 
 	resp, err := h.service.GetVideo(r.Context(), tikTokID)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
@@ -119,12 +162,16 @@ func (h *Handler) GetVideo(w http.ResponseWriter, r *http.Request) {
 // @Summary      Get historical stats for a TikTok video
 // @Description  Returns saved history of views and earnings for a TikTok video from `video_stats` table.
 // @Description  Does NOT call external provider, uses only stored snapshots.
+// @Description  Past the raw retention the range is read from the daily, then weekly rollups: one point per
+// @Description  bucket, its last snapshot, with `granularity` set to `day` or `week` instead of `hour`.
+// @Description  `?format=csv|ndjson` (or the matching Accept header) streams the snapshots row by row.
 // @Tags         videos
 // @Accept       json
-// @Produce      json
+// @Produce      json,text/csv,application/x-ndjson
 // @Param        video_id  path   string  true  "video_id video ID"
 // @Param        from      query  int64  false "Start time (unix seconds), inclusive. Example: 1732060800"
 // @Param        to        query  int64  false "End time (unix seconds), exclusive. Example: 1732665600"
+// @Param        format    query  string false "json (default), csv or ndjson"
 // @Success      200 {object} models.VideoHistoryResponse
 // @Failure      400 {object} ErrorResponse "Invalid TikTok ID or invalid date params"
 // @Failure      404 {object} ErrorResponse "Video or history not found"
@@ -133,35 +180,51 @@ func (h *Handler) GetVideo(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetVideoHistory(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "video_id")
 	if idStr == "" {
-		h.sendError(w, http.StatusBadRequest, "missing  video_id", nil)
+		h.sendError(w, r, http.StatusBadRequest, "missing  video_id", nil)
 		return
 	}
 
 	videoID, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		h.sendError(w, http.StatusBadRequest, "invalid video video_id", err)
+		h.sendError(w, r, http.StatusBadRequest, "invalid video video_id", err)
 		return
 	}
 
+	h.videoHistory(w, r, videoID)
+}
+
+// videoHistory serves the history of v1 and v2 once the path names a video
+func (h *Handler) videoHistory(w http.ResponseWriter, r *http.Request, videoID int64) {
 	q := r.URL.Query()
 	rawFrom := q.Get("from")
 	rawTo := q.Get("to")
 
 	fromTime, err := parseTimeParam(rawFrom)
 	if err != nil {
-		h.sendError(w, http.StatusBadRequest, "invalid 'from' parameter", err)
+		h.sendError(w, r, http.StatusBadRequest, "invalid 'from' parameter", err)
 		return
 	}
 
 	toTime, err := parseTimeParam(rawTo)
 	if err != nil {
-		h.sendError(w, http.StatusBadRequest, "invalid 'to' parameter", err)
+		h.sendError(w, r, http.StatusBadRequest, "invalid 'to' parameter", err)
+		return
+	}
+
+	format, err := export.Negotiate(q.Get("format"), r.Header.Get("Accept"))
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	if format != export.FormatJSON {
+		h.streamVideoHistory(w, r, format, videoID, fromTime, toTime)
 		return
 	}
 
 	resp, err := h.service.GetVideoHistory(r.Context(), videoID, fromTime, toTime)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
@@ -181,25 +244,65 @@ func (h *Handler) GetVideoHistory(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) StopVideoTracking(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "video_id")
 	if idStr == "" {
-		h.sendError(w, http.StatusBadRequest, "missing video_id", nil)
+		h.sendError(w, r, http.StatusBadRequest, "missing video_id", nil)
 		return
 	}
 
 	videoID, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		h.sendError(w, http.StatusBadRequest, "invalid video_id", err)
+		h.sendError(w, r, http.StatusBadRequest, "invalid video_id", err)
 		return
 	}
 
+	h.stopTracking(w, r, videoID)
+}
+
+func (h *Handler) stopTracking(w http.ResponseWriter, r *http.Request, videoID int64) {
 	if err := h.service.StopTracking(r.Context(), videoID); err != nil {
-		h.logger.Errorf("StopVideoTracking: service error: %v", err)
-		h.handleServiceError(w, err)
+		logging.From(r.Context(), h.logger).Errorf("StopVideoTracking: service error: %v", err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// RefreshVideo
+// @Summary      Poll a video now
+// @Description  Fetches fresh stats from the provider right away and appends a snapshot the same way the hourly updater does.
+// @Description  Concurrent refreshes of one video share a single provider call, `shared` is then true in every response.
+// @Description  A video can be refreshed once per `refresh.cooldown`, and all on-demand refreshes share the `refresh.rate_per_minute` budget;
+// @Description  refused calls get 429 with `Retry-After`.
+// @Tags         videos
+// @Produce      json
+// @Param        video_id  path  string  true  "video ID"
+// @Success      200  {object}  models.RefreshVideoResponse
+// @Failure      400  {object}  ErrorResponse "Invalid video_id"
+// @Failure      404  {object}  ErrorResponse "Video not found"
+// @Failure      429  {object}  ErrorResponse "Refreshed too recently, budget or provider rate limit exhausted"
+// @Failure      503  {object}  ErrorResponse "Provider unavailable"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /api/videos/{video_id}/refresh [post]
+func (h *Handler) RefreshVideo(w http.ResponseWriter, r *http.Request) {
+	videoID, err := strconv.ParseInt(chi.URLParam(r, "video_id"), 10, 64)
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "invalid video_id", err)
+		return
+	}
+
+	h.refreshVideo(w, r, videoID)
+}
+
+func (h *Handler) refreshVideo(w http.ResponseWriter, r *http.Request, videoID int64) {
+	resp, err := h.refresh.RefreshVideo(r.Context(), videoID)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, resp)
+}
+
 // helpers
 func (h *Handler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -209,20 +312,25 @@ func (h *Handler) sendJSON(w http.ResponseWriter, status int, data interface{})
 	}
 }
 
-func (h *Handler) sendError(w http.ResponseWriter, status int, message string, err error) {
+// sendError logs with the request logger, its trace id leads the log line to its trace
+func (h *Handler) sendError(w http.ResponseWriter, r *http.Request, status int, message string, err error) {
 	resp := ErrorResponse{
 		Error: message,
 	}
+
+	logger := logging.From(r.Context(), h.logger)
+
+	fields := []any{"status", status}
 	if err != nil {
-		h.logger.Errorf("%s: %v", message, err)
+		logger.Errorw(message, append(fields, "error", err)...)
 		resp.Message = err.Error()
 	} else {
-		h.logger.Warnf("%s", message)
+		logger.Warnw(message, fields...)
 	}
 
 	h.sendJSON(w, status, resp)
 }
-func (h *Handler) handleServiceError(w http.ResponseWriter, err error) {
+func (h *Handler) handleServiceError(w http.ResponseWriter, r *http.Request, err error) {
 	var status int
 	var message string
 
@@ -231,12 +339,41 @@ func (h *Handler) handleServiceError(w http.ResponseWriter, err error) {
 		status = http.StatusNotFound
 		message = "Resource not found"
 
+	case errors.Is(err, models.ErrAlreadyExists):
+		status = http.StatusConflict
+		message = "Resource already exists"
+
+	case errors.Is(err, models.ErrInvalidRequest):
+		status = http.StatusBadRequest
+		message = "Invalid request"
+
+	case errors.Is(err, models.ErrUnauthorized):
+		status = http.StatusUnauthorized
+		message = "Unauthorized"
+
+	case errors.Is(err, models.ErrForbidden):
+		status = http.StatusForbidden
+		message = "Forbidden"
+
+	case errors.Is(err, models.ErrRateLimited):
+		status = http.StatusTooManyRequests
+		message = "Rate limit exceeded"
+
+	case errors.Is(err, models.ErrUnavailable):
+		status = http.StatusServiceUnavailable
+		message = "Service temporarily unavailable"
+
 	default:
 		status = http.StatusInternalServerError
 		message = "Internal server error"
 	}
 
-	h.sendError(w, status, message, err)
+	var retry *models.RetryAfterError
+	if errors.As(err, &retry) && retry.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.RetryAfter.Seconds()))))
+	}
+
+	h.sendError(w, r, status, message, err)
 }
 func parseTimeParam(raw string) (*time.Time, error) {
 	if raw == "" {
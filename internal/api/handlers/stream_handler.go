@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// reconnect delay suggested to EventSource clients, milliseconds
+const streamRetryMs = 3000
+
+type StreamService interface {
+	OpenStatsStream(ctx context.Context, videoID *int64, lastEventID *models.StatsEventID) (models.StatsStream, error)
+	Heartbeat() time.Duration
+}
+
+// StreamVideoStats handles GET
+// @Summary     Live stats of a video (Server-Sent Events)
+// @Description Emits an `stats` event with a models.VideoStatsEvent every time the updater stores a snapshot
+// @Description of the video; works across replicas. A comment line is sent as heartbeat.
+// @Description Reconnect with `Last-Event-ID` (or `?last_event_id=`) to replay the snapshots missed since that event;
+// @Description the last minute before it is replayed again, so dedupe by id. A `reset` event follows a replay
+// @Description cut at the replay limit: the snapshots after it are lost and the history has to be reloaded.
+// @Tags        stream
+// @Produce     text/event-stream
+// @Param       video_id      path   int    true  "video ID"
+// @Param       Last-Event-ID header string false "id of the last event received"
+// @Param       last_event_id query  string false "same as Last-Event-ID, for clients that cannot set headers"
+// @Success     200 {object} models.VideoStatsEvent "stream of events"
+// @Failure     400 {object} ErrorResponse "Invalid video_id or Last-Event-ID"
+// @Failure     404 {object} ErrorResponse "Video not found"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/videos/{video_id}/stream [get]
+func (h *Handler) StreamVideoStats(w http.ResponseWriter, r *http.Request) {
+	videoID, err := strconv.ParseInt(chi.URLParam(r, "video_id"), 10, 64)
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "invalid video_id", err)
+		return
+	}
+
+	h.streamStats(w, r, &videoID)
+}
+
+// StreamStats handles GET
+// @Summary     Live stats of all videos of the workspace (Server-Sent Events)
+// @Description Same as /api/videos/{video_id}/stream for every video of the workspace of the API key.
+// @Tags        stream
+// @Produce     text/event-stream
+// @Param       Last-Event-ID header string false "id of the last event received"
+// @Param       last_event_id query  string false "same as Last-Event-ID, for clients that cannot set headers"
+// @Success     200 {object} models.VideoStatsEvent "stream of events"
+// @Failure     400 {object} ErrorResponse "Invalid Last-Event-ID"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/stream [get]
+func (h *Handler) StreamStats(w http.ResponseWriter, r *http.Request) {
+	h.streamStats(w, r, nil)
+}
+
+func (h *Handler) streamStats(w http.ResponseWriter, r *http.Request, videoID *int64) {
+	lastEventID, err := parseLastEventID(r)
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "invalid Last-Event-ID", err)
+		return
+	}
+
+	stream, err := h.streams.OpenStatsStream(r.Context(), videoID, lastEventID)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+	defer stream.Close()
+
+	rc := http.NewResponseController(w)
+
+	// a stream outlives the server write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		logging.From(r.Context(), h.logger).Errorf("streamStats: clear write deadline: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetryMs)
+
+	// live events are only skipped when replayed: ids are not in commit order
+	replayed := make(map[int64]bool, len(stream.Replay))
+	for _, e := range stream.Replay {
+		if err := writeStatsEvent(w, e); err != nil {
+			return
+		}
+		replayed[e.ID] = true
+	}
+
+	if stream.Truncated {
+		// the events after the replay are lost, the client reloads the history
+		if _, err := fmt.Fprint(w, "event: reset\ndata: {}\n\n"); err != nil {
+			return
+		}
+	}
+
+	if err := rc.Flush(); err != nil {
+		logging.From(r.Context(), h.logger).Errorf("streamStats: flush: %v", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(h.streams.Heartbeat())
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case e, ok := <-stream.Events:
+			if !ok {
+				// dropped (slow client, listener reconnect or shutdown): the client resumes with Last-Event-ID
+				return
+			}
+			if replayed[e.ID] {
+				continue
+			}
+			if err := writeStatsEvent(w, e); err != nil {
+				return
+			}
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeStatsEvent(w http.ResponseWriter, e models.VideoStatsEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: stats\ndata: %s\n\n", e.EventID(), data)
+	return err
+}
+
+func parseLastEventID(r *http.Request) (*models.StatsEventID, error) {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("last_event_id")
+	}
+	if raw == "" {
+		return nil, nil
+	}
+
+	id, err := models.ParseStatsEventID(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &id, nil
+}
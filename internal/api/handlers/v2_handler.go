@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+	"ttanalytic/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// /api/v2 names a video the same way on every route: {id} is the video_id, or the TikTok id
+// prefixed with tt: (see models.ParseVideoRef). Unknown videos are 404 everywhere.
+
+// GetVideoV2 handles GET
+// @Summary     Get a video by video_id or TikTok id
+// @Description Returns the last saved views and earnings. Does NOT call external provider.
+// @Tags        v2
+// @Produce     json
+// @Param       id  path  string  true  "video_id (42) or tt: + TikTok ID (tt:7301234567890)"
+// @Success     200 {object} models.TrackVideoResponse
+// @Failure     400 {object} ErrorResponse "Invalid id"
+// @Failure     404 {object} ErrorResponse "Video not found"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/v2/videos/{id} [get]
+func (h *Handler) GetVideoV2(w http.ResponseWriter, r *http.Request) {
+	video, ok := h.resolveVideo(w, r)
+	if !ok {
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, video)
+}
+
+// GetVideoHistoryV2 handles GET
+// @Summary     Get historical stats of a video by video_id or TikTok id
+// @Description Same as /api/videos/{video_id}/history.
+// @Tags        v2
+// @Produce     json,text/csv,application/x-ndjson
+// @Param       id      path   string  true  "video_id (42) or tt: + TikTok ID (tt:7301234567890)"
+// @Param       from    query  int64   false "Start time (unix seconds), inclusive"
+// @Param       to      query  int64   false "End time (unix seconds), exclusive"
+// @Param       format  query  string  false "json (default), csv or ndjson"
+// @Success     200 {object} models.VideoHistoryResponse
+// @Failure     400 {object} ErrorResponse "Invalid id or date params"
+// @Failure     404 {object} ErrorResponse "Video not found"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/v2/videos/{id}/history [get]
+func (h *Handler) GetVideoHistoryV2(w http.ResponseWriter, r *http.Request) {
+	video, ok := h.resolveVideo(w, r)
+	if !ok {
+		return
+	}
+
+	h.videoHistory(w, r, video.VideoID)
+}
+
+// StopVideoTrackingV2 handles POST
+// @Summary     Stop tracking a video by video_id or TikTok id
+// @Description Sets tracking status to "stopped" so updater no longer updates this video.
+// @Tags        v2
+// @Param       id  path  string  true  "video_id (42) or tt: + TikTok ID (tt:7301234567890)"
+// @Success     204
+// @Failure     400 {object} ErrorResponse "Invalid id"
+// @Failure     404 {object} ErrorResponse "Video not found"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/v2/videos/{id}/stop [post]
+func (h *Handler) StopVideoTrackingV2(w http.ResponseWriter, r *http.Request) {
+	video, ok := h.resolveVideo(w, r)
+	if !ok {
+		return
+	}
+
+	h.stopTracking(w, r, video.VideoID)
+}
+
+// DeleteVideoV2 handles DELETE
+// @Summary     Delete a video by video_id or TikTok id
+// @Description The video disappears at once from the API, the updater and exports; its stats are purged
+// @Description after the retention window (`purge.retention`). Requires the `admin` scope.
+// @Tags        v2
+// @Param       id  path  string  true  "video_id (42) or tt: + TikTok ID (tt:7301234567890)"
+// @Success     204
+// @Failure     400 {object} ErrorResponse "Invalid id"
+// @Failure     403 {object} ErrorResponse "Missing admin scope"
+// @Failure     404 {object} ErrorResponse "Video not found"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/v2/videos/{id} [delete]
+func (h *Handler) DeleteVideoV2(w http.ResponseWriter, r *http.Request) {
+	video, ok := h.resolveVideo(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.deletions.DeleteVideo(r.Context(), video.VideoID); err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RefreshVideoV2 handles POST
+// @Summary     Poll a video now, by video_id or TikTok id
+// @Description Same as /api/videos/{video_id}/refresh.
+// @Tags        v2
+// @Produce     json
+// @Param       id  path  string  true  "video_id (42) or tt: + TikTok ID (tt:7301234567890)"
+// @Success     200 {object} models.RefreshVideoResponse
+// @Failure     400 {object} ErrorResponse "Invalid id"
+// @Failure     404 {object} ErrorResponse "Video not found"
+// @Failure     429 {object} ErrorResponse "Refreshed too recently, budget or provider rate limit exhausted"
+// @Failure     503 {object} ErrorResponse "Provider unavailable"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/v2/videos/{id}/refresh [post]
+func (h *Handler) RefreshVideoV2(w http.ResponseWriter, r *http.Request) {
+	video, ok := h.resolveVideo(w, r)
+	if !ok {
+		return
+	}
+
+	h.refreshVideo(w, r, video.VideoID)
+}
+
+// StreamVideoStatsV2 handles GET
+// @Summary     Live stats of a video by video_id or TikTok id (Server-Sent Events)
+// @Description Same as /api/videos/{video_id}/stream.
+// @Tags        v2
+// @Produce     text/event-stream
+// @Param       id            path   string  true  "video_id (42) or tt: + TikTok ID (tt:7301234567890)"
+// @Param       Last-Event-ID header string  false "id of the last event received"
+// @Param       last_event_id query  string  false "same as Last-Event-ID, for clients that cannot set headers"
+// @Success     200 {object} models.VideoStatsEvent "stream of events"
+// @Failure     400 {object} ErrorResponse "Invalid id or Last-Event-ID"
+// @Failure     404 {object} ErrorResponse "Video not found"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/v2/videos/{id}/stream [get]
+func (h *Handler) StreamVideoStatsV2(w http.ResponseWriter, r *http.Request) {
+	video, ok := h.resolveVideo(w, r)
+	if !ok {
+		return
+	}
+
+	h.streamStats(w, r, &video.VideoID)
+}
+
+// resolveVideo looks up the video named by {id} in the workspace; on failure the error is already sent
+func (h *Handler) resolveVideo(w http.ResponseWriter, r *http.Request) (models.TrackVideoResponse, bool) {
+	ref, err := models.ParseVideoRef(chi.URLParam(r, "id"))
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "invalid id", err)
+		return models.TrackVideoResponse{}, false
+	}
+
+	video, err := h.service.GetVideoByRef(r.Context(), ref)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return models.TrackVideoResponse{}, false
+	}
+
+	return video, true
+}
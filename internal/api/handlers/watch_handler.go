@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type WatchService interface {
+	RegisterWatch(ctx context.Context, req models.RegisterWatchRequest) (models.WatchResponse, error)
+	GetWatch(ctx context.Context, watchID int64) (models.WatchResponse, error)
+}
+
+// RegisterWatch handles POST
+// @Summary     Track all videos using a hashtag or sound
+// @Description Registers a hashtag (`kind=hashtag`, value without `#`) or a sound (`kind=sound`, numeric music id).
+// @Description A background discovery job queries the provider for matching posts created at or after `start_date`
+// @Description and starts tracking them. When `allowed_creators` is set, only posts by those usernames are tracked.
+// @Tags        watches
+// @Accept      json
+// @Produce     json
+// @Param       request body models.RegisterWatchRequest true "Hashtag or sound to watch"
+// @Success     201 {object} models.WatchResponse
+// @Failure     400 {object} ErrorResponse "Invalid kind or value"
+// @Failure     409 {object} ErrorResponse "Hashtag or sound already watched"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/watches [post]
+func (h *Handler) RegisterWatch(w http.ResponseWriter, r *http.Request) {
+	var req models.RegisterWatchRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	logging.From(r.Context(), h.logger).Infof("HTTP RegisterWatch: incoming kind=%s value=%s", req.Kind, req.Value)
+
+	resp, err := h.watches.RegisterWatch(r.Context(), req)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusCreated, resp)
+}
+
+// GetWatch handles GET
+// @Summary     Get a watched hashtag or sound with aggregates
+// @Description Returns total views, earnings and number of tracked videos matched by the hashtag or sound.
+// @Tags        watches
+// @Produce     json
+// @Param       watch_id path string true "watch ID"
+// @Success     200 {object} models.WatchResponse
+// @Failure     400 {object} ErrorResponse "Invalid watch_id"
+// @Failure     404 {object} ErrorResponse "Watch not found"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/watches/{watch_id} [get]
+func (h *Handler) GetWatch(w http.ResponseWriter, r *http.Request) {
+	watchID, err := strconv.ParseInt(chi.URLParam(r, "watch_id"), 10, 64)
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "invalid watch_id", err)
+		return
+	}
+
+	resp, err := h.watches.GetWatch(r.Context(), watchID)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"ttanalytic/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, req models.CreateWebhookRequest) (models.WebhookResponse, error)
+	ListWebhooks(ctx context.Context) ([]models.WebhookResponse, error)
+	DeleteWebhook(ctx context.Context, webhookID int64) error
+	ListDeliveries(ctx context.Context, webhookID int64, limit int) ([]models.WebhookDeliveryResponse, error)
+}
+
+// CreateWebhook handles POST
+// @Summary     Subscribe a URL to video lifecycle events
+// @Description Events: `video.tracked`, `video.milestone_reached`, `video.error`, `video.stopped`, `earnings.threshold`.
+// @Description Every delivery is a JSON POST signed with the returned secret:
+// @Description `X-Webhook-Signature: t=<unix>,v1=<hex hmac-sha256(secret, "<unix>.<body>")>`.
+// @Description Non-2xx responses are retried with exponential backoff. Requires the `admin` scope.
+// @Tags        webhooks
+// @Accept      json
+// @Produce     json
+// @Param       request body models.CreateWebhookRequest true "Receiver URL and events"
+// @Success     201 {object} models.WebhookResponse
+// @Failure     400 {object} ErrorResponse "Invalid url or events"
+// @Failure     401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure     403 {object} ErrorResponse "Missing admin scope"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/webhooks [post]
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateWebhookRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	resp, err := h.webhooks.CreateWebhook(r.Context(), req)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusCreated, resp)
+}
+
+// ListWebhooks handles GET
+// @Summary     List webhook subscriptions
+// @Description Secrets are never returned. Requires the `admin` scope.
+// @Tags        webhooks
+// @Produce     json
+// @Success     200 {array}  models.WebhookResponse
+// @Failure     401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure     403 {object} ErrorResponse "Missing admin scope"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/webhooks [get]
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.webhooks.ListWebhooks(r.Context())
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, resp)
+}
+
+// DeleteWebhook handles DELETE
+// @Summary     Delete a webhook subscription
+// @Description Pending deliveries and the delivery log are removed with it. Requires the `admin` scope.
+// @Tags        webhooks
+// @Param       webhook_id path string true "webhook ID"
+// @Success     204
+// @Failure     400 {object} ErrorResponse "Invalid webhook_id"
+// @Failure     401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure     403 {object} ErrorResponse "Missing admin scope"
+// @Failure     404 {object} ErrorResponse "Webhook not found"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/webhooks/{webhook_id} [delete]
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := strconv.ParseInt(chi.URLParam(r, "webhook_id"), 10, 64)
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "invalid webhook_id", err)
+		return
+	}
+
+	if err := h.webhooks.DeleteWebhook(r.Context(), webhookID); err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries handles GET
+// @Summary     Delivery log of a webhook
+// @Description Latest deliveries first with status, attempts, last response code and next retry time.
+// @Description Requires the `admin` scope.
+// @Tags        webhooks
+// @Produce     json
+// @Param       webhook_id path  string true  "webhook ID"
+// @Param       limit      query int    false "Max deliveries, default 50, max 500"
+// @Success     200 {array}  models.WebhookDeliveryResponse
+// @Failure     400 {object} ErrorResponse "Invalid webhook_id or limit"
+// @Failure     401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure     403 {object} ErrorResponse "Missing admin scope"
+// @Failure     404 {object} ErrorResponse "Webhook not found"
+// @Failure     500 {object} ErrorResponse "Internal server error"
+// @Router      /api/webhooks/{webhook_id}/deliveries [get]
+func (h *Handler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := strconv.ParseInt(chi.URLParam(r, "webhook_id"), 10, 64)
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "invalid webhook_id", err)
+		return
+	}
+
+	limit := defaultDeliveriesLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxDeliveriesLimit {
+			h.sendError(w, r, http.StatusBadRequest, "invalid limit", err)
+			return
+		}
+	}
+
+	resp, err := h.webhooks.ListDeliveries(r.Context(), webhookID, limit)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, resp)
+}
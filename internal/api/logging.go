@@ -0,0 +1,78 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/tracing"
+
+	"github.com/go-chi/chi/middleware"
+	"go.uber.org/zap"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	maxRequestIDLen = 128
+)
+
+// requestLogging propagates X-Request-ID, hands every layer a logger carrying it and the trace id through
+// the request context and writes one access line per request once it is served
+func requestLogging(base *zap.SugaredLogger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started := time.Now()
+
+			requestID := r.Header.Get(requestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = newRequestID()
+			}
+			w.Header().Set(requestIDHeader, requestID)
+
+			logger := base.With(append(tracing.LogFields(r.Context()), "request_id", requestID)...)
+			ctx := logging.NewContext(r.Context(), logger)
+			ctx = logging.WithRequestID(ctx, requestID)
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			route, status := routeAndStatus(r, ww)
+
+			logger.Infow("http request",
+				"method", r.Method,
+				"route", route,
+				"status", status,
+				"latency", time.Since(started),
+				"bytes", ww.BytesWritten(),
+			)
+		})
+	}
+}
+
+// validRequestID accepts caller ids that are safe to echo and log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ttanalytic/internal/logging"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type infof interface {
+	Infof(format string, args ...any)
+}
+
+func TestRequestLogging(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+
+	var seenID string
+
+	r := chi.NewRouter()
+	r.Use(requestLogging(zap.New(core).Sugar()))
+	r.Get("/api/videos/{video_id}", func(w http.ResponseWriter, r *http.Request) {
+		seenID = logging.RequestID(r.Context())
+		// what a service does with the context it is given
+		logging.From[infof](r.Context(), nil).Infof("service line")
+		_, _ = w.Write([]byte("hello"))
+	})
+
+	t.Run("propagates a valid id", func(t *testing.T) {
+		logs.TakeAll()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/videos/7", nil)
+		req.Header.Set(requestIDHeader, "edge-42")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get(requestIDHeader); got != "edge-42" {
+			t.Fatalf("response %s = %q, want edge-42", requestIDHeader, got)
+		}
+		if seenID != "edge-42" {
+			t.Fatalf("request id in context = %q, want edge-42", seenID)
+		}
+
+		entries := logs.TakeAll()
+		if len(entries) != 2 {
+			t.Fatalf("expected a service line and an access line, got %d entries", len(entries))
+		}
+		for _, e := range entries {
+			if got := e.ContextMap()["request_id"]; got != "edge-42" {
+				t.Fatalf("%q: request_id = %v, want edge-42", e.Message, got)
+			}
+		}
+
+		access := entries[1].ContextMap()
+		if access["route"] != "/api/videos/{video_id}" || access["status"] != int64(200) || access["bytes"] != int64(5) {
+			t.Fatalf("unexpected access line %v", access)
+		}
+	})
+
+	t.Run("replaces an unsafe id", func(t *testing.T) {
+		logs.TakeAll()
+
+		req := httptest.NewRequest(http.MethodGet, "/nope", nil)
+		req.Header.Set(requestIDHeader, "bad id\nforged=1")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		got := rec.Header().Get(requestIDHeader)
+		if got == "" || got == req.Header.Get(requestIDHeader) {
+			t.Fatalf("expected a generated request id, got %q", got)
+		}
+
+		entries := logs.TakeAll()
+		if len(entries) != 1 || entries[0].ContextMap()["route"] != unmatchedRoute {
+			t.Fatalf("expected one access line for the unmatched route, got %v", entries)
+		}
+	})
+}
+
+// every line of a traced request carries its trace id, not only the access line
+func TestRequestLogging_TraceIDOnEveryLine(t *testing.T) {
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	core, logs := observer.New(zap.InfoLevel)
+
+	r := chi.NewRouter()
+	r.Use(traceRequests)
+	r.Use(requestLogging(zap.New(core).Sugar()))
+	r.Get("/api/videos/{video_id}", func(w http.ResponseWriter, r *http.Request) {
+		logging.From[infof](r.Context(), nil).Infof("service line")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/videos/7", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logs.TakeAll()
+	if len(entries) != 2 {
+		t.Fatalf("expected a service line and an access line, got %d entries", len(entries))
+	}
+	for _, e := range entries {
+		if got := e.ContextMap()["trace_id"]; got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Fatalf("%q: trace_id = %v, want the one of traceparent", e.Message, got)
+		}
+	}
+}
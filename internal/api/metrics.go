@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/chi/v5"
+)
+
+// routes that match no pattern share one label to keep the cardinality bounded
+const unmatchedRoute = "unmatched"
+
+type Metrics interface {
+	ObserveHTTPRequest(method, route string, status int, latency time.Duration)
+	Handler() http.Handler
+}
+
+// instrument records every request under its chi route pattern, e.g. /api/videos/{video_id}/history
+func instrument(m Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			route, status := routeAndStatus(r, ww)
+
+			m.ObserveHTTPRequest(r.Method, route, status, time.Since(started))
+		})
+	}
+}
+
+// routeAndStatus reads what a served request matched and answered: its chi route pattern, unmatchedRoute
+// when there is none, and the status written, 200 when the handler wrote none
+func routeAndStatus(r *http.Request, ww middleware.WrapResponseWriter) (string, int) {
+	route := unmatchedRoute
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		route = rctx.RoutePattern()
+	}
+
+	status := ww.Status()
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	return route, status
+}
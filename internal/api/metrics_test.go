@@ -0,0 +1,52 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type recordingMetrics struct {
+	observed []string
+}
+
+func (m *recordingMetrics) ObserveHTTPRequest(method, route string, status int, _ time.Duration) {
+	m.observed = append(m.observed, fmt.Sprintf("%s %s %d", method, route, status))
+}
+
+func (m *recordingMetrics) Handler() http.Handler {
+	return http.NotFoundHandler()
+}
+
+func TestInstrument_LabelsByRoutePattern(t *testing.T) {
+	m := &recordingMetrics{}
+
+	r := chi.NewRouter()
+	r.Use(instrument(m))
+	r.Route("/api", func(r chi.Router) {
+		r.Get("/videos/{video_id}/history", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})
+		r.Get("/videos", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("[]"))
+		})
+	})
+
+	for _, path := range []string{"/api/videos/7/history", "/api/videos/8/history", "/api/videos", "/nope/123"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	want := []string{
+		"GET /api/videos/{video_id}/history 418",
+		"GET /api/videos/{video_id}/history 418",
+		"GET /api/videos 200",
+		"GET unmatched 404",
+	}
+	if fmt.Sprint(m.observed) != fmt.Sprint(want) {
+		t.Fatalf("observed %v, want %v", m.observed, want)
+	}
+}
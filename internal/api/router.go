@@ -4,14 +4,17 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"slices"
 	"time"
 
 	"ttanalytic/internal/config"
+	"ttanalytic/internal/models"
 
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"go.uber.org/zap"
 )
 
 type Handler interface {
@@ -19,6 +22,38 @@ type Handler interface {
 	GetVideo(w http.ResponseWriter, r *http.Request)
 	GetVideoHistory(w http.ResponseWriter, r *http.Request)
 	StopVideoTracking(w http.ResponseWriter, r *http.Request)
+	RefreshVideo(w http.ResponseWriter, r *http.Request)
+	GetVideoV2(w http.ResponseWriter, r *http.Request)
+	GetVideoHistoryV2(w http.ResponseWriter, r *http.Request)
+	StopVideoTrackingV2(w http.ResponseWriter, r *http.Request)
+	RefreshVideoV2(w http.ResponseWriter, r *http.Request)
+	StreamVideoStatsV2(w http.ResponseWriter, r *http.Request)
+	DeleteVideoV2(w http.ResponseWriter, r *http.Request)
+	PurgeCreator(w http.ResponseWriter, r *http.Request)
+	ListAudit(w http.ResponseWriter, r *http.Request)
+	RegisterCreator(w http.ResponseWriter, r *http.Request)
+	GetCreator(w http.ResponseWriter, r *http.Request)
+	RegisterWatch(w http.ResponseWriter, r *http.Request)
+	GetWatch(w http.ResponseWriter, r *http.Request)
+	CreateAPIKey(w http.ResponseWriter, r *http.Request)
+	ListAPIKeys(w http.ResponseWriter, r *http.Request)
+	RevokeAPIKey(w http.ResponseWriter, r *http.Request)
+	CreateWebhook(w http.ResponseWriter, r *http.Request)
+	ListWebhooks(w http.ResponseWriter, r *http.Request)
+	DeleteWebhook(w http.ResponseWriter, r *http.Request)
+	ListWebhookDeliveries(w http.ResponseWriter, r *http.Request)
+	StreamVideoStats(w http.ResponseWriter, r *http.Request)
+	StreamStats(w http.ResponseWriter, r *http.Request)
+	ListVideos(w http.ResponseWriter, r *http.Request)
+	CreateExport(w http.ResponseWriter, r *http.Request)
+	GetExport(w http.ResponseWriter, r *http.Request)
+	DownloadExport(w http.ResponseWriter, r *http.Request)
+}
+
+// Probes serves the liveness and readiness reports
+type Probes interface {
+	LiveHandler() http.Handler
+	ReadyHandler() http.Handler
 }
 
 // Router handles HTTP routing
@@ -27,16 +62,24 @@ type Router struct {
 	handlers Handler
 }
 
-func NewRouter(cfg *config.Config, handler Handler) *Router {
+// NewRouter builds the HTTP server; metrics may be nil to disable /metrics
+func NewRouter(cfg *config.Config, handler Handler, authn Authenticator, metrics Metrics, probes Probes, logger *zap.SugaredLogger) *Router {
 	r := chi.NewRouter()
 
 	// middleware
+	r.Use(traceRequests)
+	r.Use(requestLogging(logger))
+	if metrics != nil {
+		r.Use(instrument(metrics))
+	}
 	r.Use(middleware.Recoverer)
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		AllowCredentials: true,
+		AllowedOrigins: cfg.ServerOpts.CORSAllowedOrigins,
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-API-Key", "Last-Event-ID", requestIDHeader},
+		ExposedHeaders: []string{requestIDHeader},
+		// credentials are never allowed together with a wildcard origin
+		AllowCredentials: !slices.Contains(cfg.ServerOpts.CORSAllowedOrigins, "*"),
 	}))
 
 	// health
@@ -44,18 +87,92 @@ func NewRouter(cfg *config.Config, handler Handler) *Router {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
 	})
+	r.Method(http.MethodGet, "/livez", probes.LiveHandler())
+	r.Method(http.MethodGet, "/readyz", probes.ReadyHandler())
+
+	// prometheus
+	if metrics != nil {
+		r.Handle(cfg.Metrics.Path, metrics.Handler())
+	}
+
+	// export files, authorised by the link signature
+	r.Get("/exports/{export_id}/download", handler.DownloadExport)
 
 	// Swagger UI
 	r.Get("/swagger/*", httpSwagger.WrapHandler)
 
+	authEnabled := cfg.Auth.Enabled
+	read := requireScope(authEnabled, models.ScopeRead)
+	track := requireScope(authEnabled, models.ScopeTrack)
+	admin := requireScope(authEnabled, models.ScopeAdmin)
+
 	//chi router
 	r.Route("/api", func(r chi.Router) {
+		if authEnabled {
+			r.Use(authenticate(authn))
+		} else {
+			r.Use(defaultWorkspace)
+		}
+
+		//v2: {id} is the video_id or tt:<tiktok_id> on every video route
+		r.Route("/v2", func(r chi.Router) {
+			r.With(track).Post("/videos", handler.TrackVideo)
+			r.With(read).Get("/videos", handler.ListVideos)
+			r.With(read).Get("/videos/{id}", handler.GetVideoV2)
+			r.With(read).Get("/videos/{id}/history", handler.GetVideoHistoryV2)
+			r.With(read).Get("/videos/{id}/stream", handler.StreamVideoStatsV2)
+			r.With(track).Post("/videos/{id}/refresh", handler.RefreshVideoV2)
+			r.With(admin).Post("/videos/{id}/stop", handler.StopVideoTrackingV2)
+			r.With(admin).Delete("/videos/{id}", handler.DeleteVideoV2)
+		})
+
+		//v1, kept for existing clients
 		//create or get video
-		r.Post("/videos", handler.TrackVideo)
-		r.Post("/videos/{video_id}/stop", handler.StopVideoTracking)
-		r.Get("/videos/{tiktok_id}", handler.GetVideo)
-		r.Get("/videos/{video_id}/history", handler.GetVideoHistory)
+		r.With(track).Post("/videos", handler.TrackVideo)
+		r.With(read).Get("/videos", handler.ListVideos)
+		r.With(admin).Post("/videos/{video_id}/stop", handler.StopVideoTracking)
+		r.With(track).Post("/videos/{video_id}/refresh", handler.RefreshVideo)
+		r.With(read).Get("/videos/{tiktok_id}", handler.GetVideo)
+		r.With(read).Get("/videos/{video_id}/history", handler.GetVideoHistory)
+
+		//live stats (SSE)
+		r.With(read).Get("/videos/{video_id}/stream", handler.StreamVideoStats)
+		r.With(read).Get("/stream", handler.StreamStats)
+
+		//exports
+		r.With(track).Post("/exports", handler.CreateExport)
+		r.With(read).Get("/exports/{export_id}", handler.GetExport)
+
+		//creators
+		r.With(track).Post("/creators", handler.RegisterCreator)
+		r.With(read).Get("/creators/{creator_id}", handler.GetCreator)
+
+		//hashtags and sounds
+		r.With(track).Post("/watches", handler.RegisterWatch)
+		r.With(read).Get("/watches/{watch_id}", handler.GetWatch)
+
+		//api keys
+		r.Route("/admin/keys", func(r chi.Router) {
+			r.Use(admin)
+			r.Post("/", handler.CreateAPIKey)
+			r.Get("/", handler.ListAPIKeys)
+			r.Delete("/{key_id}", handler.RevokeAPIKey)
+		})
+
+		//data-subject erasure
+		r.With(admin).Post("/admin/creators/purge", handler.PurgeCreator)
+
+		//audit log
+		r.With(admin).Get("/audit", handler.ListAudit)
 
+		//webhooks
+		r.Route("/webhooks", func(r chi.Router) {
+			r.Use(admin)
+			r.Post("/", handler.CreateWebhook)
+			r.Get("/", handler.ListWebhooks)
+			r.Delete("/{webhook_id}", handler.DeleteWebhook)
+			r.Get("/{webhook_id}/deliveries", handler.ListWebhookDeliveries)
+		})
 	})
 
 	//server
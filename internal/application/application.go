@@ -2,20 +2,29 @@ package application
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 	"ttanalytic/internal/api"
 	"ttanalytic/internal/api/handlers"
 	"ttanalytic/internal/config"
+	"ttanalytic/internal/eventbus"
+	"ttanalytic/internal/health"
 	pgprovider "ttanalytic/internal/infrastructure"
 	"ttanalytic/internal/infrastructure/dbtx"
+	"ttanalytic/internal/infrastructure/pglisten"
 	tiktokprovider "ttanalytic/internal/infrastructure/tiktok_provider"
+	"ttanalytic/internal/metrics"
+	"ttanalytic/internal/models"
 
 	"ttanalytic/internal/repo"
 	"ttanalytic/internal/service"
+	"ttanalytic/internal/tracing"
+	"ttanalytic/migrations"
 
 	"go.uber.org/zap"
 )
@@ -27,9 +36,22 @@ type Application struct {
 	repo       *repo.Repository
 	service    handlers.Service
 	updater    *service.UpdaterService
+	creators   *service.CreatorService
+	watches    *service.WatchService
+	keys       *service.APIKeyService
+	webhooks   *service.WebhookService
+	relay      *service.OutboxRelay
+	sink       eventbus.Sink
+	streams    *service.StreamService
+	exports    *service.ExportService
+	deletions  *service.DeletionService
+	metrics    *metrics.Metrics
+	health     *health.Checker
 	transactor dbtx.Transactor
 	provider   service.TikTokProvider
+	client     *tiktokprovider.Client
 	router     *api.Router
+	tracing    func(context.Context) error
 	wg         sync.WaitGroup
 }
 
@@ -45,6 +67,10 @@ func (a *Application) Start(ctx context.Context) error {
 		return fmt.Errorf("init logger: %w", err)
 	}
 
+	if err := a.initTracing(ctx); err != nil {
+		return fmt.Errorf("init tracing: %w", err)
+	}
+
 	if err := a.initDatabase(ctx); err != nil {
 		return fmt.Errorf("init database: %w", err)
 	}
@@ -53,10 +79,14 @@ func (a *Application) Start(ctx context.Context) error {
 		return fmt.Errorf("init transactor: %w", err)
 	}
 
-	if err := a.initRepository(); err != nil {
+	if err := a.initRepository(ctx); err != nil {
 		return fmt.Errorf("init repository: %w", err)
 	}
 
+	if err := a.initMetrics(); err != nil {
+		return fmt.Errorf("init metrics: %w", err)
+	}
+
 	if err := a.initProvider(); err != nil {
 		return fmt.Errorf("init provider: %w", err)
 	}
@@ -69,6 +99,42 @@ func (a *Application) Start(ctx context.Context) error {
 		return fmt.Errorf("init updater: %w", err)
 	}
 
+	if err := a.initDiscovery(ctx); err != nil {
+		return fmt.Errorf("init discovery: %w", err)
+	}
+
+	if err := a.initWebhooks(ctx); err != nil {
+		return fmt.Errorf("init webhooks: %w", err)
+	}
+
+	if err := a.initOutbox(ctx); err != nil {
+		return fmt.Errorf("init outbox: %w", err)
+	}
+
+	if err := a.initStream(ctx); err != nil {
+		return fmt.Errorf("init stream: %w", err)
+	}
+
+	if err := a.initExports(ctx); err != nil {
+		return fmt.Errorf("init exports: %w", err)
+	}
+
+	if err := a.initPurge(ctx); err != nil {
+		return fmt.Errorf("init purge: %w", err)
+	}
+
+	if err := a.initPartitions(ctx); err != nil {
+		return fmt.Errorf("init partitions: %w", err)
+	}
+
+	if err := a.initRollups(ctx); err != nil {
+		return fmt.Errorf("init rollups: %w", err)
+	}
+
+	if err := a.initHealth(); err != nil {
+		return fmt.Errorf("init health: %w", err)
+	}
+
 	if err := a.initRouter(); err != nil {
 		return fmt.Errorf("init router: %w", err)
 	}
@@ -87,6 +153,11 @@ func (a *Application) Wait(ctx context.Context, cancel context.CancelFunc) error
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer shutdownCancel()
 
+	// give the load balancer time to see /readyz fail before connections are refused
+	a.health.Drain()
+	a.logger.Infof("Readiness: draining for %s", a.cfg.Health.ShutdownDelay)
+	time.Sleep(a.cfg.Health.ShutdownDelay)
+
 	if err := a.router.Shutdown(shutdownCtx); err != nil {
 		a.logger.Errorf("HTTP server shutdown error: %v", err)
 	}
@@ -95,6 +166,16 @@ func (a *Application) Wait(ctx context.Context, cancel context.CancelFunc) error
 	a.logger.Info("Database connections closed")
 
 	a.wg.Wait()
+
+	if err := a.sink.Close(); err != nil {
+		a.logger.Errorf("Outbox sink close error: %v", err)
+	}
+
+	// flushes the spans still batched
+	if err := a.tracing(shutdownCtx); err != nil {
+		a.logger.Errorf("Tracing shutdown error: %v", err)
+	}
+
 	a.logger.Info("Graceful shutdown completed")
 
 	return nil
@@ -118,6 +199,23 @@ func (a *Application) initLogger() error {
 
 	return nil
 }
+func (a *Application) initTracing(ctx context.Context) error {
+	shutdown, err := tracing.Setup(ctx, tracing.Config{
+		Exporter:    a.cfg.Tracing.Exporter,
+		Endpoint:    a.cfg.Tracing.Endpoint,
+		Insecure:    a.cfg.Tracing.Insecure,
+		ServiceName: a.cfg.Tracing.ServiceName,
+		SampleRatio: a.cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		return err
+	}
+
+	a.tracing = shutdown
+	a.logger.Infow("Tracing configured", "exporter", a.cfg.Tracing.Exporter, "sample_ratio", a.cfg.Tracing.SampleRatio)
+
+	return nil
+}
 func (a *Application) initDatabase(ctx context.Context) error {
 	a.db = pgprovider.NewProvider(
 		a.logger,
@@ -130,6 +228,9 @@ func (a *Application) initDatabase(ctx context.Context) error {
 		a.cfg.SQLDataBase.MaxOpenConns,
 		a.cfg.SQLDataBase.ConnMaxLifetimeMin,
 	)
+	if a.cfg.SQLDataBase.ReplicaDSN != "" {
+		a.db.WithReplica(a.cfg.SQLDataBase.ReplicaDSN)
+	}
 
 	if err := a.db.Open(ctx); err != nil {
 		return fmt.Errorf("open database: %w", err)
@@ -144,16 +245,44 @@ func (a *Application) initTransactor() error {
 	return nil
 }
 
-func (a *Application) initRepository() error {
+func (a *Application) initRepository(ctx context.Context) error {
 	a.repo = repo.NewRepository(
 		a.db.DB(),
 		a.logger,
 		a.cfg.SQLDataBase.QueryTimeoutSec,
 	)
 
+	if a.db.Replica() == nil {
+		return nil
+	}
+
+	a.repo.WithReplica(a.db.Replica(), a.cfg.SQLDataBase.ReplicaMaxLag)
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.repo.MonitorReplica(ctx, a.cfg.SQLDataBase.ReplicaCheckInterval)
+	}()
+
+	a.logger.Infof("Repository: read replica configured (max_lag=%s, check_interval=%s)",
+		a.cfg.SQLDataBase.ReplicaMaxLag,
+		a.cfg.SQLDataBase.ReplicaCheckInterval,
+	)
+
 	return nil
 }
 
+// initMetrics builds the registry; the provider and the updater always report to it,
+// the router serves it only when metrics are enabled
+func (a *Application) initMetrics() error {
+	a.metrics = metrics.New()
+
+	return a.metrics.Register(
+		metrics.NewPoolCollector(a.db.Stats),
+		metrics.NewQueueLagCollector(a.repo),
+	)
+}
+
 func (a *Application) initProvider() error {
 	log := a.logger
 
@@ -166,14 +295,22 @@ func (a *Application) initProvider() error {
 		APIKey:          a.cfg.Provider.Token,
 		MaxRetriesCount: a.cfg.Provider.MaxRetriesCount,
 		RetryTimeout:    a.cfg.Provider.RetryTimeout,
+		MaxRetryDelay:   a.cfg.Provider.MaxRetryDelay,
+		CallTimeout:     a.cfg.Provider.CallTimeout,
+		MaxBatchSize:    a.cfg.Provider.MaxBatchSize,
+		MaxPostPages:    a.cfg.Provider.MaxPostPages,
+
+		BreakerThreshold: a.cfg.Provider.BreakerThreshold,
+		BreakerCooldown:  a.cfg.Provider.BreakerCooldown,
 	}
 
-	prov, err := tiktokprovider.NewClient(httpCli, cfg, log)
+	prov, err := tiktokprovider.NewClient(httpCli, cfg, log, a.metrics)
 	if err != nil {
 		return err
 	}
 
 	a.provider = prov
+	a.client = prov
 	return nil
 }
 func (a *Application) initService() error {
@@ -187,6 +324,8 @@ func (a *Application) initService() error {
 		earnings,
 		a.logger,
 		a.transactor,
+		a.repo,
+		a.repo,
 	)
 
 	return nil
@@ -198,6 +337,13 @@ func (a *Application) initUpdater(ctx context.Context) error {
 		BatchSize:      a.cfg.Updater.BatchSize,
 		MinUpdateAge:   time.Duration(a.cfg.Updater.MinUpdateAge) * time.Second,
 		MaxConcurrency: a.cfg.Updater.MaxConcurrency,
+		FlushSize:      a.cfg.Updater.FlushSize,
+
+		ViewMilestones:     a.cfg.Webhooks.ViewMilestones,
+		EarningsThresholds: a.cfg.Webhooks.EarningsThresholds,
+	}
+	if err := updaterCfg.Validate(); err != nil {
+		return err
 	}
 	earnings := service.EarningsConfig{
 		Rate: a.cfg.Earnings.Rate,
@@ -210,29 +356,364 @@ func (a *Application) initUpdater(ctx context.Context) error {
 		updaterCfg,
 		earnings,
 		a.transactor,
+		a.repo,
+		a.repo,
+		a.metrics,
 	)
 
-
 	a.wg.Add(1)
 	go func() {
 		defer a.wg.Done()
 		a.updater.Run(ctx)
 	}()
 
-	a.logger.Infof("Updater: goroutine started (interval=%s, min_update_age=%s, batch=%d)",
+	a.logger.Infof("Updater: goroutine started (interval=%s, min_update_age=%s, batch=%d, flush=%d)",
 		a.cfg.Updater.Interval,
 		a.cfg.Updater.MinUpdateAge,
 		a.cfg.Updater.BatchSize,
+		a.cfg.Updater.FlushSize,
+	)
+
+	return nil
+}
+func (a *Application) initDiscovery(ctx context.Context) error {
+	discoveryCfg := service.DiscoveryConfig{
+		Interval:   time.Duration(a.cfg.Discovery.Interval) * time.Second,
+		BatchSize:  a.cfg.Discovery.BatchSize,
+		MinSyncAge: time.Duration(a.cfg.Discovery.MinSyncAge) * time.Second,
+	}
+	earnings := service.EarningsConfig{
+		Rate: a.cfg.Earnings.Rate,
+		Per:  a.cfg.Earnings.Per,
+	}
+	a.creators = service.NewCreatorService(
+		a.repo,
+		a.client,
+		a.logger,
+		discoveryCfg,
+		earnings,
+		a.transactor,
+		a.repo,
+		a.repo,
+	)
+
+	a.watches = service.NewWatchService(
+		a.repo,
+		a.client,
+		a.logger,
+		discoveryCfg,
+		earnings,
+		a.transactor,
+		a.repo,
+		a.repo,
+	)
+
+	a.wg.Add(2)
+	go func() {
+		defer a.wg.Done()
+		a.creators.Run(ctx)
+	}()
+	go func() {
+		defer a.wg.Done()
+		a.watches.Run(ctx)
+	}()
+
+	a.logger.Infof("Discovery: goroutines started (interval=%s, min_sync_age=%s, batch=%d)",
+		discoveryCfg.Interval,
+		discoveryCfg.MinSyncAge,
+		discoveryCfg.BatchSize,
+	)
+
+	return nil
+}
+
+func (a *Application) initWebhooks(ctx context.Context) error {
+	webhooksCfg := service.WebhookConfig{
+		Interval:      time.Duration(a.cfg.Webhooks.Interval) * time.Second,
+		BatchSize:     a.cfg.Webhooks.BatchSize,
+		MaxAttempts:   a.cfg.Webhooks.MaxAttempts,
+		RetryDelay:    a.cfg.Webhooks.RetryDelay,
+		MaxRetryDelay: a.cfg.Webhooks.MaxRetryDelay,
+		Timeout:       a.cfg.Webhooks.Timeout,
+	}
+
+	a.webhooks = service.NewWebhookService(
+		a.repo,
+		&http.Client{Timeout: webhooksCfg.Timeout},
+		a.logger,
+		webhooksCfg,
+		a.transactor,
+		a.repo,
+	)
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.webhooks.Run(ctx)
+	}()
+
+	a.logger.Infof("Webhooks: dispatcher started (interval=%s, batch=%d, max_attempts=%d)",
+		webhooksCfg.Interval,
+		webhooksCfg.BatchSize,
+		webhooksCfg.MaxAttempts,
 	)
 
 	return nil
 }
+
+func (a *Application) initOutbox(ctx context.Context) error {
+	sink, err := newEventSink(a.cfg.Outbox)
+	if err != nil {
+		return err
+	}
+	a.sink = sink
+
+	outboxCfg := service.OutboxConfig{
+		Interval:      time.Duration(a.cfg.Outbox.Interval) * time.Second,
+		BatchSize:     a.cfg.Outbox.BatchSize,
+		RetryDelay:    a.cfg.Outbox.RetryDelay,
+		MaxRetryDelay: a.cfg.Outbox.MaxRetryDelay,
+		Lease:         a.cfg.Outbox.Lease,
+		MaxAttempts:   a.cfg.Outbox.MaxAttempts,
+	}
+
+	a.relay = service.NewOutboxRelay(a.repo, a.sink, a.logger, outboxCfg)
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.relay.Run(ctx)
+	}()
+
+	a.logger.Infof("Outbox: relay started (sink=%s, interval=%s, batch=%d)",
+		a.cfg.Outbox.Sink,
+		outboxCfg.Interval,
+		outboxCfg.BatchSize,
+	)
+
+	return nil
+}
+
+// newEventSink builds the configured outbox sink. NATS is wired in code with
+// eventbus.NewNATSSink around a JetStream client.
+func newEventSink(cfg config.OutboxConfig) (eventbus.Sink, error) {
+	switch cfg.Sink {
+	case "stdout", "":
+		return eventbus.NewStdoutSink(), nil
+	case "file":
+		return eventbus.OpenFileSink(cfg.FilePath)
+	case "http":
+		if cfg.URL == "" {
+			return nil, errors.New("outbox: sink http requires url")
+		}
+		return eventbus.NewHTTPSink(cfg.URL, &http.Client{Timeout: cfg.Lease}), nil
+	default:
+		return nil, fmt.Errorf("outbox: unknown sink %q", cfg.Sink)
+	}
+}
+
+func (a *Application) initStream(ctx context.Context) error {
+	streamCfg := service.StreamConfig{
+		Heartbeat:    a.cfg.Stream.Heartbeat,
+		BufferSize:   a.cfg.Stream.BufferSize,
+		ReplayLimit:  a.cfg.Stream.ReplayLimit,
+		ReplayWindow: a.cfg.Stream.ReplayWindow,
+	}
+
+	a.streams = service.NewStreamService(a.repo, a.logger, streamCfg)
+	listener := pglisten.NewListener(a.db.DB(), models.StatsChannel, a.logger, a.cfg.Stream.RetryDelay)
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		listener.Run(ctx, a.streams)
+
+		// end open SSE responses so the HTTP server can shut down
+		a.streams.Close()
+	}()
+
+	a.logger.Infof("Stream: listening on %s (heartbeat=%s)", models.StatsChannel, streamCfg.Heartbeat)
+
+	return nil
+}
+
+func (a *Application) initExports(ctx context.Context) error {
+	signingKey := []byte(a.cfg.Exports.SigningKey)
+	if len(signingKey) == 0 {
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			return fmt.Errorf("generate signing key: %w", err)
+		}
+		a.logger.Warnf("Exports: no signing_key set, download links only work on this replica until restart")
+	}
+
+	exportCfg := service.ExportConfig{
+		Dir:          a.cfg.Exports.Dir,
+		Interval:     time.Duration(a.cfg.Exports.Interval) * time.Second,
+		Lease:        a.cfg.Exports.Lease,
+		MaxAttempts:  a.cfg.Exports.MaxAttempts,
+		LinkTTL:      a.cfg.Exports.LinkTTL,
+		SigningKey:   signingKey,
+		PublicURL:    strings.TrimSuffix(a.cfg.Exports.PublicURL, "/"),
+		RowGroupSize: a.cfg.Exports.RowGroupSize,
+	}
+
+	a.exports = service.NewExportService(a.repo, a.logger, exportCfg, a.transactor, a.repo)
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.exports.Run(ctx)
+	}()
+
+	a.logger.Infof("Exports: worker started (dir=%s, interval=%s, link_ttl=%s)",
+		exportCfg.Dir, exportCfg.Interval, exportCfg.LinkTTL)
+
+	return nil
+}
+
+// initPurge serves video deletion and creator erasure, and starts the job removing
+// deleted videos once their retention window is over
+func (a *Application) initPurge(ctx context.Context) error {
+	purgeCfg := service.DeletionConfig{
+		Retention: a.cfg.Purge.Retention,
+		Interval:  a.cfg.Purge.Interval,
+		BatchSize: a.cfg.Purge.BatchSize,
+	}
+
+	a.deletions = service.NewDeletionService(a.repo, a.transactor, a.logger, purgeCfg)
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.deletions.Run(ctx)
+	}()
+
+	a.logger.Infof("Purge: worker started (retention=%s, interval=%s, batch=%d)",
+		purgeCfg.Retention, purgeCfg.Interval, purgeCfg.BatchSize)
+
+	return nil
+}
+
+// initPartitions starts the job keeping the monthly partitions of video_stats
+func (a *Application) initPartitions(ctx context.Context) error {
+	partitionsCfg := service.PartitionConfig{
+		Interval:     a.cfg.Partitions.Interval,
+		Premake:      a.cfg.Partitions.Premake,
+		ArchiveAfter: a.cfg.Partitions.ArchiveAfter,
+		RawRetention: a.cfg.Rollups.RawRetention,
+	}
+	if err := partitionsCfg.Validate(); err != nil {
+		return err
+	}
+
+	partitions := service.NewPartitionService(a.repo, a.transactor, a.logger, partitionsCfg)
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		partitions.Run(ctx)
+	}()
+
+	a.logger.Infof("Partitions: worker started (interval=%s, premake=%d, archive_after=%d)",
+		partitionsCfg.Interval,
+		partitionsCfg.Premake,
+		partitionsCfg.ArchiveAfter,
+	)
+
+	return nil
+}
+
+// initRollups starts the job maintaining the daily and weekly rollups and the retention of video_stats
+func (a *Application) initRollups(ctx context.Context) error {
+	rollupsCfg := service.RollupConfig{
+		Interval:       a.cfg.Rollups.Interval,
+		RawRetention:   a.cfg.Rollups.RawRetention,
+		DailyRetention: a.cfg.Rollups.DailyRetention,
+		BatchSize:      a.cfg.Rollups.BatchSize,
+	}
+
+	rollups := service.NewRollupService(a.repo, a.transactor, a.logger, rollupsCfg)
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		rollups.Run(ctx)
+	}()
+
+	a.logger.Infof("Rollups: worker started (interval=%s, raw_retention=%s, daily_retention=%s)",
+		rollupsCfg.Interval,
+		rollupsCfg.RawRetention,
+		rollupsCfg.DailyRetention,
+	)
+
+	return nil
+}
+
+// initHealth registers the probes: /livez restarts a process whose updater stopped,
+// /readyz takes an instance out of rotation when the database or its schema is not usable
+func (a *Application) initHealth() error {
+	schema, err := migrations.Latest()
+	if err != nil {
+		return fmt.Errorf("embedded migrations: %w", err)
+	}
+
+	staleAfter := a.cfg.Health.UpdaterStaleAfter
+	if staleAfter <= 0 {
+		staleAfter = 3 * time.Duration(a.cfg.Updater.Interval) * time.Second
+	}
+
+	a.health = health.New(a.cfg.Health.Timeout)
+
+	a.health.AddLiveness("updater", health.Heartbeat(a.updater.Heartbeat, staleAfter))
+
+	a.health.AddReadiness("database", health.Ping(a.db), true)
+	a.health.AddReadiness("migrations", health.SchemaVersion(a.repo, schema), true)
+	// an Ensemble outage only stalls polling, reads keep working
+	a.health.AddReadiness("provider", health.Circuit(a.client.CircuitState), false)
+	if a.db.Replica() != nil {
+		// reads fall back to the primary while the replica is down or lagging
+		a.health.AddReadiness("replica", a.repo.ReplicaStatus, false)
+	}
+
+	a.logger.Infof("Health: schema version %d expected, updater stale after %s", schema, staleAfter)
+
+	return nil
+}
+
 func (a *Application) initRouter() error {
+	a.keys = service.NewAPIKeyService(a.repo, a.logger, a.transactor, a.repo)
+
+	refresh := service.NewRefreshService(a.updater, a.logger, service.RefreshConfig{
+		Cooldown:      a.cfg.Refresh.Cooldown,
+		RatePerMinute: a.cfg.Refresh.RatePerMinute,
+		Burst:         a.cfg.Refresh.Burst,
+	})
+
 	h := handlers.NewHandler(
 		a.service,
+		a.creators,
+		a.watches,
+		a.keys,
+		a.webhooks,
+		a.streams,
+		a.exports,
+		refresh,
+		a.deletions,
+		service.NewAuditService(a.repo, a.logger),
 		a.logger,
 	)
-	a.router = api.NewRouter(a.cfg, h)
+	var routerMetrics api.Metrics
+	if a.cfg.Metrics.Enabled {
+		routerMetrics = a.metrics
+		a.logger.Infof("Metrics: serving %s", a.cfg.Metrics.Path)
+	}
+
+	a.router = api.NewRouter(a.cfg, h, a.keys, routerMetrics, a.health, a.logger)
+
+	if !a.cfg.Auth.Enabled {
+		a.logger.Warnf("Auth: disabled, /api is open to anyone")
+	}
 
 	a.startHTTPServer()
 
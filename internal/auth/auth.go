@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"ttanalytic/internal/models"
+)
+
+const (
+	keyPrefix  = "tta_"
+	keyBytes   = 32
+	prefixSize = len(keyPrefix) + 8
+)
+
+type ctxKey struct{}
+
+// GenerateKey returns a new raw API key, its display prefix and the hash stored in the db.
+// The raw key is shown to the user once and never stored.
+func GenerateKey() (raw, prefix, hash string, err error) {
+	buf := make([]byte, keyBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", fmt.Errorf("generate api key: %w", err)
+	}
+
+	raw = keyPrefix + hex.EncodeToString(buf)
+
+	return raw, raw[:prefixSize], HashKey(raw), nil
+}
+
+// HashKey hashes a raw key for lookup. Keys are 256-bit random, so a fast hash is sufficient.
+func HashKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewContext stores the authenticated key in ctx
+func NewContext(ctx context.Context, key *models.APIKey) context.Context {
+	return context.WithValue(ctx, ctxKey{}, key)
+}
+
+// FromContext returns the authenticated key, if any
+func FromContext(ctx context.Context) (*models.APIKey, bool) {
+	key, ok := ctx.Value(ctxKey{}).(*models.APIKey)
+	return key, ok
+}
@@ -0,0 +1,40 @@
+// Package backoff computes the delays between the retries of the provider client, the webhook deliveries
+// and the outbox relay
+package backoff
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Exponential doubles base per attempt (1-based) after the first, capped at max when max > 0
+func Exponential(base, max time.Duration, attempt int) time.Duration {
+	d := base
+	for i := 1; i < attempt && d < math.MaxInt64/2 && (max <= 0 || d < max); i++ {
+		d *= 2
+	}
+	if max > 0 && d > max {
+		return max
+	}
+
+	return d
+}
+
+// FullJitter picks a uniformly random delay in [0, d), spreading the retries of many callers over all of it
+func FullJitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+
+	return rand.N(d)
+}
+
+// HalfJitter keeps at least half of the delay so retries never bunch up at zero
+func HalfJitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+
+	return d/2 + rand.N(d/2+1)
+}
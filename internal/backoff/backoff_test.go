@@ -0,0 +1,49 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponential(t *testing.T) {
+	tests := []struct {
+		name      string
+		base, max time.Duration
+		attempt   int
+		want      time.Duration
+	}{
+		{"first attempt waits base", time.Second, time.Minute, 1, time.Second},
+		{"doubles per attempt", time.Second, time.Minute, 4, 8 * time.Second},
+		{"capped by max", time.Second, time.Minute, 10, time.Minute},
+		{"no cap without max", time.Second, 0, 11, 1024 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Exponential(tt.base, tt.max, tt.attempt); got != tt.want {
+				t.Fatalf("Exponential(%s, %s, %d) = %s, want %s", tt.base, tt.max, tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExponential_DoesNotOverflow(t *testing.T) {
+	if got := Exponential(time.Second, 0, 200); got < time.Second {
+		t.Fatalf("Exponential(1s, 0, 200) = %s, want a long delay", got)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for range 100 {
+		if d := FullJitter(time.Second); d < 0 || d >= time.Second {
+			t.Fatalf("FullJitter(1s) = %s, want [0, 1s)", d)
+		}
+		if d := HalfJitter(time.Second); d < time.Second/2 || d > time.Second {
+			t.Fatalf("HalfJitter(1s) = %s, want [0.5s, 1s]", d)
+		}
+	}
+
+	if FullJitter(0) != 0 || HalfJitter(0) != 0 {
+		t.Fatal("expected no delay to stay none")
+	}
+}
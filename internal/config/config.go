@@ -9,18 +9,33 @@ import (
 )
 
 type Config struct {
-	ListenAddr  string         `yaml:"listen_addr" env:"HTTP_LISTEN_ADDR" env-required:"true"`
-	ServerOpts  ServerOpts     `yaml:"server_opts"`
-	SQLDataBase SQLDataBase    `yaml:"sql_database"`
-	Provider    ProviderConfig `yaml:"provider"`
-	Earnings    EarningsConfig `yaml:"earnings"`
-	Updater     UpdaterConfig  `yaml:"updater"`
+	ListenAddr  string           `yaml:"listen_addr" env:"HTTP_LISTEN_ADDR" env-required:"true"`
+	ServerOpts  ServerOpts       `yaml:"server_opts"`
+	SQLDataBase SQLDataBase      `yaml:"sql_database"`
+	Provider    ProviderConfig   `yaml:"provider"`
+	Earnings    EarningsConfig   `yaml:"earnings"`
+	Updater     UpdaterConfig    `yaml:"updater"`
+	Refresh     RefreshConfig    `yaml:"refresh"`
+	Discovery   DiscoveryConfig  `yaml:"discovery"`
+	Auth        AuthConfig       `yaml:"auth"`
+	Webhooks    WebhooksConfig   `yaml:"webhooks"`
+	Outbox      OutboxConfig     `yaml:"outbox"`
+	Stream      StreamConfig     `yaml:"stream"`
+	Exports     ExportsConfig    `yaml:"exports"`
+	Purge       PurgeConfig      `yaml:"purge"`
+	Partitions  PartitionsConfig `yaml:"partitions"`
+	Rollups     RollupsConfig    `yaml:"rollups"`
+	Metrics     MetricsConfig    `yaml:"metrics"`
+	Tracing     TracingConfig    `yaml:"tracing"`
+	Health      HealthConfig     `yaml:"health"`
 }
 
 type ServerOpts struct {
 	ReadTimeout  int `yaml:"read_timeout"  env:"HTTP_READ_TIMEOUT"  env-default:"10"`
 	WriteTimeout int `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" env-default:"10"`
 	IdleTimeout  int `yaml:"idle_timeout"  env:"HTTP_IDLE_TIMEOUT"  env-default:"60"`
+
+	CORSAllowedOrigins []string `yaml:"cors_allowed_origins" env:"HTTP_CORS_ALLOWED_ORIGINS" env-separator:"," env-default:"*"`
 }
 
 type SQLDataBase struct {
@@ -33,6 +48,11 @@ type SQLDataBase struct {
 	MaxOpenConns       int32  `yaml:"max_open_conns"    env:"DB_MAX_OPEN_CONNS"     env-default:"10"`
 	ConnMaxLifetimeMin int    `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"  env-default:"5"` // minutes
 	QueryTimeoutSec    int    `yaml:"query_timeout"     env:"DB_QUERY_TIMEOUT"      env-default:"2"` // seconds
+
+	// history, list and analytics reads go to the replica while it is at most ReplicaMaxLag behind; empty = primary only
+	ReplicaDSN           string        `yaml:"replica_dsn"            env:"DB_REPLICA_DSN"`
+	ReplicaMaxLag        time.Duration `yaml:"replica_max_lag"        env:"DB_REPLICA_MAX_LAG"        env-default:"10s"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" env:"DB_REPLICA_CHECK_INTERVAL" env-default:"5s"`
 }
 
 type ProviderConfig struct {
@@ -42,6 +62,13 @@ type ProviderConfig struct {
 	TimeoutSec      int           `yaml:"timeoutSec"`
 	MaxRetriesCount int           `yaml:"max_retries" env:"ENSEMBLE_MAX_RETRIES" env-default:"3"`
 	RetryTimeout    time.Duration `yaml:"retry_timeout" env:"ENSEMBLE_RETRY_TIMEOUT" env-default:"2s"`
+	MaxRetryDelay   time.Duration `yaml:"max_retry_delay" env:"ENSEMBLE_MAX_RETRY_DELAY" env-default:"30s"`
+	CallTimeout     time.Duration `yaml:"call_timeout" env:"ENSEMBLE_CALL_TIMEOUT" env-default:"60s"`
+	MaxBatchSize    int           `yaml:"max_batch_size" env:"ENSEMBLE_MAX_BATCH_SIZE" env-default:"50"`
+	MaxPostPages    int           `yaml:"max_post_pages" env:"ENSEMBLE_MAX_POST_PAGES" env-default:"10"`
+
+	BreakerThreshold int           `yaml:"breaker_threshold" env:"ENSEMBLE_BREAKER_THRESHOLD" env-default:"5"`
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown"  env:"ENSEMBLE_BREAKER_COOLDOWN"  env-default:"30s"`
 }
 
 type EarningsConfig struct {
@@ -53,6 +80,113 @@ type UpdaterConfig struct {
 	BatchSize      int `yaml:"batch_size"`
 	MinUpdateAge   int `yaml:"min_update_age"`
 	MaxConcurrency int `yaml:"max_concurrency"`
+	FlushSize      int `yaml:"flush_size"`
+}
+
+// RefreshConfig limits POST /api/videos/{video_id}/refresh, per replica
+type RefreshConfig struct {
+	Cooldown      time.Duration `yaml:"cooldown"        env:"REFRESH_COOLDOWN"        env-default:"1m"` // per video
+	RatePerMinute float64       `yaml:"rate_per_minute" env:"REFRESH_RATE_PER_MINUTE" env-default:"30"` // provider calls, 0 = unlimited
+	Burst         int           `yaml:"burst"           env:"REFRESH_BURST"           env-default:"10"`
+}
+
+type DiscoveryConfig struct {
+	Interval   int `yaml:"interval"`
+	BatchSize  int `yaml:"batch_size"`
+	MinSyncAge int `yaml:"min_sync_age"`
+}
+
+type WebhooksConfig struct {
+	Interval           int           `yaml:"interval"`     // seconds
+	BatchSize          int           `yaml:"batch_size"`   // deliveries claimed per pass
+	MaxAttempts        int           `yaml:"max_attempts"` // then the delivery is marked failed
+	RetryDelay         time.Duration `yaml:"retry_delay"     env:"WEBHOOKS_RETRY_DELAY"     env-default:"30s"`
+	MaxRetryDelay      time.Duration `yaml:"max_retry_delay" env:"WEBHOOKS_MAX_RETRY_DELAY" env-default:"1h"`
+	Timeout            time.Duration `yaml:"timeout"         env:"WEBHOOKS_TIMEOUT"         env-default:"10s"`
+	ViewMilestones     []int64       `yaml:"view_milestones"`
+	EarningsThresholds []float64     `yaml:"earnings_thresholds"`
+}
+
+// Sink: stdout, file (FilePath) or http (URL); events are published at least once
+// with their event id as idempotency key
+type OutboxConfig struct {
+	Sink          string        `yaml:"sink"            env:"OUTBOX_SINK"      env-default:"stdout"`
+	FilePath      string        `yaml:"file_path"       env:"OUTBOX_FILE_PATH"`
+	URL           string        `yaml:"url"             env:"OUTBOX_URL"`
+	Interval      int           `yaml:"interval"`   // seconds
+	BatchSize     int           `yaml:"batch_size"` // events claimed per pass
+	RetryDelay    time.Duration `yaml:"retry_delay"     env-default:"5s"`
+	MaxRetryDelay time.Duration `yaml:"max_retry_delay" env-default:"10m"`
+	Lease         time.Duration `yaml:"lease"           env-default:"30s"`
+	MaxAttempts   int           `yaml:"max_attempts"    env-default:"20"` // then the event is marked failed, 0 = retried until published
+}
+
+type StreamConfig struct {
+	Heartbeat    time.Duration `yaml:"heartbeat"     env-default:"15s"`
+	BufferSize   int           `yaml:"buffer_size"   env-default:"64"`   // live events per client before it is dropped
+	ReplayLimit  int           `yaml:"replay_limit"  env-default:"1000"` // events replayed from Last-Event-ID
+	ReplayWindow time.Duration `yaml:"replay_window" env-default:"1m"`   // replayed again before Last-Event-ID, for late commits
+	RetryDelay   time.Duration `yaml:"retry_delay"   env-default:"5s"`   // LISTEN reconnect delay
+}
+
+// SigningKey signs download links and must be shared by all replicas; Dir too
+type ExportsConfig struct {
+	Dir          string        `yaml:"dir"            env:"EXPORT_DIR"         env-default:"./exports"`
+	PublicURL    string        `yaml:"public_url"     env:"EXPORT_PUBLIC_URL"` // prefix of download links
+	SigningKey   string        `yaml:"signing_key"    env:"EXPORT_SIGNING_KEY"`
+	Interval     int           `yaml:"interval"`                         // seconds between job polls
+	LinkTTL      time.Duration `yaml:"link_ttl"       env-default:"24h"` // then the file is removed
+	Lease        time.Duration `yaml:"lease"          env-default:"5m"`  // renewed while a job runs, taken over once it runs out
+	MaxAttempts  int           `yaml:"max_attempts"   env-default:"3"`   // claims of a job before it is failed
+	RowGroupSize int           `yaml:"row_group_size" env-default:"100000"`
+}
+
+// deleted videos stay restorable from the database for Retention, then the purge job removes them
+type PurgeConfig struct {
+	Retention time.Duration `yaml:"retention"  env:"PURGE_RETENTION" env-default:"720h"`
+	Interval  time.Duration `yaml:"interval"   env-default:"1h"`
+	BatchSize int           `yaml:"batch_size" env-default:"500"` // videos per transaction
+}
+
+// video_stats is partitioned by month; the job keeps the next months ready and archives old ones
+type PartitionsConfig struct {
+	Interval     time.Duration `yaml:"interval"      env-default:"6h"`
+	Premake      int           `yaml:"premake"       env-default:"3"`                                // future months created ahead
+	ArchiveAfter int           `yaml:"archive_after" env:"PARTITIONS_ARCHIVE_AFTER" env-default:"0"` // months kept attached, 0 keeps all
+}
+
+// daily and weekly rollups of video_stats; history reads them past the raw and daily retention
+type RollupsConfig struct {
+	Interval       time.Duration `yaml:"interval"        env-default:"15m"`
+	RawRetention   time.Duration `yaml:"raw_retention"   env:"ROLLUPS_RAW_RETENTION"   env-default:"0"` // hourly snapshots kept, 0 keeps all
+	DailyRetention time.Duration `yaml:"daily_retention" env:"ROLLUPS_DAILY_RETENTION" env-default:"0"` // daily rows kept, 0 keeps all
+	BatchSize      int           `yaml:"batch_size"      env-default:"10000"`                           // rows removed per statement
+}
+
+// /metrics is served without authentication, keep it off the public ingress
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" env:"METRICS_ENABLED" env-default:"true"`
+	Path    string `yaml:"path"    env:"METRICS_PATH"    env-default:"/metrics"`
+}
+
+// Exporter: none, stdout or otlp (OTLP/HTTP); with none trace ids still reach the logs
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"     env:"TRACING_EXPORTER"     env-default:"none"`
+	Endpoint    string  `yaml:"endpoint"     env:"TRACING_ENDPOINT"` // host:port of the collector
+	Insecure    bool    `yaml:"insecure"     env:"TRACING_INSECURE"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME" env-default:"ttanalytic"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+}
+
+// UpdaterStaleAfter defaults to three updater intervals when zero
+type HealthConfig struct {
+	Timeout           time.Duration `yaml:"timeout"             env:"HEALTH_TIMEOUT"       env-default:"2s"` // per check
+	UpdaterStaleAfter time.Duration `yaml:"updater_stale_after" env:"HEALTH_UPDATER_STALE_AFTER"`
+	ShutdownDelay     time.Duration `yaml:"shutdown_delay"      env:"HEALTH_SHUTDOWN_DELAY" env-default:"5s"` // /readyz fails this long before the server stops
+}
+
+type AuthConfig struct {
+	Enabled bool `yaml:"enabled" env:"AUTH_ENABLED" env-default:"true"`
 }
 
 const (
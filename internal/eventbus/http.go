@@ -0,0 +1,55 @@
+package eventbus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"ttanalytic/internal/models"
+)
+
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	HeaderEventType      = "X-Event-Type"
+)
+
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// HTTPSink POSTs each event payload to a collector; any 2xx counts as accepted
+type HTTPSink struct {
+	url    string
+	client HTTPDoer
+}
+
+func NewHTTPSink(url string, client HTTPDoer) *HTTPSink {
+	return &HTTPSink{url: url, client: client}
+}
+
+func (s *HTTPSink) Publish(ctx context.Context, event models.OutboxEvent) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(event.Payload))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderIdempotencyKey, event.EventID)
+	req.Header.Set(HeaderEventType, event.EventType)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send event %s: %w", event.EventID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("collector returned %d for event %s: %s", resp.StatusCode, event.EventID, body)
+	}
+
+	return nil
+}
+
+func (s *HTTPSink) Close() error { return nil }
@@ -0,0 +1,101 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"ttanalytic/internal/models"
+)
+
+// HeaderMsgID is the JetStream de-duplication header
+const HeaderMsgID = "Nats-Msg-Id"
+
+// Msg mirrors nats.Msg so a NATS connection can be plugged in without a dependency here
+type Msg struct {
+	Subject string
+	Header  map[string][]string
+	Data    []byte
+}
+
+// Publisher is the subset of a NATS (JetStream) client the sink needs. With nats.go it is
+// a small adapter around jetstream.JetStream.PublishMsg.
+type Publisher interface {
+	PublishMsg(ctx context.Context, msg *Msg) error
+}
+
+// NATSSink publishes each event to "<prefix>.<event type>", e.g. ttanalytic.video.tracked
+type NATSSink struct {
+	pub    Publisher
+	prefix string
+}
+
+func NewNATSSink(pub Publisher, subjectPrefix string) *NATSSink {
+	return &NATSSink{pub: pub, prefix: subjectPrefix}
+}
+
+func (s *NATSSink) Publish(ctx context.Context, event models.OutboxEvent) error {
+	subject := event.EventType
+	if s.prefix != "" {
+		subject = s.prefix + "." + subject
+	}
+
+	msg := &Msg{
+		Subject: subject,
+		Header: map[string][]string{
+			HeaderMsgID:     {event.EventID},
+			HeaderEventType: {event.EventType},
+		},
+		Data: event.Payload,
+	}
+
+	if err := s.pub.PublishMsg(ctx, msg); err != nil {
+		return fmt.Errorf("publish event %s to %s: %w", event.EventID, subject, err)
+	}
+
+	return nil
+}
+
+func (s *NATSSink) Close() error { return nil }
+
+// MemoryBus is an in-process Publisher for tests and single-binary setups.
+// Like JetStream it drops a message whose Nats-Msg-Id was already published.
+type MemoryBus struct {
+	mu   sync.Mutex
+	seen map[string]bool
+	msgs []Msg
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{seen: make(map[string]bool)}
+}
+
+func (b *MemoryBus) PublishMsg(_ context.Context, msg *Msg) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if id := firstHeader(msg.Header, HeaderMsgID); id != "" {
+		if b.seen[id] {
+			return nil
+		}
+		b.seen[id] = true
+	}
+
+	b.msgs = append(b.msgs, *msg)
+
+	return nil
+}
+
+// Messages returns what was published so far, in order
+func (b *MemoryBus) Messages() []Msg {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]Msg(nil), b.msgs...)
+}
+
+func firstHeader(h map[string][]string, key string) string {
+	if len(h[key]) == 0 {
+		return ""
+	}
+	return h[key][0]
+}
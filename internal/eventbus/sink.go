@@ -0,0 +1,16 @@
+// Package eventbus publishes outbox events to external consumers.
+//
+// Delivery is at-least-once: the relay retries until a sink accepts an event, so
+// every sink forwards the event id as an idempotency key for consumers to dedupe on.
+package eventbus
+
+import (
+	"context"
+	"ttanalytic/internal/models"
+)
+
+// Sink publishes one event; a nil error means the event must not be sent again
+type Sink interface {
+	Publish(ctx context.Context, event models.OutboxEvent) error
+	Close() error
+}
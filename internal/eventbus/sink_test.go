@@ -0,0 +1,82 @@
+package eventbus
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"ttanalytic/internal/models"
+)
+
+func testEvent(id string) models.OutboxEvent {
+	return models.OutboxEvent{
+		ID:        1,
+		EventID:   id,
+		EventType: models.EventVideoTracked,
+		Payload:   []byte(`{"id": "` + id + `", "type": "video.tracked"}`),
+	}
+}
+
+func TestWriterSink_WritesNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+
+	for _, id := range []string{"evt_1", "evt_2"} {
+		if err := sink.Publish(context.Background(), testEvent(id)); err != nil {
+			t.Fatalf("Publish(%s): %v", id, err)
+		}
+	}
+
+	want := `{"id":"evt_1","type":"video.tracked"}` + "\n" + `{"id":"evt_2","type":"video.tracked"}` + "\n"
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestHTTPSink_SendsIdempotencyKey(t *testing.T) {
+	var gotKey string
+	status := http.StatusAccepted
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get(HeaderIdempotencyKey)
+		w.WriteHeader(status)
+	}))
+	defer collector.Close()
+
+	sink := NewHTTPSink(collector.URL, collector.Client())
+
+	if err := sink.Publish(context.Background(), testEvent("evt_1")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if gotKey != "evt_1" {
+		t.Fatalf("expected idempotency key evt_1, got %q", gotKey)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := sink.Publish(context.Background(), testEvent("evt_2")); err == nil {
+		t.Fatalf("expected error for %d", status)
+	}
+}
+
+func TestNATSSink_MemoryBusDropsDuplicates(t *testing.T) {
+	bus := NewMemoryBus()
+	sink := NewNATSSink(bus, "ttanalytic")
+
+	for _, id := range []string{"evt_1", "evt_1", "evt_2"} {
+		if err := sink.Publish(context.Background(), testEvent(id)); err != nil {
+			t.Fatalf("Publish(%s): %v", id, err)
+		}
+	}
+
+	msgs := bus.Messages()
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	if msgs[0].Subject != "ttanalytic.video.tracked" {
+		t.Fatalf("unexpected subject %q", msgs[0].Subject)
+	}
+	if id := msgs[1].Header[HeaderMsgID][0]; id != "evt_2" {
+		t.Fatalf("expected evt_2, got %q", id)
+	}
+}
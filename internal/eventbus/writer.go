@@ -0,0 +1,62 @@
+package eventbus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"ttanalytic/internal/models"
+)
+
+// WriterSink writes events as newline-delimited JSON (the event payload, one per line)
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+	c  io.Closer
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// NewStdoutSink is for local runs and piping into other tools
+func NewStdoutSink() *WriterSink {
+	return NewWriterSink(os.Stdout)
+}
+
+// OpenFileSink appends to path, creating it if needed
+func OpenFileSink(path string) (*WriterSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open event file %s: %w", path, err)
+	}
+
+	return &WriterSink{w: f, c: f}, nil
+}
+
+func (s *WriterSink) Publish(_ context.Context, event models.OutboxEvent) error {
+	var line bytes.Buffer
+	if err := json.Compact(&line, event.Payload); err != nil {
+		return fmt.Errorf("event %s: %w", event.EventID, err)
+	}
+	line.WriteByte('\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.Write(line.Bytes()); err != nil {
+		return fmt.Errorf("write event %s: %w", event.EventID, err)
+	}
+
+	return nil
+}
+
+func (s *WriterSink) Close() error {
+	if s.c == nil {
+		return nil
+	}
+	return s.c.Close()
+}
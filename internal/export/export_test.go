@@ -0,0 +1,96 @@
+package export
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"ttanalytic/internal/models"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		accept  string
+		want    Format
+		wantErr bool
+	}{
+		{name: "default", want: FormatJSON},
+		{name: "query wins", query: "CSV", accept: "application/x-ndjson", want: FormatCSV},
+		{name: "accept header", accept: "text/html, application/x-ndjson;q=0.9", want: FormatNDJSON},
+		{name: "unknown accept", accept: "text/html", want: FormatJSON},
+		{name: "unknown query", query: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Negotiate(tt.query, tt.accept)
+
+			if tt.wantErr {
+				if !errors.Is(err, models.ErrInvalidRequest) {
+					t.Fatalf("expected ErrInvalidRequest, got %v", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("Negotiate() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignVerifyLink(t *testing.T) {
+	secret := []byte("export-secret")
+	now := time.Unix(1700000000, 0)
+	link := SignLink(secret, 4, 9, now.Add(time.Hour))
+
+	if !strings.HasPrefix(link, "/exports/9/download?") {
+		t.Fatalf("unexpected link %s", link)
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("parse link: %v", err)
+	}
+	valid := u.Query()
+
+	tampered := url.Values{}
+	for k, v := range valid {
+		tampered[k] = v
+	}
+	tampered.Set("workspace", "5")
+
+	tests := []struct {
+		name     string
+		secret   []byte
+		exportID int64
+		query    url.Values
+		now      time.Time
+		wantErr  bool
+	}{
+		{name: "valid", secret: secret, exportID: 9, query: valid, now: now},
+		{name: "expired", secret: secret, exportID: 9, query: valid, now: now.Add(2 * time.Hour), wantErr: true},
+		{name: "other export", secret: secret, exportID: 10, query: valid, now: now, wantErr: true},
+		{name: "other workspace", secret: secret, exportID: 9, query: tampered, now: now, wantErr: true},
+		{name: "wrong secret", secret: []byte("other"), exportID: 9, query: valid, now: now, wantErr: true},
+		{name: "missing params", secret: secret, exportID: 9, query: url.Values{}, now: now, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspaceID, err := VerifyLink(tt.secret, tt.exportID, tt.query, tt.now)
+
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidLink) {
+					t.Fatalf("expected ErrInvalidLink, got %v", err)
+				}
+				return
+			}
+			if err != nil || workspaceID != 4 {
+				t.Fatalf("VerifyLink() = %d, %v; want 4", workspaceID, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Package export holds the output formats of the data endpoints and the signed download links of export jobs.
+package export
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+	"ttanalytic/internal/models"
+)
+
+type Format string
+
+const (
+	FormatJSON   Format = "json"
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+var contentTypes = map[Format]string{
+	FormatJSON:   "application/json",
+	FormatCSV:    "text/csv",
+	FormatNDJSON: "application/x-ndjson",
+}
+
+func (f Format) ContentType() string {
+	return contentTypes[f]
+}
+
+// Negotiate picks the format from ?format= or else the Accept header, JSON by default
+func Negotiate(query, accept string) (Format, error) {
+	if query != "" {
+		f := Format(strings.ToLower(query))
+		if _, ok := contentTypes[f]; !ok {
+			return "", fmt.Errorf("unsupported format %q, use json, csv or ndjson: %w", query, models.ErrInvalidRequest)
+		}
+		return f, nil
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		for f, ct := range contentTypes {
+			if mediaType == ct {
+				return f, nil
+			}
+		}
+	}
+
+	return FormatJSON, nil
+}
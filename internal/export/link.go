@@ -0,0 +1,51 @@
+package export
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidLink = errors.New("invalid or expired download link")
+
+// SignLink returns the download path of an export, valid until expires.
+// The workspace is part of the signature so the link alone authorizes the download.
+func SignLink(secret []byte, workspaceID, exportID int64, expires time.Time) string {
+	q := url.Values{}
+	q.Set("workspace", strconv.FormatInt(workspaceID, 10))
+	q.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	q.Set("signature", linkMAC(secret, workspaceID, exportID, expires.Unix()))
+
+	return fmt.Sprintf("/exports/%d/download?%s", exportID, q.Encode())
+}
+
+// VerifyLink checks the query of a link produced by SignLink and returns its workspace
+func VerifyLink(secret []byte, exportID int64, query url.Values, now time.Time) (int64, error) {
+	workspaceID, err := strconv.ParseInt(query.Get("workspace"), 10, 64)
+	if err != nil {
+		return 0, ErrInvalidLink
+	}
+
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || now.Unix() > expires {
+		return 0, ErrInvalidLink
+	}
+
+	want := linkMAC(secret, workspaceID, exportID, expires)
+	if !hmac.Equal([]byte(query.Get("signature")), []byte(want)) {
+		return 0, ErrInvalidLink
+	}
+
+	return workspaceID, nil
+}
+
+func linkMAC(secret []byte, workspaceID, exportID, expires int64) string {
+	h := hmac.New(sha256.New, secret)
+	fmt.Fprintf(h, "%d.%d.%d", workspaceID, exportID, expires)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type SchemaSource interface {
+	SchemaVersion(ctx context.Context) (version uint, dirty bool, err error)
+}
+
+// Ping fails when the database does not answer within the check timeout
+func Ping(db Pinger) CheckFunc {
+	return func(ctx context.Context) error {
+		return db.Ping(ctx)
+	}
+}
+
+// SchemaVersion fails unless the applied migration is exactly the one the binary was built for
+func SchemaVersion(src SchemaSource, want uint) CheckFunc {
+	return func(ctx context.Context) error {
+		version, dirty, err := src.SchemaVersion(ctx)
+		if err != nil {
+			return fmt.Errorf("read schema version: %w", err)
+		}
+		if dirty {
+			return fmt.Errorf("schema version %d is dirty, a migration failed halfway", version)
+		}
+		if version != want {
+			return fmt.Errorf("schema version %d, binary expects %d", version, want)
+		}
+
+		return nil
+	}
+}
+
+// Heartbeat fails when last has not moved for maxAge, e.g. a worker goroutine died or hangs
+func Heartbeat(last func() time.Time, maxAge time.Duration) CheckFunc {
+	return func(context.Context) error {
+		at := last()
+		if at.IsZero() {
+			return fmt.Errorf("no heartbeat yet")
+		}
+		if age := time.Since(at); age > maxAge {
+			return fmt.Errorf("last heartbeat %s ago, max %s", age.Round(time.Second), maxAge)
+		}
+
+		return nil
+	}
+}
+
+// Circuit fails while the circuit breaker reported by state is open
+func Circuit(state func() string) CheckFunc {
+	return func(context.Context) error {
+		if s := state(); s == "open" {
+			return fmt.Errorf("circuit %s", s)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,165 @@
+// Package health runs the liveness and readiness checks behind /livez and /readyz.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusFailing  = "failing"
+	StatusDegraded = "degraded" // only non-critical checks fail
+)
+
+// CheckFunc returns nil when the dependency is healthy
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	critical bool
+}
+
+type CheckResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	Critical   bool   `json:"critical"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+var errShuttingDown = errors.New("shutting down")
+
+type Checker struct {
+	timeout  time.Duration
+	liveness []check
+	ready    []check
+	draining atomic.Bool
+}
+
+// New builds a checker; every check gets timeout to answer
+func New(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// AddLiveness registers a check whose failure means the process must be restarted
+func (c *Checker) AddLiveness(name string, fn CheckFunc) {
+	c.liveness = append(c.liveness, check{name: name, fn: fn, critical: true})
+}
+
+// AddReadiness registers a check whose failure takes the instance out of rotation;
+// a non-critical one is reported but only degrades the status
+func (c *Checker) AddReadiness(name string, fn CheckFunc, critical bool) {
+	c.ready = append(c.ready, check{name: name, fn: fn, critical: critical})
+}
+
+// Drain makes readiness fail from now on, so the load balancer stops routing here before the server stops
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+func (c *Checker) Live(ctx context.Context) Report {
+	return c.run(ctx, c.liveness)
+}
+
+func (c *Checker) Ready(ctx context.Context) Report {
+	checks := c.ready
+	if c.draining.Load() {
+		checks = append([]check{{name: "shutdown", fn: func(context.Context) error { return errShuttingDown }, critical: true}}, checks...)
+	}
+
+	return c.run(ctx, checks)
+}
+
+// run executes the checks concurrently
+func (c *Checker) run(ctx context.Context, checks []check) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for _, chk := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := c.runOne(ctx, chk)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Checks[chk.name] = result
+			switch {
+			case result.Status == StatusOK:
+			case chk.critical:
+				report.Status = StatusFailing
+			case report.Status == StatusOK:
+				report.Status = StatusDegraded
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (c *Checker) runOne(ctx context.Context, chk check) CheckResult {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	started := time.Now()
+	err := chk.fn(ctx)
+
+	result := CheckResult{
+		Status:     StatusOK,
+		Critical:   chk.critical,
+		DurationMS: time.Since(started).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = StatusFailing
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// LiveHandler serves the liveness report: 200 unless a check fails, then 503
+func (c *Checker) LiveHandler() http.Handler {
+	return reportHandler(c.Live)
+}
+
+// ReadyHandler serves the readiness report: 200 when ok or degraded, 503 when failing
+func (c *Checker) ReadyHandler() http.Handler {
+	return reportHandler(c.Ready)
+}
+
+func reportHandler(probe func(context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := probe(r.Context())
+
+		status := http.StatusOK
+		if report.Status == StatusFailing {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,149 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeSchema struct {
+	version uint
+	dirty   bool
+	err     error
+}
+
+func (f fakeSchema) SchemaVersion(context.Context) (uint, bool, error) {
+	return f.version, f.dirty, f.err
+}
+
+func serve(t *testing.T, h http.Handler) (int, Report) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var report Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+
+	return rec.Code, report
+}
+
+func TestReady(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("down") }
+
+	tests := []struct {
+		name       string
+		setup      func(c *Checker)
+		wantCode   int
+		wantStatus string
+	}{
+		{
+			name: "all ok",
+			setup: func(c *Checker) {
+				c.AddReadiness("database", ok, true)
+				c.AddReadiness("provider", ok, false)
+			},
+			wantCode:   http.StatusOK,
+			wantStatus: StatusOK,
+		},
+		{
+			name: "non-critical failure degrades",
+			setup: func(c *Checker) {
+				c.AddReadiness("database", ok, true)
+				c.AddReadiness("provider", down, false)
+			},
+			wantCode:   http.StatusOK,
+			wantStatus: StatusDegraded,
+		},
+		{
+			name: "critical failure fails",
+			setup: func(c *Checker) {
+				c.AddReadiness("database", down, true)
+				c.AddReadiness("provider", down, false)
+			},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: StatusFailing,
+		},
+		{
+			name: "draining fails",
+			setup: func(c *Checker) {
+				c.AddReadiness("database", ok, true)
+				c.Drain()
+			},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: StatusFailing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(time.Second)
+			tt.setup(c)
+
+			code, report := serve(t, c.ReadyHandler())
+			if code != tt.wantCode || report.Status != tt.wantStatus {
+				t.Fatalf("got %d %s, want %d %s (%+v)", code, report.Status, tt.wantCode, tt.wantStatus, report.Checks)
+			}
+		})
+	}
+}
+
+func TestReady_CheckTimeout(t *testing.T) {
+	c := New(10 * time.Millisecond)
+	c.AddReadiness("database", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, true)
+
+	_, report := serve(t, c.ReadyHandler())
+
+	if got := report.Checks["database"]; got.Status != StatusFailing || got.Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("expected the slow check to time out, got %+v", got)
+	}
+}
+
+func TestLive_Heartbeat(t *testing.T) {
+	last := time.Now().Add(-time.Hour)
+
+	c := New(time.Second)
+	c.AddLiveness("updater", Heartbeat(func() time.Time { return last }, time.Minute))
+
+	if code, _ := serve(t, c.LiveHandler()); code != http.StatusServiceUnavailable {
+		t.Fatalf("stale heartbeat: got %d, want 503", code)
+	}
+
+	last = time.Now()
+	if code, _ := serve(t, c.LiveHandler()); code != http.StatusOK {
+		t.Fatalf("fresh heartbeat: got %d, want 200", code)
+	}
+}
+
+func TestSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     fakeSchema
+		wantErr bool
+	}{
+		{name: "matches", src: fakeSchema{version: 12}},
+		{name: "behind", src: fakeSchema{version: 11}, wantErr: true},
+		{name: "ahead", src: fakeSchema{version: 13}, wantErr: true},
+		{name: "dirty", src: fakeSchema{version: 12, dirty: true}, wantErr: true},
+		{name: "unreadable", src: fakeSchema{err: errors.New("no table")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SchemaVersion(tt.src, 12)(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got err %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Package pglisten keeps a dedicated connection LISTENing on a Postgres channel.
+package pglisten
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Logger interface {
+	Errorf(format string, args ...any)
+	Infof(format string, args ...any)
+}
+
+// Handler receives the notifications of the channel. OnListen is called every time
+// LISTEN is (re)established; notifications sent while disconnected are lost.
+type Handler interface {
+	OnListen()
+	OnNotification(payload string)
+}
+
+type Listener struct {
+	pool       *pgxpool.Pool
+	channel    string
+	logger     Logger
+	retryDelay time.Duration
+}
+
+func NewListener(pool *pgxpool.Pool, channel string, logger Logger, retryDelay time.Duration) *Listener {
+	return &Listener{
+		pool:       pool,
+		channel:    channel,
+		logger:     logger,
+		retryDelay: retryDelay,
+	}
+}
+
+// Run listens until ctx is done, reconnecting after retryDelay on errors
+func (l *Listener) Run(ctx context.Context, h Handler) {
+	for {
+		err := l.listen(ctx, h)
+		if ctx.Err() != nil {
+			l.logger.Infof("pglisten: %s shutdown", l.channel)
+			return
+		}
+
+		l.logger.Errorf("pglisten: %s: %v, reconnecting in %s", l.channel, err, l.retryDelay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(l.retryDelay):
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context, h Handler) error {
+	pooled, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+
+	// a LISTENing connection must not go back to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.channel}.Sanitize()); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	h.OnListen()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("wait for notification: %w", err)
+		}
+
+		h.OnNotification(n.Payload)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"time"
+	"ttanalytic/internal/tracing"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -14,8 +15,10 @@ type Logger interface {
 }
 type Provider struct {
 	db        *pgxpool.Pool
+	replica   *pgxpool.Pool
 	logger    Logger
 	cs        string
+	replicaCS string
 	idlConns  int32
 	openConns int32
 	lifetime  int
@@ -45,20 +48,18 @@ func NewProvider(
 		lifetime:  lifetime,
 	}
 }
-func (p *Provider) Open(ctx context.Context) error {
-	cfg, err := pgxpool.ParseConfig(p.cs)
-	if err != nil {
-		return fmt.Errorf("parse config: %w", err)
-	}
 
-	cfg.MaxConns = p.openConns
-	cfg.MinConns = p.idlConns
-	cfg.MaxConnLifetime = time.Duration(p.lifetime) * time.Minute
-	cfg.MaxConnIdleTime = 5 * time.Minute
+// WithReplica makes Open also open a pool on the read replica at dsn, with the pool settings of the primary
+func (p *Provider) WithReplica(dsn string) *Provider {
+	p.replicaCS = dsn
 
-	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	return p
+}
+
+func (p *Provider) Open(ctx context.Context) error {
+	pool, cfg, err := p.newPool(ctx, p.cs)
 	if err != nil {
-		return fmt.Errorf("create pool: %w", err)
+		return err
 	}
 
 	if pingErr := pool.Ping(ctx); pingErr != nil {
@@ -70,17 +71,76 @@ func (p *Provider) Open(ctx context.Context) error {
 	p.db = pool
 	p.logger.Infof("Connected to PostgreSQL: %s/%s", cfg.ConnConfig.Host, cfg.ConnConfig.Database)
 
+	if p.replicaCS == "" {
+		return nil
+	}
+
+	replica, cfg, err := p.newPool(ctx, p.replicaCS)
+	if err != nil {
+		return fmt.Errorf("replica: %w", err)
+	}
+
+	// the primary can serve every read, a replica down at start is used once it answers
+	if pingErr := replica.Ping(ctx); pingErr != nil {
+		p.logger.Errorf("PostgreSQL replica %s/%s not reachable, reads stay on the primary: %v",
+			cfg.ConnConfig.Host, cfg.ConnConfig.Database, pingErr)
+	} else {
+		p.logger.Infof("Connected to PostgreSQL replica: %s/%s", cfg.ConnConfig.Host, cfg.ConnConfig.Database)
+	}
+
+	p.replica = replica
+
 	return nil
 }
+
+func (p *Provider) newPool(ctx context.Context, cs string) (*pgxpool.Pool, *pgxpool.Config, error) {
+	cfg, err := pgxpool.ParseConfig(cs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse config: %w", err)
+	}
+
+	cfg.MaxConns = p.openConns
+	cfg.MinConns = p.idlConns
+	cfg.MaxConnLifetime = time.Duration(p.lifetime) * time.Minute
+	cfg.MaxConnIdleTime = 5 * time.Minute
+	cfg.ConnConfig.Tracer = tracing.QueryTracer{}
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create pool: %w", err)
+	}
+
+	return pool, cfg, nil
+}
+
 func (p *Provider) DB() *pgxpool.Pool {
 	return p.db
 }
 
+// Replica is the pool of the read replica, nil when none is configured
+func (p *Provider) Replica() *pgxpool.Pool {
+	return p.replica
+}
+
 func (p *Provider) Close() {
 	if p.db != nil {
 		p.db.Close()
 		p.logger.Infof("PostgreSQL connection closed")
 	}
+
+	if p.replica != nil {
+		p.replica.Close()
+		p.logger.Infof("PostgreSQL replica connection closed")
+	}
+}
+
+// Ping acquires a connection and round-trips to the server
+func (p *Provider) Ping(ctx context.Context) error {
+	if p.db == nil {
+		return fmt.Errorf("database not open")
+	}
+
+	return p.db.Ping(ctx)
 }
 
 func (p *Provider) Stats() *pgxpool.Stat {
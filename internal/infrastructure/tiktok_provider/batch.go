@@ -0,0 +1,114 @@
+package tiktokprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+)
+
+const (
+	multiInfoEndpoint   = "tt/post/multi-info"
+	defaultMaxBatchSize = 50
+)
+
+// numeric post id from canonical URLs: /@user/video/123 or /@user/photo/123
+var awemeIDPattern = regexp.MustCompile(`/(?:video|photo)/(\d+)`)
+
+// GetVideoStatsBatch fetches stats for many posts with one request per MaxBatchSize ids.
+// Results are returned in the order of videoURLs. A failed item carries its own Err;
+// the returned error is set only when no result could be produced at all.
+// URLs without a post id (e.g. short vm.tiktok.com links) are returned Unresolved, for the caller to poll
+// them one by one with GetVideoStats.
+func (c *Client) GetVideoStatsBatch(ctx context.Context, videoURLs []string) ([]models.VideoStatsResult, error) {
+	results := make([]models.VideoStatsResult, len(videoURLs))
+
+	// positions in videoURLs per aweme id; the same post may be tracked under two URLs
+	byID := make(map[string][]int, len(videoURLs))
+	ids := make([]string, 0, len(videoURLs))
+
+	for i, videoURL := range videoURLs {
+		results[i].URL = videoURL
+
+		id, ok := VideoIDFromURL(videoURL)
+		if !ok {
+			results[i].Unresolved = true
+			continue
+		}
+
+		if _, seen := byID[id]; !seen {
+			ids = append(ids, id)
+		}
+		byID[id] = append(byID[id], i)
+	}
+
+	size := c.cfg.MaxBatchSize
+	if size <= 0 {
+		size = defaultMaxBatchSize
+	}
+
+	for start := 0; start < len(ids); start += size {
+		end := min(start+size, len(ids))
+		chunk := ids[start:end]
+
+		var stats map[string]*models.VideoStats
+
+		err := c.withRetries(ctx, func(ctx context.Context, attempt int) error {
+			var err error
+			stats, err = c.getMultiPostStats(ctx, chunk, attempt)
+			return err
+		})
+
+		// a failed chunk fails only its own items
+		for _, id := range chunk {
+			for _, i := range byID[id] {
+				switch {
+				case err != nil:
+					results[i].Err = err
+				case stats[id] == nil:
+					results[i].Err = ErrVideoNotFound
+				default:
+					results[i].Stats = stats[id]
+				}
+			}
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	return results, nil
+}
+
+func (c *Client) getMultiPostStats(ctx context.Context, ids []string, attempt int) (map[string]*models.VideoStats, error) {
+	params := url.Values{}
+	params.Set("aweme_ids", strings.Join(ids, ";"))
+
+	body, err := c.get(ctx, multiInfoEndpoint, params, attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	var data EnsemblePostInfoResponse
+	if err := json.Unmarshal(body, &data); err != nil {
+		logging.From(ctx, c.logger).Errorf("ensemble: decode failed path=%s err=%v", multiInfoEndpoint, err)
+		return nil, fmt.Errorf("decode ensemble response: %w", err)
+	}
+
+	return data.StatsByAwemeID(), nil
+}
+
+// VideoIDFromURL extracts the numeric post id of a canonical video or photo URL
+func VideoIDFromURL(videoURL string) (string, bool) {
+	m := awemeIDPattern.FindStringSubmatch(videoURL)
+	if m == nil {
+		return "", false
+	}
+
+	return m[1], true
+}
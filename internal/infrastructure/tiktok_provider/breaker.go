@@ -0,0 +1,104 @@
+package tiktokprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"ttanalytic/internal/models"
+)
+
+// ErrCircuitOpen is returned without calling Ensemble while the circuit is open,
+// it wraps models.ErrUnavailable so callers can map it to 503
+var ErrCircuitOpen = fmt.Errorf("ensemble circuit open: %w", models.ErrUnavailable)
+
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// breaker opens after threshold consecutive calls failed with a transient error
+// (5xx, 429, timeouts, transport) and lets a single probe call through once cooldown has passed.
+// Final errors such as 404 or 400 prove Ensemble is up and close it again.
+type breaker struct {
+	threshold int // 0 disables the breaker
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		state:     CircuitClosed,
+	}
+}
+
+// allow reports whether a call may go out; in half_open only the probe does
+func (b *breaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		return true
+	case CircuitHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// record takes the final result of an allowed call
+func (b *breaker) record(err error) {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		// says nothing about Ensemble; a canceled probe lets the next call probe again
+		if b.state == CircuitHalfOpen {
+			b.state = CircuitOpen
+		}
+	case isRetryable(err):
+		b.failures++
+		if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+			b.state = CircuitOpen
+			b.openedAt = b.now()
+		}
+	default:
+		b.state = CircuitClosed
+		b.failures = 0
+	}
+}
+
+func (b *breaker) current() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		return CircuitHalfOpen
+	}
+
+	return b.state
+}
@@ -0,0 +1,100 @@
+package tiktokprovider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClient_Breaker(t *testing.T) {
+	mockHTTP := &scriptedHTTPClient{responses: []scriptedResponse{
+		{status: http.StatusBadGateway},
+		{status: http.StatusBadGateway},
+		{status: http.StatusOK, body: `{"data":[{"aweme_id":"1","statistics":{"play_count":7}}]}`},
+	}}
+
+	c, err := NewClient(mockHTTP, Config{
+		BaseURL:          "https://fake-ensemble.test/api/",
+		MaxRetriesCount:  1,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Minute,
+	}, dummyLogger{}, nil)
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+
+	now := time.Now()
+	c.breaker.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	videoURL := "https://www.tiktok.com/@user/video/1"
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetVideoStats(ctx, videoURL); !errors.Is(err, ErrBadResponse) {
+			t.Fatalf("call %d: expected ErrBadResponse, got %v", i+1, err)
+		}
+	}
+	if got := c.CircuitState(); got != CircuitOpen {
+		t.Fatalf("expected open circuit after 2 failures, got %s", got)
+	}
+
+	if _, err := c.GetVideoStats(ctx, videoURL); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if mockHTTP.calls != 2 {
+		t.Fatalf("open circuit must not call ensemble, got %d calls", mockHTTP.calls)
+	}
+
+	now = now.Add(time.Minute)
+	if got := c.CircuitState(); got != CircuitHalfOpen {
+		t.Fatalf("expected half_open after cooldown, got %s", got)
+	}
+
+	stats, err := c.GetVideoStats(ctx, videoURL)
+	if err != nil {
+		t.Fatalf("probe call failed: %v", err)
+	}
+	if stats.Views != 7 {
+		t.Fatalf("expected 7 views, got %d", stats.Views)
+	}
+	if got := c.CircuitState(); got != CircuitClosed {
+		t.Fatalf("expected closed circuit after a successful probe, got %s", got)
+	}
+}
+
+func TestBreaker_FinalErrorsKeepItClosed(t *testing.T) {
+	b := newBreaker(1, time.Minute)
+
+	b.record(ErrVideoNotFound)
+	b.record(&StatusError{StatusCode: http.StatusBadRequest, err: ErrBadRequest})
+	b.record(context.Canceled)
+
+	if got := b.current(); got != CircuitClosed {
+		t.Fatalf("expected closed, got %s", got)
+	}
+}
+
+func TestBreaker_FailedProbeReopens(t *testing.T) {
+	b := newBreaker(3, time.Minute)
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		b.record(&StatusError{StatusCode: http.StatusServiceUnavailable, err: ErrBadResponse})
+	}
+
+	now = now.Add(time.Minute)
+	if !b.allow() {
+		t.Fatal("expected the probe to be allowed after cooldown")
+	}
+	if b.allow() {
+		t.Fatal("only one probe may go out while half_open")
+	}
+
+	b.record(&StatusError{StatusCode: http.StatusServiceUnavailable, err: ErrBadResponse})
+	if got := b.current(); got != CircuitOpen {
+		t.Fatalf("expected the failed probe to reopen the circuit, got %s", got)
+	}
+}
@@ -9,23 +9,51 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strings"
 	"time"
+	"ttanalytic/internal/backoff"
+	"ttanalytic/internal/logging"
 	"ttanalytic/internal/models"
+	"ttanalytic/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
 	ErrBadResponse  = errors.New("bad response from ensemble")
 	ErrBadRequest   = errors.New("bad request to ensemble")
 	ErrInvalidToken = errors.New("invalid ensemble token")
+
+	// ErrRateLimited wraps models.ErrRateLimited so callers can map it to 429
+	ErrRateLimited = fmt.Errorf("ensemble rate limit exceeded: %w", models.ErrRateLimited)
+
+	// ErrVideoNotFound wraps models.ErrNotFound so callers can map it to 404
+	ErrVideoNotFound = fmt.Errorf("video not found on ensemble: %w", models.ErrNotFound)
 )
 
+const maxLoggedBody = 512
+
 type Logger interface {
 	Errorf(format string, args ...any)
 	Warnf(format string, args ...any)
 	Infof(format string, args ...any)
 	Info(args ...any)
+	Infow(msg string, keysAndValues ...any)
+	Warnw(msg string, keysAndValues ...any)
+}
+
+// Metrics observes every request attempt; kind is "ok" or the class of the error
+type Metrics interface {
+	ObserveProviderRequest(endpoint, kind string, attempt int, latency time.Duration)
 }
 
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveProviderRequest(string, string, int, time.Duration) {}
+
 type HTTPClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
@@ -36,6 +64,12 @@ type Client struct {
 	apiKey  string
 	cfg     Config
 	logger  Logger
+	metrics Metrics
+	breaker *breaker
+
+	// seams for tests
+	jitter func(d time.Duration) time.Duration
+	sleep  func(ctx context.Context, d time.Duration) error
 }
 
 type Config struct {
@@ -43,10 +77,18 @@ type Config struct {
 	APIKey  string
 
 	MaxRetriesCount int
-	RetryTimeout    time.Duration
+	RetryTimeout    time.Duration // base delay, doubled on every attempt
+	MaxRetryDelay   time.Duration // cap for a single backoff delay
+	CallTimeout     time.Duration // overall deadline per provider call including retries
+	MaxBatchSize    int           // posts per multi-info request
+	MaxPostPages    int           // pages fetched per user when listing posts
+
+	BreakerThreshold int           // consecutive transiently failed calls that open the circuit, 0 disables it
+	BreakerCooldown  time.Duration // open circuit rejects calls for this long, then lets one probe through
 }
 
-func NewClient(http HTTPClient, cfg Config, logger Logger) (*Client, error) {
+// NewClient builds the EnsembleData client; metrics may be nil
+func NewClient(http HTTPClient, cfg Config, logger Logger, metrics Metrics) (*Client, error) {
 	u, err := url.Parse(cfg.BaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid ensemble base url: %w", err)
@@ -58,6 +100,14 @@ func NewClient(http HTTPClient, cfg Config, logger Logger) (*Client, error) {
 		apiKey:  cfg.APIKey,
 		cfg:     cfg,
 		logger:  logger,
+		metrics: metrics,
+		breaker: newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		jitter:  backoff.FullJitter,
+		sleep:   sleepCtx,
+	}
+
+	if c.metrics == nil {
+		c.metrics = noopMetrics{}
 	}
 
 	// // health-check (+ctx)
@@ -68,95 +118,246 @@ func NewClient(http HTTPClient, cfg Config, logger Logger) (*Client, error) {
 	return c, nil
 }
 
-// retries
+// GetVideoStats retries transient failures with exponential backoff and full jitter,
+// honoring Retry-After and the overall CallTimeout
 func (c *Client) GetVideoStats(ctx context.Context, videoURL string) (*models.VideoStats, error) {
-	if c.cfg.MaxRetriesCount <= 0 {
-		c.cfg.MaxRetriesCount = 1
+	var stats *models.VideoStats
+
+	ctx, span := tracing.Start(ctx, "ensemble.GetVideoStats")
+	defer span.End()
+
+	err := c.withRetries(ctx, func(ctx context.Context, attempt int) error {
+		var err error
+		stats, err = c.getVideoStats(ctx, videoURL, attempt)
+		return err
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// CircuitState is closed, open or half_open
+func (c *Client) CircuitState() string {
+	return c.breaker.current()
+}
+
+// withRetries runs call through the circuit breaker and the retry loop
+func (c *Client) withRetries(ctx context.Context, call func(ctx context.Context, attempt int) error) error {
+	if !c.breaker.allow() {
+		return ErrCircuitOpen
 	}
+
+	err := c.retry(ctx, call)
+	c.breaker.record(err)
+
+	return err
+}
+
+func (c *Client) retry(ctx context.Context, call func(ctx context.Context, attempt int) error) error {
+	maxAttempts := c.cfg.MaxRetriesCount
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	if c.cfg.CallTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.cfg.CallTimeout)
+		defer cancel()
+	}
+
 	var lastErr error
 
-	for attempt := 1; attempt <= c.cfg.MaxRetriesCount; attempt++ {
-		stats, err := c.getVideoStats(ctx, videoURL)
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err := call(ctx, attempt)
 		if err == nil {
 			if attempt > 1 {
-				c.logger.Infof("ensemble: success on attempt %d", attempt)
+				logging.From(ctx, c.logger).Infof("ensemble: success on attempt %d", attempt)
 			}
-			return stats, nil
+			return nil
 		}
 
-		c.logger.Warnf("ensemble: attempt %d/%d failed: %v", attempt, c.cfg.MaxRetriesCount, err)
+		logging.From(ctx, c.logger).Warnf("ensemble: attempt %d/%d failed: %v", attempt, maxAttempts, c.scrub(err.Error()))
+
+		if ctx.Err() != nil {
+			return fmt.Errorf("ensemble: call aborted: %w", err)
+		}
 
-		if errors.Is(err, ErrBadRequest) || errors.Is(err, ErrInvalidToken) {
-			return nil, err
+		if !isRetryable(err) {
+			return err
 		}
 
 		lastErr = err
 
-		if attempt == c.cfg.MaxRetriesCount {
+		if attempt == maxAttempts {
 			break
 		}
 
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-time.After(c.cfg.RetryTimeout):
+		delay := c.jitter(c.retryDelay(attempt))
+		if retryAfter := retryAfterOf(err); retryAfter > 0 {
+			delay = retryAfter
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return fmt.Errorf("ensemble: next retry in %s exceeds call deadline: %w", delay, err)
 		}
-	}
 
-	return nil, fmt.Errorf("ensemble: all retries failed: %w", lastErr)
+		if err := c.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
 
+	return fmt.Errorf("ensemble: all retries failed: %w", lastErr)
 }
 
 // Provider
-func (c *Client) getVideoStats(ctx context.Context, videoURL string) (*models.VideoStats, error) {
+func (c *Client) getVideoStats(ctx context.Context, videoURL string, attempt int) (*models.VideoStats, error) {
+	params := url.Values{}
+	params.Set("url", videoURL)
+	params.Set("new_version", "false")
+	params.Set("download_video", "false")
+
+	body, err := c.get(ctx, "tt/post/info", params, attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	var data EnsemblePostInfoResponse
+	if err := json.Unmarshal(body, &data); err != nil {
+		logging.From(ctx, c.logger).Errorf("ensemble: decode failed path=tt/post/info err=%v", err)
+		return nil, fmt.Errorf("decode ensemble response: %w", err)
+	}
+
+	// deleted or private videos come back as 200 with empty data
+	if len(data.Data) == 0 {
+		return nil, ErrVideoNotFound
+	}
+
+	return data.ToProviderStats(), nil
+}
+
+// get performs a single authenticated GET and returns the body of a 200 response
+func (c *Client) get(ctx context.Context, endpoint string, params url.Values, attempt int) (body []byte, err error) {
 	fullURL := c.baseURL
-	fullURL.Path = path.Join(fullURL.Path, "tt/post/info")
+	fullURL.Path = path.Join(fullURL.Path, endpoint)
 
 	q := fullURL.Query()
-	q.Set("url", videoURL)
+	for name, values := range params {
+		q[name] = values
+	}
 	q.Set("token", c.apiKey)
-	q.Set("new_version", "false")
-	q.Set("download_video", "false")
 	fullURL.RawQuery = q.Encode()
 
-	//saccess - finaly URL
-	c.logger.Infof("ensemble request URL: %s", fullURL.String())
-
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL.String(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("build request: %w", err)
+		return nil, fmt.Errorf("build request: %w", redactError(err))
 	}
 
+	// one span per attempt; the api key is in the query so the url is not recorded
+	ctx, span := tracing.Start(ctx, "ensemble GET "+endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("ensemble.endpoint", endpoint),
+			attribute.Int("ensemble.attempt", attempt),
+		),
+	)
+	req = req.WithContext(ctx)
+
+	started := time.Now()
+	defer func() {
+		kind := errorKind(err)
+		c.metrics.ObserveProviderRequest(endpoint, kind, attempt, time.Since(started))
+
+		span.SetAttributes(attribute.String("ensemble.outcome", kind))
+		tracing.End(span, err)
+	}()
+
 	resp, err := c.client.Do(req)
+	if resp != nil {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	}
 	if err != nil {
-		c.logger.Errorf("ensemble: http request failed url=%s: %v", fullURL.String(), err)
+		err = redactError(err)
+		c.logRequest(req, attempt, 0, time.Since(started), err)
 		return nil, fmt.Errorf("request to ensemble failed: %w", err)
 	}
 	defer func() {
 		if cerr := resp.Body.Close(); cerr != nil {
-			c.logger.Errorf("ensemble: close response body: %v", cerr)
+			logging.From(ctx, c.logger).Errorf("ensemble: close response body: %v", cerr)
 		}
 	}()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err = io.ReadAll(resp.Body)
 	if err != nil {
+		err = redactError(err)
+		c.logRequest(req, attempt, resp.StatusCode, time.Since(started), err)
 		return nil, fmt.Errorf("read body: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		c.logger.Errorf("ensemble: bad status url=%s status=%d body=%s",
-			fullURL.String(), resp.StatusCode, string(body))
-		return nil, ErrBadResponse
+		statusErr := newStatusError(resp, time.Now())
+		c.logRequest(req, attempt, resp.StatusCode, time.Since(started), statusErr)
+		// the headers tell rate limits and retry hints apart, secrets are masked
+		logging.From(ctx, c.logger).Errorf("ensemble: bad status=%d headers=%v body=%s",
+			resp.StatusCode, RedactHeaders(resp.Header), truncate(c.scrub(string(body)), maxLoggedBody))
+		return nil, statusErr
 	}
 
-	var data EnsemblePostInfoResponse
-	if err := json.Unmarshal(body, &data); err != nil {
-		c.logger.Errorf("ensemble: decode failed url=%s err=%v",
-			fullURL.String(), err)
-		return nil, fmt.Errorf("decode ensemble response: %w", err)
+	c.logRequest(req, attempt, resp.StatusCode, time.Since(started), nil)
+
+	return body, nil
+}
+
+// logRequest writes one structured line per attempt; the query string is never logged
+func (c *Client) logRequest(req *http.Request, attempt, status int, latency time.Duration, err error) {
+	fields := append(tracing.LogFields(req.Context()),
+		"method", req.Method,
+		"host", req.URL.Host,
+		"path", req.URL.Path,
+		"status", status,
+		"latency", latency,
+		"attempt", attempt,
+	)
+
+	logger := logging.From(req.Context(), c.logger)
+
+	if err != nil {
+		logger.Warnw("ensemble request failed", append(fields, "error", c.scrub(err.Error()))...)
+		return
 	}
 
-	return data.ToProviderStats(), nil
+	logger.Infow("ensemble request", fields...)
+}
+
+// scrub masks the api key if it leaks into free-form text such as response bodies
+func (c *Client) scrub(s string) string {
+	if c.apiKey == "" {
+		return s
+	}
+
+	return strings.ReplaceAll(s, c.apiKey, redacted)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	return s[:n] + "..."
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
 
 // helpers health-check
@@ -172,16 +373,16 @@ func (c *Client) testConnection(ctx context.Context) error {
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to connect to ensemble: %w", err)
+		return fmt.Errorf("failed to connect to ensemble: %w", redactError(err))
 	}
 	defer func() {
 		if cerr := resp.Body.Close(); cerr != nil {
-			c.logger.Errorf("ensemble: close response body in testConnection: %v", cerr)
+			logging.From(ctx, c.logger).Errorf("ensemble: close response body in testConnection: %v", cerr)
 		}
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		c.logger.Errorf("ensemble: testConnection bad status=%d", resp.StatusCode)
+		logging.From(ctx, c.logger).Errorf("ensemble: testConnection bad status=%d", resp.StatusCode)
 		return ErrInvalidToken
 	}
 
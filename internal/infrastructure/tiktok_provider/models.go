@@ -1,6 +1,8 @@
 package tiktokprovider
 
 import (
+	"fmt"
+	"time"
 	"ttanalytic/internal/models"
 )
 
@@ -24,3 +26,50 @@ func (e EnsemblePostInfoResponse) ToProviderStats() *models.VideoStats {
 		Views: stats.PlayCount,
 	}
 }
+
+// StatsByAwemeID indexes a multi-post response; deleted posts are simply absent
+func (e EnsemblePostInfoResponse) StatsByAwemeID() map[string]*models.VideoStats {
+	out := make(map[string]*models.VideoStats, len(e.Data))
+
+	for _, item := range e.Data {
+		out[item.AwemeID] = &models.VideoStats{
+			Views: item.Statistics.PlayCount,
+		}
+	}
+
+	return out
+}
+
+type EnsembleAwemeInfo struct {
+	AwemeID    string `json:"aweme_id"`
+	CreateTime int64  `json:"create_time"`
+	Author     struct {
+		UniqueID string `json:"unique_id"`
+	} `json:"author"`
+	Statistics struct {
+		PlayCount int64 `json:"play_count"`
+	} `json:"statistics"`
+}
+
+// EnsemblePostsResponse is shared by the user, hashtag and music feed endpoints
+type EnsemblePostsResponse struct {
+	Data struct {
+		Data       []EnsembleAwemeInfo `json:"data"`
+		NextCursor int64               `json:"nextCursor"`
+	} `json:"data"`
+}
+
+// ToCreatorPost falls back to the author embedded in the post when username is empty
+func (a EnsembleAwemeInfo) ToCreatorPost(username string) models.CreatorPost {
+	if username == "" {
+		username = a.Author.UniqueID
+	}
+
+	return models.CreatorPost{
+		TikTokID: a.AwemeID,
+		Username: username,
+		URL:      fmt.Sprintf("https://www.tiktok.com/@%s/video/%s", username, a.AwemeID),
+		PostedAt: time.Unix(a.CreateTime, 0).UTC(),
+		Views:    a.Statistics.PlayCount,
+	}
+}
@@ -0,0 +1,159 @@
+package tiktokprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+)
+
+const (
+	userPostsEndpoint    = "tt/user/posts"
+	hashtagPostsEndpoint = "tt/hashtag/posts"
+	musicPostsEndpoint   = "tt/music/posts"
+	defaultMaxPostPages  = 10
+)
+
+// feed describes one paginated posts endpoint
+type feed struct {
+	endpoint string
+	params   url.Values
+	// username for post URLs; empty means take it from the post author
+	username string
+	// newest-first feeds can stop paging at the first post older than since
+	sorted bool
+}
+
+// ListUserPosts pages through a user's feed (newest first) and returns posts created at or after since
+func (c *Client) ListUserPosts(ctx context.Context, username string, since time.Time) ([]models.CreatorPost, error) {
+	params := url.Values{}
+	params.Set("username", username)
+	params.Set("depth", "1")
+	params.Set("oldest_createtime", strconv.FormatInt(since.Unix(), 10))
+
+	var posts []models.CreatorPost
+
+	err := c.listPosts(ctx, feed{
+		endpoint: userPostsEndpoint,
+		params:   params,
+		username: username,
+		sorted:   true,
+	}, since, func(page []models.CreatorPost) (bool, error) {
+		posts = append(posts, page...)
+		return true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list posts of %s: %w", username, err)
+	}
+
+	return posts, nil
+}
+
+// ListHashtagPosts calls fn with the posts tagged with the hashtag created at or after since, page by page,
+// until fn returns false
+func (c *Client) ListHashtagPosts(ctx context.Context, hashtag string, since time.Time, fn func([]models.CreatorPost) (bool, error)) error {
+	params := url.Values{}
+	params.Set("name", hashtag)
+
+	if err := c.listPosts(ctx, feed{
+		endpoint: hashtagPostsEndpoint,
+		params:   params,
+	}, since, fn); err != nil {
+		return fmt.Errorf("list posts of #%s: %w", hashtag, err)
+	}
+
+	return nil
+}
+
+// ListMusicPosts calls fn with the posts using the sound created at or after since, page by page,
+// until fn returns false
+func (c *Client) ListMusicPosts(ctx context.Context, musicID string, since time.Time, fn func([]models.CreatorPost) (bool, error)) error {
+	params := url.Values{}
+	params.Set("id", musicID)
+
+	if err := c.listPosts(ctx, feed{
+		endpoint: musicPostsEndpoint,
+		params:   params,
+	}, since, fn); err != nil {
+		return fmt.Errorf("list posts of sound %s: %w", musicID, err)
+	}
+
+	return nil
+}
+
+// listPosts pages through the feed and calls fn with the posts of each page created at or after since;
+// fn returning false stops the listing
+func (c *Client) listPosts(ctx context.Context, f feed, since time.Time, fn func([]models.CreatorPost) (bool, error)) error {
+	maxPages := c.cfg.MaxPostPages
+	if maxPages <= 0 {
+		maxPages = defaultMaxPostPages
+	}
+
+	var cursor int64
+
+	for page := 0; page < maxPages; page++ {
+		var resp *EnsemblePostsResponse
+
+		err := c.withRetries(ctx, func(ctx context.Context, attempt int) error {
+			var err error
+			resp, err = c.getPostsPage(ctx, f, cursor, attempt)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		var posts []models.CreatorPost
+		reachedSince := false
+		for _, item := range resp.Data.Data {
+			post := item.ToCreatorPost(f.username)
+			if post.PostedAt.Before(since) {
+				reachedSince = true
+				continue
+			}
+			posts = append(posts, post)
+		}
+
+		more, err := fn(posts)
+		if err != nil {
+			return err
+		}
+
+		if !more || (f.sorted && reachedSince) || resp.Data.NextCursor == 0 || resp.Data.NextCursor == cursor || len(resp.Data.Data) == 0 {
+			return nil
+		}
+
+		cursor = resp.Data.NextCursor
+	}
+
+	logging.From(ctx, c.logger).Warnf("ensemble: stopped listing %s after %d pages", f.endpoint, maxPages)
+
+	return nil
+}
+
+func (c *Client) getPostsPage(ctx context.Context, f feed, cursor int64, attempt int) (*EnsemblePostsResponse, error) {
+	params := url.Values{}
+	for name, values := range f.params {
+		params[name] = values
+	}
+	if cursor > 0 {
+		params.Set("cursor", strconv.FormatInt(cursor, 10))
+	}
+
+	body, err := c.get(ctx, f.endpoint, params, attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	var data EnsemblePostsResponse
+	if err := json.Unmarshal(body, &data); err != nil {
+		logging.From(ctx, c.logger).Errorf("ensemble: decode failed path=%s err=%v", f.endpoint, err)
+		return nil, fmt.Errorf("decode ensemble response: %w", err)
+	}
+
+	return &data, nil
+}
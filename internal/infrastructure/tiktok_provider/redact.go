@@ -0,0 +1,77 @@
+package tiktokprovider
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const redacted = "REDACTED"
+
+// sensitive query params and headers, compared case-insensitively
+var (
+	sensitiveParams = []string{"token", "api_key", "apikey", "key", "access_token"}
+	sensitiveHeader = []string{"Authorization", "X-Api-Key", "Cookie", "Set-Cookie"}
+)
+
+// RedactURL returns the URL string with credentials and secret query params masked
+func RedactURL(u url.URL) string {
+	if u.User != nil {
+		u.User = url.User(redacted)
+	}
+
+	q := u.Query()
+	changed := false
+
+	for name := range q {
+		if isSensitiveParam(name) {
+			q.Set(name, redacted)
+			changed = true
+		}
+	}
+
+	if changed {
+		u.RawQuery = q.Encode()
+	}
+
+	return u.String()
+}
+
+// RedactHeaders returns a copy of the headers with secret values masked
+func RedactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+
+	for _, name := range sensitiveHeader {
+		if out.Get(name) != "" {
+			out.Set(name, redacted)
+		}
+	}
+
+	return out
+}
+
+// redactError strips secrets from *url.Error, which embeds the full request URL
+func redactError(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+
+	u, parseErr := url.Parse(urlErr.URL)
+	if parseErr != nil {
+		return &url.Error{Op: urlErr.Op, URL: redacted, Err: urlErr.Err}
+	}
+
+	return &url.Error{Op: urlErr.Op, URL: RedactURL(*u), Err: urlErr.Err}
+}
+
+func isSensitiveParam(name string) bool {
+	for _, p := range sensitiveParams {
+		if strings.EqualFold(name, p) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,155 @@
+package tiktokprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"ttanalytic/internal/backoff"
+)
+
+const (
+	defaultRetryTimeout  = 500 * time.Millisecond
+	defaultMaxRetryDelay = 30 * time.Second
+)
+
+// StatusError is returned for any non-200 response from the provider.
+// It unwraps to one of the package sentinel errors.
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+	err        error
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%v: status=%d", e.err, e.StatusCode)
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.err
+}
+
+// newStatusError maps an HTTP status to a sentinel error
+func newStatusError(resp *http.Response, now time.Time) *StatusError {
+	var sentinel error
+
+	switch code := resp.StatusCode; {
+	case code == http.StatusBadRequest:
+		sentinel = ErrBadRequest
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		sentinel = ErrInvalidToken
+	case code == http.StatusNotFound || code == http.StatusGone:
+		sentinel = ErrVideoNotFound
+	case code == http.StatusTooManyRequests:
+		sentinel = ErrRateLimited
+	default:
+		sentinel = ErrBadResponse
+	}
+
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), now),
+		err:        sentinel,
+	}
+}
+
+// isRetryable reports whether another attempt can succeed:
+// 5xx, 429, timeouts and transport failures are retried, everything else is final.
+func isRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests ||
+			statusErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// errorKind classifies the outcome of one attempt for metrics
+func errorKind(err error) string {
+	var statusErr *StatusError
+	var netErr net.Error
+
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, ErrVideoNotFound):
+		return "not_found"
+	case errors.Is(err, ErrInvalidToken):
+		return "invalid_token"
+	case errors.Is(err, ErrBadRequest):
+		return "bad_request"
+	case errors.As(err, &statusErr) && statusErr.StatusCode >= http.StatusInternalServerError:
+		return "server_error"
+	case errors.Is(err, ErrBadResponse):
+		return "bad_response"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	default:
+		return "transport"
+	}
+}
+
+// retryAfterOf returns the server-requested delay, if any
+func retryAfterOf(err error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryAfter
+	}
+
+	return 0
+}
+
+// parseRetryAfter accepts both delay-seconds and HTTP-date forms
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
+
+// retryDelay returns the exponential ceiling of the delay after the given attempt (1-based)
+func (c *Client) retryDelay(attempt int) time.Duration {
+	base, max := c.cfg.RetryTimeout, c.cfg.MaxRetryDelay
+	if base <= 0 {
+		base = defaultRetryTimeout
+	}
+	if max <= 0 {
+		max = defaultMaxRetryDelay
+	}
+
+	return backoff.Exponential(base, max, attempt)
+}
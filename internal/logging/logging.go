@@ -0,0 +1,40 @@
+// Package logging carries the request-scoped logger and request id in the context.
+package logging
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type ctxKey struct{}
+
+type requestIDKey struct{}
+
+// NewContext makes l the logger of every layer handling ctx
+func NewContext(ctx context.Context, l *zap.SugaredLogger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// From returns the logger carried by ctx when it satisfies the caller's logger interface,
+// otherwise fallback, e.g. in background jobs and tests
+func From[L any](ctx context.Context, fallback L) L {
+	if l, ok := ctx.Value(ctxKey{}).(*zap.SugaredLogger); ok {
+		if scoped, ok := any(l).(L); ok {
+			return scoped
+		}
+	}
+
+	return fallback
+}
+
+// WithRequestID records the id of the request handled with ctx, e.g. for audit entries
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID is the id of the request handled with ctx, empty outside of requests
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
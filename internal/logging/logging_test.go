@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type printer interface {
+	Infof(format string, args ...any)
+}
+
+type fallbackLogger struct{ calls int }
+
+func (f *fallbackLogger) Infof(string, ...any) { f.calls++ }
+
+type unsatisfiable interface {
+	Trace(msg string)
+}
+
+func TestFrom(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	scoped := zap.New(core).Sugar().With("request_id", "abc")
+	fallback := &fallbackLogger{}
+
+	From[printer](context.Background(), fallback).Infof("no request")
+	if fallback.calls != 1 {
+		t.Fatalf("expected the fallback without a scoped logger, got %d calls", fallback.calls)
+	}
+
+	ctx := NewContext(context.Background(), scoped)
+	From[printer](ctx, fallback).Infof("in request")
+
+	if fallback.calls != 1 || logs.Len() != 1 {
+		t.Fatalf("expected the scoped logger, fallback calls %d, logged %d", fallback.calls, logs.Len())
+	}
+	if got := logs.All()[0].ContextMap()["request_id"]; got != "abc" {
+		t.Fatalf("request_id = %v, want abc", got)
+	}
+
+	// a logger interface zap does not implement keeps the fallback
+	if From[unsatisfiable](ctx, nil) != nil {
+		t.Fatal("expected the fallback for an interface zap does not satisfy")
+	}
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// lagQueryTimeout bounds the query run on every scrape
+const lagQueryTimeout = 2 * time.Second
+
+var (
+	poolAcquiredDesc = newPoolDesc("acquired_conns", "Connections currently in use.")
+	poolIdleDesc     = newPoolDesc("idle_conns", "Idle connections.")
+	poolTotalDesc    = newPoolDesc("total_conns", "Open connections.")
+	poolMaxDesc      = newPoolDesc("max_conns", "Maximum size of the pool.")
+
+	poolAcquireCountDesc    = newPoolDesc("acquires_total", "Successful connection acquires.")
+	poolAcquireDurationDesc = newPoolDesc("acquire_duration_seconds_total", "Time spent waiting for connections.")
+	poolEmptyAcquireDesc    = newPoolDesc("empty_acquires_total", "Acquires that waited because the pool was empty.")
+	poolCanceledAcquireDesc = newPoolDesc("canceled_acquires_total", "Acquires canceled by their context.")
+	poolNewConnsDesc        = newPoolDesc("new_conns_total", "Connections opened.")
+
+	queueLagDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "updater", "queue_lag_seconds"),
+		"Age of the updated_at of the least recently updated active video, 0 when none.",
+		nil, nil,
+	)
+)
+
+func newPoolDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+}
+
+// PoolCollector reports pgxpool.Stat at scrape time
+type PoolCollector struct {
+	stat func() *pgxpool.Stat
+}
+
+// NewPoolCollector takes a func so it can be registered before the pool is open
+func NewPoolCollector(stat func() *pgxpool.Stat) *PoolCollector {
+	return &PoolCollector{stat: stat}
+}
+
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolAcquiredDesc
+	ch <- poolIdleDesc
+	ch <- poolTotalDesc
+	ch <- poolMaxDesc
+	ch <- poolAcquireCountDesc
+	ch <- poolAcquireDurationDesc
+	ch <- poolEmptyAcquireDesc
+	ch <- poolCanceledAcquireDesc
+	ch <- poolNewConnsDesc
+}
+
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stat()
+	if s == nil {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(poolAcquiredDesc, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdleDesc, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolTotalDesc, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolMaxDesc, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireCountDesc, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireDurationDesc, prometheus.CounterValue, s.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquireDesc, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolCanceledAcquireDesc, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolNewConnsDesc, prometheus.CounterValue, float64(s.NewConnsCount()))
+}
+
+type LagSource interface {
+	OldestActiveVideoUpdate(ctx context.Context) (*time.Time, error)
+}
+
+// QueueLagCollector reports how far behind the updater is, queried at scrape time
+type QueueLagCollector struct {
+	source LagSource
+	now    func() time.Time
+}
+
+func NewQueueLagCollector(source LagSource) *QueueLagCollector {
+	return &QueueLagCollector{source: source, now: time.Now}
+}
+
+func (c *QueueLagCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueLagDesc
+}
+
+func (c *QueueLagCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), lagQueryTimeout)
+	defer cancel()
+
+	oldest, err := c.source.OldestActiveVideoUpdate(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(queueLagDesc, err)
+		return
+	}
+
+	var lag float64
+	if oldest != nil {
+		lag = max(c.now().Sub(*oldest).Seconds(), 0)
+	}
+
+	ch <- prometheus.MustNewConstMetric(queueLagDesc, prometheus.GaugeValue, lag)
+}
@@ -0,0 +1,132 @@
+// Package metrics holds the Prometheus collectors of the service, served on /metrics.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "ttanalytic"
+
+// Metrics implements the observer interfaces of the router, the provider client and the updater
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	providerRequests *prometheus.CounterVec
+	providerDuration *prometheus.HistogramVec
+
+	updaterTicks        *prometheus.CounterVec
+	updaterTickDuration prometheus.Histogram
+	updaterVideos       *prometheus.CounterVec
+}
+
+// New registers the service metrics plus the Go runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route pattern, method and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route pattern and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+
+		providerRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "provider_requests_total",
+			Help:      "TikTok provider requests by endpoint, outcome kind and attempt.",
+		}, []string{"endpoint", "kind", "attempt"}),
+		providerDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "provider_request_duration_seconds",
+			Help:      "TikTok provider request latency by endpoint, one observation per attempt.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"endpoint"}),
+
+		updaterTicks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "updater_ticks_total",
+			Help:      "Updater passes by result (ok or error).",
+		}, []string{"result"}),
+		updaterTickDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "updater_tick_duration_seconds",
+			Help:      "Duration of an updater pass over all due videos.",
+			Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800},
+		}),
+		updaterVideos: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "updater_videos_total",
+			Help:      "Videos handled by the updater by result (updated, unchanged, failed).",
+		}, []string{"result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.providerRequests,
+		m.providerDuration,
+		m.updaterTicks,
+		m.updaterTickDuration,
+		m.updaterVideos,
+	)
+
+	return m
+}
+
+// Register adds collectors built outside the package, such as NewPoolCollector
+func (m *Metrics) Register(cs ...prometheus.Collector) error {
+	for _, c := range cs {
+		if err := m.registry.Register(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Handler serves the registry in the Prometheus text format. A failing collector
+// (the queue lag query while the database is down) drops only its own metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
+
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, latency time.Duration) {
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(latency.Seconds())
+}
+
+func (m *Metrics) ObserveProviderRequest(endpoint, kind string, attempt int, latency time.Duration) {
+	m.providerRequests.WithLabelValues(endpoint, kind, strconv.Itoa(attempt)).Inc()
+	m.providerDuration.WithLabelValues(endpoint).Observe(latency.Seconds())
+}
+
+func (m *Metrics) ObserveUpdaterTick(duration time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+
+	m.updaterTicks.WithLabelValues(result).Inc()
+	m.updaterTickDuration.Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveUpdaterVideo(result string) {
+	m.updaterVideos.WithLabelValues(result).Inc()
+}
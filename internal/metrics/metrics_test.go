@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type stubLagSource struct {
+	oldest *time.Time
+	err    error
+}
+
+func (s stubLagSource) OldestActiveVideoUpdate(context.Context) (*time.Time, error) {
+	return s.oldest, s.err
+}
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+
+	return string(body)
+}
+
+func TestMetrics_Exposition(t *testing.T) {
+	m := New()
+
+	now := time.Unix(1700000000, 0)
+	oldest := now.Add(-90 * time.Second)
+	lag := NewQueueLagCollector(stubLagSource{oldest: &oldest})
+	lag.now = func() time.Time { return now }
+
+	if err := m.Register(lag); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	m.ObserveHTTPRequest("GET", "/api/videos", 200, 10*time.Millisecond)
+	m.ObserveProviderRequest("tt/post/info", "rate_limited", 2, time.Second)
+	m.ObserveUpdaterTick(3*time.Second, nil)
+	m.ObserveUpdaterVideo("unchanged")
+
+	body := scrape(t, m)
+
+	for _, want := range []string{
+		`ttanalytic_http_requests_total{method="GET",route="/api/videos",status="200"} 1`,
+		`ttanalytic_provider_requests_total{attempt="2",endpoint="tt/post/info",kind="rate_limited"} 1`,
+		`ttanalytic_updater_ticks_total{result="ok"} 1`,
+		`ttanalytic_updater_videos_total{result="unchanged"} 1`,
+		`ttanalytic_updater_queue_lag_seconds 90`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+}
+
+func TestQueueLagCollector_NoActiveVideos(t *testing.T) {
+	m := New()
+	if err := m.Register(NewQueueLagCollector(stubLagSource{})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if body := scrape(t, m); !strings.Contains(body, "ttanalytic_updater_queue_lag_seconds 0") {
+		t.Fatalf("expected zero lag, got:\n%s", body)
+	}
+}
+
+func TestQueueLagCollector_ErrorKeepsOtherMetrics(t *testing.T) {
+	m := New()
+	if err := m.Register(NewQueueLagCollector(stubLagSource{err: errors.New("db down")})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	body := scrape(t, m)
+
+	if strings.Contains(body, "ttanalytic_updater_queue_lag_seconds ") {
+		t.Fatalf("expected no lag sample, got:\n%s", body)
+	}
+	if !strings.Contains(body, "go_goroutines") {
+		t.Fatalf("expected the other metrics, got:\n%s", body)
+	}
+}
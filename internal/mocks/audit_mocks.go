@@ -0,0 +1,89 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ttanalytic/internal/service (interfaces: AuditRepository,AuditRecorder)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	models "ttanalytic/internal/models"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAuditRepository is a mock of AuditRepository interface.
+type MockAuditRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditRepositoryMockRecorder
+}
+
+// MockAuditRepositoryMockRecorder is the mock recorder for MockAuditRepository.
+type MockAuditRepositoryMockRecorder struct {
+	mock *MockAuditRepository
+}
+
+// NewMockAuditRepository creates a new mock instance.
+func NewMockAuditRepository(ctrl *gomock.Controller) *MockAuditRepository {
+	mock := &MockAuditRepository{ctrl: ctrl}
+	mock.recorder = &MockAuditRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditRepository) EXPECT() *MockAuditRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ListAuditEntries mocks base method.
+func (m *MockAuditRepository) ListAuditEntries(arg0 context.Context, arg1 models.AuditFilter) ([]models.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEntries", arg0, arg1)
+	ret0, _ := ret[0].([]models.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditEntries indicates an expected call of ListAuditEntries.
+func (mr *MockAuditRepositoryMockRecorder) ListAuditEntries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEntries", reflect.TypeOf((*MockAuditRepository)(nil).ListAuditEntries), arg0, arg1)
+}
+
+// MockAuditRecorder is a mock of AuditRecorder interface.
+type MockAuditRecorder struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditRecorderMockRecorder
+}
+
+// MockAuditRecorderMockRecorder is the mock recorder for MockAuditRecorder.
+type MockAuditRecorderMockRecorder struct {
+	mock *MockAuditRecorder
+}
+
+// NewMockAuditRecorder creates a new mock instance.
+func NewMockAuditRecorder(ctrl *gomock.Controller) *MockAuditRecorder {
+	mock := &MockAuditRecorder{ctrl: ctrl}
+	mock.recorder = &MockAuditRecorderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditRecorder) EXPECT() *MockAuditRecorderMockRecorder {
+	return m.recorder
+}
+
+// AppendAuditEntry mocks base method.
+func (m *MockAuditRecorder) AppendAuditEntry(arg0 context.Context, arg1 models.CreateAuditEntryInput) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendAuditEntry", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AppendAuditEntry indicates an expected call of AppendAuditEntry.
+func (mr *MockAuditRecorderMockRecorder) AppendAuditEntry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendAuditEntry", reflect.TypeOf((*MockAuditRecorder)(nil).AppendAuditEntry), arg0, arg1)
+}
@@ -0,0 +1,221 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ttanalytic/internal/service (interfaces: CreatorRepository,CreatorPostsProvider)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	models "ttanalytic/internal/models"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCreatorRepository is a mock of CreatorRepository interface.
+type MockCreatorRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCreatorRepositoryMockRecorder
+}
+
+// MockCreatorRepositoryMockRecorder is the mock recorder for MockCreatorRepository.
+type MockCreatorRepositoryMockRecorder struct {
+	mock *MockCreatorRepository
+}
+
+// NewMockCreatorRepository creates a new mock instance.
+func NewMockCreatorRepository(ctrl *gomock.Controller) *MockCreatorRepository {
+	mock := &MockCreatorRepository{ctrl: ctrl}
+	mock.recorder = &MockCreatorRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCreatorRepository) EXPECT() *MockCreatorRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AppendVideoStats mocks base method.
+func (m *MockCreatorRepository) AppendVideoStats(arg0 context.Context, arg1 models.CreateVideoStatsInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendVideoStats", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AppendVideoStats indicates an expected call of AppendVideoStats.
+func (mr *MockCreatorRepositoryMockRecorder) AppendVideoStats(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendVideoStats", reflect.TypeOf((*MockCreatorRepository)(nil).AppendVideoStats), arg0, arg1)
+}
+
+// AttachVideoToCreator mocks base method.
+func (m *MockCreatorRepository) AttachVideoToCreator(arg0 context.Context, arg1, arg2 int64, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AttachVideoToCreator", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AttachVideoToCreator indicates an expected call of AttachVideoToCreator.
+func (mr *MockCreatorRepositoryMockRecorder) AttachVideoToCreator(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachVideoToCreator", reflect.TypeOf((*MockCreatorRepository)(nil).AttachVideoToCreator), arg0, arg1, arg2, arg3)
+}
+
+// CreateCreator mocks base method.
+func (m *MockCreatorRepository) CreateCreator(arg0 context.Context, arg1 models.CreateCreatorInput) (*models.Creator, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCreator", arg0, arg1)
+	ret0, _ := ret[0].(*models.Creator)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCreator indicates an expected call of CreateCreator.
+func (mr *MockCreatorRepositoryMockRecorder) CreateCreator(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCreator", reflect.TypeOf((*MockCreatorRepository)(nil).CreateCreator), arg0, arg1)
+}
+
+// CreateVideo mocks base method.
+func (m *MockCreatorRepository) CreateVideo(arg0 context.Context, arg1 models.CreateVideoInput) (*models.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVideo", arg0, arg1)
+	ret0, _ := ret[0].(*models.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateVideo indicates an expected call of CreateVideo.
+func (mr *MockCreatorRepositoryMockRecorder) CreateVideo(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVideo", reflect.TypeOf((*MockCreatorRepository)(nil).CreateVideo), arg0, arg1)
+}
+
+// FindVideoByTikTokID mocks base method.
+func (m *MockCreatorRepository) FindVideoByTikTokID(arg0 context.Context, arg1 string) (*models.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindVideoByTikTokID", arg0, arg1)
+	ret0, _ := ret[0].(*models.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindVideoByTikTokID indicates an expected call of FindVideoByTikTokID.
+func (mr *MockCreatorRepositoryMockRecorder) FindVideoByTikTokID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindVideoByTikTokID", reflect.TypeOf((*MockCreatorRepository)(nil).FindVideoByTikTokID), arg0, arg1)
+}
+
+// GetCreator mocks base method.
+func (m *MockCreatorRepository) GetCreator(arg0 context.Context, arg1 int64) (*models.Creator, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCreator", arg0, arg1)
+	ret0, _ := ret[0].(*models.Creator)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCreator indicates an expected call of GetCreator.
+func (mr *MockCreatorRepositoryMockRecorder) GetCreator(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCreator", reflect.TypeOf((*MockCreatorRepository)(nil).GetCreator), arg0, arg1)
+}
+
+// GetCreatorAggregates mocks base method.
+func (m *MockCreatorRepository) GetCreatorAggregates(arg0 context.Context, arg1 int64) (models.CreatorAggregates, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCreatorAggregates", arg0, arg1)
+	ret0, _ := ret[0].(models.CreatorAggregates)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCreatorAggregates indicates an expected call of GetCreatorAggregates.
+func (mr *MockCreatorRepositoryMockRecorder) GetCreatorAggregates(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCreatorAggregates", reflect.TypeOf((*MockCreatorRepository)(nil).GetCreatorAggregates), arg0, arg1)
+}
+
+// ListCreatorsForSync mocks base method.
+func (m *MockCreatorRepository) ListCreatorsForSync(arg0 context.Context, arg1 time.Duration, arg2 int) ([]models.Creator, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCreatorsForSync", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.Creator)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCreatorsForSync indicates an expected call of ListCreatorsForSync.
+func (mr *MockCreatorRepositoryMockRecorder) ListCreatorsForSync(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCreatorsForSync", reflect.TypeOf((*MockCreatorRepository)(nil).ListCreatorsForSync), arg0, arg1, arg2)
+}
+
+// MarkCreatorSynced mocks base method.
+func (m *MockCreatorRepository) MarkCreatorSynced(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkCreatorSynced", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkCreatorSynced indicates an expected call of MarkCreatorSynced.
+func (mr *MockCreatorRepositoryMockRecorder) MarkCreatorSynced(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkCreatorSynced", reflect.TypeOf((*MockCreatorRepository)(nil).MarkCreatorSynced), arg0, arg1)
+}
+
+// SetCreatorSyncError mocks base method.
+func (m *MockCreatorRepository) SetCreatorSyncError(arg0 context.Context, arg1 int64, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCreatorSyncError", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCreatorSyncError indicates an expected call of SetCreatorSyncError.
+func (mr *MockCreatorRepositoryMockRecorder) SetCreatorSyncError(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCreatorSyncError", reflect.TypeOf((*MockCreatorRepository)(nil).SetCreatorSyncError), arg0, arg1, arg2)
+}
+
+// MockCreatorPostsProvider is a mock of CreatorPostsProvider interface.
+type MockCreatorPostsProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockCreatorPostsProviderMockRecorder
+}
+
+// MockCreatorPostsProviderMockRecorder is the mock recorder for MockCreatorPostsProvider.
+type MockCreatorPostsProviderMockRecorder struct {
+	mock *MockCreatorPostsProvider
+}
+
+// NewMockCreatorPostsProvider creates a new mock instance.
+func NewMockCreatorPostsProvider(ctrl *gomock.Controller) *MockCreatorPostsProvider {
+	mock := &MockCreatorPostsProvider{ctrl: ctrl}
+	mock.recorder = &MockCreatorPostsProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCreatorPostsProvider) EXPECT() *MockCreatorPostsProviderMockRecorder {
+	return m.recorder
+}
+
+// ListUserPosts mocks base method.
+func (m *MockCreatorPostsProvider) ListUserPosts(arg0 context.Context, arg1 string, arg2 time.Time) ([]models.CreatorPost, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserPosts", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.CreatorPost)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserPosts indicates an expected call of ListUserPosts.
+func (mr *MockCreatorPostsProviderMockRecorder) ListUserPosts(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserPosts", reflect.TypeOf((*MockCreatorPostsProvider)(nil).ListUserPosts), arg0, arg1, arg2)
+}
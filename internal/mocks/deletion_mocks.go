@@ -0,0 +1,171 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ttanalytic/internal/service (interfaces: DeletionRepository)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	models "ttanalytic/internal/models"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockDeletionRepository is a mock of DeletionRepository interface.
+type MockDeletionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeletionRepositoryMockRecorder
+}
+
+// MockDeletionRepositoryMockRecorder is the mock recorder for MockDeletionRepository.
+type MockDeletionRepositoryMockRecorder struct {
+	mock *MockDeletionRepository
+}
+
+// NewMockDeletionRepository creates a new mock instance.
+func NewMockDeletionRepository(ctrl *gomock.Controller) *MockDeletionRepository {
+	mock := &MockDeletionRepository{ctrl: ctrl}
+	mock.recorder = &MockDeletionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeletionRepository) EXPECT() *MockDeletionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AppendAuditEntry mocks base method.
+func (m *MockDeletionRepository) AppendAuditEntry(arg0 context.Context, arg1 models.CreateAuditEntryInput) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendAuditEntry", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AppendAuditEntry indicates an expected call of AppendAuditEntry.
+func (mr *MockDeletionRepositoryMockRecorder) AppendAuditEntry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendAuditEntry", reflect.TypeOf((*MockDeletionRepository)(nil).AppendAuditEntry), arg0, arg1)
+}
+
+// DeleteCreatorsByUsername mocks base method.
+func (m *MockDeletionRepository) DeleteCreatorsByUsername(arg0 context.Context, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCreatorsByUsername", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteCreatorsByUsername indicates an expected call of DeleteCreatorsByUsername.
+func (mr *MockDeletionRepositoryMockRecorder) DeleteCreatorsByUsername(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCreatorsByUsername", reflect.TypeOf((*MockDeletionRepository)(nil).DeleteCreatorsByUsername), arg0, arg1)
+}
+
+// DeleteVideos mocks base method.
+func (m *MockDeletionRepository) DeleteVideos(arg0 context.Context, arg1 []int64) (models.DeletedVideos, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteVideos", arg0, arg1)
+	ret0, _ := ret[0].(models.DeletedVideos)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteVideos indicates an expected call of DeleteVideos.
+func (mr *MockDeletionRepositoryMockRecorder) DeleteVideos(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVideos", reflect.TypeOf((*MockDeletionRepository)(nil).DeleteVideos), arg0, arg1)
+}
+
+// FindVideoByID mocks base method.
+func (m *MockDeletionRepository) FindVideoByID(arg0 context.Context, arg1 int64) (*models.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindVideoByID", arg0, arg1)
+	ret0, _ := ret[0].(*models.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindVideoByID indicates an expected call of FindVideoByID.
+func (mr *MockDeletionRepositoryMockRecorder) FindVideoByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindVideoByID", reflect.TypeOf((*MockDeletionRepository)(nil).FindVideoByID), arg0, arg1)
+}
+
+// ListVideoIDsByUsername mocks base method.
+func (m *MockDeletionRepository) ListVideoIDsByUsername(arg0 context.Context, arg1 string) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVideoIDsByUsername", arg0, arg1)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVideoIDsByUsername indicates an expected call of ListVideoIDsByUsername.
+func (mr *MockDeletionRepositoryMockRecorder) ListVideoIDsByUsername(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVideoIDsByUsername", reflect.TypeOf((*MockDeletionRepository)(nil).ListVideoIDsByUsername), arg0, arg1)
+}
+
+// ListVideosForPurge mocks base method.
+func (m *MockDeletionRepository) ListVideosForPurge(arg0 context.Context, arg1 time.Time, arg2 int) ([]models.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVideosForPurge", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVideosForPurge indicates an expected call of ListVideosForPurge.
+func (mr *MockDeletionRepositoryMockRecorder) ListVideosForPurge(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVideosForPurge", reflect.TypeOf((*MockDeletionRepository)(nil).ListVideosForPurge), arg0, arg1, arg2)
+}
+
+// RedactAuditUsername mocks base method.
+func (m *MockDeletionRepository) RedactAuditUsername(arg0 context.Context, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedactAuditUsername", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedactAuditUsername indicates an expected call of RedactAuditUsername.
+func (mr *MockDeletionRepositoryMockRecorder) RedactAuditUsername(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedactAuditUsername", reflect.TypeOf((*MockDeletionRepository)(nil).RedactAuditUsername), arg0, arg1)
+}
+
+// RemoveAllowedCreator mocks base method.
+func (m *MockDeletionRepository) RemoveAllowedCreator(arg0 context.Context, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveAllowedCreator", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveAllowedCreator indicates an expected call of RemoveAllowedCreator.
+func (mr *MockDeletionRepositoryMockRecorder) RemoveAllowedCreator(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAllowedCreator", reflect.TypeOf((*MockDeletionRepository)(nil).RemoveAllowedCreator), arg0, arg1)
+}
+
+// SoftDeleteVideo mocks base method.
+func (m *MockDeletionRepository) SoftDeleteVideo(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDeleteVideo", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SoftDeleteVideo indicates an expected call of SoftDeleteVideo.
+func (mr *MockDeletionRepositoryMockRecorder) SoftDeleteVideo(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDeleteVideo", reflect.TypeOf((*MockDeletionRepository)(nil).SoftDeleteVideo), arg0, arg1)
+}
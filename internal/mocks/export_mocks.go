@@ -0,0 +1,212 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ttanalytic/internal/service (interfaces: ExportRepository)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	models "ttanalytic/internal/models"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockExportRepository is a mock of ExportRepository interface.
+type MockExportRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockExportRepositoryMockRecorder
+}
+
+// MockExportRepositoryMockRecorder is the mock recorder for MockExportRepository.
+type MockExportRepositoryMockRecorder struct {
+	mock *MockExportRepository
+}
+
+// NewMockExportRepository creates a new mock instance.
+func NewMockExportRepository(ctrl *gomock.Controller) *MockExportRepository {
+	mock := &MockExportRepository{ctrl: ctrl}
+	mock.recorder = &MockExportRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExportRepository) EXPECT() *MockExportRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimExportJob mocks base method.
+func (m *MockExportRepository) ClaimExportJob(arg0 context.Context, arg1 string, arg2 time.Duration, arg3 int) (*models.ExportJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimExportJob", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*models.ExportJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimExportJob indicates an expected call of ClaimExportJob.
+func (mr *MockExportRepositoryMockRecorder) ClaimExportJob(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimExportJob", reflect.TypeOf((*MockExportRepository)(nil).ClaimExportJob), arg0, arg1, arg2, arg3)
+}
+
+// CreateExportJob mocks base method.
+func (m *MockExportRepository) CreateExportJob(arg0 context.Context, arg1 models.CreateExportJobInput) (*models.ExportJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateExportJob", arg0, arg1)
+	ret0, _ := ret[0].(*models.ExportJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateExportJob indicates an expected call of CreateExportJob.
+func (mr *MockExportRepositoryMockRecorder) CreateExportJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExportJob", reflect.TypeOf((*MockExportRepository)(nil).CreateExportJob), arg0, arg1)
+}
+
+// ExpireExports mocks base method.
+func (m *MockExportRepository) ExpireExports(arg0 context.Context, arg1 int) ([]models.ExportJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireExports", arg0, arg1)
+	ret0, _ := ret[0].([]models.ExportJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireExports indicates an expected call of ExpireExports.
+func (mr *MockExportRepositoryMockRecorder) ExpireExports(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireExports", reflect.TypeOf((*MockExportRepository)(nil).ExpireExports), arg0, arg1)
+}
+
+// FailAbandonedExports mocks base method.
+func (m *MockExportRepository) FailAbandonedExports(arg0 context.Context, arg1 int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailAbandonedExports", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FailAbandonedExports indicates an expected call of FailAbandonedExports.
+func (mr *MockExportRepositoryMockRecorder) FailAbandonedExports(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailAbandonedExports", reflect.TypeOf((*MockExportRepository)(nil).FailAbandonedExports), arg0, arg1)
+}
+
+// FindVideoByID mocks base method.
+func (m *MockExportRepository) FindVideoByID(arg0 context.Context, arg1 int64) (*models.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindVideoByID", arg0, arg1)
+	ret0, _ := ret[0].(*models.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindVideoByID indicates an expected call of FindVideoByID.
+func (mr *MockExportRepositoryMockRecorder) FindVideoByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindVideoByID", reflect.TypeOf((*MockExportRepository)(nil).FindVideoByID), arg0, arg1)
+}
+
+// GetExportJob mocks base method.
+func (m *MockExportRepository) GetExportJob(arg0 context.Context, arg1 int64) (*models.ExportJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExportJob", arg0, arg1)
+	ret0, _ := ret[0].(*models.ExportJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExportJob indicates an expected call of GetExportJob.
+func (mr *MockExportRepositoryMockRecorder) GetExportJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExportJob", reflect.TypeOf((*MockExportRepository)(nil).GetExportJob), arg0, arg1)
+}
+
+// GetRollupState mocks base method.
+func (m *MockExportRepository) GetRollupState(arg0 context.Context) (models.RollupState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRollupState", arg0)
+	ret0, _ := ret[0].(models.RollupState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRollupState indicates an expected call of GetRollupState.
+func (mr *MockExportRepositoryMockRecorder) GetRollupState(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRollupState", reflect.TypeOf((*MockExportRepository)(nil).GetRollupState), arg0)
+}
+
+// MarkExportDone mocks base method.
+func (m *MockExportRepository) MarkExportDone(arg0 context.Context, arg1 int64, arg2, arg3 string, arg4, arg5 int64, arg6 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkExportDone", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkExportDone indicates an expected call of MarkExportDone.
+func (mr *MockExportRepositoryMockRecorder) MarkExportDone(arg0, arg1, arg2, arg3, arg4, arg5, arg6 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkExportDone", reflect.TypeOf((*MockExportRepository)(nil).MarkExportDone), arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+}
+
+// MarkExportFailed mocks base method.
+func (m *MockExportRepository) MarkExportFailed(arg0 context.Context, arg1 int64, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkExportFailed", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkExportFailed indicates an expected call of MarkExportFailed.
+func (mr *MockExportRepositoryMockRecorder) MarkExportFailed(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkExportFailed", reflect.TypeOf((*MockExportRepository)(nil).MarkExportFailed), arg0, arg1, arg2, arg3)
+}
+
+// PinReads mocks base method.
+func (m *MockExportRepository) PinReads(arg0 context.Context) context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PinReads", arg0)
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// PinReads indicates an expected call of PinReads.
+func (mr *MockExportRepositoryMockRecorder) PinReads(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PinReads", reflect.TypeOf((*MockExportRepository)(nil).PinReads), arg0)
+}
+
+// RenewExportLease mocks base method.
+func (m *MockExportRepository) RenewExportLease(arg0 context.Context, arg1 int64, arg2 string, arg3 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenewExportLease", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenewExportLease indicates an expected call of RenewExportLease.
+func (mr *MockExportRepositoryMockRecorder) RenewExportLease(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenewExportLease", reflect.TypeOf((*MockExportRepository)(nil).RenewExportLease), arg0, arg1, arg2, arg3)
+}
+
+// StreamStatsForExport mocks base method.
+func (m *MockExportRepository) StreamStatsForExport(arg0 context.Context, arg1 string, arg2 *int64, arg3, arg4 time.Time, arg5 func(models.ExportStatRow) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamStatsForExport", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamStatsForExport indicates an expected call of StreamStatsForExport.
+func (mr *MockExportRepositoryMockRecorder) StreamStatsForExport(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamStatsForExport", reflect.TypeOf((*MockExportRepository)(nil).StreamStatsForExport), arg0, arg1, arg2, arg3, arg4, arg5)
+}
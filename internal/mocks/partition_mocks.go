@@ -0,0 +1,111 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ttanalytic/internal/service (interfaces: PartitionRepository)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	models "ttanalytic/internal/models"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPartitionRepository is a mock of PartitionRepository interface.
+type MockPartitionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPartitionRepositoryMockRecorder
+}
+
+// MockPartitionRepositoryMockRecorder is the mock recorder for MockPartitionRepository.
+type MockPartitionRepositoryMockRecorder struct {
+	mock *MockPartitionRepository
+}
+
+// NewMockPartitionRepository creates a new mock instance.
+func NewMockPartitionRepository(ctrl *gomock.Controller) *MockPartitionRepository {
+	mock := &MockPartitionRepository{ctrl: ctrl}
+	mock.recorder = &MockPartitionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPartitionRepository) EXPECT() *MockPartitionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ArchiveStatsPartition mocks base method.
+func (m *MockPartitionRepository) ArchiveStatsPartition(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveStatsPartition", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ArchiveStatsPartition indicates an expected call of ArchiveStatsPartition.
+func (mr *MockPartitionRepositoryMockRecorder) ArchiveStatsPartition(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveStatsPartition", reflect.TypeOf((*MockPartitionRepository)(nil).ArchiveStatsPartition), arg0, arg1)
+}
+
+// CreateStatsPartition mocks base method.
+func (m *MockPartitionRepository) CreateStatsPartition(arg0 context.Context, arg1 time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateStatsPartition", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateStatsPartition indicates an expected call of CreateStatsPartition.
+func (mr *MockPartitionRepositoryMockRecorder) CreateStatsPartition(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateStatsPartition", reflect.TypeOf((*MockPartitionRepository)(nil).CreateStatsPartition), arg0, arg1)
+}
+
+// GetRollupState mocks base method.
+func (m *MockPartitionRepository) GetRollupState(arg0 context.Context) (models.RollupState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRollupState", arg0)
+	ret0, _ := ret[0].(models.RollupState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRollupState indicates an expected call of GetRollupState.
+func (mr *MockPartitionRepositoryMockRecorder) GetRollupState(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRollupState", reflect.TypeOf((*MockPartitionRepository)(nil).GetRollupState), arg0)
+}
+
+// ListStatsPartitions mocks base method.
+func (m *MockPartitionRepository) ListStatsPartitions(arg0 context.Context) ([]models.StatsPartition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStatsPartitions", arg0)
+	ret0, _ := ret[0].([]models.StatsPartition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStatsPartitions indicates an expected call of ListStatsPartitions.
+func (mr *MockPartitionRepositoryMockRecorder) ListStatsPartitions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStatsPartitions", reflect.TypeOf((*MockPartitionRepository)(nil).ListStatsPartitions), arg0)
+}
+
+// LockPartitionMaintenance mocks base method.
+func (m *MockPartitionRepository) LockPartitionMaintenance(arg0 context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockPartitionMaintenance", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockPartitionMaintenance indicates an expected call of LockPartitionMaintenance.
+func (mr *MockPartitionRepositoryMockRecorder) LockPartitionMaintenance(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockPartitionMaintenance", reflect.TypeOf((*MockPartitionRepository)(nil).LockPartitionMaintenance), arg0)
+}
@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ttanalytic/internal/service (interfaces: VideoRefresher)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	models "ttanalytic/internal/models"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockVideoRefresher is a mock of VideoRefresher interface.
+type MockVideoRefresher struct {
+	ctrl     *gomock.Controller
+	recorder *MockVideoRefresherMockRecorder
+}
+
+// MockVideoRefresherMockRecorder is the mock recorder for MockVideoRefresher.
+type MockVideoRefresherMockRecorder struct {
+	mock *MockVideoRefresher
+}
+
+// NewMockVideoRefresher creates a new mock instance.
+func NewMockVideoRefresher(ctrl *gomock.Controller) *MockVideoRefresher {
+	mock := &MockVideoRefresher{ctrl: ctrl}
+	mock.recorder = &MockVideoRefresherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVideoRefresher) EXPECT() *MockVideoRefresherMockRecorder {
+	return m.recorder
+}
+
+// RefreshVideo mocks base method.
+func (m *MockVideoRefresher) RefreshVideo(arg0 context.Context, arg1 int64) (*models.Video, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshVideo", arg0, arg1)
+	ret0, _ := ret[0].(*models.Video)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RefreshVideo indicates an expected call of RefreshVideo.
+func (mr *MockVideoRefresherMockRecorder) RefreshVideo(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshVideo", reflect.TypeOf((*MockVideoRefresher)(nil).RefreshVideo), arg0, arg1)
+}
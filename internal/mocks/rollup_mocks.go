@@ -0,0 +1,185 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ttanalytic/internal/service (interfaces: RollupRepository)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	models "ttanalytic/internal/models"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockRollupRepository is a mock of RollupRepository interface.
+type MockRollupRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRollupRepositoryMockRecorder
+}
+
+// MockRollupRepositoryMockRecorder is the mock recorder for MockRollupRepository.
+type MockRollupRepositoryMockRecorder struct {
+	mock *MockRollupRepository
+}
+
+// NewMockRollupRepository creates a new mock instance.
+func NewMockRollupRepository(ctrl *gomock.Controller) *MockRollupRepository {
+	mock := &MockRollupRepository{ctrl: ctrl}
+	mock.recorder = &MockRollupRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRollupRepository) EXPECT() *MockRollupRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteDailyStatsBefore mocks base method.
+func (m *MockRollupRepository) DeleteDailyStatsBefore(arg0 context.Context, arg1 time.Time, arg2 int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDailyStatsBefore", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteDailyStatsBefore indicates an expected call of DeleteDailyStatsBefore.
+func (mr *MockRollupRepositoryMockRecorder) DeleteDailyStatsBefore(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDailyStatsBefore", reflect.TypeOf((*MockRollupRepository)(nil).DeleteDailyStatsBefore), arg0, arg1, arg2)
+}
+
+// DeleteRawStatsBefore mocks base method.
+func (m *MockRollupRepository) DeleteRawStatsBefore(arg0 context.Context, arg1 time.Time, arg2 int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRawStatsBefore", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteRawStatsBefore indicates an expected call of DeleteRawStatsBefore.
+func (mr *MockRollupRepositoryMockRecorder) DeleteRawStatsBefore(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRawStatsBefore", reflect.TypeOf((*MockRollupRepository)(nil).DeleteRawStatsBefore), arg0, arg1, arg2)
+}
+
+// DropStatsPartition mocks base method.
+func (m *MockRollupRepository) DropStatsPartition(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DropStatsPartition", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DropStatsPartition indicates an expected call of DropStatsPartition.
+func (mr *MockRollupRepositoryMockRecorder) DropStatsPartition(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropStatsPartition", reflect.TypeOf((*MockRollupRepository)(nil).DropStatsPartition), arg0, arg1)
+}
+
+// GetRollupState mocks base method.
+func (m *MockRollupRepository) GetRollupState(arg0 context.Context) (models.RollupState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRollupState", arg0)
+	ret0, _ := ret[0].(models.RollupState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRollupState indicates an expected call of GetRollupState.
+func (mr *MockRollupRepositoryMockRecorder) GetRollupState(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRollupState", reflect.TypeOf((*MockRollupRepository)(nil).GetRollupState), arg0)
+}
+
+// ListStatsPartitions mocks base method.
+func (m *MockRollupRepository) ListStatsPartitions(arg0 context.Context) ([]models.StatsPartition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStatsPartitions", arg0)
+	ret0, _ := ret[0].([]models.StatsPartition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStatsPartitions indicates an expected call of ListStatsPartitions.
+func (mr *MockRollupRepositoryMockRecorder) ListStatsPartitions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStatsPartitions", reflect.TypeOf((*MockRollupRepository)(nil).ListStatsPartitions), arg0)
+}
+
+// LockPartitionMaintenance mocks base method.
+func (m *MockRollupRepository) LockPartitionMaintenance(arg0 context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockPartitionMaintenance", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockPartitionMaintenance indicates an expected call of LockPartitionMaintenance.
+func (mr *MockRollupRepositoryMockRecorder) LockPartitionMaintenance(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockPartitionMaintenance", reflect.TypeOf((*MockRollupRepository)(nil).LockPartitionMaintenance), arg0)
+}
+
+// LockRollups mocks base method.
+func (m *MockRollupRepository) LockRollups(arg0 context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockRollups", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockRollups indicates an expected call of LockRollups.
+func (mr *MockRollupRepositoryMockRecorder) LockRollups(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockRollups", reflect.TypeOf((*MockRollupRepository)(nil).LockRollups), arg0)
+}
+
+// RollupDailyStats mocks base method.
+func (m *MockRollupRepository) RollupDailyStats(arg0 context.Context, arg1, arg2 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollupDailyStats", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RollupDailyStats indicates an expected call of RollupDailyStats.
+func (mr *MockRollupRepositoryMockRecorder) RollupDailyStats(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollupDailyStats", reflect.TypeOf((*MockRollupRepository)(nil).RollupDailyStats), arg0, arg1, arg2)
+}
+
+// RollupWeeklyStats mocks base method.
+func (m *MockRollupRepository) RollupWeeklyStats(arg0 context.Context, arg1, arg2 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollupWeeklyStats", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RollupWeeklyStats indicates an expected call of RollupWeeklyStats.
+func (mr *MockRollupRepositoryMockRecorder) RollupWeeklyStats(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollupWeeklyStats", reflect.TypeOf((*MockRollupRepository)(nil).RollupWeeklyStats), arg0, arg1, arg2)
+}
+
+// UpdateRollupState mocks base method.
+func (m *MockRollupRepository) UpdateRollupState(arg0 context.Context, arg1 models.RollupState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRollupState", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRollupState indicates an expected call of UpdateRollupState.
+func (mr *MockRollupRepositoryMockRecorder) UpdateRollupState(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRollupState", reflect.TypeOf((*MockRollupRepository)(nil).UpdateRollupState), arg0, arg1)
+}
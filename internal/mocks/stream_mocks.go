@@ -0,0 +1,67 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ttanalytic/internal/service (interfaces: StreamRepository)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	models "ttanalytic/internal/models"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockStreamRepository is a mock of StreamRepository interface.
+type MockStreamRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockStreamRepositoryMockRecorder
+}
+
+// MockStreamRepositoryMockRecorder is the mock recorder for MockStreamRepository.
+type MockStreamRepositoryMockRecorder struct {
+	mock *MockStreamRepository
+}
+
+// NewMockStreamRepository creates a new mock instance.
+func NewMockStreamRepository(ctrl *gomock.Controller) *MockStreamRepository {
+	mock := &MockStreamRepository{ctrl: ctrl}
+	mock.recorder = &MockStreamRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStreamRepository) EXPECT() *MockStreamRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindVideoByID mocks base method.
+func (m *MockStreamRepository) FindVideoByID(arg0 context.Context, arg1 int64) (*models.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindVideoByID", arg0, arg1)
+	ret0, _ := ret[0].(*models.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindVideoByID indicates an expected call of FindVideoByID.
+func (mr *MockStreamRepositoryMockRecorder) FindVideoByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindVideoByID", reflect.TypeOf((*MockStreamRepository)(nil).FindVideoByID), arg0, arg1)
+}
+
+// ListVideoStatsSince mocks base method.
+func (m *MockStreamRepository) ListVideoStatsSince(arg0 context.Context, arg1 *int64, arg2 models.StatsEventID, arg3 time.Duration, arg4 int) ([]models.VideoStatsEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVideoStatsSince", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]models.VideoStatsEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVideoStatsSince indicates an expected call of ListVideoStatsSince.
+func (mr *MockStreamRepositoryMockRecorder) ListVideoStatsSince(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVideoStatsSince", reflect.TypeOf((*MockStreamRepository)(nil).ListVideoStatsSince), arg0, arg1, arg2, arg3, arg4)
+}
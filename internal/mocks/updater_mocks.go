@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: ttanalytic/internal/service (interfaces: UpdaterRepository,TikTokProvider,Logger,Transactor)
+// Source: ttanalytic/internal/service (interfaces: UpdaterRepository,TikTokProvider,BatchTikTokProvider,Logger,Transactor,UpdaterMetrics)
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -50,6 +50,36 @@ func (mr *MockUpdaterRepositoryMockRecorder) AppendVideoStats(arg0, arg1 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendVideoStats", reflect.TypeOf((*MockUpdaterRepository)(nil).AppendVideoStats), arg0, arg1)
 }
 
+// CopyVideoStats mocks base method.
+func (m *MockUpdaterRepository) CopyVideoStats(arg0 context.Context, arg1 []models.VideoStatsWrite) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyVideoStats", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CopyVideoStats indicates an expected call of CopyVideoStats.
+func (mr *MockUpdaterRepositoryMockRecorder) CopyVideoStats(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyVideoStats", reflect.TypeOf((*MockUpdaterRepository)(nil).CopyVideoStats), arg0, arg1)
+}
+
+// FindVideoByID mocks base method.
+func (m *MockUpdaterRepository) FindVideoByID(arg0 context.Context, arg1 int64) (*models.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindVideoByID", arg0, arg1)
+	ret0, _ := ret[0].(*models.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindVideoByID indicates an expected call of FindVideoByID.
+func (mr *MockUpdaterRepositoryMockRecorder) FindVideoByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindVideoByID", reflect.TypeOf((*MockUpdaterRepository)(nil).FindVideoByID), arg0, arg1)
+}
+
 // ListVideosForUpdate mocks base method.
 func (m *MockUpdaterRepository) ListVideosForUpdate(arg0 context.Context, arg1 time.Duration, arg2 int) ([]models.Video, error) {
 	m.ctrl.T.Helper()
@@ -93,6 +123,21 @@ func (mr *MockUpdaterRepositoryMockRecorder) UpdateVideoAggregates(arg0, arg1 in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVideoAggregates", reflect.TypeOf((*MockUpdaterRepository)(nil).UpdateVideoAggregates), arg0, arg1)
 }
 
+// UpdateVideoAggregatesBulk mocks base method.
+func (m *MockUpdaterRepository) UpdateVideoAggregatesBulk(arg0 context.Context, arg1 []models.VideoStatsWrite) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVideoAggregatesBulk", arg0, arg1)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateVideoAggregatesBulk indicates an expected call of UpdateVideoAggregatesBulk.
+func (mr *MockUpdaterRepositoryMockRecorder) UpdateVideoAggregatesBulk(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVideoAggregatesBulk", reflect.TypeOf((*MockUpdaterRepository)(nil).UpdateVideoAggregatesBulk), arg0, arg1)
+}
+
 // MockTikTokProvider is a mock of TikTokProvider interface.
 type MockTikTokProvider struct {
 	ctrl     *gomock.Controller
@@ -131,6 +176,59 @@ func (mr *MockTikTokProviderMockRecorder) GetVideoStats(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVideoStats", reflect.TypeOf((*MockTikTokProvider)(nil).GetVideoStats), arg0, arg1)
 }
 
+// MockBatchTikTokProvider is a mock of BatchTikTokProvider interface.
+type MockBatchTikTokProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockBatchTikTokProviderMockRecorder
+}
+
+// MockBatchTikTokProviderMockRecorder is the mock recorder for MockBatchTikTokProvider.
+type MockBatchTikTokProviderMockRecorder struct {
+	mock *MockBatchTikTokProvider
+}
+
+// NewMockBatchTikTokProvider creates a new mock instance.
+func NewMockBatchTikTokProvider(ctrl *gomock.Controller) *MockBatchTikTokProvider {
+	mock := &MockBatchTikTokProvider{ctrl: ctrl}
+	mock.recorder = &MockBatchTikTokProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBatchTikTokProvider) EXPECT() *MockBatchTikTokProviderMockRecorder {
+	return m.recorder
+}
+
+// GetVideoStats mocks base method.
+func (m *MockBatchTikTokProvider) GetVideoStats(arg0 context.Context, arg1 string) (*models.VideoStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVideoStats", arg0, arg1)
+	ret0, _ := ret[0].(*models.VideoStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVideoStats indicates an expected call of GetVideoStats.
+func (mr *MockBatchTikTokProviderMockRecorder) GetVideoStats(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVideoStats", reflect.TypeOf((*MockBatchTikTokProvider)(nil).GetVideoStats), arg0, arg1)
+}
+
+// GetVideoStatsBatch mocks base method.
+func (m *MockBatchTikTokProvider) GetVideoStatsBatch(arg0 context.Context, arg1 []string) ([]models.VideoStatsResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVideoStatsBatch", arg0, arg1)
+	ret0, _ := ret[0].([]models.VideoStatsResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVideoStatsBatch indicates an expected call of GetVideoStatsBatch.
+func (mr *MockBatchTikTokProviderMockRecorder) GetVideoStatsBatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVideoStatsBatch", reflect.TypeOf((*MockBatchTikTokProvider)(nil).GetVideoStatsBatch), arg0, arg1)
+}
+
 // MockLogger is a mock of Logger interface.
 type MockLogger struct {
 	ctrl     *gomock.Controller
@@ -257,3 +355,50 @@ func (mr *MockTransactorMockRecorder) WithinTransaction(arg0, arg1 interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTransaction", reflect.TypeOf((*MockTransactor)(nil).WithinTransaction), arg0, arg1)
 }
+
+// MockUpdaterMetrics is a mock of UpdaterMetrics interface.
+type MockUpdaterMetrics struct {
+	ctrl     *gomock.Controller
+	recorder *MockUpdaterMetricsMockRecorder
+}
+
+// MockUpdaterMetricsMockRecorder is the mock recorder for MockUpdaterMetrics.
+type MockUpdaterMetricsMockRecorder struct {
+	mock *MockUpdaterMetrics
+}
+
+// NewMockUpdaterMetrics creates a new mock instance.
+func NewMockUpdaterMetrics(ctrl *gomock.Controller) *MockUpdaterMetrics {
+	mock := &MockUpdaterMetrics{ctrl: ctrl}
+	mock.recorder = &MockUpdaterMetricsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUpdaterMetrics) EXPECT() *MockUpdaterMetricsMockRecorder {
+	return m.recorder
+}
+
+// ObserveUpdaterTick mocks base method.
+func (m *MockUpdaterMetrics) ObserveUpdaterTick(arg0 time.Duration, arg1 error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ObserveUpdaterTick", arg0, arg1)
+}
+
+// ObserveUpdaterTick indicates an expected call of ObserveUpdaterTick.
+func (mr *MockUpdaterMetricsMockRecorder) ObserveUpdaterTick(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObserveUpdaterTick", reflect.TypeOf((*MockUpdaterMetrics)(nil).ObserveUpdaterTick), arg0, arg1)
+}
+
+// ObserveUpdaterVideo mocks base method.
+func (m *MockUpdaterMetrics) ObserveUpdaterVideo(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ObserveUpdaterVideo", arg0)
+}
+
+// ObserveUpdaterVideo indicates an expected call of ObserveUpdaterVideo.
+func (mr *MockUpdaterMetricsMockRecorder) ObserveUpdaterVideo(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObserveUpdaterVideo", reflect.TypeOf((*MockUpdaterMetrics)(nil).ObserveUpdaterVideo), arg0)
+}
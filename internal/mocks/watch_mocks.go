@@ -0,0 +1,235 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ttanalytic/internal/service (interfaces: WatchRepository,WatchPostsProvider)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	models "ttanalytic/internal/models"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockWatchRepository is a mock of WatchRepository interface.
+type MockWatchRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWatchRepositoryMockRecorder
+}
+
+// MockWatchRepositoryMockRecorder is the mock recorder for MockWatchRepository.
+type MockWatchRepositoryMockRecorder struct {
+	mock *MockWatchRepository
+}
+
+// NewMockWatchRepository creates a new mock instance.
+func NewMockWatchRepository(ctrl *gomock.Controller) *MockWatchRepository {
+	mock := &MockWatchRepository{ctrl: ctrl}
+	mock.recorder = &MockWatchRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWatchRepository) EXPECT() *MockWatchRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AppendVideoStats mocks base method.
+func (m *MockWatchRepository) AppendVideoStats(arg0 context.Context, arg1 models.CreateVideoStatsInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendVideoStats", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AppendVideoStats indicates an expected call of AppendVideoStats.
+func (mr *MockWatchRepositoryMockRecorder) AppendVideoStats(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendVideoStats", reflect.TypeOf((*MockWatchRepository)(nil).AppendVideoStats), arg0, arg1)
+}
+
+// CreateVideo mocks base method.
+func (m *MockWatchRepository) CreateVideo(arg0 context.Context, arg1 models.CreateVideoInput) (*models.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVideo", arg0, arg1)
+	ret0, _ := ret[0].(*models.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateVideo indicates an expected call of CreateVideo.
+func (mr *MockWatchRepositoryMockRecorder) CreateVideo(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVideo", reflect.TypeOf((*MockWatchRepository)(nil).CreateVideo), arg0, arg1)
+}
+
+// CreateWatch mocks base method.
+func (m *MockWatchRepository) CreateWatch(arg0 context.Context, arg1 models.CreateWatchInput) (*models.Watch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWatch", arg0, arg1)
+	ret0, _ := ret[0].(*models.Watch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWatch indicates an expected call of CreateWatch.
+func (mr *MockWatchRepositoryMockRecorder) CreateWatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWatch", reflect.TypeOf((*MockWatchRepository)(nil).CreateWatch), arg0, arg1)
+}
+
+// FindVideoByTikTokID mocks base method.
+func (m *MockWatchRepository) FindVideoByTikTokID(arg0 context.Context, arg1 string) (*models.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindVideoByTikTokID", arg0, arg1)
+	ret0, _ := ret[0].(*models.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindVideoByTikTokID indicates an expected call of FindVideoByTikTokID.
+func (mr *MockWatchRepositoryMockRecorder) FindVideoByTikTokID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindVideoByTikTokID", reflect.TypeOf((*MockWatchRepository)(nil).FindVideoByTikTokID), arg0, arg1)
+}
+
+// GetWatch mocks base method.
+func (m *MockWatchRepository) GetWatch(arg0 context.Context, arg1 int64) (*models.Watch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWatch", arg0, arg1)
+	ret0, _ := ret[0].(*models.Watch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWatch indicates an expected call of GetWatch.
+func (mr *MockWatchRepositoryMockRecorder) GetWatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWatch", reflect.TypeOf((*MockWatchRepository)(nil).GetWatch), arg0, arg1)
+}
+
+// GetWatchAggregates mocks base method.
+func (m *MockWatchRepository) GetWatchAggregates(arg0 context.Context, arg1 int64) (models.WatchAggregates, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWatchAggregates", arg0, arg1)
+	ret0, _ := ret[0].(models.WatchAggregates)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWatchAggregates indicates an expected call of GetWatchAggregates.
+func (mr *MockWatchRepositoryMockRecorder) GetWatchAggregates(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWatchAggregates", reflect.TypeOf((*MockWatchRepository)(nil).GetWatchAggregates), arg0, arg1)
+}
+
+// LinkWatchVideo mocks base method.
+func (m *MockWatchRepository) LinkWatchVideo(arg0 context.Context, arg1, arg2 int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkWatchVideo", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LinkWatchVideo indicates an expected call of LinkWatchVideo.
+func (mr *MockWatchRepositoryMockRecorder) LinkWatchVideo(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkWatchVideo", reflect.TypeOf((*MockWatchRepository)(nil).LinkWatchVideo), arg0, arg1, arg2)
+}
+
+// ListWatchesForSync mocks base method.
+func (m *MockWatchRepository) ListWatchesForSync(arg0 context.Context, arg1 time.Duration, arg2 int) ([]models.Watch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWatchesForSync", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.Watch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWatchesForSync indicates an expected call of ListWatchesForSync.
+func (mr *MockWatchRepositoryMockRecorder) ListWatchesForSync(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWatchesForSync", reflect.TypeOf((*MockWatchRepository)(nil).ListWatchesForSync), arg0, arg1, arg2)
+}
+
+// MarkWatchSynced mocks base method.
+func (m *MockWatchRepository) MarkWatchSynced(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkWatchSynced", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkWatchSynced indicates an expected call of MarkWatchSynced.
+func (mr *MockWatchRepositoryMockRecorder) MarkWatchSynced(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWatchSynced", reflect.TypeOf((*MockWatchRepository)(nil).MarkWatchSynced), arg0, arg1)
+}
+
+// SetWatchSyncError mocks base method.
+func (m *MockWatchRepository) SetWatchSyncError(arg0 context.Context, arg1 int64, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWatchSyncError", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWatchSyncError indicates an expected call of SetWatchSyncError.
+func (mr *MockWatchRepositoryMockRecorder) SetWatchSyncError(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWatchSyncError", reflect.TypeOf((*MockWatchRepository)(nil).SetWatchSyncError), arg0, arg1, arg2)
+}
+
+// MockWatchPostsProvider is a mock of WatchPostsProvider interface.
+type MockWatchPostsProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockWatchPostsProviderMockRecorder
+}
+
+// MockWatchPostsProviderMockRecorder is the mock recorder for MockWatchPostsProvider.
+type MockWatchPostsProviderMockRecorder struct {
+	mock *MockWatchPostsProvider
+}
+
+// NewMockWatchPostsProvider creates a new mock instance.
+func NewMockWatchPostsProvider(ctrl *gomock.Controller) *MockWatchPostsProvider {
+	mock := &MockWatchPostsProvider{ctrl: ctrl}
+	mock.recorder = &MockWatchPostsProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWatchPostsProvider) EXPECT() *MockWatchPostsProviderMockRecorder {
+	return m.recorder
+}
+
+// ListHashtagPosts mocks base method.
+func (m *MockWatchPostsProvider) ListHashtagPosts(arg0 context.Context, arg1 string, arg2 time.Time, arg3 func([]models.CreatorPost) (bool, error)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListHashtagPosts", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListHashtagPosts indicates an expected call of ListHashtagPosts.
+func (mr *MockWatchPostsProviderMockRecorder) ListHashtagPosts(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListHashtagPosts", reflect.TypeOf((*MockWatchPostsProvider)(nil).ListHashtagPosts), arg0, arg1, arg2, arg3)
+}
+
+// ListMusicPosts mocks base method.
+func (m *MockWatchPostsProvider) ListMusicPosts(arg0 context.Context, arg1 string, arg2 time.Time, arg3 func([]models.CreatorPost) (bool, error)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMusicPosts", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListMusicPosts indicates an expected call of ListMusicPosts.
+func (mr *MockWatchPostsProviderMockRecorder) ListMusicPosts(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMusicPosts", reflect.TypeOf((*MockWatchPostsProvider)(nil).ListMusicPosts), arg0, arg1, arg2, arg3)
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// API key scopes; admin implies every other scope
+const (
+	ScopeRead  = "read"
+	ScopeTrack = "track"
+	ScopeAdmin = "admin"
+)
+
+var knownScopes = map[string]bool{
+	ScopeRead:  true,
+	ScopeTrack: true,
+	ScopeAdmin: true,
+}
+
+// REQUEST DTO
+// comes from a client
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"   example:"dashboard"`
+	Scopes []string `json:"scopes" example:"read,track"`
+}
+
+// checks incoming data from the client
+func (r *CreateAPIKeyRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return fmt.Errorf("name must be provided")
+	}
+
+	if len(r.Scopes) == 0 {
+		return fmt.Errorf("at least one scope must be provided")
+	}
+
+	for i, scope := range r.Scopes {
+		r.Scopes[i] = strings.ToLower(strings.TrimSpace(scope))
+		if !knownScopes[r.Scopes[i]] {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	return nil
+}
+
+// RESPONSE DTO
+// give to the client
+type APIKeyResponse struct {
+	KeyID       int64    `json:"key_id"       example:"1"`
+	WorkspaceID int64    `json:"workspace_id" example:"1"`
+	Name        string   `json:"name"         example:"dashboard"`
+	Prefix      string   `json:"prefix"       example:"tta_1a2b3c4d"`
+	Scopes      []string `json:"scopes"       example:"read,track"`
+	CreatedAt   string   `json:"created_at"   example:"2025-11-24T01:30:00Z"`
+	LastUsedAt  *string  `json:"last_used_at" example:"2025-11-24T01:30:00Z"`
+	RevokedAt   *string  `json:"revoked_at"   example:"2025-11-24T01:30:00Z"`
+}
+
+// returned once on creation, the raw key is not stored
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key" example:"tta_1a2b3c4d..."`
+}
+
+// domain/db model
+type APIKey struct {
+	ID          int64
+	WorkspaceID int64
+	Name        string
+	Prefix      string
+	Scopes      []string
+	CreatedAt   time.Time
+	LastUsedAt  *time.Time
+	RevokedAt   *time.Time
+}
+
+// HasScope reports whether the key grants scope; admin grants everything
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// to create an api key recording
+type CreateAPIKeyInput struct {
+	Name   string
+	Prefix string
+	Hash   string
+	Scopes []string
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// audit actions
+const (
+	AuditVideoTracked       = "video.tracked"
+	AuditVideoStopped       = "video.stopped"
+	AuditVideoResumed       = "video.resumed"
+	AuditVideoRefreshed     = "video.refreshed"
+	AuditVideoDeleted       = "video.deleted"
+	AuditVideoPurged        = "video.purged"
+	AuditEarningsRecomputed = "earnings.recomputed"
+	AuditCreatorRegistered  = "creator.registered"
+	AuditCreatorPurged      = "creator.purged"
+	AuditWatchRegistered    = "watch.registered"
+	AuditAPIKeyCreated      = "api_key.created"
+	AuditAPIKeyRevoked      = "api_key.revoked"
+	AuditWebhookCreated     = "webhook.created"
+	AuditWebhookDeleted     = "webhook.deleted"
+	AuditExportCreated      = "export.created"
+)
+
+// audit target types
+const (
+	AuditTargetVideo     = "video"
+	AuditTargetCreator   = "creator"
+	AuditTargetWatch     = "watch"
+	AuditTargetAPIKey    = "api_key"
+	AuditTargetWebhook   = "webhook"
+	AuditTargetExport    = "export"
+	AuditTargetWorkspace = "workspace"
+)
+
+// actors that are not an API key ("api_key:<id>")
+const (
+	ActorSystem    = "system"    // background jobs
+	ActorAnonymous = "anonymous" // requests while auth is disabled
+	ActorCLI       = "cli"       // ttctl and apikey run against the database
+)
+
+// domain/db model
+type AuditEntry struct {
+	ID          int64
+	WorkspaceID int64
+	Actor       string
+	Action      string
+	TargetType  string
+	TargetID    *int64
+	RequestID   string
+	Before      json.RawMessage // nil when the target did not exist
+	After       json.RawMessage // nil when the target is gone
+	Details     json.RawMessage
+	CreatedAt   time.Time
+}
+
+// CreateAuditEntryInput is written in the transaction of the change it describes;
+// Before, After and Details are marshalled to JSON, nil Before/After are stored as NULL
+type CreateAuditEntryInput struct {
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   *int64
+	RequestID  string
+	Before     any
+	After      any
+	Details    any
+}
+
+// AuditFilter selects entries of the workspace, newest first; empty fields match everything
+type AuditFilter struct {
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   *int64
+	From       *time.Time // inclusive
+	To         *time.Time // exclusive
+	BeforeID   int64      // entries with a smaller id, 0 starts at the newest
+	Limit      int
+}
+
+// REQUEST DTO
+// PurgeCreatorRequest names the TikTok author of a data-subject request
+type PurgeCreatorRequest struct {
+	Username string `json:"username" example:"someuser"`
+}
+
+func (r *PurgeCreatorRequest) Validate() error {
+	r.Username = NormalizeUsername(r.Username)
+
+	if !usernamePattern.MatchString(r.Username) {
+		return fmt.Errorf("invalid username %q", r.Username)
+	}
+	return nil
+}
+
+// RESPONSE DTO
+type AuditEntryResponse struct {
+	ID         int64           `json:"id"                   example:"42"`
+	Actor      string          `json:"actor"                example:"api_key:3"`
+	Action     string          `json:"action"               example:"video.stopped"`
+	TargetType string          `json:"target_type"          example:"video"`
+	TargetID   *int64          `json:"target_id,omitempty"  example:"7"`
+	RequestID  string          `json:"request_id,omitempty" example:"9f1c2a7b4e6d8a0c"`
+	Before     json.RawMessage `json:"before,omitempty"     swaggertype:"object"`
+	After      json.RawMessage `json:"after,omitempty"      swaggertype:"object"`
+	Details    json.RawMessage `json:"details,omitempty"    swaggertype:"object"`
+	CreatedAt  string          `json:"created_at"           example:"2025-11-24T01:30:00Z"`
+}
+
+type AuditListResponse struct {
+	Entries      []AuditEntryResponse `json:"entries"`
+	NextBeforeID *int64               `json:"next_before_id,omitempty" example:"17"`
+}
+
+// PurgeResult counts what a creator purge removed from the workspace
+type PurgeResult struct {
+	Videos       int64 `json:"videos"         example:"12"`
+	Stats        int64 `json:"stats"          example:"3400"`
+	Creators     int64 `json:"creators"       example:"1"`
+	Events       int64 `json:"events"         example:"30"` // outbox events and webhook deliveries about the videos
+	WatchFilters int64 `json:"watch_filters"  example:"1"`  // watches the username was removed from allowed_creators of
+	AuditEntries int64 `json:"audit_entries"  example:"9"`  // earlier audit entries whose snapshots were redacted
+	AuditEntryID int64 `json:"audit_entry_id" example:"7"`
+}
+
+// DeletedVideos counts what a hard delete of videos removed; ExportFiles are the files of
+// the removed export jobs, to be deleted once the transaction commits
+type DeletedVideos struct {
+	Videos       int64
+	Stats        int64
+	Events       int64
+	AuditEntries int64 // audit entries of the videos whose snapshots were redacted
+	ExportFiles  []string
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TikTok usernames: letters, digits, underscores and dots
+var usernamePattern = regexp.MustCompile(`^[a-z0-9._]{2,24}$`)
+
+// REQUEST DTO
+// comes from a client
+type RegisterCreatorRequest struct {
+	Username  string     `json:"username"   example:"someuser"`
+	StartDate *time.Time `json:"start_date" example:"2025-11-24T00:00:00Z"`
+}
+
+// checks incoming data and normalizes the username ("@SomeUser" -> "someuser")
+func (r *RegisterCreatorRequest) Validate() error {
+	r.Username = NormalizeUsername(r.Username)
+
+	if !usernamePattern.MatchString(r.Username) {
+		return fmt.Errorf("invalid username %q", r.Username)
+	}
+	return nil
+}
+
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(username), "@"))
+}
+
+// RESPONSE DTO
+// give to the client
+type CreatorResponse struct {
+	CreatorID     int64   `json:"creator_id"     example:"1"`
+	Username      string  `json:"username"       example:"someuser"`
+	StartDate     string  `json:"start_date"     example:"2025-11-24T00:00:00Z"`
+	Status        string  `json:"status"         example:"active"`
+	LastSyncedAt  *string `json:"last_synced_at" example:"2025-11-24T01:30:00Z"`
+	VideoCount    int64   `json:"video_count"    example:"12"`
+	TotalViews    int64   `json:"total_views"    example:"150000"`
+	TotalEarnings float64 `json:"total_earnings" example:"15"`
+	Currency      string  `json:"currency"       example:"USD"`
+	CreatedAt     string  `json:"created_at"     example:"2025-11-24T01:30:00Z"`
+}
+
+// domain/db model
+type Creator struct {
+	ID             int64
+	WorkspaceID    int64
+	Username       string
+	TrackFrom      time.Time
+	TrackingStatus string
+	LastSyncedAt   *time.Time
+	LastError      *string
+	LastErrorAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// sums over all videos of a creator
+type CreatorAggregates struct {
+	VideoCount    int64
+	TotalViews    int64
+	TotalEarnings float64
+}
+
+// to create a creator recording
+type CreateCreatorInput struct {
+	Username  string
+	TrackFrom time.Time
+}
+
+// a post returned by the provider when listing a user's, hashtag's or sound's videos
+type CreatorPost struct {
+	TikTokID string
+	Username string
+	URL      string
+	PostedAt time.Time
+	Views    int64
+}
@@ -1,6 +1,9 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 // Sentinel errors
 var (
@@ -24,4 +27,24 @@ var (
 
 	// ErrConflict indicates a conflict with current state
 	ErrConflict = errors.New("conflict")
+
+	// ErrRateLimited indicates a call refused by a rate limit or a spending budget
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrUnavailable indicates a dependency is temporarily down
+	ErrUnavailable = errors.New("temporarily unavailable")
 )
+
+// RetryAfterError tells the caller how long to wait before trying again
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// aggregate types of domain events
+const (
+	AggregateVideo = "video"
+)
+
+// Event is a domain event recorded in the transaction of the state change.
+// ID is the idempotency key: recording the same ID twice is a no-op and
+// consumers use it to drop redeliveries.
+type Event struct {
+	ID            string
+	Type          string
+	AggregateType string
+	AggregateID   int64
+	Payload       []byte
+}
+
+// domain/db model of an outbox row waiting for the relay
+type OutboxEvent struct {
+	ID            int64
+	WorkspaceID   int64
+	EventID       string
+	EventType     string
+	AggregateType string
+	AggregateID   int64
+	Payload       []byte
+	Attempts      int
+	CreatedAt     time.Time
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// export job statuses
+const (
+	ExportPending = "pending"
+	ExportRunning = "running"
+	ExportDone    = "done"
+	ExportFailed  = "failed"
+	ExportExpired = "expired"
+)
+
+const ExportFormatParquet = "parquet"
+
+// REQUEST DTO
+// comes from a client
+type CreateExportRequest struct {
+	VideoID *int64 `json:"video_id,omitempty" example:"7"`          // all videos of the workspace when empty
+	From    int64  `json:"from"               example:"1732060800"` // unix seconds, inclusive
+	To      int64  `json:"to"                 example:"1732665600"` // unix seconds, exclusive
+}
+
+func (r *CreateExportRequest) Validate() error {
+	if r.From <= 0 || r.To <= 0 {
+		return fmt.Errorf("from and to must be unix timestamps")
+	}
+	if r.From >= r.To {
+		return fmt.Errorf("from must be before to")
+	}
+	if r.VideoID != nil && *r.VideoID <= 0 {
+		return fmt.Errorf("video_id must be positive")
+	}
+
+	return nil
+}
+
+// RESPONSE DTO
+// give to the client
+type ExportResponse struct {
+	ExportID    int64   `json:"export_id"              example:"3"`
+	Status      string  `json:"status"                 example:"done"`
+	Format      string  `json:"format"                 example:"parquet"`
+	VideoID     *int64  `json:"video_id,omitempty"     example:"7"`
+	From        string  `json:"from"                   example:"2025-11-20T00:00:00Z"`
+	To          string  `json:"to"                     example:"2025-11-27T00:00:00Z"`
+	Rows        int64   `json:"rows"                   example:"16800"`
+	SizeBytes   int64   `json:"size_bytes"             example:"540000"`
+	DownloadURL *string `json:"download_url,omitempty" example:"/exports/3/download?workspace=1&expires=1732700000&signature=..."`
+	ExpiresAt   *string `json:"expires_at,omitempty"   example:"2025-11-28T00:00:00Z"`
+	Error       *string `json:"error,omitempty"`
+	CreatedAt   string  `json:"created_at"             example:"2025-11-27T10:00:00Z"`
+}
+
+// send the video list
+type VideoListResponse struct {
+	Videos      []TrackVideoResponse `json:"videos"`
+	NextAfterID *int64               `json:"next_after_id,omitempty" example:"120"`
+}
+
+// domain/db model
+type ExportJob struct {
+	ID          int64
+	WorkspaceID int64
+	VideoID     *int64
+	From        time.Time
+	To          time.Time
+	Format      string
+	Status      string
+	FilePath    *string
+	Rows        int64
+	SizeBytes   int64
+	Error       *string
+	ExpiresAt   *time.Time
+	CreatedAt   time.Time
+	FinishedAt  *time.Time
+	Attempts    int // claims so far, the current one included
+}
+
+// to create an export job recording
+type CreateExportJobInput struct {
+	VideoID *int64
+	From    time.Time
+	To      time.Time
+	Format  string
+}
+
+// to filter the video list; Limit 0 means no limit
+type VideoListFilter struct {
+	Status  string
+	AfterID int64
+	Limit   int
+}
+
+// one exported snapshot
+type ExportStatRow struct {
+	VideoID    int64
+	TikTokID   string
+	CapturedAt time.Time
+	Views      int64
+	Earnings   float64
+}
@@ -40,9 +40,18 @@ type TrackVideoResponse struct {
 	Status          string  `json:"status"          example:"active"`
 }
 
+// RefreshVideoResponse is the video after an on-demand poll; result is updated or unchanged.
+// Shared is set when one provider call answered several concurrent refreshes of the video.
+type RefreshVideoResponse struct {
+	Video  TrackVideoResponse `json:"video"`
+	Result string             `json:"result" example:"updated"`
+	Shared bool               `json:"shared" example:"false"`
+}
+
 // domain/db model
 type Video struct {
 	ID              int64
+	WorkspaceID     int64
 	TikTokID        string
 	URL             string
 	CurrentViews    int64
@@ -53,13 +62,17 @@ type Video struct {
 	TrackingStatus string // "active", "stopped", "error"
 	LastError      *string
 	LastErrorAt    *time.Time
+
+	CreatorID *int64
+	PostedAt  *time.Time
 }
 
 // biuld history video
 type VideoStatPoint struct {
-	CapturedAt time.Time `json:"captured_at"`
-	Views      int64     `json:"views"`
-	Earnings   float64   `json:"earnings"`
+	CapturedAt  time.Time `json:"captured_at"`
+	Views       int64     `json:"views"`
+	Earnings    float64   `json:"earnings"`
+	Granularity string    `json:"granularity" example:"hour"` // hour, or day / week past the raw retention
 }
 
 // send history video
@@ -75,6 +88,8 @@ type CreateVideoInput struct {
 	CurrentViews    int64
 	CurrentEarnings float64
 	TrackingStatus  string
+	CreatorID       *int64
+	PostedAt        *time.Time
 }
 
 // internal input for stats journal
@@ -88,6 +103,35 @@ type UpdateVideoAggregatesInput struct {
 	Views    int64
 	Earnings float64
 }
+
+// VideoStatsWrite is one polled video in a bulk write of the updater: its new snapshot and aggregates
+type VideoStatsWrite struct {
+	WorkspaceID int64
+	VideoID     int64
+	Views       int64
+	Earnings    float64
+}
 type VideoStats struct {
 	Views int64
 }
+
+// one item of a batch provider call; Err is set when only this item failed, Unresolved when the batch
+// could not poll it and it has to be polled on its own
+type VideoStatsResult struct {
+	URL        string
+	Stats      *VideoStats
+	Err        error
+	Unresolved bool
+}
+
+// totals of the videos of a workspace
+type PortfolioSummary struct {
+	Videos        int64                `json:"videos"         example:"120"`
+	Active        int64                `json:"active"         example:"110"`
+	Stopped       int64                `json:"stopped"        example:"6"`
+	Errored       int64                `json:"errored"        example:"4"`
+	TotalViews    int64                `json:"total_views"    example:"9800000"`
+	TotalEarnings float64              `json:"total_earnings" example:"980"`
+	Currency      string               `json:"currency"       example:"USD"`
+	Top           []TrackVideoResponse `json:"top"`
+}
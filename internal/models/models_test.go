@@ -0,0 +1,168 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegisterCreatorRequest_Validate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "@SomeUser", want: "someuser"},
+		{in: " some.user_1 ", want: "some.user_1"},
+		{in: "", wantErr: true},
+		{in: "bad user", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		req := RegisterCreatorRequest{Username: tt.in}
+		err := req.Validate()
+
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Validate(%q): expected error", tt.in)
+			}
+			continue
+		}
+
+		if err != nil || req.Username != tt.want {
+			t.Errorf("Validate(%q) = %q, %v; want %q", tt.in, req.Username, err, tt.want)
+		}
+	}
+}
+
+func TestRegisterWatchRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       RegisterWatchRequest
+		wantValue string
+		wantErr   bool
+	}{
+		{name: "hashtag with #", req: RegisterWatchRequest{Kind: WatchKindHashtag, Value: "#BrandTag"}, wantValue: "brandtag"},
+		{name: "hashtag with space", req: RegisterWatchRequest{Kind: WatchKindHashtag, Value: "brand tag"}, wantErr: true},
+		{name: "sound id", req: RegisterWatchRequest{Kind: WatchKindSound, Value: " 7000000000000000001 "}, wantValue: "7000000000000000001"},
+		{name: "sound name", req: RegisterWatchRequest{Kind: WatchKindSound, Value: "some song"}, wantErr: true},
+		{name: "unknown kind", req: RegisterWatchRequest{Kind: "user", Value: "x"}, wantErr: true},
+		{name: "bad allowed creator", req: RegisterWatchRequest{Kind: WatchKindHashtag, Value: "x", AllowedCreators: []string{"a b"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+
+			if err != nil || tt.req.Value != tt.wantValue {
+				t.Fatalf("got %q, %v; want %q", tt.req.Value, err, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestWatch_Allows(t *testing.T) {
+	open := Watch{}
+	if !open.Allows("anyone") {
+		t.Fatalf("empty allow-list must allow everyone")
+	}
+
+	restricted := Watch{AllowedCreators: []string{"partner"}}
+	if !restricted.Allows("@Partner") {
+		t.Fatalf("expected allow-list match to be case-insensitive")
+	}
+	if restricted.Allows("someone") {
+		t.Fatalf("expected non-listed creator to be rejected")
+	}
+}
+
+func TestCreateWebhookRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        CreateWebhookRequest
+		wantEvents int
+		wantErr    bool
+	}{
+		{name: "valid", req: CreateWebhookRequest{URL: "https://example.com/hook", Events: []string{EventVideoTracked, EventVideoError}}, wantEvents: 2},
+		{name: "dedupes events", req: CreateWebhookRequest{URL: "http://example.com", Events: []string{EventVideoTracked, " video.tracked "}}, wantEvents: 1},
+		{name: "relative url", req: CreateWebhookRequest{URL: "/hook", Events: []string{EventVideoTracked}}, wantErr: true},
+		{name: "bad scheme", req: CreateWebhookRequest{URL: "ftp://example.com", Events: []string{EventVideoTracked}}, wantErr: true},
+		{name: "no events", req: CreateWebhookRequest{URL: "https://example.com"}, wantErr: true},
+		{name: "unknown event", req: CreateWebhookRequest{URL: "https://example.com", Events: []string{"video.deleted"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+
+			if err != nil || len(tt.req.Events) != tt.wantEvents {
+				t.Fatalf("got %v, %v; want %d events", tt.req.Events, err, tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestParseVideoRef(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    VideoRef
+		wantErr bool
+	}{
+		{in: "42", want: VideoRef{ID: 42}},
+		{in: "tt:7301234567890", want: VideoRef{TikTokID: "7301234567890"}},
+		// a bare TikTok id is an internal id, only the prefix selects the TikTok form
+		{in: "7301234567890", want: VideoRef{ID: 7301234567890}},
+		{in: "0", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "tt:", wantErr: true},
+		{in: "tt:abc", wantErr: true},
+		{in: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseVideoRef(tt.in)
+
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("ParseVideoRef(%q): expected ErrInvalidRequest, got %v", tt.in, err)
+			}
+			continue
+		}
+
+		if err != nil || got != tt.want {
+			t.Errorf("ParseVideoRef(%q) = %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+		if got.String() != tt.in {
+			t.Errorf("VideoRef.String() = %q, want %q", got.String(), tt.in)
+		}
+	}
+}
+
+func TestParseStatsEventID(t *testing.T) {
+	id := StatsEventID{ID: 1042, CapturedAt: time.Date(2025, 11, 20, 12, 0, 0, 123e6, time.UTC)}
+
+	got, err := ParseStatsEventID(id.String())
+	if err != nil || got.ID != id.ID || !got.CapturedAt.Equal(id.CapturedAt) {
+		t.Fatalf("ParseStatsEventID(%q) = %+v, %v; want %+v", id.String(), got, err, id)
+	}
+
+	for _, raw := range []string{"1042", "1042-", "-1763640000123", "0-1763640000123", "x-1", "1042-x"} {
+		if _, err := ParseStatsEventID(raw); !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("ParseStatsEventID(%q): expected ErrInvalidRequest, got %v", raw, err)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// StatsPartition is one monthly partition of video_stats holding captured_at in [From, To)
+type StatsPartition struct {
+	Name string
+	From time.Time
+	To   time.Time
+}
+
+// StatsPartitionName names the partition of the month holding t, as the database function does
+func StatsPartitionName(t time.Time) string {
+	return "video_stats_" + t.UTC().Format("2006_01")
+}
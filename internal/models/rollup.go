@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// granularities of history points: raw snapshots and the daily and weekly rollups
+const (
+	GranularityHour = "hour"
+	GranularityDay  = "day"
+	GranularityWeek = "week"
+)
+
+// RollupState is where the rollups and the retention of video_stats stand. Buckets before RolledUpTo
+// are complete; raw snapshots before RawFrom and daily rows before DailyFrom are gone (nil: none removed).
+type RollupState struct {
+	RolledUpTo time.Time
+	RawFrom    *time.Time
+	DailyFrom  *time.Time
+}
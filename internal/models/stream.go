@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatsChannel is the Postgres NOTIFY channel carrying VideoStatsEvent payloads
+const StatsChannel = "video_stats"
+
+// one appended video_stats row, sent to SSE clients; its SSE event id is EventID
+type VideoStatsEvent struct {
+	ID          int64     `json:"id"           example:"1042"`
+	VideoID     int64     `json:"video_id"     example:"7"`
+	WorkspaceID int64     `json:"workspace_id" example:"1"`
+	Views       int64     `json:"views"        example:"15000"`
+	Earnings    float64   `json:"earnings"     example:"1.5"`
+	CapturedAt  time.Time `json:"captured_at"  example:"2025-11-20T12:00:00Z"`
+}
+
+// EventID is the SSE event id of the snapshot
+func (e VideoStatsEvent) EventID() StatsEventID {
+	return StatsEventID{ID: e.ID, CapturedAt: e.CapturedAt}
+}
+
+// StatsEventID is the SSE event id of a snapshot, "<id>-<captured_at in unix ms>": the replay from
+// Last-Event-ID starts at its captured_at, so only the video_stats partitions from there on are read
+type StatsEventID struct {
+	ID         int64
+	CapturedAt time.Time
+}
+
+// ParseStatsEventID accepts the ids written by StatsEventID.String
+func ParseStatsEventID(raw string) (StatsEventID, error) {
+	rawID, rawAt, ok := strings.Cut(raw, "-")
+	if !ok {
+		return StatsEventID{}, fmt.Errorf("invalid event id %q: %w", raw, ErrInvalidRequest)
+	}
+
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil || id <= 0 {
+		return StatsEventID{}, fmt.Errorf("invalid event id %q: %w", raw, ErrInvalidRequest)
+	}
+
+	ms, err := strconv.ParseInt(rawAt, 10, 64)
+	if err != nil || ms < 0 {
+		return StatsEventID{}, fmt.Errorf("invalid event id %q: %w", raw, ErrInvalidRequest)
+	}
+
+	return StatsEventID{ID: id, CapturedAt: time.UnixMilli(ms).UTC()}, nil
+}
+
+func (id StatsEventID) String() string {
+	return strconv.FormatInt(id.ID, 10) + "-" + strconv.FormatInt(id.CapturedAt.UnixMilli(), 10)
+}
+
+// StatsStream is an open subscription: Replay holds the events missed since
+// Last-Event-ID, then Events delivers live ones until it is closed.
+// Events is closed when the consumer falls behind or the server shuts down;
+// clients reconnect with Last-Event-ID and resume from the replay.
+// Truncated is set when Replay was cut at the replay limit: the events after it
+// are lost and the client has to reload the history.
+type StatsStream struct {
+	Replay    []VideoStatsEvent
+	Truncated bool
+	Events    <-chan VideoStatsEvent
+	Close     func()
+}
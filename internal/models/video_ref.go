@@ -0,0 +1,55 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TikTokIDPrefix marks a TikTok id in a /api/v2 video path, e.g. /api/v2/videos/tt:7301234567890
+const TikTokIDPrefix = "tt:"
+
+// VideoRef identifies a video by its internal id or, with the tt: prefix, by its TikTok id
+type VideoRef struct {
+	ID       int64
+	TikTokID string
+}
+
+// ParseVideoRef accepts a positive internal id ("42") or a prefixed TikTok id ("tt:7301234567890")
+func ParseVideoRef(raw string) (VideoRef, error) {
+	if tikTokID, ok := strings.CutPrefix(raw, TikTokIDPrefix); ok {
+		if !isDigits(tikTokID) {
+			return VideoRef{}, fmt.Errorf("invalid tiktok id %q: %w", tikTokID, ErrInvalidRequest)
+		}
+		return VideoRef{TikTokID: tikTokID}, nil
+	}
+
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id <= 0 {
+		return VideoRef{}, fmt.Errorf("invalid video id %q, use a video_id or %s<tiktok_id>: %w", raw, TikTokIDPrefix, ErrInvalidRequest)
+	}
+
+	return VideoRef{ID: id}, nil
+}
+
+func (r VideoRef) String() string {
+	if r.TikTokID != "" {
+		return TikTokIDPrefix + r.TikTokID
+	}
+
+	return strconv.FormatInt(r.ID, 10)
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
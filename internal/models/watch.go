@@ -0,0 +1,117 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	WatchKindHashtag = "hashtag"
+	WatchKindSound   = "sound"
+)
+
+var (
+	hashtagPattern = regexp.MustCompile(`^[\p{L}\p{N}_]{1,100}$`)
+	musicIDPattern = regexp.MustCompile(`^\d{1,32}$`)
+)
+
+// REQUEST DTO
+// comes from a client
+type RegisterWatchRequest struct {
+	Kind            string     `json:"kind"             example:"hashtag" enums:"hashtag,sound"`
+	Value           string     `json:"value"            example:"brandtag"`
+	StartDate       *time.Time `json:"start_date"       example:"2025-11-24T00:00:00Z"`
+	AllowedCreators []string   `json:"allowed_creators" example:"someuser"`
+}
+
+// checks incoming data and normalizes the value ("#BrandTag" -> "brandtag")
+func (r *RegisterWatchRequest) Validate() error {
+	switch r.Kind {
+	case WatchKindHashtag:
+		r.Value = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(r.Value), "#"))
+		if !hashtagPattern.MatchString(r.Value) {
+			return fmt.Errorf("invalid hashtag %q", r.Value)
+		}
+	case WatchKindSound:
+		r.Value = strings.TrimSpace(r.Value)
+		if !musicIDPattern.MatchString(r.Value) {
+			return fmt.Errorf("invalid sound id %q, expected numeric music id", r.Value)
+		}
+	default:
+		return fmt.Errorf("kind must be %q or %q", WatchKindHashtag, WatchKindSound)
+	}
+
+	for i, username := range r.AllowedCreators {
+		r.AllowedCreators[i] = NormalizeUsername(username)
+		if !usernamePattern.MatchString(r.AllowedCreators[i]) {
+			return fmt.Errorf("invalid allowed creator %q", username)
+		}
+	}
+	return nil
+}
+
+// RESPONSE DTO
+// give to the client
+type WatchResponse struct {
+	WatchID         int64    `json:"watch_id"         example:"1"`
+	Kind            string   `json:"kind"             example:"hashtag"`
+	Value           string   `json:"value"            example:"brandtag"`
+	StartDate       string   `json:"start_date"       example:"2025-11-24T00:00:00Z"`
+	AllowedCreators []string `json:"allowed_creators" example:"someuser"`
+	Status          string   `json:"status"           example:"active"`
+	LastSyncedAt    *string  `json:"last_synced_at"   example:"2025-11-24T01:30:00Z"`
+	VideoCount      int64    `json:"video_count"      example:"12"`
+	TotalViews      int64    `json:"total_views"      example:"150000"`
+	TotalEarnings   float64  `json:"total_earnings"   example:"15"`
+	Currency        string   `json:"currency"         example:"USD"`
+	CreatedAt       string   `json:"created_at"       example:"2025-11-24T01:30:00Z"`
+}
+
+// domain/db model
+type Watch struct {
+	ID              int64
+	WorkspaceID     int64
+	Kind            string
+	Value           string
+	TrackFrom       time.Time
+	AllowedCreators []string
+	TrackingStatus  string
+	LastSyncedAt    *time.Time
+	LastError       *string
+	LastErrorAt     *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// Allows reports whether a post by username passes the creator allow-list
+func (w Watch) Allows(username string) bool {
+	if len(w.AllowedCreators) == 0 {
+		return true
+	}
+
+	username = NormalizeUsername(username)
+	for _, allowed := range w.AllowedCreators {
+		if allowed == username {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sums over all videos matched by a watch
+type WatchAggregates struct {
+	VideoCount    int64
+	TotalViews    int64
+	TotalEarnings float64
+}
+
+// to create a watch recording
+type CreateWatchInput struct {
+	Kind            string
+	Value           string
+	TrackFrom       time.Time
+	AllowedCreators []string
+}
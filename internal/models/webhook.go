@@ -0,0 +1,152 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// video lifecycle events, published through the outbox and delivered to webhook subscriptions
+const (
+	EventVideoTracked      = "video.tracked"
+	EventVideoMilestone    = "video.milestone_reached"
+	EventVideoError        = "video.error"
+	EventVideoStopped      = "video.stopped"
+	EventEarningsThreshold = "earnings.threshold"
+)
+
+var knownEvents = map[string]bool{
+	EventVideoTracked:      true,
+	EventVideoMilestone:    true,
+	EventVideoError:        true,
+	EventVideoStopped:      true,
+	EventEarningsThreshold: true,
+}
+
+// webhook delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// REQUEST DTO
+// comes from a client
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"    example:"https://example.com/hooks/tiktok"`
+	Events []string `json:"events" example:"video.tracked,video.milestone_reached"`
+}
+
+// checks incoming data and drops duplicate events
+func (r *CreateWebhookRequest) Validate() error {
+	u, err := url.Parse(strings.TrimSpace(r.URL))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http(s) url")
+	}
+	r.URL = u.String()
+
+	if len(r.Events) == 0 {
+		return fmt.Errorf("at least one event must be provided")
+	}
+
+	seen := make(map[string]bool, len(r.Events))
+	events := r.Events[:0]
+	for _, event := range r.Events {
+		event = strings.TrimSpace(event)
+		if !knownEvents[event] {
+			return fmt.Errorf("unknown event %q", event)
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	r.Events = events
+
+	return nil
+}
+
+// RESPONSE DTO
+// give to the client
+type WebhookResponse struct {
+	WebhookID int64    `json:"webhook_id" example:"1"`
+	URL       string   `json:"url"        example:"https://example.com/hooks/tiktok"`
+	Events    []string `json:"events"     example:"video.tracked"`
+	CreatedAt string   `json:"created_at" example:"2025-11-24T01:30:00Z"`
+	// returned only on creation, used to verify the X-Webhook-Signature header
+	Secret string `json:"secret,omitempty" example:"whsec_1a2b3c..."`
+}
+
+// RESPONSE DTO
+// give to the client
+type WebhookDeliveryResponse struct {
+	DeliveryID     int64   `json:"delivery_id"      example:"1"`
+	EventID        string  `json:"event_id"         example:"evt_1a2b3c4d5e6f7a8b"`
+	EventType      string  `json:"event_type"       example:"video.tracked"`
+	Status         string  `json:"status"           example:"delivered" enums:"pending,delivered,failed"`
+	Attempts       int     `json:"attempts"         example:"1"`
+	LastStatusCode *int    `json:"last_status_code" example:"200"`
+	LastError      *string `json:"last_error"       example:"receiver returned 500"`
+	NextAttemptAt  *string `json:"next_attempt_at"  example:"2025-11-24T01:30:00Z"`
+	DeliveredAt    *string `json:"delivered_at"     example:"2025-11-24T01:30:00Z"`
+	CreatedAt      string  `json:"created_at"       example:"2025-11-24T01:30:00Z"`
+}
+
+// body POSTed to subscribers
+type VideoEventPayload struct {
+	ID          string         `json:"id"`
+	Type        string         `json:"type"`
+	WorkspaceID int64          `json:"workspace_id"`
+	CreatedAt   string         `json:"created_at"`
+	Data        VideoEventData `json:"data"`
+}
+
+type VideoEventData struct {
+	VideoID   int64    `json:"video_id"`
+	TikTokID  string   `json:"tiktok_id,omitempty"`
+	URL       string   `json:"url,omitempty"`
+	Views     int64    `json:"views,omitempty"`
+	Earnings  float64  `json:"earnings,omitempty"`
+	Currency  string   `json:"currency,omitempty"`
+	Milestone *int64   `json:"milestone,omitempty"`
+	Threshold *float64 `json:"threshold,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// domain/db model
+type Webhook struct {
+	ID          int64
+	WorkspaceID int64
+	URL         string
+	Secret      string
+	Events      []string
+	CreatedAt   time.Time
+}
+
+// domain/db model; URL and Secret come from the subscription when claimed for dispatch
+type WebhookDelivery struct {
+	ID             int64
+	WorkspaceID    int64
+	WebhookID      int64
+	EventID        string
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int
+	NextAttemptAt  *time.Time
+	LastStatusCode *int
+	LastError      *string
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+
+	URL    string
+	Secret string
+}
+
+// to create a webhook subscription recording
+type CreateWebhookInput struct {
+	URL    string
+	Secret string
+	Events []string
+}
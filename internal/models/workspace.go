@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// existing single-tenant data is migrated into this workspace, also used when auth is disabled
+const DefaultWorkspaceID int64 = 1
+
+// domain/db model
+type Workspace struct {
+	ID        int64
+	Name      string
+	CreatedAt time.Time
+}
@@ -0,0 +1,183 @@
+package repo
+
+import (
+	"context"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+)
+
+// SetVideoActiveStatus puts a stopped or errored video back in the updater queue
+func (r *Repository) SetVideoActiveStatus(ctx context.Context, videoID int64) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	// updated_at is left alone so the video is polled on the next tick
+	query := `
+        UPDATE videos
+        SET
+            tracking_status = $1,
+            last_error      = NULL,
+            last_error_at   = NULL
+        WHERE id = $2
+            AND workspace_id = $3
+            AND deleted_at IS NULL
+    `
+
+	tag, err := r.getDB(ctx).Exec(ctx, query, models.VideoStatusActive, videoID, workspaceID)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: SetVideoActiveStatus video_id=%d error: %v", videoID, err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+// RecomputeEarnings rewrites the earnings of every video of the workspace from its views,
+// and of every snapshot too when history is set; it returns the number of videos updated
+func (r *Repository) RecomputeEarnings(ctx context.Context, rate float64, per int64, history bool) (int64, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// a full rewrite of the workspace may take longer than one query timeout
+	db := r.getDB(ctx)
+
+	tag, err := db.Exec(ctx, `
+        UPDATE videos
+        SET current_earnings = current_views::double precision / $1 * $2
+        WHERE workspace_id = $3
+    `, per, rate, workspaceID)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: RecomputeEarnings videos error: %v", err)
+		return 0, err
+	}
+
+	if history {
+		// rollups hold snapshots too and are rewritten with the raw rows
+		for _, table := range []string{"video_stats", "video_stats_daily", "video_stats_weekly"} {
+			_, err = db.Exec(ctx, `
+                UPDATE `+table+` s
+                SET earnings = s.views::double precision / $1 * $2
+                FROM videos v
+                WHERE v.id = s.video_id
+                    AND v.workspace_id = $3
+            `, per, rate, workspaceID)
+			if err != nil {
+				logging.From(ctx, r.logger).Errorf("Repository: RecomputeEarnings %s error: %v", table, err)
+				return 0, err
+			}
+		}
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// PortfolioSummary totals the videos of the workspace
+func (r *Repository) PortfolioSummary(ctx context.Context) (*models.PortfolioSummary, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT
+            count(*),
+            count(*) FILTER (WHERE tracking_status = 'active'),
+            count(*) FILTER (WHERE tracking_status = 'stopped'),
+            count(*) FILTER (WHERE tracking_status = 'error'),
+            COALESCE(sum(current_views), 0),
+            COALESCE(sum(current_earnings), 0)
+        FROM videos
+        WHERE workspace_id = $1
+            AND deleted_at IS NULL
+    `
+
+	var s models.PortfolioSummary
+	if err := r.readDB(ctx).QueryRow(ctx, query, workspaceID).Scan(
+		&s.Videos,
+		&s.Active,
+		&s.Stopped,
+		&s.Errored,
+		&s.TotalViews,
+		&s.TotalEarnings,
+	); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: PortfolioSummary error: %v", err)
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// TopVideos returns the videos of the workspace with the highest earnings
+func (r *Repository) TopVideos(ctx context.Context, limit int) ([]models.Video, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT
+            id,
+            workspace_id,
+            tiktok_id,
+            url,
+            current_views,
+            current_earnings,
+            created_at,
+            updated_at,
+            tracking_status,
+            last_error,
+            last_error_at
+        FROM videos
+        WHERE workspace_id = $1
+            AND deleted_at IS NULL
+        ORDER BY current_earnings DESC, id ASC
+        LIMIT $2
+    `
+
+	rows, err := r.readDB(ctx).Query(ctx, query, workspaceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.Video, 0, limit)
+	for rows.Next() {
+		var v models.Video
+		if err := rows.Scan(
+			&v.ID,
+			&v.WorkspaceID,
+			&v.TikTokID,
+			&v.URL,
+			&v.CurrentViews,
+			&v.CurrentEarnings,
+			&v.CreatedAt,
+			&v.UpdatedAt,
+			&v.TrackingStatus,
+			&v.LastError,
+			&v.LastErrorAt,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+
+	return result, rows.Err()
+}
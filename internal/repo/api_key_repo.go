@@ -0,0 +1,192 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const apiKeyColumns = `
+        id,
+        workspace_id,
+        name,
+        key_prefix,
+        scopes,
+        created_at,
+        last_used_at,
+        revoked_at
+`
+
+// CreateAPIKey stores the hash of a newly generated key in the workspace of ctx
+func (r *Repository) CreateAPIKey(ctx context.Context, input models.CreateAPIKeyInput) (*models.APIKey, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+    INSERT INTO api_keys (workspace_id, name, key_prefix, key_hash, scopes)
+    VALUES ($1, $2, $3, $4, $5)
+    RETURNING` + apiKeyColumns
+
+	k, err := scanAPIKey(r.getDB(ctx).QueryRow(ctx, query, workspaceID, input.Name, input.Prefix, input.Hash, input.Scopes))
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: CreateAPIKey query error: %v", err)
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// FindAPIKeyByHash returns the key including revoked ones; callers check RevokedAt.
+// It is not workspace scoped: the key is what resolves the workspace.
+func (r *Repository) FindAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `SELECT` + apiKeyColumns + `FROM api_keys WHERE key_hash = $1`
+
+	k, err := scanAPIKey(r.getDB(ctx).QueryRow(ctx, query, hash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// GetAPIKey returns a key of the workspace by id, revoked ones included
+func (r *Repository) GetAPIKey(ctx context.Context, keyID int64) (*models.APIKey, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `SELECT` + apiKeyColumns + `FROM api_keys WHERE id = $1 AND workspace_id = $2`
+
+	k, err := scanAPIKey(r.getDB(ctx).QueryRow(ctx, query, keyID, workspaceID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// ListAPIKeys returns all keys of the workspace, newest first
+func (r *Repository) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `SELECT` + apiKeyColumns + `FROM api_keys WHERE workspace_id = $1 ORDER BY id DESC`
+
+	rows, err := r.getDB(ctx).Query(ctx, query, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.APIKey
+
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *k)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RevokeAPIKey marks the key revoked, ErrNotFound if it does not exist or is already revoked
+func (r *Repository) RevokeAPIKey(ctx context.Context, keyID int64) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE api_keys
+        SET revoked_at = NOW()
+        WHERE id = $1 AND workspace_id = $2 AND revoked_at IS NULL
+    `
+
+	tag, err := r.getDB(ctx).Exec(ctx, query, keyID, workspaceID)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: RevokeAPIKey key_id=%d error: %v", keyID, err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+// TouchAPIKey records usage at most once a minute to avoid a write per request.
+// Called during authentication, before the workspace is known.
+func (r *Repository) TouchAPIKey(ctx context.Context, keyID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE api_keys
+        SET last_used_at = NOW()
+        WHERE id = $1
+            AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+    `
+
+	if _, err := r.getDB(ctx).Exec(ctx, query, keyID); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: TouchAPIKey key_id=%d error: %v", keyID, err)
+		return err
+	}
+
+	return nil
+}
+
+func scanAPIKey(row pgx.Row) (*models.APIKey, error) {
+	var k models.APIKey
+	if err := row.Scan(
+		&k.ID,
+		&k.WorkspaceID,
+		&k.Name,
+		&k.Prefix,
+		&k.Scopes,
+		&k.CreatedAt,
+		&k.LastUsedAt,
+		&k.RevokedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &k, nil
+}
@@ -0,0 +1,181 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+)
+
+const auditColumns = `
+        id,
+        workspace_id,
+        actor,
+        action,
+        target_type,
+        target_id,
+        request_id,
+        before,
+        after,
+        details,
+        created_at
+`
+
+// AppendAuditEntry records an entry for the workspace in ctx and returns its id.
+// Pass the transaction ctx of the change so the entry is committed or rolled back with it.
+func (r *Repository) AppendAuditEntry(ctx context.Context, input models.CreateAuditEntryInput) (int64, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	before, err := marshalAuditValue(input.Before)
+	if err != nil {
+		return 0, fmt.Errorf("encode audit before: %w", err)
+	}
+
+	after, err := marshalAuditValue(input.After)
+	if err != nil {
+		return 0, fmt.Errorf("encode audit after: %w", err)
+	}
+
+	details := []byte("{}")
+	if input.Details != nil {
+		if details, err = json.Marshal(input.Details); err != nil {
+			return 0, fmt.Errorf("encode audit details: %w", err)
+		}
+	}
+
+	var requestID *string
+	if input.RequestID != "" {
+		requestID = &input.RequestID
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO audit_log (workspace_id, actor, action, target_type, target_id, request_id, before, after, details)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING id
+    `
+
+	var id int64
+	if err := r.getDB(ctx).QueryRow(ctx, query,
+		workspaceID,
+		input.Actor,
+		input.Action,
+		input.TargetType,
+		input.TargetID,
+		requestID,
+		before,
+		after,
+		details,
+	).Scan(&id); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: AppendAuditEntry %s error: %v", input.Action, err)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ListAuditEntries returns entries of the workspace matching the filter, newest first
+func (r *Repository) ListAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	where := []string{"workspace_id = $1"}
+	args := []any{workspaceID}
+
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.Actor != "" {
+		add("actor = $%d", filter.Actor)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.TargetType != "" {
+		add("target_type = $%d", filter.TargetType)
+	}
+	if filter.TargetID != nil {
+		add("target_id = $%d", *filter.TargetID)
+	}
+	if filter.From != nil {
+		add("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("created_at < $%d", *filter.To)
+	}
+	if filter.BeforeID > 0 {
+		add("id < $%d", filter.BeforeID)
+	}
+
+	args = append(args, filter.Limit)
+
+	query := `SELECT` + auditColumns + `FROM audit_log
+        WHERE ` + strings.Join(where, " AND ") + fmt.Sprintf(`
+        ORDER BY id DESC
+        LIMIT $%d`, len(args))
+
+	rows, err := r.readDB(ctx).Query(ctx, query, args...)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: ListAuditEntries query error: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.AuditEntry, 0, filter.Limit)
+
+	for rows.Next() {
+		var (
+			e         models.AuditEntry
+			requestID *string
+		)
+		if err := rows.Scan(
+			&e.ID,
+			&e.WorkspaceID,
+			&e.Actor,
+			&e.Action,
+			&e.TargetType,
+			&e.TargetID,
+			&requestID,
+			&e.Before,
+			&e.After,
+			&e.Details,
+			&e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if requestID != nil {
+			e.RequestID = *requestID
+		}
+		result = append(result, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// marshalAuditValue keeps a missing before/after NULL instead of the JSON null literal
+func marshalAuditValue(v any) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
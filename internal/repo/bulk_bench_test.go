@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"ttanalytic/internal/infrastructure/dbtx"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+)
+
+// BENCH_DATABASE_URL points at a migrated database the benchmarks may write to, they are skipped without it:
+//
+//	BENCH_DATABASE_URL=postgres://... go test ./internal/repo -run '^$' -bench UpdaterWrites
+const benchVideos = 500
+
+// BenchmarkUpdaterWrites writes the stats of one flush of benchVideos videos per iteration:
+// per_video as the updater does with flush_size 0, bulk as it does with flush_size >= benchVideos.
+func BenchmarkUpdaterWrites(b *testing.B) {
+	r, pool := openTestDB(b, "BENCH_DATABASE_URL")
+	ctx := tenant.NewContext(context.Background(), 1)
+	transactor := dbtx.NewTransactor(pool)
+	videos := seedVideos(b, r, pool, benchVideos)
+
+	writes := func(n int) []models.VideoStatsWrite {
+		out := make([]models.VideoStatsWrite, 0, len(videos))
+		for _, v := range videos {
+			views := int64(n+1) * 1000
+			out = append(out, models.VideoStatsWrite{WorkspaceID: 1, VideoID: v.ID, Views: views, Earnings: float64(views) / 10000})
+		}
+		return out
+	}
+
+	b.Run("per_video", func(b *testing.B) {
+		for n := range b.N {
+			for _, w := range writes(n) {
+				err := transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+					if err := r.AppendVideoStats(txCtx, models.CreateVideoStatsInput{VideoID: w.VideoID, Views: w.Views, Earnings: w.Earnings}); err != nil {
+						return err
+					}
+					return r.UpdateVideoAggregates(txCtx, models.UpdateVideoAggregatesInput{VideoID: w.VideoID, Views: w.Views, Earnings: w.Earnings})
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("bulk", func(b *testing.B) {
+		for n := range b.N {
+			batch := writes(n)
+			err := transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+				if _, err := r.UpdateVideoAggregatesBulk(txCtx, batch); err != nil {
+					return err
+				}
+				_, err := r.CopyVideoStats(txCtx, batch)
+				return err
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UpdateVideoAggregatesBulk sets the aggregates of many videos in one statement and returns the ids it updated,
+// locking their rows until the end of the transaction in ctx. Writes name their workspace: like
+// ListVideosForUpdate this serves the updater across workspaces. Deleted videos and videos outside the
+// workspace of their write are skipped.
+func (r *Repository) UpdateVideoAggregatesBulk(ctx context.Context, writes []models.VideoStatsWrite) ([]int64, error) {
+	if len(writes) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	values := make([]string, 0, len(writes))
+	args := make([]any, 0, 4*len(writes))
+
+	for _, w := range writes {
+		n := len(args)
+		values = append(values, fmt.Sprintf("($%d::bigint, $%d::bigint, $%d::bigint, $%d::double precision)", n+1, n+2, n+3, n+4))
+		args = append(args, w.VideoID, w.WorkspaceID, w.Views, w.Earnings)
+	}
+
+	query := `
+        UPDATE videos v
+        SET
+            current_views    = u.views,
+            current_earnings = u.earnings,
+            updated_at       = NOW()
+        FROM (VALUES ` + strings.Join(values, ", ") + `) AS u(id, workspace_id, views, earnings)
+        WHERE v.id = u.id
+            AND v.workspace_id = u.workspace_id
+            AND v.deleted_at IS NULL
+        RETURNING v.id
+    `
+
+	rows, err := r.getDB(ctx).Query(ctx, query, args...)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: UpdateVideoAggregatesBulk query error: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0, len(writes))
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: UpdateVideoAggregatesBulk query error: %v", err)
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// CopyVideoStats appends one snapshot per write with COPY, captured now. It does not check the videos:
+// call it in the transaction of UpdateVideoAggregatesBulk with the writes of the ids that returned.
+func (r *Repository) CopyVideoStats(ctx context.Context, writes []models.VideoStatsWrite) (int64, error) {
+	if len(writes) == 0 {
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	rows := make([][]any, 0, len(writes))
+	for _, w := range writes {
+		rows = append(rows, []any{w.VideoID, w.Views, w.Earnings})
+	}
+
+	n, err := r.getDB(ctx).CopyFrom(ctx,
+		pgx.Identifier{"video_stats"},
+		[]string{"video_id", "views", "earnings"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: CopyVideoStats copy error: %v", err)
+		return 0, err
+	}
+
+	return n, nil
+}
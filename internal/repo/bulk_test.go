@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"ttanalytic/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+func TestRepository_UpdateVideoAggregatesBulk_OneStatement(t *testing.T) {
+	r, mock := newMockRepository(t)
+
+	writes := []models.VideoStatsWrite{
+		{WorkspaceID: 1, VideoID: 10, Views: 1000, Earnings: 0.1},
+		{WorkspaceID: otherWorkspace, VideoID: 20, Views: 2000, Earnings: 0.2},
+	}
+
+	// the workspace of every write is matched, deleted videos drop out of RETURNING
+	mock.ExpectQuery(`FROM \(VALUES \(\$1::bigint, \$2::bigint, \$3::bigint, \$4::double precision\), `+
+		`\(\$5::bigint, \$6::bigint, \$7::bigint, \$8::double precision\)\) AS u\(id, workspace_id, views, earnings\)\s+`+
+		`WHERE v.id = u.id\s+AND v.workspace_id = u.workspace_id\s+AND v.deleted_at IS NULL`).
+		WithArgs(int64(10), int64(1), int64(1000), 0.1, int64(20), otherWorkspace, int64(2000), 0.2).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(10)))
+
+	ids, err := r.UpdateVideoAggregatesBulk(context.Background(), writes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != 10 {
+		t.Fatalf("expected video 10 only, got %v", ids)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRepository_CopyVideoStats(t *testing.T) {
+	r, mock := newMockRepository(t)
+
+	writes := []models.VideoStatsWrite{
+		{WorkspaceID: 1, VideoID: 10, Views: 1000, Earnings: 0.1},
+		{WorkspaceID: 1, VideoID: 11, Views: 3000, Earnings: 0.3},
+	}
+
+	mock.ExpectCopyFrom(pgx.Identifier{"video_stats"}, []string{"video_id", "views", "earnings"}).
+		WillReturnResult(2)
+
+	n, err := r.CopyVideoStats(context.Background(), writes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 rows copied, got %d", n)
+	}
+
+	// nothing to write, no round trip
+	if _, err := r.CopyVideoStats(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,262 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const uniqueViolation = "23505"
+
+const creatorColumns = `
+        id,
+        workspace_id,
+        username,
+        track_from,
+        tracking_status,
+        last_synced_at,
+        last_error,
+        last_error_at,
+        created_at,
+        updated_at
+`
+
+// CreateCreator registers a creator account, ErrAlreadyExists if the username is taken in the workspace
+func (r *Repository) CreateCreator(ctx context.Context, input models.CreateCreatorInput) (*models.Creator, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	db := r.getDB(ctx)
+
+	query := `
+    INSERT INTO creators (workspace_id, username, track_from)
+    VALUES ($1, $2, $3)
+    RETURNING` + creatorColumns
+
+	c, err := scanCreator(db.QueryRow(ctx, query, workspaceID, input.Username, input.TrackFrom))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return nil, models.ErrAlreadyExists
+		}
+		logging.From(ctx, r.logger).Errorf("Repository: CreateCreator query error: %v", err)
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// GetCreator returns a creator by internal id
+func (r *Repository) GetCreator(ctx context.Context, creatorID int64) (*models.Creator, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `SELECT` + creatorColumns + `FROM creators WHERE id = $1 AND workspace_id = $2`
+
+	c, err := scanCreator(r.getDB(ctx).QueryRow(ctx, query, creatorID, workspaceID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// GetCreatorAggregates sums views and earnings over all videos of the creator
+func (r *Repository) GetCreatorAggregates(ctx context.Context, creatorID int64) (models.CreatorAggregates, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return models.CreatorAggregates{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT
+            COUNT(*),
+            COALESCE(SUM(current_views), 0),
+            COALESCE(SUM(current_earnings), 0)
+        FROM videos
+        WHERE creator_id = $1
+            AND workspace_id = $2
+            AND deleted_at IS NULL
+    `
+
+	var agg models.CreatorAggregates
+	if err := r.readDB(ctx).QueryRow(ctx, query, creatorID, workspaceID).Scan(
+		&agg.VideoCount,
+		&agg.TotalViews,
+		&agg.TotalEarnings,
+	); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: GetCreatorAggregates creator_id=%d error: %v", creatorID, err)
+		return models.CreatorAggregates{}, err
+	}
+
+	return agg, nil
+}
+
+// ListCreatorsForSync returns active creators of all workspaces not synced for at least minSyncAge,
+// never-synced first
+func (r *Repository) ListCreatorsForSync(ctx context.Context, minSyncAge time.Duration, limit int) ([]models.Creator, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	cutoff := time.Now().Add(-minSyncAge)
+
+	query := `SELECT` + creatorColumns + `
+        FROM creators
+        WHERE tracking_status = 'active'
+            AND (last_synced_at IS NULL OR last_synced_at <= $1)
+        ORDER BY last_synced_at ASC NULLS FIRST
+        LIMIT $2
+    `
+
+	rows, err := r.db.Query(ctx, query, cutoff, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.Creator, 0, limit)
+
+	for rows.Next() {
+		c, err := scanCreator(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// MarkCreatorSynced records a successful discovery pass and clears the last error
+func (r *Repository) MarkCreatorSynced(ctx context.Context, creatorID int64) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE creators
+        SET
+            last_synced_at = NOW(),
+            last_error     = NULL,
+            last_error_at  = NULL,
+            updated_at     = NOW()
+        WHERE id = $1
+            AND workspace_id = $2
+    `
+
+	if _, err := r.getDB(ctx).Exec(ctx, query, creatorID, workspaceID); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: MarkCreatorSynced creator_id=%d error: %v", creatorID, err)
+		return err
+	}
+
+	return nil
+}
+
+// AttachVideoToCreator sets the creator of a video tracked before its creator was, e.g. through POST /api/videos.
+// A video that already has a creator keeps it.
+func (r *Repository) AttachVideoToCreator(ctx context.Context, videoID, creatorID int64, postedAt time.Time) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE videos
+        SET
+            creator_id = $1,
+            posted_at  = COALESCE(posted_at, $2),
+            updated_at = NOW()
+        WHERE id = $3
+            AND workspace_id = $4
+            AND creator_id IS NULL
+    `
+
+	if _, err := r.getDB(ctx).Exec(ctx, query, creatorID, postedAt, videoID, workspaceID); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: AttachVideoToCreator video_id=%d error: %v", videoID, err)
+		return err
+	}
+
+	return nil
+}
+
+// SetCreatorSyncError keeps the creator active but records why the last pass failed
+func (r *Repository) SetCreatorSyncError(ctx context.Context, creatorID int64, errText string) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE creators
+        SET
+            last_synced_at = NOW(),
+            last_error     = $1,
+            last_error_at  = NOW(),
+            updated_at     = NOW()
+        WHERE id = $2
+            AND workspace_id = $3
+    `
+
+	if _, err := r.getDB(ctx).Exec(ctx, query, errText, creatorID, workspaceID); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: SetCreatorSyncError creator_id=%d error: %v", creatorID, err)
+		return err
+	}
+
+	return nil
+}
+
+func scanCreator(row pgx.Row) (*models.Creator, error) {
+	var c models.Creator
+	if err := row.Scan(
+		&c.ID,
+		&c.WorkspaceID,
+		&c.Username,
+		&c.TrackFrom,
+		&c.TrackingStatus,
+		&c.LastSyncedAt,
+		&c.LastError,
+		&c.LastErrorAt,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
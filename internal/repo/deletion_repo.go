@@ -0,0 +1,339 @@
+package repo
+
+import (
+	"context"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+)
+
+// SoftDeleteVideo hides a video of the workspace from every query until the purge job removes it
+func (r *Repository) SoftDeleteVideo(ctx context.Context, videoID int64) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE videos
+        SET
+            deleted_at = NOW(),
+            updated_at = NOW()
+        WHERE id = $1
+            AND workspace_id = $2
+            AND deleted_at IS NULL
+    `
+
+	tag, err := r.getDB(ctx).Exec(ctx, query, videoID, workspaceID)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: SoftDeleteVideo video_id=%d error: %v", videoID, err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListVideosForPurge locks videos of all workspaces soft deleted before the cutoff, oldest first.
+// Only ID and WorkspaceID are set. Call it in a transaction, other replicas skip the locked rows.
+func (r *Repository) ListVideosForPurge(ctx context.Context, deletedBefore time.Time, limit int) ([]models.Video, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT id, workspace_id
+        FROM videos
+        WHERE deleted_at IS NOT NULL
+            AND deleted_at <= $1
+        ORDER BY deleted_at
+        LIMIT $2
+        FOR UPDATE SKIP LOCKED
+    `
+
+	rows, err := r.getDB(ctx).Query(ctx, query, deletedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.Video, 0, limit)
+
+	for rows.Next() {
+		var v models.Video
+		if err := rows.Scan(&v.ID, &v.WorkspaceID); err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ListVideoIDsByUsername returns the videos of the workspace posted by the TikTok author,
+// soft deleted ones included: linked to the creator account or with /@username/ in the url.
+// username must be normalized (see models.NormalizeUsername).
+func (r *Repository) ListVideoIDsByUsername(ctx context.Context, username string) ([]int64, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	// position instead of LIKE: usernames may contain _, a LIKE wildcard
+	query := `
+        SELECT v.id
+        FROM videos v
+        LEFT JOIN creators c ON c.id = v.creator_id
+        WHERE v.workspace_id = $1
+            AND (c.username = $2 OR position('/@' || $2 || '/' IN lower(v.url)) > 0)
+        ORDER BY v.id
+    `
+
+	rows, err := r.getDB(ctx).Query(ctx, query, workspaceID, username)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: ListVideoIDsByUsername query error: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// DeleteVideos removes videos of the workspace for good together with their stats, export jobs,
+// outbox events and webhook deliveries. Run it in a transaction; remove the returned export files
+// only after the commit.
+func (r *Repository) DeleteVideos(ctx context.Context, videoIDs []int64) (models.DeletedVideos, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return models.DeletedVideos{}, err
+	}
+
+	var result models.DeletedVideos
+	if len(videoIDs) == 0 {
+		return result, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	db := r.getDB(ctx)
+
+	// deliveries hold a copy of the event payload, not a reference to the outbox row
+	tag, err := db.Exec(ctx, `
+        DELETE FROM webhook_deliveries
+        WHERE workspace_id = $1
+            AND (payload->'data'->>'video_id')::bigint = ANY($2)
+    `, workspaceID, videoIDs)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: DeleteVideos webhook deliveries error: %v", err)
+		return models.DeletedVideos{}, err
+	}
+	result.Events += tag.RowsAffected()
+
+	tag, err = db.Exec(ctx, `
+        DELETE FROM outbox_events
+        WHERE workspace_id = $1
+            AND aggregate_type = $2
+            AND aggregate_id = ANY($3)
+    `, workspaceID, models.AggregateVideo, videoIDs)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: DeleteVideos outbox events error: %v", err)
+		return models.DeletedVideos{}, err
+	}
+	result.Events += tag.RowsAffected()
+
+	// the rows would cascade with the videos, the files would not
+	rows, err := db.Query(ctx, `
+        DELETE FROM export_jobs
+        WHERE workspace_id = $1
+            AND video_id = ANY($2)
+        RETURNING file_path
+    `, workspaceID, videoIDs)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: DeleteVideos export jobs error: %v", err)
+		return models.DeletedVideos{}, err
+	}
+	for rows.Next() {
+		var path *string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return models.DeletedVideos{}, err
+		}
+		if path != nil {
+			result.ExportFiles = append(result.ExportFiles, *path)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return models.DeletedVideos{}, err
+	}
+
+	// the snapshots of the videos hold their URL, so the username of the author
+	tag, err = db.Exec(ctx, `
+        UPDATE audit_log
+        SET
+            before = CASE WHEN before IS NULL THEN NULL ELSE `+redactedSnapshot+` END,
+            after  = CASE WHEN after IS NULL THEN NULL ELSE `+redactedSnapshot+` END
+        WHERE workspace_id = $1
+            AND target_type = $2
+            AND target_id = ANY($3)
+            AND (before IS NOT NULL OR after IS NOT NULL)
+    `, workspaceID, models.AuditTargetVideo, videoIDs)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: DeleteVideos audit entries error: %v", err)
+		return models.DeletedVideos{}, err
+	}
+	result.AuditEntries = tag.RowsAffected()
+
+	tag, err = db.Exec(ctx, `
+        DELETE FROM video_stats s
+        USING videos v
+        WHERE v.id = s.video_id
+            AND v.workspace_id = $1
+            AND v.id = ANY($2)
+    `, workspaceID, videoIDs)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: DeleteVideos stats error: %v", err)
+		return models.DeletedVideos{}, err
+	}
+	result.Stats = tag.RowsAffected()
+
+	tag, err = db.Exec(ctx, `
+        DELETE FROM videos
+        WHERE workspace_id = $1
+            AND id = ANY($2)
+    `, workspaceID, videoIDs)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: DeleteVideos videos error: %v", err)
+		return models.DeletedVideos{}, err
+	}
+	result.Videos = tag.RowsAffected()
+
+	return result, nil
+}
+
+// DeleteCreatorsByUsername removes the creator account of the workspace, returns the number of rows
+func (r *Repository) DeleteCreatorsByUsername(ctx context.Context, username string) (int64, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `DELETE FROM creators WHERE workspace_id = $1 AND username = $2`
+
+	tag, err := r.getDB(ctx).Exec(ctx, query, workspaceID, username)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: DeleteCreatorsByUsername error: %v", err)
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// RemoveAllowedCreator drops the username from allowed_creators of the watches of the workspace,
+// returns the number of watches changed
+func (r *Repository) RemoveAllowedCreator(ctx context.Context, username string) (int64, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE watches
+        SET
+            allowed_creators = array_remove(allowed_creators, $2),
+            updated_at       = NOW()
+        WHERE workspace_id = $1
+            AND $2 = ANY(allowed_creators)
+    `
+
+	tag, err := r.getDB(ctx).Exec(ctx, query, workspaceID, username)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: RemoveAllowedCreator error: %v", err)
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// redactedSnapshot replaces the before/after of audit entries describing personal data that was purged
+const redactedSnapshot = `'{"redacted": true}'::jsonb`
+
+// RedactAuditUsername removes a username from the audit entries of the workspace: creator snapshots naming it are
+// redacted, the watch snapshots lose it from allowed_creators. Returns the number of entries changed.
+// Video entries are redacted by DeleteVideos.
+func (r *Repository) RedactAuditUsername(ctx context.Context, username string) (int64, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	db := r.getDB(ctx)
+
+	creators, err := db.Exec(ctx, `
+        UPDATE audit_log
+        SET
+            before = CASE WHEN before IS NULL THEN NULL ELSE `+redactedSnapshot+` END,
+            after  = CASE WHEN after IS NULL THEN NULL ELSE `+redactedSnapshot+` END
+        WHERE workspace_id = $1
+            AND target_type = $2
+            AND (before->>'username' = $3 OR after->>'username' = $3)
+    `, workspaceID, models.AuditTargetCreator, username)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: RedactAuditUsername creators error: %v", err)
+		return 0, err
+	}
+
+	watches, err := db.Exec(ctx, `
+        UPDATE audit_log
+        SET
+            before = CASE WHEN before->'allowed_creators' ? $3
+                THEN jsonb_set(before, '{allowed_creators}', (before->'allowed_creators') - $3) ELSE before END,
+            after  = CASE WHEN after->'allowed_creators' ? $3
+                THEN jsonb_set(after, '{allowed_creators}', (after->'allowed_creators') - $3) ELSE after END
+        WHERE workspace_id = $1
+            AND target_type = $2
+            AND (before->'allowed_creators' ? $3 OR after->'allowed_creators' ? $3)
+    `, workspaceID, models.AuditTargetWatch, username)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: RedactAuditUsername watches error: %v", err)
+		return 0, err
+	}
+
+	return creators.RowsAffected() + watches.RowsAffected(), nil
+}
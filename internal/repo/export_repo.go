@@ -0,0 +1,477 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// The Stream* methods read straight from pgx.Rows and call fn per row, so exports of any size
+// are never held in memory. They run without the query timeout: the caller's ctx (the HTTP
+// request or the export job) bounds them, and a client that disconnects cancels the query.
+
+// StreamVideos calls fn for each video of the workspace matching the filter, by id
+func (r *Repository) StreamVideos(ctx context.Context, filter models.VideoListFilter, fn func(models.Video) error) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        SELECT
+            id,
+            workspace_id,
+            tiktok_id,
+            url,
+            current_views,
+            current_earnings,
+            created_at,
+            updated_at,
+            tracking_status,
+            last_error,
+            last_error_at
+        FROM videos
+        WHERE workspace_id = $1
+            AND id > $2
+            AND deleted_at IS NULL
+    `
+
+	args := []any{workspaceID, filter.AfterID}
+
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" AND tracking_status = $%d", len(args))
+	}
+
+	query += " ORDER BY id ASC"
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.readDB(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v models.Video
+		if err := rows.Scan(
+			&v.ID,
+			&v.WorkspaceID,
+			&v.TikTokID,
+			&v.URL,
+			&v.CurrentViews,
+			&v.CurrentEarnings,
+			&v.CreatedAt,
+			&v.UpdatedAt,
+			&v.TrackingStatus,
+			&v.LastError,
+			&v.LastErrorAt,
+		); err != nil {
+			return err
+		}
+
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// StreamVideoHistory calls fn for the raw snapshots of a video of the workspace in [from, to), oldest first
+func (r *Repository) StreamVideoHistory(ctx context.Context, videoID int64, from, to *time.Time, fn func(models.VideoStatPoint) error) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query, args := statsQuery("video_stats", videoID, workspaceID, from, to)
+
+	return r.streamStatPoints(ctx, query, args, fn)
+}
+
+// GetVideoHistory returns what StreamVideoHistory streams, read within the query timeout
+func (r *Repository) GetVideoHistory(ctx context.Context, videoID int64, from, to *time.Time) ([]models.VideoStatPoint, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	var result []models.VideoStatPoint
+
+	err := r.StreamVideoHistory(ctx, videoID, from, to, func(p models.VideoStatPoint) error {
+		result = append(result, p)
+		return nil
+	})
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: GetVideoHistory video_id=%d error: %v", videoID, err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// StreamStatsForExport calls fn for the points in [from, to) of one video, or of all videos of the workspace
+// when videoID is nil, read from the raw snapshots or the rollup of granularity
+func (r *Repository) StreamStatsForExport(ctx context.Context, granularity string, videoID *int64, from, to time.Time, fn func(models.ExportStatRow) error) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        SELECT s.video_id, v.tiktok_id, s.captured_at, s.views, s.earnings
+        FROM ` + statsTable(granularity) + ` s
+        JOIN videos v ON v.id = s.video_id
+        WHERE v.workspace_id = $1
+            AND v.deleted_at IS NULL
+            AND ($2::bigint IS NULL OR s.video_id = $2)
+            AND s.captured_at >= $3
+            AND s.captured_at < $4
+        ORDER BY s.video_id, s.captured_at
+    `
+
+	rows, err := r.readDB(ctx).Query(ctx, query, workspaceID, videoID, from, to)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row models.ExportStatRow
+		if err := rows.Scan(
+			&row.VideoID,
+			&row.TikTokID,
+			&row.CapturedAt,
+			&row.Views,
+			&row.Earnings,
+		); err != nil {
+			return err
+		}
+
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+const exportJobColumns = `
+            id,
+            workspace_id,
+            video_id,
+            range_from,
+            range_to,
+            format,
+            status,
+            file_path,
+            rows,
+            size_bytes,
+            error,
+            expires_at,
+            created_at,
+            finished_at,
+            attempts`
+
+func (r *Repository) CreateExportJob(ctx context.Context, input models.CreateExportJobInput) (*models.ExportJob, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO export_jobs (workspace_id, video_id, range_from, range_to, format)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING` + exportJobColumns
+
+	job, err := scanExportJob(r.getDB(ctx).QueryRow(ctx, query,
+		workspaceID,
+		input.VideoID,
+		input.From,
+		input.To,
+		input.Format,
+	))
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: CreateExportJob error: %v", err)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (r *Repository) GetExportJob(ctx context.Context, exportID int64) (*models.ExportJob, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `SELECT` + exportJobColumns + `
+        FROM export_jobs
+        WHERE id = $1
+            AND workspace_id = $2
+    `
+
+	return scanExportJob(r.getDB(ctx).QueryRow(ctx, query, exportID, workspaceID))
+}
+
+// ClaimExportJob takes the oldest pending job of any workspace for worker, or a running one whose
+// worker stopped renewing its lease, unless it was already claimed maxAttempts times.
+// ErrNotFound when there is nothing to do.
+func (r *Repository) ClaimExportJob(ctx context.Context, worker string, lease time.Duration, maxAttempts int) (*models.ExportJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        WITH next AS (
+            SELECT id
+            FROM export_jobs
+            WHERE status = 'pending'
+                OR (status = 'running'
+                    AND (lease_until IS NULL OR lease_until < NOW())
+                    AND attempts < $3)
+            ORDER BY id
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED
+        )
+        UPDATE export_jobs j
+        SET
+            status      = 'running',
+            started_at  = NOW(),
+            claimed_by  = $1,
+            lease_until = NOW() + $2 * INTERVAL '1 millisecond',
+            attempts    = j.attempts + 1
+        FROM next
+        WHERE j.id = next.id
+        RETURNING` + prefixColumns(exportJobColumns, "j.")
+
+	return scanExportJob(r.db.QueryRow(ctx, query, worker, lease.Milliseconds(), maxAttempts))
+}
+
+// FailAbandonedExports fails the running jobs of all workspaces whose lease ran out on their
+// last attempt, so they do not stay running forever
+func (r *Repository) FailAbandonedExports(ctx context.Context, maxAttempts int) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE export_jobs
+        SET
+            status      = 'failed',
+            error       = 'abandoned after ' || attempts || ' attempts',
+            finished_at = NOW()
+        WHERE status = 'running'
+            AND (lease_until IS NULL OR lease_until < NOW())
+            AND attempts >= $1
+    `
+
+	tag, err := r.db.Exec(ctx, query, maxAttempts)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: FailAbandonedExports error: %v", err)
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// RenewExportLease pushes back the lease of a job worker is running.
+// ErrNotFound when the job is no longer worker's: it was taken over or finished.
+func (r *Repository) RenewExportLease(ctx context.Context, exportID int64, worker string, lease time.Duration) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE export_jobs
+        SET lease_until = NOW() + $1 * INTERVAL '1 millisecond'
+        WHERE id = $2
+            AND workspace_id = $3
+            AND status = 'running'
+            AND claimed_by = $4
+    `
+
+	tag, err := r.getDB(ctx).Exec(ctx, query, lease.Milliseconds(), exportID, workspaceID, worker)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: RenewExportLease export_id=%d error: %v", exportID, err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+// MarkExportDone finishes a job worker is running. ErrNotFound when it is no longer worker's.
+func (r *Repository) MarkExportDone(ctx context.Context, exportID int64, worker, filePath string, rows, sizeBytes int64, expiresAt time.Time) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE export_jobs
+        SET
+            status      = 'done',
+            file_path   = $1,
+            rows        = $2,
+            size_bytes  = $3,
+            expires_at  = $4,
+            error       = NULL,
+            finished_at = NOW()
+        WHERE id = $5
+            AND workspace_id = $6
+            AND status = 'running'
+            AND claimed_by = $7
+    `
+
+	tag, err := r.getDB(ctx).Exec(ctx, query, filePath, rows, sizeBytes, expiresAt, exportID, workspaceID, worker)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: MarkExportDone export_id=%d error: %v", exportID, err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+// MarkExportFailed fails a job worker is running. ErrNotFound when it is no longer worker's.
+func (r *Repository) MarkExportFailed(ctx context.Context, exportID int64, worker, errText string) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE export_jobs
+        SET
+            status      = 'failed',
+            error       = $1,
+            finished_at = NOW()
+        WHERE id = $2
+            AND workspace_id = $3
+            AND status = 'running'
+            AND claimed_by = $4
+    `
+
+	tag, err := r.getDB(ctx).Exec(ctx, query, errText, exportID, workspaceID, worker)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: MarkExportFailed export_id=%d error: %v", exportID, err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+// ExpireExports marks done jobs of all workspaces past expires_at as expired and
+// returns them so the caller can remove their files
+func (r *Repository) ExpireExports(ctx context.Context, limit int) ([]models.ExportJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        WITH due AS (
+            SELECT id
+            FROM export_jobs
+            WHERE status = 'done'
+                AND expires_at <= NOW()
+            ORDER BY id
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        UPDATE export_jobs j
+        SET status = 'expired'
+        FROM due
+        WHERE j.id = due.id
+        RETURNING` + prefixColumns(exportJobColumns, "j.")
+
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.ExportJob
+
+	for rows.Next() {
+		job, err := scanExportJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func scanExportJob(row pgx.Row) (*models.ExportJob, error) {
+	var j models.ExportJob
+	if err := row.Scan(
+		&j.ID,
+		&j.WorkspaceID,
+		&j.VideoID,
+		&j.From,
+		&j.To,
+		&j.Format,
+		&j.Status,
+		&j.FilePath,
+		&j.Rows,
+		&j.SizeBytes,
+		&j.Error,
+		&j.ExpiresAt,
+		&j.CreatedAt,
+		&j.FinishedAt,
+		&j.Attempts,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &j, nil
+}
+
+// prefixColumns qualifies a column list with a table alias, for UPDATE ... FROM ... RETURNING
+func prefixColumns(columns, alias string) string {
+	parts := strings.Split(columns, ",")
+	for i, p := range parts {
+		parts[i] = "\n            " + alias + strings.TrimSpace(p)
+	}
+
+	return strings.Join(parts, ",")
+}
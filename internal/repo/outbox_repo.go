@@ -0,0 +1,181 @@
+package repo
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+)
+
+// RecordEvent appends the event to the outbox and fans it out to webhook subscriptions.
+// Pass the transaction ctx of the state change; an event id that was already recorded is skipped.
+func (r *Repository) RecordEvent(ctx context.Context, event models.Event) error {
+	if err := r.AppendOutboxEvent(ctx, event); err != nil {
+		return err
+	}
+
+	return r.EnqueueWebhookEvent(ctx, event)
+}
+
+// AppendOutboxEvent writes the event for the relay, ON CONFLICT on the idempotency key does nothing
+func (r *Repository) AppendOutboxEvent(ctx context.Context, event models.Event) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO outbox_events (workspace_id, event_id, event_type, aggregate_type, aggregate_id, payload)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (event_id) DO NOTHING
+    `
+
+	if _, err := r.getDB(ctx).Exec(ctx, query,
+		workspaceID,
+		event.ID,
+		event.Type,
+		event.AggregateType,
+		event.AggregateID,
+		event.Payload,
+	); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: AppendOutboxEvent %s %s error: %v", event.Type, event.ID, err)
+		return err
+	}
+
+	return nil
+}
+
+// ClaimOutboxEvents leases unpublished events of all workspaces for the relay, oldest first.
+// Like ClaimDueDeliveries, claimed rows are pushed lease into the future so other replicas skip them.
+func (r *Repository) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        WITH due AS (
+            SELECT id
+            FROM outbox_events
+            WHERE published_at IS NULL
+                AND failed_at IS NULL
+                AND next_attempt_at <= NOW()
+            ORDER BY id
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        UPDATE outbox_events o
+        SET
+            attempts        = o.attempts + 1,
+            next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+        FROM due
+        WHERE o.id = due.id
+        RETURNING
+            o.id,
+            o.workspace_id,
+            o.event_id,
+            o.event_type,
+            o.aggregate_type,
+            o.aggregate_id,
+            o.payload,
+            o.attempts,
+            o.created_at
+    `
+
+	rows, err := r.db.Query(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.OutboxEvent, 0, limit)
+
+	for rows.Next() {
+		var e models.OutboxEvent
+		if err := rows.Scan(
+			&e.ID,
+			&e.WorkspaceID,
+			&e.EventID,
+			&e.EventType,
+			&e.AggregateType,
+			&e.AggregateID,
+			&e.Payload,
+			&e.Attempts,
+			&e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// UPDATE ... RETURNING does not keep the order of the CTE
+	slices.SortFunc(result, func(a, b models.OutboxEvent) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return result, nil
+}
+
+// MarkOutboxEventPublished records that the sink accepted the event
+func (r *Repository) MarkOutboxEventPublished(ctx context.Context, id int64) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE outbox_events
+        SET
+            published_at = NOW(),
+            last_error   = NULL
+        WHERE id = $1
+            AND workspace_id = $2
+    `
+
+	if _, err := r.getDB(ctx).Exec(ctx, query, id, workspaceID); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: MarkOutboxEventPublished id=%d error: %v", id, err)
+		return err
+	}
+
+	return nil
+}
+
+// MarkOutboxEventFailed keeps the event unpublished and schedules the next attempt, or gives up on it when
+// nextAttemptAt is nil: the event stays in the table with failed_at set and the relay skips it.
+func (r *Repository) MarkOutboxEventFailed(ctx context.Context, id int64, errText string, nextAttemptAt *time.Time) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE outbox_events
+        SET
+            last_error      = $1,
+            next_attempt_at = COALESCE($2, next_attempt_at),
+            failed_at       = CASE WHEN $2::timestamptz IS NULL THEN NOW() END
+        WHERE id = $3
+            AND workspace_id = $4
+    `
+
+	if _, err := r.getDB(ctx).Exec(ctx, query, errText, nextAttemptAt, id, workspaceID); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: MarkOutboxEventFailed id=%d error: %v", id, err)
+		return err
+	}
+
+	return nil
+}
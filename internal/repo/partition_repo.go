@@ -0,0 +1,127 @@
+package repo
+
+import (
+	"context"
+	"strings"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// partitionLockKey is the transaction-level advisory lock of the partition maintenance,
+// one replica at a time creates and detaches partitions
+const partitionLockKey int64 = 0x7474_7061_7274 // "ttpart"
+
+// statsArchiveSchema holds the detached months of video_stats
+const statsArchiveSchema = "video_stats_archive"
+
+// LockPartitionMaintenance takes the maintenance lock until the end of the transaction in ctx,
+// false when another replica holds it
+func (r *Repository) LockPartitionMaintenance(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	var ok bool
+	if err := r.getDB(ctx).QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, partitionLockKey).Scan(&ok); err != nil {
+		return false, err
+	}
+
+	return ok, nil
+}
+
+// CreateStatsPartition creates the video_stats partition of the month holding month, moving its rows out
+// of the default partition. It reports false when the partition exists already. Not workspace scoped.
+func (r *Repository) CreateStatsPartition(ctx context.Context, month time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	var name *string
+	if err := r.getDB(ctx).QueryRow(ctx, `SELECT create_video_stats_partition($1)`, month).Scan(&name); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: CreateStatsPartition %s error: %v", models.StatsPartitionName(month), err)
+		return false, err
+	}
+
+	return name != nil, nil
+}
+
+// ListStatsPartitions returns the monthly partitions attached to video_stats, oldest first;
+// the default partition is left out. Not workspace scoped.
+func (r *Repository) ListStatsPartitions(ctx context.Context) ([]models.StatsPartition, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT c.relname
+        FROM pg_inherits i
+        JOIN pg_class c ON c.oid = i.inhrelid
+        WHERE i.inhparent = 'video_stats'::regclass
+        ORDER BY c.relname
+    `
+
+	rows, err := r.getDB(ctx).Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.StatsPartition
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		// partitions are named video_stats_YYYY_MM, which also skips video_stats_default
+		from, err := time.Parse("2006_01", strings.TrimPrefix(name, "video_stats_"))
+		if err != nil {
+			continue
+		}
+
+		result = append(result, models.StatsPartition{Name: name, From: from, To: from.AddDate(0, 1, 0)})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ArchiveStatsPartition detaches a monthly partition from video_stats and moves it to the archive schema:
+// its rows leave every query but stay in the database until an operator dumps and drops the table
+func (r *Repository) ArchiveStatsPartition(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	db := r.getDB(ctx)
+	table := pgx.Identifier{name}.Sanitize()
+
+	if _, err := db.Exec(ctx, `ALTER TABLE video_stats DETACH PARTITION `+table); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: ArchiveStatsPartition %s detach error: %v", name, err)
+		return err
+	}
+
+	if _, err := db.Exec(ctx, `ALTER TABLE `+table+` SET SCHEMA `+pgx.Identifier{statsArchiveSchema}.Sanitize()); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: ArchiveStatsPartition %s move error: %v", name, err)
+		return err
+	}
+
+	return nil
+}
+
+// DropStatsPartition drops a monthly partition of video_stats with its rows, cheaper than deleting them
+// one by one once the whole month is past the raw retention
+func (r *Repository) DropStatsPartition(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	if _, err := r.getDB(ctx).Exec(ctx, `DROP TABLE `+pgx.Identifier{name}.Sanitize()); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: DropStatsPartition %s error: %v", name, err)
+		return err
+	}
+
+	return nil
+}
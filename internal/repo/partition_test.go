@@ -0,0 +1,90 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+// history reads must compare captured_at with plain bounds so the planner prunes the months outside them
+func TestRepository_StreamVideoHistory_BoundsPrunePartitions(t *testing.T) {
+	r, mock := newMockRepository(t)
+	ctx := tenant.NewContext(context.Background(), otherWorkspace)
+
+	from := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`AND v.deleted_at IS NULL\s+AND s.captured_at >= \$3 AND s.captured_at < \$4 ORDER BY s.captured_at ASC$`).
+		WithArgs(int64(1), otherWorkspace, from, to).
+		WillReturnRows(pgxmock.NewRows([]string{"captured_at", "views", "earnings"}))
+
+	if err := r.StreamVideoHistory(ctx, 1, &from, &to, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// without bounds there is nothing to prune by and no NULL placeholder either
+	mock.ExpectQuery(`AND v.deleted_at IS NULL\s+ORDER BY s.captured_at ASC$`).
+		WithArgs(int64(1), otherWorkspace).
+		WillReturnRows(pgxmock.NewRows([]string{"captured_at", "views", "earnings"}))
+
+	if err := r.StreamVideoHistory(ctx, 1, nil, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// the SSE replay starts at the captured_at carried in the event id, not at one looked up by id in every month
+func TestRepository_ListVideoStatsSince_BoundPrunesPartitions(t *testing.T) {
+	r, mock := newMockRepository(t)
+	ctx := tenant.NewContext(context.Background(), otherWorkspace)
+
+	after := models.StatsEventID{ID: 42, CapturedAt: time.Date(2025, 11, 20, 12, 0, 0, 0, time.UTC)}
+	videoID := int64(1)
+
+	mock.ExpectQuery(`WHERE v.workspace_id = \$1\s+AND s.captured_at >= \$2\s+AND s.id <> \$3\s`).
+		WithArgs(otherWorkspace, after.CapturedAt.Add(-time.Minute), after.ID, &videoID, 10).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "video_id", "workspace_id", "views", "earnings", "captured_at"}))
+
+	if _, err := r.ListVideoStatsSince(ctx, &videoID, after, time.Minute, 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRepository_ListStatsPartitions_SkipsDefault(t *testing.T) {
+	r, mock := newMockRepository(t)
+
+	mock.ExpectQuery(`FROM pg_inherits`).
+		WillReturnRows(pgxmock.NewRows([]string{"relname"}).
+			AddRow("video_stats_2025_10").
+			AddRow("video_stats_2025_11").
+			AddRow("video_stats_default"))
+
+	partitions, err := r.ListStatsPartitions(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(partitions) != 2 {
+		t.Fatalf("expected the two monthly partitions, got %+v", partitions)
+	}
+	if p := partitions[1]; p.Name != "video_stats_2025_11" ||
+		!p.From.Equal(time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)) ||
+		!p.To.Equal(time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected partition: %+v", p)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,354 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+	"ttanalytic/internal/infrastructure/dbtx"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// dbReader is the part of dbRunner the read-only queries need
+type dbReader interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// replica is a streaming replica serving the reads that tolerate its lag
+type replica struct {
+	db     PgDriver
+	maxLag time.Duration
+
+	mu      sync.Mutex
+	healthy bool
+	err     error
+}
+
+func (rep *replica) isHealthy() bool {
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+
+	return rep.healthy
+}
+
+// set records the outcome of a check or a failed read and returns whether the replica was healthy before
+func (rep *replica) set(err error) bool {
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+
+	was := rep.healthy
+	rep.healthy, rep.err = err == nil, err
+
+	return was
+}
+
+func (rep *replica) status() error {
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+
+	if !rep.healthy && rep.err == nil {
+		return errors.New("not checked yet")
+	}
+
+	return rep.err
+}
+
+// WithReplica routes the history, list and analytics reads to db while CheckReplica finds it at most maxLag
+// behind the primary. Until the first successful check, and after a failed one, they stay on the primary.
+func (r *Repository) WithReplica(db PgDriver, maxLag time.Duration) *Repository {
+	r.replica = &replica{db: db, maxLag: maxLag}
+
+	return r
+}
+
+// readDB is getDB for read-only queries that may see data up to the tolerated lag old. A transaction in ctx
+// keeps them on the primary, reading what it wrote.
+func (r *Repository) readDB(ctx context.Context) dbReader {
+	if tx, ok := dbtx.TxFromContext(ctx); ok {
+		return tx
+	}
+	if r.replica == nil {
+		return r.db
+	}
+	if pin, ok := ctx.Value(readPinKey{}).(*readPin); ok {
+		if pin.choose(r.replica.isHealthy()) {
+			return r.db
+		}
+		return replicaReader{r: r, pin: pin}
+	}
+	if !r.replica.isHealthy() {
+		return r.db
+	}
+
+	return replicaReader{r: r}
+}
+
+// replicaLagQuery is 0 on a replica that replayed everything it received, or on a server not in recovery at all
+const replicaLagQuery = `
+    SELECT CASE
+        WHEN NOT pg_is_in_recovery() THEN 0
+        WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+        ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+    END::double precision
+`
+
+// CheckReplica measures how far the replica is behind and routes reads to it only while that is within the
+// tolerated lag. It returns why the replica is not used, nil when it is or none is configured.
+func (r *Repository) CheckReplica(ctx context.Context) error {
+	if r.replica == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	var lagSec float64
+	err := r.replica.db.QueryRow(ctx, replicaLagQuery).Scan(&lagSec)
+	if err != nil {
+		err = fmt.Errorf("check replica lag: %w", err)
+	} else if lag := time.Duration(lagSec * float64(time.Second)); lag > r.replica.maxLag {
+		err = fmt.Errorf("replica %s behind, max %s", lag.Round(time.Millisecond), r.replica.maxLag)
+	}
+
+	was := r.replica.set(err)
+
+	switch {
+	case err != nil && was:
+		r.logger.Warnf("Repository: reads moved to the primary: %v", err)
+	case err == nil && !was:
+		r.logger.Infof("Repository: reads routed to the replica")
+	}
+
+	return err
+}
+
+// ReplicaStatus is the error of the last replica check or failed replica read, nil while reads go to the replica.
+// It is nil too without a replica.
+func (r *Repository) ReplicaStatus(context.Context) error {
+	if r.replica == nil {
+		return nil
+	}
+
+	return r.replica.status()
+}
+
+// MonitorReplica runs CheckReplica every interval until ctx is done
+func (r *Repository) MonitorReplica(ctx context.Context, interval time.Duration) {
+	if r.replica == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_ = r.CheckReplica(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// replicaFailed tells whether a replica read failed because of the replica rather than the query: it is
+// unreachable, shutting down or cancelled the query in a recovery conflict. Reads then leave the replica until the
+// next successful check and the failed one is run again on the primary.
+func (r *Repository) replicaFailed(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var (
+		pgErr      *pgconn.PgError
+		connectErr *pgconn.ConnectError
+		netErr     net.Error
+	)
+
+	switch {
+	case errors.As(err, &pgErr):
+		// 57: operator intervention (shutdown, recovery conflict cancel), 40001: conflict with recovery
+		if !strings.HasPrefix(pgErr.Code, "57") && pgErr.Code != "40001" {
+			return false
+		}
+	case errors.As(err, &connectErr), errors.As(err, &netErr),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+	default:
+		return false
+	}
+
+	if r.replica.set(err) {
+		r.logger.Warnf("Repository: replica read failed, reads moved to the primary: %v", err)
+	}
+
+	return true
+}
+
+// replicaReader runs reads on the replica and again on the primary when the replica fails them before returning
+// a row. A failure after that is classified all the same, the reads that follow go to the primary.
+type replicaReader struct {
+	r *Repository
+	// pin, when set, allows the fallback only while its reads have not served anything yet
+	pin *readPin
+}
+
+// fallback tells whether a read the replica failed may run again on the primary
+func (rr replicaReader) fallback() bool {
+	return rr.pin == nil || rr.pin.moveToPrimary()
+}
+
+func (rr replicaReader) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	rows, err := rr.r.replica.db.Query(ctx, sql, args...)
+	if err != nil {
+		if rr.r.replicaFailed(ctx, err) && rr.fallback() {
+			return rr.r.db.Query(ctx, sql, args...)
+		}
+		return nil, err
+	}
+
+	return &replicaRows{Rows: rows, rr: rr, ctx: ctx, sql: sql, args: args}, nil
+}
+
+func (rr replicaReader) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return replicaRow{rr: rr, ctx: ctx, sql: sql, args: args}
+}
+
+// replicaRows classifies the errors of a replica read that surface while its rows are read, the way
+// QueryRow errors surface on Scan. One found before the first row still runs the read on the primary.
+type replicaRows struct {
+	pgx.Rows
+
+	rr   replicaReader
+	ctx  context.Context
+	sql  string
+	args []any
+
+	read    bool
+	primary bool
+}
+
+func (rows *replicaRows) Next() bool {
+	if rows.Rows.Next() {
+		rows.served()
+		return true
+	}
+	if rows.primary {
+		return false
+	}
+
+	err := rows.Rows.Err()
+	if err == nil {
+		rows.served()
+		return false
+	}
+	if !rows.rr.r.replicaFailed(rows.ctx, err) || rows.read || !rows.rr.fallback() {
+		return false
+	}
+
+	primary, err := rows.rr.r.db.Query(rows.ctx, rows.sql, rows.args...)
+	if err != nil {
+		rows.Rows, rows.primary = errRows{Rows: rows.Rows, err: err}, true
+		return false
+	}
+	rows.Rows.Close()
+	rows.Rows, rows.primary = primary, true
+
+	return rows.Rows.Next()
+}
+
+func (rows *replicaRows) served() {
+	rows.read = true
+	if rows.rr.pin != nil {
+		rows.rr.pin.serve()
+	}
+}
+
+// errRows is closed rows failed with err, the primary failing the read the replica failed first
+type errRows struct {
+	pgx.Rows
+	err error
+}
+
+func (rows errRows) Next() bool { return false }
+func (rows errRows) Err() error { return rows.err }
+
+// replicaRow runs its query on Scan, where QueryRow errors surface
+type replicaRow struct {
+	rr   replicaReader
+	ctx  context.Context
+	sql  string
+	args []any
+}
+
+func (row replicaRow) Scan(dest ...any) error {
+	err := row.rr.r.replica.db.QueryRow(row.ctx, row.sql, row.args...).Scan(dest...)
+	if err != nil && row.rr.r.replicaFailed(row.ctx, err) && row.rr.fallback() {
+		return row.rr.r.db.QueryRow(row.ctx, row.sql, row.args...).Scan(dest...)
+	}
+	if row.rr.pin != nil && (err == nil || errors.Is(err, pgx.ErrNoRows)) {
+		row.rr.pin.serve()
+	}
+
+	return err
+}
+
+type readPinKey struct{}
+
+// readPin is the source the reads of one PinReads context go to
+type readPin struct {
+	mu      sync.Mutex
+	chosen  bool
+	primary bool
+	served  bool
+}
+
+// choose fixes the source on the first read: the replica when it is used at that time
+func (pin *readPin) choose(replicaHealthy bool) (primary bool) {
+	pin.mu.Lock()
+	defer pin.mu.Unlock()
+
+	if !pin.chosen {
+		pin.chosen, pin.primary = true, !replicaHealthy
+	}
+
+	return pin.primary
+}
+
+func (pin *readPin) serve() {
+	pin.mu.Lock()
+	defer pin.mu.Unlock()
+
+	pin.served = true
+}
+
+// moveToPrimary moves the reads to the primary unless the replica already served one of them
+func (pin *readPin) moveToPrimary() bool {
+	pin.mu.Lock()
+	defer pin.mu.Unlock()
+
+	if pin.served {
+		return false
+	}
+	pin.primary = true
+
+	return true
+}
+
+// PinReads returns a ctx whose reads all go to one source, the one the first of them goes to: a replica further
+// behind than the primary must not answer a part of them. A replica failing the first read moves them all to the
+// primary; failing a later one fails that read instead of mixing what the two have seen.
+func (r *Repository) PinReads(ctx context.Context) context.Context {
+	if r.replica == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, readPinKey{}, &readPin{})
+}
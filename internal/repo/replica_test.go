@@ -0,0 +1,231 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+	"ttanalytic/internal/tenant"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+func newReplicaRepository(t *testing.T, maxLag time.Duration) (*Repository, pgxmock.PgxPoolIface, pgxmock.PgxPoolIface) {
+	t.Helper()
+
+	r, primary := newMockRepository(t)
+
+	replica, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("pgxmock: %v", err)
+	}
+	t.Cleanup(replica.Close)
+
+	return r.WithReplica(replica, maxLag), primary, replica
+}
+
+func summaryRows() *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"videos", "active", "stopped", "errored", "views", "earnings"}).
+		AddRow(int64(3), int64(2), int64(1), int64(0), int64(3000), 0.3)
+}
+
+func TestRepository_ReadsGoToReplicaOnceChecked(t *testing.T) {
+	r, primary, replica := newReplicaRepository(t, 10*time.Second)
+	ctx := tenant.NewContext(context.Background(), otherWorkspace)
+
+	// not checked yet
+	primary.ExpectQuery(`FROM videos`).WithArgs(otherWorkspace).WillReturnRows(summaryRows())
+	if _, err := r.PortfolioSummary(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.ReplicaStatus(ctx); err == nil {
+		t.Fatal("expected the replica to be reported unused before the first check")
+	}
+
+	replica.ExpectQuery(`pg_last_xact_replay_timestamp`).WillReturnRows(pgxmock.NewRows([]string{"lag"}).AddRow(0.5))
+	if err := r.CheckReplica(ctx); err != nil {
+		t.Fatalf("unexpected check error: %v", err)
+	}
+
+	replica.ExpectQuery(`FROM videos`).WithArgs(otherWorkspace).WillReturnRows(summaryRows())
+	summary, err := r.PortfolioSummary(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.Videos != 3 || r.ReplicaStatus(ctx) != nil {
+		t.Fatalf("expected the summary from the replica, got %+v (%v)", summary, r.ReplicaStatus(ctx))
+	}
+
+	for name, mock := range map[string]pgxmock.PgxPoolIface{"primary": primary, "replica": replica} {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestRepository_LaggingReplicaKeepsReadsOnPrimary(t *testing.T) {
+	r, primary, replica := newReplicaRepository(t, 10*time.Second)
+	ctx := tenant.NewContext(context.Background(), otherWorkspace)
+
+	replica.ExpectQuery(`pg_last_xact_replay_timestamp`).WillReturnRows(pgxmock.NewRows([]string{"lag"}).AddRow(0.0))
+	replica.ExpectQuery(`pg_last_xact_replay_timestamp`).WillReturnRows(pgxmock.NewRows([]string{"lag"}).AddRow(42.0))
+
+	if err := r.CheckReplica(ctx); err != nil {
+		t.Fatalf("unexpected check error: %v", err)
+	}
+	if err := r.CheckReplica(ctx); err == nil {
+		t.Fatal("expected a replica 42s behind to be refused with a 10s tolerance")
+	}
+
+	primary.ExpectQuery(`FROM videos`).WithArgs(otherWorkspace).WillReturnRows(summaryRows())
+	if _, err := r.PortfolioSummary(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, mock := range map[string]pgxmock.PgxPoolIface{"primary": primary, "replica": replica} {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestRepository_ReplicaFailureFallsBackToPrimary(t *testing.T) {
+	r, primary, replica := newReplicaRepository(t, 10*time.Second)
+	ctx := tenant.NewContext(context.Background(), otherWorkspace)
+
+	replica.ExpectQuery(`pg_last_xact_replay_timestamp`).WillReturnRows(pgxmock.NewRows([]string{"lag"}).AddRow(0.0))
+	if err := r.CheckReplica(ctx); err != nil {
+		t.Fatalf("unexpected check error: %v", err)
+	}
+
+	// an error of the query itself is the caller's, the primary would fail it the same way
+	replica.ExpectQuery(`FROM videos`).WithArgs(otherWorkspace, 5).
+		WillReturnError(&pgconn.PgError{Code: "42703", Message: "column does not exist"})
+	if _, err := r.TopVideos(ctx, 5); err == nil {
+		t.Fatal("expected the query error")
+	}
+
+	// an unreachable replica is not: the read is run again on the primary and the next ones stay there
+	replica.ExpectQuery(`FROM videos`).WithArgs(otherWorkspace).
+		WillReturnError(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+	primary.ExpectQuery(`FROM videos`).WithArgs(otherWorkspace).WillReturnRows(summaryRows())
+	primary.ExpectQuery(`FROM videos`).WithArgs(otherWorkspace).WillReturnRows(summaryRows())
+
+	for range 2 {
+		if _, err := r.PortfolioSummary(ctx); err != nil {
+			t.Fatalf("expected the primary to serve the read, got %v", err)
+		}
+	}
+	if err := r.ReplicaStatus(ctx); err == nil {
+		t.Fatal("expected the replica failure to be reported")
+	}
+
+	for name, mock := range map[string]pgxmock.PgxPoolIface{"primary": primary, "replica": replica} {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+}
+
+func historyRows() *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"captured_at", "views", "earnings"})
+}
+
+func checkReplica(t *testing.T, r *Repository, replica pgxmock.PgxPoolIface) {
+	t.Helper()
+
+	replica.ExpectQuery(`pg_last_xact_replay_timestamp`).WillReturnRows(pgxmock.NewRows([]string{"lag"}).AddRow(0.0))
+	if err := r.CheckReplica(context.Background()); err != nil {
+		t.Fatalf("unexpected check error: %v", err)
+	}
+}
+
+// a replica failing while its rows are read is classified like one failing the query
+func TestRepository_ReplicaRowErrorsAreClassified(t *testing.T) {
+	r, primary, replica := newReplicaRepository(t, 10*time.Second)
+	ctx := tenant.NewContext(context.Background(), otherWorkspace)
+	lost := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+
+	// before the first row the read is run again on the primary
+	checkReplica(t, r, replica)
+	replica.ExpectQuery(`FROM video_stats`).WithArgs(int64(7), otherWorkspace).WillReturnRows(historyRows().CloseError(lost))
+	primary.ExpectQuery(`FROM video_stats`).WithArgs(int64(7), otherWorkspace).
+		WillReturnRows(historyRows().AddRow(time.Now(), int64(10), 0.01))
+
+	points, err := r.GetVideoHistory(ctx, 7, nil, nil)
+	if err != nil || len(points) != 1 {
+		t.Fatalf("expected the primary to serve the read, got %v (%v)", points, err)
+	}
+	if err := r.ReplicaStatus(ctx); err == nil {
+		t.Fatal("expected the replica failure to be reported")
+	}
+
+	// after it the read fails, the next one goes to the primary
+	checkReplica(t, r, replica)
+	replica.ExpectQuery(`FROM video_stats`).WithArgs(int64(7), otherWorkspace).
+		WillReturnRows(historyRows().AddRow(time.Now(), int64(10), 0.01).CloseError(lost))
+	if _, err := r.GetVideoHistory(ctx, 7, nil, nil); !errors.Is(err, lost) {
+		t.Fatalf("expected the replica failure, got %v", err)
+	}
+	if err := r.ReplicaStatus(ctx); err == nil {
+		t.Fatal("expected the replica failure to be reported")
+	}
+
+	primary.ExpectQuery(`FROM video_stats`).WithArgs(int64(7), otherWorkspace).WillReturnRows(historyRows())
+	if _, err := r.GetVideoHistory(ctx, 7, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, mock := range map[string]pgxmock.PgxPoolIface{"primary": primary, "replica": replica} {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestRepository_PinnedReadsStayOnOneSource(t *testing.T) {
+	r, primary, replica := newReplicaRepository(t, 10*time.Second)
+	ctx := tenant.NewContext(context.Background(), otherWorkspace)
+	down := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	state := func() *pgxmock.Rows {
+		return pgxmock.NewRows([]string{"rolled_up_to", "raw_from", "daily_from"}).AddRow(time.Now(), nil, nil)
+	}
+
+	// the replica failing the first read moves all of them to the primary
+	checkReplica(t, r, replica)
+	pinned := r.PinReads(ctx)
+
+	replica.ExpectQuery(`FROM stats_rollup_state`).WillReturnError(down)
+	primary.ExpectQuery(`FROM stats_rollup_state`).WillReturnRows(state())
+	primary.ExpectQuery(`FROM video_stats`).WithArgs(int64(7), otherWorkspace).WillReturnRows(historyRows())
+
+	if _, err := r.GetRollupState(pinned); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// even once the replica is back
+	checkReplica(t, r, replica)
+	if _, err := r.GetVideoHistory(pinned, 7, nil, nil); err != nil {
+		t.Fatalf("expected the history from the primary too, got %v", err)
+	}
+
+	// failing a later one fails it rather than reading the rest from the primary
+	pinned = r.PinReads(ctx)
+
+	replica.ExpectQuery(`FROM stats_rollup_state`).WillReturnRows(state())
+	replica.ExpectQuery(`FROM video_stats`).WithArgs(int64(7), otherWorkspace).WillReturnError(down)
+
+	if _, err := r.GetRollupState(pinned); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := r.GetVideoHistory(pinned, 7, nil, nil); !errors.Is(err, down) {
+		t.Fatalf("expected the replica failure, got %v", err)
+	}
+
+	for name, mock := range map[string]pgxmock.PgxPoolIface{"primary": primary, "replica": replica} {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package repo
+
+import (
+	"context"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+)
+
+// rollupLockKey is the transaction-level advisory lock of the rollup job, one replica at a time rolls up
+const rollupLockKey int64 = 0x7474_726f_6c6c // "ttroll"
+
+// LockRollups takes the rollup lock until the end of the transaction in ctx, false when another replica holds it
+func (r *Repository) LockRollups(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	var ok bool
+	if err := r.getDB(ctx).QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, rollupLockKey).Scan(&ok); err != nil {
+		return false, err
+	}
+
+	return ok, nil
+}
+
+// GetRollupState reads where the rollups and the retention stand. Not workspace scoped. Outside a transaction
+// it may come from the replica, the history reads pin it to the source of their rows (see PinReads).
+func (r *Repository) GetRollupState(ctx context.Context) (models.RollupState, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	var st models.RollupState
+	if err := r.readDB(ctx).QueryRow(ctx, `
+        SELECT rolled_up_to, raw_from, daily_from
+        FROM stats_rollup_state
+    `).Scan(&st.RolledUpTo, &st.RawFrom, &st.DailyFrom); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: GetRollupState query error: %v", err)
+		return models.RollupState{}, err
+	}
+
+	return st, nil
+}
+
+// UpdateRollupState records where the rollups and the retention stand
+func (r *Repository) UpdateRollupState(ctx context.Context, st models.RollupState) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	if _, err := r.getDB(ctx).Exec(ctx, `
+        UPDATE stats_rollup_state
+        SET
+            rolled_up_to = $1,
+            raw_from = $2,
+            daily_from = $3
+    `, st.RolledUpTo, st.RawFrom, st.DailyFrom); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: UpdateRollupState query error: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// RollupDailyStats rebuilds the daily rows of the snapshots captured in [from, to) of all workspaces;
+// from must start a UTC day so its buckets are whole. It returns the number of rows written.
+func (r *Repository) RollupDailyStats(ctx context.Context, from, to time.Time) (int64, error) {
+	// one day of snapshots of every video may take longer than one query timeout
+	tag, err := r.getDB(ctx).Exec(ctx, `
+        INSERT INTO video_stats_daily (video_id, bucket, captured_at, views, earnings, samples)
+        SELECT DISTINCT ON (video_id, bucket)
+            video_id, bucket, captured_at, views, earnings,
+            COUNT(*) OVER (PARTITION BY video_id, bucket)
+        FROM (
+            SELECT video_id, date_trunc('day', captured_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS bucket,
+                captured_at, views, earnings
+            FROM video_stats
+            WHERE captured_at >= $1
+                AND captured_at < $2
+        ) s
+        ORDER BY video_id, bucket, captured_at DESC
+        ON CONFLICT (video_id, bucket) DO UPDATE
+        SET
+            captured_at = EXCLUDED.captured_at,
+            views = EXCLUDED.views,
+            earnings = EXCLUDED.earnings,
+            samples = EXCLUDED.samples
+    `, from, to)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: RollupDailyStats query error: %v", err)
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// RollupWeeklyStats rebuilds the weekly rows from the daily buckets in [from, to) of all workspaces;
+// from must start an ISO week (Monday, UTC). It returns the number of rows written.
+func (r *Repository) RollupWeeklyStats(ctx context.Context, from, to time.Time) (int64, error) {
+	tag, err := r.getDB(ctx).Exec(ctx, `
+        INSERT INTO video_stats_weekly (video_id, bucket, captured_at, views, earnings, samples)
+        SELECT DISTINCT ON (video_id, bucket)
+            video_id, bucket, captured_at, views, earnings,
+            SUM(samples) OVER (PARTITION BY video_id, bucket)
+        FROM (
+            SELECT video_id, date_trunc('week', bucket AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS bucket,
+                captured_at, views, earnings, samples
+            FROM video_stats_daily
+            WHERE bucket >= $1
+                AND bucket < $2
+        ) d
+        ORDER BY video_id, bucket, captured_at DESC
+        ON CONFLICT (video_id, bucket) DO UPDATE
+        SET
+            captured_at = EXCLUDED.captured_at,
+            views = EXCLUDED.views,
+            earnings = EXCLUDED.earnings,
+            samples = EXCLUDED.samples
+    `, from, to)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: RollupWeeklyStats query error: %v", err)
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// DeleteRawStatsBefore removes up to limit snapshots of all workspaces captured before the cutoff
+// and returns how many it removed
+func (r *Repository) DeleteRawStatsBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	tag, err := r.getDB(ctx).Exec(ctx, `
+        DELETE FROM video_stats
+        WHERE (id, captured_at) IN (
+            SELECT id, captured_at
+            FROM video_stats
+            WHERE captured_at < $1
+            LIMIT $2
+        )
+    `, before, limit)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: DeleteRawStatsBefore query error: %v", err)
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// DeleteDailyStatsBefore removes up to limit daily rows of all workspaces with a bucket before the cutoff
+// and returns how many it removed
+func (r *Repository) DeleteDailyStatsBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	tag, err := r.getDB(ctx).Exec(ctx, `
+        DELETE FROM video_stats_daily
+        WHERE (video_id, bucket) IN (
+            SELECT video_id, bucket
+            FROM video_stats_daily
+            WHERE bucket < $1
+            LIMIT $2
+        )
+    `, before, limit)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: DeleteDailyStatsBefore query error: %v", err)
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// statsTable is the table holding the points of granularity: the raw snapshots or one of their rollups
+func statsTable(granularity string) string {
+	switch granularity {
+	case models.GranularityDay:
+		return "video_stats_daily"
+	case models.GranularityWeek:
+		return "video_stats_weekly"
+	default:
+		return "video_stats"
+	}
+}
+
+// StreamVideoRollups is StreamVideoHistory over the daily or weekly rollup; points are the last
+// snapshot of each bucket and are selected by its captured_at
+func (r *Repository) StreamVideoRollups(ctx context.Context, granularity string, videoID int64, from, to *time.Time, fn func(models.VideoStatPoint) error) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query, args := statsQuery(statsTable(granularity), videoID, workspaceID, from, to)
+
+	return r.streamStatPoints(ctx, query, args, fn)
+}
+
+// GetVideoRollups returns what StreamVideoRollups streams, read within the query timeout
+func (r *Repository) GetVideoRollups(ctx context.Context, granularity string, videoID int64, from, to *time.Time) ([]models.VideoStatPoint, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	var result []models.VideoStatPoint
+
+	err := r.StreamVideoRollups(ctx, granularity, videoID, from, to, func(p models.VideoStatPoint) error {
+		result = append(result, p)
+		return nil
+	})
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: GetVideoRollups video_id=%d error: %v", videoID, err)
+		return nil, err
+	}
+
+	return result, nil
+}
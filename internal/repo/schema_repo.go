@@ -0,0 +1,27 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SchemaVersion reads the golang-migrate bookkeeping table; version is 0 before the first migration
+func (r *Repository) SchemaVersion(ctx context.Context) (version uint, dirty bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `SELECT version, dirty FROM schema_migrations LIMIT 1`
+
+	var v int64
+	if err := r.getDB(ctx).QueryRow(ctx, query).Scan(&v, &dirty); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	return uint(v), dirty, nil
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"time"
 	"ttanalytic/internal/infrastructure/dbtx"
+	"ttanalytic/internal/logging"
 	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -18,12 +20,14 @@ type PgDriver interface {
 	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
 	QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
 	Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error)
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
 	Close()
 }
 type dbRunner interface {
 	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
 }
 
 // Logger is the interface for logging
@@ -34,9 +38,13 @@ type Logger interface {
 	Info(args ...any)
 }
 
-// Repository handles database operations
+// Repository handles database operations.
+// Every query is scoped to the workspace in ctx (see tenant.NewContext) except the
+// List*ForSync/ListVideosForUpdate scans of background jobs and the api key lookup.
+// History, list and analytics reads go through readDB and may be served by a replica (see WithReplica).
 type Repository struct {
 	db         PgDriver
+	replica    *replica
 	logger     Logger
 	timeoutSec int
 }
@@ -59,12 +67,18 @@ func NewRepository(db PgDriver, logger Logger, timeoutSec int) *Repository {
 
 // FindVideoByTikTokID fiend video - returns video with the db
 func (r *Repository) FindVideoByTikTokID(ctx context.Context, tikTokID string) (*models.Video, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
 	defer cancel()
 
 	query := `
     SELECT
         v.id,
+        v.workspace_id,
         v.tiktok_id,
         v.url,
         v.current_views,
@@ -73,14 +87,19 @@ func (r *Repository) FindVideoByTikTokID(ctx context.Context, tikTokID string) (
         v.updated_at,
 		v.tracking_status,
         v.last_error,
-        v.last_error_at
+        v.last_error_at,
+        v.creator_id,
+        v.posted_at
     FROM videos v
-    WHERE v.tiktok_id = $1
+    WHERE v.workspace_id = $1
+        AND v.tiktok_id = $2
+        AND v.deleted_at IS NULL
 `
 
 	var v models.Video
-	err := r.db.QueryRow(ctx, query, tikTokID).Scan(
+	err = r.db.QueryRow(ctx, query, workspaceID, tikTokID).Scan(
 		&v.ID,
+		&v.WorkspaceID,
 		&v.TikTokID,
 		&v.URL,
 		&v.CurrentViews,
@@ -90,6 +109,8 @@ func (r *Repository) FindVideoByTikTokID(ctx context.Context, tikTokID string) (
 		&v.TrackingStatus,
 		&v.LastError,
 		&v.LastErrorAt,
+		&v.CreatorID,
+		&v.PostedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -103,49 +124,74 @@ func (r *Repository) FindVideoByTikTokID(ctx context.Context, tikTokID string) (
 
 // CreateVideo creates a new video in the database
 func (r *Repository) CreateVideo(ctx context.Context, input models.CreateVideoInput) (*models.Video, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
 	defer cancel()
 
 	db := r.getDB(ctx)
 
 	query := `
-    INSERT INTO videos (tiktok_id, url, current_views, current_earnings)
-    VALUES ($1, $2, $3, $4)
+    INSERT INTO videos (workspace_id, tiktok_id, url, current_views, current_earnings, creator_id, posted_at)
+    VALUES ($1, $2, $3, $4, $5, $6, $7)
     RETURNING
         id,
+        workspace_id,
         tiktok_id,
         url,
         current_views,
         current_earnings,
         created_at,
-        updated_at
+        updated_at,
+        creator_id,
+        posted_at
 `
 
 	var v models.Video
-	err := db.QueryRow(
+	err = db.QueryRow(
 		ctx,
 		query,
+		workspaceID,
 		input.TikTokID,
 		input.URL,
 		input.CurrentViews,
 		input.CurrentEarnings,
+		input.CreatorID,
+		input.PostedAt,
 	).Scan(
 		&v.ID,
+		&v.WorkspaceID,
 		&v.TikTokID,
 		&v.URL,
 		&v.CurrentViews,
 		&v.CurrentEarnings,
 		&v.CreatedAt,
 		&v.UpdatedAt,
+		&v.CreatorID,
+		&v.PostedAt,
 	)
 	if err != nil {
-		r.logger.Errorf("CreateVideo query error: %v", err)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return nil, models.ErrAlreadyExists
+		}
+		logging.From(ctx, r.logger).Errorf("CreateVideo query error: %v", err)
 		return nil, err
 	}
 
 	return &v, nil
 }
+
+// AppendVideoStats writes a snapshot, ErrNotFound if the video belongs to another workspace
 func (r *Repository) AppendVideoStats(ctx context.Context, input models.CreateVideoStatsInput) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
 	defer cancel()
 
@@ -153,21 +199,33 @@ func (r *Repository) AppendVideoStats(ctx context.Context, input models.CreateVi
 
 	query := `
         INSERT INTO video_stats  (video_id, views, earnings)
-        VALUES ($1, $2, $3)
+        SELECT id, $2, $3
+        FROM videos
+        WHERE id = $1
+            AND workspace_id = $4
+            AND deleted_at IS NULL
     `
 
-	_, err := db.Exec(ctx, query,
+	tag, err := db.Exec(ctx, query,
 		input.VideoID,
 		input.Views,
 		input.Earnings,
+		workspaceID,
 	)
 	if err != nil {
-		r.logger.Errorf("Repository: AppendVideoStats query error: %v", err)
+		logging.From(ctx, r.logger).Errorf("Repository: AppendVideoStats query error: %v", err)
 		return err
 	}
 
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+
 	return nil
 }
+
+// ListVideosForUpdate scans all workspaces for the updater; callers scope follow-up writes
+// with tenant.NewContext(ctx, video.WorkspaceID)
 func (r *Repository) ListVideosForUpdate(ctx context.Context, minupdateage time.Duration, limit int) ([]models.Video, error) {
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
 	defer cancel()
@@ -178,6 +236,7 @@ func (r *Repository) ListVideosForUpdate(ctx context.Context, minupdateage time.
 	query := `
         SELECT
             id,
+            workspace_id,
             tiktok_id,
             url,
             current_views,
@@ -190,6 +249,7 @@ func (r *Repository) ListVideosForUpdate(ctx context.Context, minupdateage time.
         FROM videos
 		WHERE tracking_status = 'active'
   			AND updated_at <= $1
+  			AND deleted_at IS NULL
         ORDER BY updated_at ASC
         LIMIT $2
     `
@@ -206,6 +266,7 @@ func (r *Repository) ListVideosForUpdate(ctx context.Context, minupdateage time.
 		var v models.Video
 		if err := rows.Scan(
 			&v.ID,
+			&v.WorkspaceID,
 			&v.TikTokID,
 			&v.URL,
 			&v.CurrentViews,
@@ -227,7 +288,34 @@ func (r *Repository) ListVideosForUpdate(ctx context.Context, minupdateage time.
 
 	return result, nil
 }
+
+// OldestActiveVideoUpdate returns the updated_at of the least recently updated active video
+// across all workspaces, nil when there is none; it measures how far behind the updater is
+func (r *Repository) OldestActiveVideoUpdate(ctx context.Context) (*time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT min(updated_at)
+        FROM videos
+        WHERE tracking_status = 'active'
+            AND deleted_at IS NULL
+    `
+
+	var oldest *time.Time
+	if err := r.db.QueryRow(ctx, query).Scan(&oldest); err != nil {
+		return nil, err
+	}
+
+	return oldest, nil
+}
+
 func (r *Repository) UpdateVideoAggregates(ctx context.Context, input models.UpdateVideoAggregatesInput) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
 	defer cancel()
 
@@ -240,76 +328,78 @@ func (r *Repository) UpdateVideoAggregates(ctx context.Context, input models.Upd
             current_earnings = $2,
 			    updated_at  = NOW()
         WHERE id = $3
+            AND workspace_id = $4
+            AND deleted_at IS NULL
     `
 
-	_, err := db.Exec(ctx, query,
+	_, err = db.Exec(ctx, query,
 		input.Views,
 		input.Earnings,
 		input.VideoID,
+		workspaceID,
 	)
 	if err != nil {
-		r.logger.Errorf("Repository: UpdateVideoAggregates query error: %v", err)
+		logging.From(ctx, r.logger).Errorf("Repository: UpdateVideoAggregates query error: %v", err)
 		return err
 	}
 
 	return nil
 }
-func (r *Repository) GetVideoHistory(ctx context.Context, videoID int64, from, to *time.Time) ([]*models.VideoStatPoint, error) {
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
-	defer cancel()
-
-	db := r.getDB(ctx)
 
+// statsQuery selects the points of a video in [from, to) from video_stats or one of its rollups. The bounds
+// are plain comparisons on captured_at, left out when nil, so the planner prunes the video_stats partitions
+// outside them; a "$3 IS NULL OR captured_at >= $3" form would scan every month.
+func statsQuery(table string, videoID, workspaceID int64, from, to *time.Time) (string, []any) {
 	query := `
-        SELECT captured_at, views, earnings
-        FROM video_stats
-        WHERE video_id = $1
+        SELECT s.captured_at, s.views, s.earnings
+        FROM ` + table + ` s
+        JOIN videos v ON v.id = s.video_id
+        WHERE s.video_id = $1
+            AND v.workspace_id = $2
+            AND v.deleted_at IS NULL
     `
 
-	args := []any{videoID}
-	argPos := 2
+	args := []any{videoID, workspaceID}
 
 	if from != nil {
-		query += fmt.Sprintf(" AND captured_at >= $%d", argPos)
 		args = append(args, *from)
-		argPos++
+		query += fmt.Sprintf(" AND s.captured_at >= $%d", len(args))
 	}
 
 	if to != nil {
-		query += fmt.Sprintf(" AND captured_at < $%d", argPos)
 		args = append(args, *to)
-		argPos++
+		query += fmt.Sprintf(" AND s.captured_at < $%d", len(args))
 	}
 
-	query += " ORDER BY captured_at ASC"
+	return query + " ORDER BY s.captured_at ASC", args
+}
 
-	rows, err := db.Query(ctx, query, args...)
+func (r *Repository) streamStatPoints(ctx context.Context, query string, args []any, fn func(models.VideoStatPoint) error) error {
+	rows, err := r.readDB(ctx).Query(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var result []*models.VideoStatPoint
-
 	for rows.Next() {
-		var v models.VideoStatPoint
-		if err := rows.Scan(
-			&v.CapturedAt,
-			&v.Views,
-			&v.Earnings,
-		); err != nil {
-			return nil, err
+		var p models.VideoStatPoint
+		if err := rows.Scan(&p.CapturedAt, &p.Views, &p.Earnings); err != nil {
+			return err
 		}
-		result = append(result, &v)
-	}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+		if err := fn(p); err != nil {
+			return err
+		}
 	}
 
-	return result, nil
+	return rows.Err()
 }
 func (r *Repository) SetVideoErrorStatus(ctx context.Context, videoID int64, errText string) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
 	defer cancel()
 
@@ -323,21 +413,29 @@ func (r *Repository) SetVideoErrorStatus(ctx context.Context, videoID int64, err
             last_error_at   = NOW(),
             updated_at      = NOW()
         WHERE id = $3
+            AND workspace_id = $4
+            AND deleted_at IS NULL
     `
 
-	_, err := db.Exec(ctx, query,
+	_, err = db.Exec(ctx, query,
 		models.VideoStatusError,
 		errText,
 		videoID,
+		workspaceID,
 	)
 	if err != nil {
-		r.logger.Errorf("Repository: SetVideoErrorStatus video_id=%d error: %v", videoID, err)
+		logging.From(ctx, r.logger).Errorf("Repository: SetVideoErrorStatus video_id=%d error: %v", videoID, err)
 		return err
 	}
 
 	return nil
 }
 func (r *Repository) SetVideoStoppedStatus(ctx context.Context, videoID int64) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
 	defer cancel()
 
@@ -349,16 +447,139 @@ func (r *Repository) SetVideoStoppedStatus(ctx context.Context, videoID int64) e
             tracking_status = $1,
             updated_at      = NOW()
         WHERE id = $2
+            AND workspace_id = $3
+            AND deleted_at IS NULL
     `
 
-	_, err := db.Exec(ctx, query,
+	tag, err := db.Exec(ctx, query,
 		models.VideoStatusStopped,
 		videoID,
+		workspaceID,
 	)
 	if err != nil {
-		r.logger.Errorf("Repository: SetVideoStoppedStatus video_id=%d error: %v", videoID, err)
+		logging.From(ctx, r.logger).Errorf("Repository: SetVideoStoppedStatus video_id=%d error: %v", videoID, err)
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
 
 	return nil
 }
+
+// FindVideoByID returns the video of the workspace in ctx
+func (r *Repository) FindVideoByID(ctx context.Context, videoID int64) (*models.Video, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+    SELECT
+        v.id,
+        v.workspace_id,
+        v.tiktok_id,
+        v.url,
+        v.current_views,
+        v.current_earnings,
+        v.created_at,
+        v.updated_at,
+        v.tracking_status,
+        v.last_error,
+        v.last_error_at
+    FROM videos v
+    WHERE v.workspace_id = $1
+        AND v.id = $2
+        AND v.deleted_at IS NULL
+`
+
+	var v models.Video
+	err = r.getDB(ctx).QueryRow(ctx, query, workspaceID, videoID).Scan(
+		&v.ID,
+		&v.WorkspaceID,
+		&v.TikTokID,
+		&v.URL,
+		&v.CurrentViews,
+		&v.CurrentEarnings,
+		&v.CreatedAt,
+		&v.UpdatedAt,
+		&v.TrackingStatus,
+		&v.LastError,
+		&v.LastErrorAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// ListVideoStatsSince returns the snapshots of the workspace appended after the event after, of one video when
+// videoID is set, by captured_at. Ids are taken before commit, so a snapshot committed late can have a
+// lower id than one already sent: the snapshots captured up to window before after are returned again
+// too. The event itself is left out. Used to replay an SSE stream from Last-Event-ID; the plain bound on
+// captured_at lets the planner skip the video_stats partitions before it.
+func (r *Repository) ListVideoStatsSince(ctx context.Context, videoID *int64, after models.StatsEventID, window time.Duration, limit int) ([]models.VideoStatsEvent, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query, args := statsSinceQuery(workspaceID, videoID, after, window, limit)
+
+	rows, err := r.getDB(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.VideoStatsEvent
+
+	for rows.Next() {
+		var e models.VideoStatsEvent
+		if err := rows.Scan(
+			&e.ID,
+			&e.VideoID,
+			&e.WorkspaceID,
+			&e.Views,
+			&e.Earnings,
+			&e.CapturedAt,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// statsSinceQuery is the query of ListVideoStatsSince
+func statsSinceQuery(workspaceID int64, videoID *int64, after models.StatsEventID, window time.Duration, limit int) (string, []any) {
+	query := `
+        SELECT s.id, s.video_id, v.workspace_id, s.views, s.earnings, s.captured_at
+        FROM video_stats s
+        JOIN videos v ON v.id = s.video_id
+        WHERE v.workspace_id = $1
+            AND s.captured_at >= $2
+            AND s.id <> $3
+            AND v.deleted_at IS NULL
+            AND ($4::bigint IS NULL OR s.video_id = $4)
+        ORDER BY s.captured_at ASC, s.id ASC
+        LIMIT $5
+    `
+
+	return query, []any{workspaceID, after.CapturedAt.Add(-window), after.ID, videoID, limit}
+}
@@ -0,0 +1,280 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const watchColumns = `
+        id,
+        workspace_id,
+        kind,
+        value,
+        track_from,
+        allowed_creators,
+        tracking_status,
+        last_synced_at,
+        last_error,
+        last_error_at,
+        created_at,
+        updated_at
+`
+
+// CreateWatch registers a hashtag or sound, ErrAlreadyExists if the workspace watches it already
+func (r *Repository) CreateWatch(ctx context.Context, input models.CreateWatchInput) (*models.Watch, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	allowed := input.AllowedCreators
+	if allowed == nil {
+		allowed = []string{}
+	}
+
+	query := `
+    INSERT INTO watches (workspace_id, kind, value, track_from, allowed_creators)
+    VALUES ($1, $2, $3, $4, $5)
+    RETURNING` + watchColumns
+
+	w, err := scanWatch(r.getDB(ctx).QueryRow(ctx, query, workspaceID, input.Kind, input.Value, input.TrackFrom, allowed))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return nil, models.ErrAlreadyExists
+		}
+		logging.From(ctx, r.logger).Errorf("Repository: CreateWatch query error: %v", err)
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// GetWatch returns a watch by internal id
+func (r *Repository) GetWatch(ctx context.Context, watchID int64) (*models.Watch, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `SELECT` + watchColumns + `FROM watches WHERE id = $1 AND workspace_id = $2`
+
+	w, err := scanWatch(r.getDB(ctx).QueryRow(ctx, query, watchID, workspaceID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// GetWatchAggregates sums views and earnings over all videos matched by the watch
+func (r *Repository) GetWatchAggregates(ctx context.Context, watchID int64) (models.WatchAggregates, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return models.WatchAggregates{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT
+            COUNT(*),
+            COALESCE(SUM(v.current_views), 0),
+            COALESCE(SUM(v.current_earnings), 0)
+        FROM watch_videos wv
+        JOIN videos v ON v.id = wv.video_id
+        WHERE wv.watch_id = $1
+            AND v.workspace_id = $2
+            AND v.deleted_at IS NULL
+    `
+
+	var agg models.WatchAggregates
+	if err := r.readDB(ctx).QueryRow(ctx, query, watchID, workspaceID).Scan(
+		&agg.VideoCount,
+		&agg.TotalViews,
+		&agg.TotalEarnings,
+	); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: GetWatchAggregates watch_id=%d error: %v", watchID, err)
+		return models.WatchAggregates{}, err
+	}
+
+	return agg, nil
+}
+
+// ListWatchesForSync returns active watches of all workspaces not synced for at least minSyncAge,
+// never-synced first
+func (r *Repository) ListWatchesForSync(ctx context.Context, minSyncAge time.Duration, limit int) ([]models.Watch, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	cutoff := time.Now().Add(-minSyncAge)
+
+	query := `SELECT` + watchColumns + `
+        FROM watches
+        WHERE tracking_status = 'active'
+            AND (last_synced_at IS NULL OR last_synced_at <= $1)
+        ORDER BY last_synced_at ASC NULLS FIRST
+        LIMIT $2
+    `
+
+	rows, err := r.db.Query(ctx, query, cutoff, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.Watch, 0, limit)
+
+	for rows.Next() {
+		w, err := scanWatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// LinkWatchVideo attributes a video to a watch, false if it was linked already.
+// ErrNotFound if the watch or the video belongs to another workspace.
+func (r *Repository) LinkWatchVideo(ctx context.Context, watchID, videoID int64) (bool, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        WITH pair AS (
+            SELECT w.id AS watch_id, v.id AS video_id
+            FROM watches w
+            JOIN videos v ON v.workspace_id = w.workspace_id
+            WHERE w.id = $1
+                AND v.id = $2
+                AND w.workspace_id = $3
+                AND v.deleted_at IS NULL
+        ), linked AS (
+            INSERT INTO watch_videos (watch_id, video_id)
+            SELECT watch_id, video_id FROM pair
+            ON CONFLICT DO NOTHING
+            RETURNING 1
+        )
+        SELECT (SELECT COUNT(*) FROM pair), (SELECT COUNT(*) FROM linked)
+    `
+
+	var found, linked int
+	if err := r.getDB(ctx).QueryRow(ctx, query, watchID, videoID, workspaceID).Scan(&found, &linked); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: LinkWatchVideo watch_id=%d video_id=%d error: %v", watchID, videoID, err)
+		return false, err
+	}
+
+	if found == 0 {
+		return false, models.ErrNotFound
+	}
+
+	return linked > 0, nil
+}
+
+// MarkWatchSynced records a successful discovery pass and clears the last error
+func (r *Repository) MarkWatchSynced(ctx context.Context, watchID int64) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE watches
+        SET
+            last_synced_at = NOW(),
+            last_error     = NULL,
+            last_error_at  = NULL,
+            updated_at     = NOW()
+        WHERE id = $1
+            AND workspace_id = $2
+    `
+
+	if _, err := r.getDB(ctx).Exec(ctx, query, watchID, workspaceID); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: MarkWatchSynced watch_id=%d error: %v", watchID, err)
+		return err
+	}
+
+	return nil
+}
+
+// SetWatchSyncError keeps the watch active but records why the last pass failed
+func (r *Repository) SetWatchSyncError(ctx context.Context, watchID int64, errText string) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE watches
+        SET
+            last_synced_at = NOW(),
+            last_error     = $1,
+            last_error_at  = NOW(),
+            updated_at     = NOW()
+        WHERE id = $2
+            AND workspace_id = $3
+    `
+
+	if _, err := r.getDB(ctx).Exec(ctx, query, errText, watchID, workspaceID); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: SetWatchSyncError watch_id=%d error: %v", watchID, err)
+		return err
+	}
+
+	return nil
+}
+
+func scanWatch(row pgx.Row) (*models.Watch, error) {
+	var w models.Watch
+	if err := row.Scan(
+		&w.ID,
+		&w.WorkspaceID,
+		&w.Kind,
+		&w.Value,
+		&w.TrackFrom,
+		&w.AllowedCreators,
+		&w.TrackingStatus,
+		&w.LastSyncedAt,
+		&w.LastError,
+		&w.LastErrorAt,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &w, nil
+}
@@ -0,0 +1,392 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const webhookColumns = `
+        id,
+        workspace_id,
+        url,
+        secret,
+        events,
+        created_at
+`
+
+const deliveryColumns = `
+        id,
+        workspace_id,
+        webhook_id,
+        event_id,
+        event_type,
+        payload,
+        status,
+        attempts,
+        next_attempt_at,
+        last_status_code,
+        last_error,
+        delivered_at,
+        created_at
+`
+
+// CreateWebhook subscribes a url of the workspace to events
+func (r *Repository) CreateWebhook(ctx context.Context, input models.CreateWebhookInput) (*models.Webhook, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+    INSERT INTO webhooks (workspace_id, url, secret, events)
+    VALUES ($1, $2, $3, $4)
+    RETURNING` + webhookColumns
+
+	w, err := scanWebhook(r.getDB(ctx).QueryRow(ctx, query, workspaceID, input.URL, input.Secret, input.Events))
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: CreateWebhook query error: %v", err)
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// GetWebhook returns a subscription of the workspace by id
+func (r *Repository) GetWebhook(ctx context.Context, webhookID int64) (*models.Webhook, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `SELECT` + webhookColumns + `FROM webhooks WHERE id = $1 AND workspace_id = $2`
+
+	w, err := scanWebhook(r.getDB(ctx).QueryRow(ctx, query, webhookID, workspaceID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// ListWebhooks returns all subscriptions of the workspace, newest first
+func (r *Repository) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `SELECT` + webhookColumns + `FROM webhooks WHERE workspace_id = $1 ORDER BY id DESC`
+
+	rows, err := r.getDB(ctx).Query(ctx, query, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.Webhook
+
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteWebhook removes the subscription together with its pending deliveries and log
+func (r *Repository) DeleteWebhook(ctx context.Context, webhookID int64) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `DELETE FROM webhooks WHERE id = $1 AND workspace_id = $2`
+
+	tag, err := r.getDB(ctx).Exec(ctx, query, webhookID, workspaceID)
+	if err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: DeleteWebhook webhook_id=%d error: %v", webhookID, err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+// EnqueueWebhookEvent writes one pending delivery per subscription of the workspace
+// interested in the event. Pass the transaction ctx of the state change.
+func (r *Repository) EnqueueWebhookEvent(ctx context.Context, event models.Event) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO webhook_deliveries (workspace_id, webhook_id, event_id, event_type, payload)
+        SELECT workspace_id, id, $2, $3, $4
+        FROM webhooks
+        WHERE workspace_id = $1
+            AND $3 = ANY(events)
+        ON CONFLICT (webhook_id, event_id) DO NOTHING
+    `
+
+	if _, err := r.getDB(ctx).Exec(ctx, query, workspaceID, event.ID, event.Type, event.Payload); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: EnqueueWebhookEvent %s %s error: %v", event.Type, event.ID, err)
+		return err
+	}
+
+	return nil
+}
+
+// ListWebhookDeliveries returns the delivery log of a subscription, newest first
+func (r *Repository) ListWebhookDeliveries(ctx context.Context, webhookID int64, limit int) ([]models.WebhookDelivery, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `SELECT` + deliveryColumns + `
+        FROM webhook_deliveries
+        WHERE webhook_id = $1
+            AND workspace_id = $2
+        ORDER BY id DESC
+        LIMIT $3
+    `
+
+	rows, err := r.getDB(ctx).Query(ctx, query, webhookID, workspaceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.WebhookDelivery, 0, limit)
+
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ClaimDueDeliveries leases pending deliveries of all workspaces for the dispatcher.
+// Claimed rows are pushed lease into the future and their attempt counted, so another
+// replica does not pick them up and a crash mid-delivery still ends in a retry.
+func (r *Repository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        WITH due AS (
+            SELECT id
+            FROM webhook_deliveries
+            WHERE status = 'pending'
+                AND next_attempt_at <= NOW()
+            ORDER BY next_attempt_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        UPDATE webhook_deliveries d
+        SET
+            attempts        = d.attempts + 1,
+            next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+        FROM due, webhooks w
+        WHERE d.id = due.id
+            AND w.id = d.webhook_id
+        RETURNING
+            d.id,
+            d.workspace_id,
+            d.webhook_id,
+            d.event_id,
+            d.event_type,
+            d.payload,
+            d.attempts,
+            w.url,
+            w.secret
+    `
+
+	rows, err := r.db.Query(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.WebhookDelivery, 0, limit)
+
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := rows.Scan(
+			&d.ID,
+			&d.WorkspaceID,
+			&d.WebhookID,
+			&d.EventID,
+			&d.EventType,
+			&d.Payload,
+			&d.Attempts,
+			&d.URL,
+			&d.Secret,
+		); err != nil {
+			return nil, err
+		}
+		d.Status = models.DeliveryPending
+		result = append(result, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// MarkDeliverySucceeded records a 2xx response from the receiver
+func (r *Repository) MarkDeliverySucceeded(ctx context.Context, deliveryID int64, statusCode int) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE webhook_deliveries
+        SET
+            status           = 'delivered',
+            last_status_code = $1,
+            last_error       = NULL,
+            next_attempt_at  = NULL,
+            delivered_at     = NOW()
+        WHERE id = $2
+            AND workspace_id = $3
+    `
+
+	if _, err := r.getDB(ctx).Exec(ctx, query, statusCode, deliveryID, workspaceID); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: MarkDeliverySucceeded delivery_id=%d error: %v", deliveryID, err)
+		return err
+	}
+
+	return nil
+}
+
+// MarkDeliveryFailed schedules a retry at nextAttemptAt, or gives up when it is nil.
+// statusCode is 0 when the receiver could not be reached.
+func (r *Repository) MarkDeliveryFailed(ctx context.Context, deliveryID int64, statusCode int, errText string, nextAttemptAt *time.Time) error {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	status := models.DeliveryPending
+	if nextAttemptAt == nil {
+		status = models.DeliveryFailed
+	}
+
+	var code *int
+	if statusCode > 0 {
+		code = &statusCode
+	}
+
+	query := `
+        UPDATE webhook_deliveries
+        SET
+            status           = $1,
+            last_status_code = $2,
+            last_error       = $3,
+            next_attempt_at  = $4
+        WHERE id = $5
+            AND workspace_id = $6
+    `
+
+	if _, err := r.getDB(ctx).Exec(ctx, query, status, code, errText, nextAttemptAt, deliveryID, workspaceID); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: MarkDeliveryFailed delivery_id=%d error: %v", deliveryID, err)
+		return err
+	}
+
+	return nil
+}
+
+func scanWebhook(row pgx.Row) (*models.Webhook, error) {
+	var w models.Webhook
+	if err := row.Scan(
+		&w.ID,
+		&w.WorkspaceID,
+		&w.URL,
+		&w.Secret,
+		&w.Events,
+		&w.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+func scanDelivery(row pgx.Row) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	if err := row.Scan(
+		&d.ID,
+		&d.WorkspaceID,
+		&d.WebhookID,
+		&d.EventID,
+		&d.EventType,
+		&d.Payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.LastStatusCode,
+		&d.LastError,
+		&d.DeliveredAt,
+		&d.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// EnsureWorkspace returns the workspace with the given name, creating it if needed
+func (r *Repository) EnsureWorkspace(ctx context.Context, name string) (*models.Workspace, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	// the no-op update makes RETURNING yield the existing row on conflict
+	query := `
+    INSERT INTO workspaces (name)
+    VALUES ($1)
+    ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+    RETURNING id, name, created_at
+`
+
+	var w models.Workspace
+	if err := r.getDB(ctx).QueryRow(ctx, query, name).Scan(&w.ID, &w.Name, &w.CreatedAt); err != nil {
+		logging.From(ctx, r.logger).Errorf("Repository: EnsureWorkspace name=%s error: %v", name, err)
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+// FindWorkspaceByName returns ErrNotFound for unknown names
+func (r *Repository) FindWorkspaceByName(ctx context.Context, name string) (*models.Workspace, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.timeoutSec)*time.Second)
+	defer cancel()
+
+	query := `SELECT id, name, created_at FROM workspaces WHERE name = $1`
+
+	var w models.Workspace
+	if err := r.getDB(ctx).QueryRow(ctx, query, name).Scan(&w.ID, &w.Name, &w.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &w, nil
+}
@@ -0,0 +1,366 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Errorf(string, ...any) {}
+func (nopLogger) Warnf(string, ...any)  {}
+func (nopLogger) Infof(string, ...any)  {}
+func (nopLogger) Info(...any)           {}
+
+const otherWorkspace int64 = 2
+
+func newMockRepository(t *testing.T) (*Repository, pgxmock.PgxPoolIface) {
+	t.Helper()
+
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("pgxmock: %v", err)
+	}
+	t.Cleanup(mock.Close)
+
+	return NewRepository(mock, nopLogger{}, 5), mock
+}
+
+// workspace scoped methods must refuse to run without a workspace instead of reading everything
+func TestRepository_ScopedQueriesRequireWorkspace(t *testing.T) {
+	calls := map[string]func(ctx context.Context, r *Repository) error{
+		"FindVideoByTikTokID": func(ctx context.Context, r *Repository) error {
+			_, err := r.FindVideoByTikTokID(ctx, "7301")
+			return err
+		},
+		"CreateVideo": func(ctx context.Context, r *Repository) error {
+			_, err := r.CreateVideo(ctx, models.CreateVideoInput{TikTokID: "7301"})
+			return err
+		},
+		"AppendVideoStats": func(ctx context.Context, r *Repository) error {
+			return r.AppendVideoStats(ctx, models.CreateVideoStatsInput{VideoID: 1})
+		},
+		"UpdateVideoAggregates": func(ctx context.Context, r *Repository) error {
+			return r.UpdateVideoAggregates(ctx, models.UpdateVideoAggregatesInput{VideoID: 1})
+		},
+		"StreamVideoHistory": func(ctx context.Context, r *Repository) error {
+			return r.StreamVideoHistory(ctx, 1, nil, nil, nil)
+		},
+		"StreamVideoRollups": func(ctx context.Context, r *Repository) error {
+			return r.StreamVideoRollups(ctx, models.GranularityDay, 1, nil, nil, nil)
+		},
+		"GetVideoHistory": func(ctx context.Context, r *Repository) error {
+			_, err := r.GetVideoHistory(ctx, 1, nil, nil)
+			return err
+		},
+		"GetVideoRollups": func(ctx context.Context, r *Repository) error {
+			_, err := r.GetVideoRollups(ctx, models.GranularityDay, 1, nil, nil)
+			return err
+		},
+		"SetVideoErrorStatus": func(ctx context.Context, r *Repository) error {
+			return r.SetVideoErrorStatus(ctx, 1, "boom")
+		},
+		"SetVideoStoppedStatus": func(ctx context.Context, r *Repository) error {
+			return r.SetVideoStoppedStatus(ctx, 1)
+		},
+		"GetCreator": func(ctx context.Context, r *Repository) error {
+			_, err := r.GetCreator(ctx, 1)
+			return err
+		},
+		"GetCreatorAggregates": func(ctx context.Context, r *Repository) error {
+			_, err := r.GetCreatorAggregates(ctx, 1)
+			return err
+		},
+		"GetWatch": func(ctx context.Context, r *Repository) error {
+			_, err := r.GetWatch(ctx, 1)
+			return err
+		},
+		"LinkWatchVideo": func(ctx context.Context, r *Repository) error {
+			_, err := r.LinkWatchVideo(ctx, 1, 1)
+			return err
+		},
+		"ListAPIKeys": func(ctx context.Context, r *Repository) error {
+			_, err := r.ListAPIKeys(ctx)
+			return err
+		},
+		"RevokeAPIKey": func(ctx context.Context, r *Repository) error {
+			return r.RevokeAPIKey(ctx, 1)
+		},
+		"SoftDeleteVideo": func(ctx context.Context, r *Repository) error {
+			return r.SoftDeleteVideo(ctx, 1)
+		},
+		"DeleteVideos": func(ctx context.Context, r *Repository) error {
+			_, err := r.DeleteVideos(ctx, []int64{1})
+			return err
+		},
+		"AttachVideoToCreator": func(ctx context.Context, r *Repository) error {
+			return r.AttachVideoToCreator(ctx, 1, 7, time.Now())
+		},
+		"RedactAuditUsername": func(ctx context.Context, r *Repository) error {
+			_, err := r.RedactAuditUsername(ctx, "someuser")
+			return err
+		},
+		"ListVideoIDsByUsername": func(ctx context.Context, r *Repository) error {
+			_, err := r.ListVideoIDsByUsername(ctx, "someuser")
+			return err
+		},
+		"AppendAuditEntry": func(ctx context.Context, r *Repository) error {
+			_, err := r.AppendAuditEntry(ctx, models.CreateAuditEntryInput{Action: models.AuditVideoDeleted})
+			return err
+		},
+		"ListAuditEntries": func(ctx context.Context, r *Repository) error {
+			_, err := r.ListAuditEntries(ctx, models.AuditFilter{Limit: 10})
+			return err
+		},
+		"GetAPIKey": func(ctx context.Context, r *Repository) error {
+			_, err := r.GetAPIKey(ctx, 1)
+			return err
+		},
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			r, mock := newMockRepository(t)
+
+			err := call(context.Background(), r)
+			if !errors.Is(err, models.ErrForbidden) {
+				t.Fatalf("expected ErrForbidden without workspace, got %v", err)
+			}
+
+			// no query may reach the database
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestRepository_FindVideoByTikTokID_OtherWorkspaceNotFound(t *testing.T) {
+	r, mock := newMockRepository(t)
+	ctx := tenant.NewContext(context.Background(), otherWorkspace)
+
+	// the video exists in workspace 1 only, the lookup is bound to workspace 2
+	mock.ExpectQuery(`WHERE v.workspace_id = \$1\s+AND v.tiktok_id = \$2`).
+		WithArgs(otherWorkspace, "7301").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+
+	_, err := r.FindVideoByTikTokID(ctx, "7301")
+	if !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRepository_CreateVideo_UsesContextWorkspace(t *testing.T) {
+	r, mock := newMockRepository(t)
+	ctx := tenant.NewContext(context.Background(), otherWorkspace)
+	now := time.Now()
+
+	mock.ExpectQuery(`INSERT INTO videos \(workspace_id,`).
+		WithArgs(otherWorkspace, "7301", "url", int64(10), 0.001, pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "workspace_id", "tiktok_id", "url", "current_views", "current_earnings",
+			"created_at", "updated_at", "creator_id", "posted_at",
+		}).AddRow(int64(5), otherWorkspace, "7301", "url", int64(10), 0.001, now, now, nil, nil))
+
+	video, err := r.CreateVideo(ctx, models.CreateVideoInput{
+		TikTokID:        "7301",
+		URL:             "url",
+		CurrentViews:    10,
+		CurrentEarnings: 0.001,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if video.WorkspaceID != otherWorkspace {
+		t.Fatalf("expected workspace %d, got %d", otherWorkspace, video.WorkspaceID)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRepository_CrossWorkspaceWritesAreRejected(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), otherWorkspace)
+
+	t.Run("AppendVideoStats", func(t *testing.T) {
+		r, mock := newMockRepository(t)
+
+		mock.ExpectExec(`INSERT INTO video_stats`).
+			WithArgs(int64(1), int64(100), 0.01, otherWorkspace).
+			WillReturnResult(pgxmock.NewResult("INSERT", 0))
+
+		err := r.AppendVideoStats(ctx, models.CreateVideoStatsInput{VideoID: 1, Views: 100, Earnings: 0.01})
+		if !errors.Is(err, models.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("SetVideoStoppedStatus", func(t *testing.T) {
+		r, mock := newMockRepository(t)
+
+		mock.ExpectExec(`UPDATE videos`).
+			WithArgs(models.VideoStatusStopped, int64(1), otherWorkspace).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		if err := r.SetVideoStoppedStatus(ctx, 1); !errors.Is(err, models.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("SoftDeleteVideo", func(t *testing.T) {
+		r, mock := newMockRepository(t)
+
+		mock.ExpectExec(`UPDATE videos`).
+			WithArgs(int64(1), otherWorkspace).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		if err := r.SoftDeleteVideo(ctx, 1); !errors.Is(err, models.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("LinkWatchVideo", func(t *testing.T) {
+		r, mock := newMockRepository(t)
+
+		mock.ExpectQuery(`WITH pair AS`).
+			WithArgs(int64(3), int64(1), otherWorkspace).
+			WillReturnRows(pgxmock.NewRows([]string{"found", "linked"}).AddRow(0, 0))
+
+		if _, err := r.LinkWatchVideo(ctx, 3, 1); !errors.Is(err, models.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("RevokeAPIKey", func(t *testing.T) {
+		r, mock := newMockRepository(t)
+
+		mock.ExpectExec(`UPDATE api_keys`).
+			WithArgs(int64(9), otherWorkspace).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		if err := r.RevokeAPIKey(ctx, 9); !errors.Is(err, models.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestRepository_StreamVideoHistory_JoinsWorkspace(t *testing.T) {
+	r, mock := newMockRepository(t)
+	ctx := tenant.NewContext(context.Background(), otherWorkspace)
+
+	mock.ExpectQuery(`FROM video_stats s\s+JOIN videos v ON v.id = s.video_id\s+WHERE s.video_id = \$1\s+AND v.workspace_id = \$2`).
+		WithArgs(int64(1), otherWorkspace).
+		WillReturnRows(pgxmock.NewRows([]string{"captured_at", "views", "earnings"}))
+
+	// rollups are scoped the same way
+	mock.ExpectQuery(`FROM video_stats_weekly s\s+JOIN videos v ON v.id = s.video_id\s+WHERE s.video_id = \$1\s+AND v.workspace_id = \$2`).
+		WithArgs(int64(1), otherWorkspace).
+		WillReturnRows(pgxmock.NewRows([]string{"captured_at", "views", "earnings"}))
+
+	points := 0
+	count := func(models.VideoStatPoint) error {
+		points++
+		return nil
+	}
+
+	if err := r.StreamVideoHistory(ctx, 1, nil, nil, count); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.StreamVideoRollups(ctx, models.GranularityWeek, 1, nil, nil, count); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if points != 0 {
+		t.Fatalf("expected no points from another workspace, got %d", points)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// the buffered history is bounded by the query timeout, the streamed one only by the caller
+func TestRepository_BufferedHistoryHasQueryTimeout(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("pgxmock: %v", err)
+	}
+	t.Cleanup(mock.Close)
+
+	r := NewRepository(mock, nopLogger{}, 1)
+	ctx := tenant.NewContext(context.Background(), otherWorkspace)
+
+	points := func() *pgxmock.Rows {
+		return pgxmock.NewRows([]string{"captured_at", "views", "earnings"}).AddRow(time.Now(), int64(10), 0.01)
+	}
+
+	mock.ExpectQuery(`FROM video_stats`).WithArgs(int64(7), otherWorkspace).WillReturnRows(points()).WillDelayFor(1100 * time.Millisecond)
+	if _, err := r.GetVideoHistory(ctx, 7, nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the query timeout, got %v", err)
+	}
+
+	mock.ExpectQuery(`FROM video_stats`).WithArgs(int64(7), otherWorkspace).WillReturnRows(points()).WillDelayFor(1100 * time.Millisecond)
+	if err := r.StreamVideoHistory(ctx, 7, nil, nil, func(models.VideoStatPoint) error { return nil }); err != nil {
+		t.Fatalf("expected the stream to outlive the query timeout, got %v", err)
+	}
+}
+
+func TestRepository_ListAuditEntries_ScopesAndFilters(t *testing.T) {
+	r, mock := newMockRepository(t)
+	ctx := tenant.NewContext(context.Background(), otherWorkspace)
+	targetID := int64(7)
+
+	mock.ExpectQuery(`FROM audit_log\s+WHERE workspace_id = \$1 AND action = \$2 AND target_type = \$3 AND target_id = \$4 AND id < \$5\s+ORDER BY id DESC\s+LIMIT \$6`).
+		WithArgs(otherWorkspace, models.AuditVideoStopped, models.AuditTargetVideo, targetID, int64(100), 20).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "workspace_id", "actor", "action", "target_type", "target_id", "request_id", "before", "after", "details", "created_at",
+		}))
+
+	entries, err := r.ListAuditEntries(ctx, models.AuditFilter{
+		Action:     models.AuditVideoStopped,
+		TargetType: models.AuditTargetVideo,
+		TargetID:   &targetID,
+		BeforeID:   100,
+		Limit:      20,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no entries, got %d", len(entries))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,167 @@
+//go:generate mockgen -destination=../mocks/api_key_mocks.go -package=mocks ttanalytic/internal/service APIKeyRepository
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"ttanalytic/internal/auth"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, input models.CreateAPIKeyInput) (*models.APIKey, error)
+	GetAPIKey(ctx context.Context, keyID int64) (*models.APIKey, error)
+	FindAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID int64) error
+	TouchAPIKey(ctx context.Context, keyID int64) error
+}
+
+type APIKeyService struct {
+	repo       APIKeyRepository
+	logger     Logger
+	transactor Transactor
+	audit      AuditRecorder
+}
+
+func NewAPIKeyService(repo APIKeyRepository, logger Logger, transactor Transactor, audit AuditRecorder) *APIKeyService {
+	return &APIKeyService{
+		repo:       repo,
+		logger:     logger,
+		transactor: transactor,
+		audit:      auditOrNoop(audit),
+	}
+}
+
+// Authenticate resolves a raw key; unknown and revoked keys are ErrUnauthorized
+func (s *APIKeyService) Authenticate(ctx context.Context, rawKey string) (*models.APIKey, error) {
+	rawKey = strings.TrimSpace(rawKey)
+	if rawKey == "" {
+		return nil, fmt.Errorf("missing api key: %w", models.ErrUnauthorized)
+	}
+
+	key, err := s.repo.FindAPIKeyByHash(ctx, auth.HashKey(rawKey))
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, fmt.Errorf("unknown api key: %w", models.ErrUnauthorized)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if key.RevokedAt != nil {
+		return nil, fmt.Errorf("api key %d revoked: %w", key.ID, models.ErrUnauthorized)
+	}
+
+	if err := s.repo.TouchAPIKey(ctx, key.ID); err != nil {
+		logging.From(ctx, s.logger).Warnf("Authenticate: touch api key %d: %v", key.ID, err)
+	}
+
+	return key, nil
+}
+
+// CreateAPIKey mints a key; the raw value is only available in this response
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, req models.CreateAPIKeyRequest) (models.CreateAPIKeyResponse, error) {
+	raw, prefix, hash, err := auth.GenerateKey()
+	if err != nil {
+		return models.CreateAPIKeyResponse{}, err
+	}
+
+	var key *models.APIKey
+
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		key, err = s.repo.CreateAPIKey(txCtx, models.CreateAPIKeyInput{
+			Name:   req.Name,
+			Prefix: prefix,
+			Hash:   hash,
+			Scopes: req.Scopes,
+		})
+		if err != nil {
+			return err
+		}
+
+		// the response without the raw key, which is never stored
+		return recordChange(txCtx, s.audit, models.AuditAPIKeyCreated, models.AuditTargetAPIKey, key.ID, nil, buildAPIKeyResponse(*key))
+	})
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("CreateAPIKey: repo error: %v", err)
+		return models.CreateAPIKeyResponse{}, err
+	}
+
+	logging.From(ctx, s.logger).Infof("CreateAPIKey: key %d (%s) created with scopes %v", key.ID, key.Prefix, key.Scopes)
+
+	return models.CreateAPIKeyResponse{
+		APIKeyResponse: buildAPIKeyResponse(*key),
+		Key:            raw,
+	}, nil
+}
+
+func (s *APIKeyService) ListAPIKeys(ctx context.Context) ([]models.APIKeyResponse, error) {
+	keys, err := s.repo.ListAPIKeys(ctx)
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("ListAPIKeys: repo error: %v", err)
+		return nil, err
+	}
+
+	resp := make([]models.APIKeyResponse, 0, len(keys))
+	for _, k := range keys {
+		resp = append(resp, buildAPIKeyResponse(k))
+	}
+
+	return resp, nil
+}
+
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, keyID int64) error {
+	err := s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		before, err := s.repo.GetAPIKey(txCtx, keyID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.repo.RevokeAPIKey(txCtx, keyID); err != nil {
+			return err
+		}
+
+		after, err := s.repo.GetAPIKey(txCtx, keyID)
+		if err != nil {
+			return err
+		}
+
+		return recordChange(txCtx, s.audit, models.AuditAPIKeyRevoked, models.AuditTargetAPIKey, keyID, buildAPIKeyResponse(*before), buildAPIKeyResponse(*after))
+	})
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("RevokeAPIKey: key %d: %v", keyID, err)
+		return err
+	}
+
+	logging.From(ctx, s.logger).Infof("RevokeAPIKey: key %d revoked", keyID)
+
+	return nil
+}
+
+func buildAPIKeyResponse(k models.APIKey) models.APIKeyResponse {
+	resp := models.APIKeyResponse{
+		KeyID:       k.ID,
+		WorkspaceID: k.WorkspaceID,
+		Name:        k.Name,
+		Prefix:      k.Prefix,
+		Scopes:      k.Scopes,
+		CreatedAt:   k.CreatedAt.UTC().Format(time.RFC3339),
+	}
+
+	if k.LastUsedAt != nil {
+		usedAt := k.LastUsedAt.UTC().Format(time.RFC3339)
+		resp.LastUsedAt = &usedAt
+	}
+
+	if k.RevokedAt != nil {
+		revokedAt := k.RevokedAt.UTC().Format(time.RFC3339)
+		resp.RevokedAt = &revokedAt
+	}
+
+	return resp
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"ttanalytic/internal/auth"
+	"ttanalytic/internal/mocks"
+	"ttanalytic/internal/models"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	revokedAt := time.Now()
+
+	tests := []struct {
+		name    string
+		rawKey  string
+		setup   func(repo *mocks.MockAPIKeyRepository)
+		wantErr error
+		wantKey bool
+	}{
+		{
+			name:    "missing key",
+			rawKey:  "  ",
+			setup:   func(repo *mocks.MockAPIKeyRepository) {},
+			wantErr: models.ErrUnauthorized,
+		},
+		{
+			name:   "unknown key",
+			rawKey: "tta_unknown",
+			setup: func(repo *mocks.MockAPIKeyRepository) {
+				repo.EXPECT().FindAPIKeyByHash(gomock.Any(), auth.HashKey("tta_unknown")).Return(nil, models.ErrNotFound)
+			},
+			wantErr: models.ErrUnauthorized,
+		},
+		{
+			name:   "revoked key",
+			rawKey: "tta_revoked",
+			setup: func(repo *mocks.MockAPIKeyRepository) {
+				repo.EXPECT().FindAPIKeyByHash(gomock.Any(), auth.HashKey("tta_revoked")).
+					Return(&models.APIKey{ID: 2, RevokedAt: &revokedAt}, nil)
+			},
+			wantErr: models.ErrUnauthorized,
+		},
+		{
+			name:   "db error is not unauthorized",
+			rawKey: "tta_any",
+			setup: func(repo *mocks.MockAPIKeyRepository) {
+				repo.EXPECT().FindAPIKeyByHash(gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+		},
+		{
+			name:   "active key",
+			rawKey: "tta_valid",
+			setup: func(repo *mocks.MockAPIKeyRepository) {
+				repo.EXPECT().FindAPIKeyByHash(gomock.Any(), auth.HashKey("tta_valid")).
+					Return(&models.APIKey{ID: 3, Scopes: []string{models.ScopeRead}}, nil)
+				repo.EXPECT().TouchAPIKey(gomock.Any(), int64(3)).Return(nil)
+			},
+			wantKey: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockAPIKeyRepository(ctrl)
+			logger := mocks.NewMockLogger(ctrl)
+			tt.setup(repo)
+
+			key, err := NewAPIKeyService(repo, logger, nil, nil).Authenticate(context.Background(), tt.rawKey)
+
+			switch {
+			case tt.wantKey:
+				if err != nil || key == nil || key.ID != 3 {
+					t.Fatalf("expected key 3, got %+v, %v", key, err)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+			default:
+				if err == nil || errors.Is(err, models.ErrUnauthorized) {
+					t.Fatalf("expected internal error, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_CreateAPIKey_StoresOnlyHash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockAPIKeyRepository(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	var stored models.CreateAPIKeyInput
+
+	repo.EXPECT().
+		CreateAPIKey(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, input models.CreateAPIKeyInput) (*models.APIKey, error) {
+			stored = input
+			return &models.APIKey{ID: 1, Name: input.Name, Prefix: input.Prefix, Scopes: input.Scopes, CreatedAt: time.Now()}, nil
+		})
+
+	transactor := mocks.NewMockTransactor(ctrl)
+	transactor.EXPECT().
+		WithinTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+
+	// the audit entry describes the key without its secret
+	audit := mocks.NewMockAuditRecorder(ctrl)
+	audit.EXPECT().AppendAuditEntry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, in models.CreateAuditEntryInput) (int64, error) {
+			if _, ok := in.After.(models.APIKeyResponse); !ok || in.Action != models.AuditAPIKeyCreated {
+				t.Fatalf("unexpected audit entry: %+v", in)
+			}
+			return 1, nil
+		})
+
+	resp, err := NewAPIKeyService(repo, logger, transactor, audit).CreateAPIKey(context.Background(), models.CreateAPIKeyRequest{
+		Name:   "dashboard",
+		Scopes: []string{models.ScopeRead},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(resp.Key, resp.Prefix) {
+		t.Fatalf("key %q does not start with prefix %q", resp.Key, resp.Prefix)
+	}
+	if stored.Hash != auth.HashKey(resp.Key) {
+		t.Fatalf("stored hash does not match the returned key")
+	}
+	if strings.Contains(stored.Hash, resp.Key) {
+		t.Fatalf("raw key must not be stored")
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"ttanalytic/internal/auth"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+)
+
+// AuditRecorder writes audit entries. Callers pass the transaction ctx so an entry is
+// committed or rolled back with the change it describes.
+type AuditRecorder interface {
+	AppendAuditEntry(ctx context.Context, input models.CreateAuditEntryInput) (int64, error)
+}
+
+type noopAudit struct{}
+
+func (noopAudit) AppendAuditEntry(context.Context, models.CreateAuditEntryInput) (int64, error) {
+	return 0, nil
+}
+
+func auditOrNoop(audit AuditRecorder) AuditRecorder {
+	if audit == nil {
+		return noopAudit{}
+	}
+	return audit
+}
+
+type actorKey struct{}
+
+// WithActor names the caller of changes made without an API key, e.g. models.ActorCLI
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// recordChange writes the audit entry of a change to one target; before is nil for a
+// created target and after is nil for a removed one
+func recordChange(ctx context.Context, audit AuditRecorder, action, targetType string, targetID int64, before, after any) error {
+	entry := auditEntry(ctx, action, targetType, &targetID, nil)
+	entry.Before = before
+	entry.After = after
+
+	if _, err := audit.AppendAuditEntry(ctx, entry); err != nil {
+		return fmt.Errorf("audit %s of %s %d: %w", action, targetType, targetID, err)
+	}
+
+	return nil
+}
+
+// auditEntry describes a change made by the caller in ctx: the API key of the request
+// (anonymous while auth is disabled) and its request id
+func auditEntry(ctx context.Context, action, targetType string, targetID *int64, details any) models.CreateAuditEntryInput {
+	return models.CreateAuditEntryInput{
+		Actor:      auditActor(ctx),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		RequestID:  logging.RequestID(ctx),
+		Details:    details,
+	}
+}
+
+func auditActor(ctx context.Context) string {
+	if key, ok := auth.FromContext(ctx); ok {
+		return fmt.Sprintf("api_key:%d", key.ID)
+	}
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return models.ActorAnonymous
+}
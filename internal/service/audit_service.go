@@ -0,0 +1,67 @@
+//go:generate mockgen -destination=../mocks/audit_mocks.go -package=mocks ttanalytic/internal/service AuditRepository,AuditRecorder
+
+package service
+
+import (
+	"context"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tracing"
+)
+
+type AuditRepository interface {
+	ListAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+// AuditService reads the audit log of a workspace
+type AuditService struct {
+	repo   AuditRepository
+	logger Logger
+}
+
+func NewAuditService(repo AuditRepository, logger Logger) *AuditService {
+	return &AuditService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// ListAudit returns one page of entries, newest first; pass NextBeforeID back as BeforeID
+func (s *AuditService) ListAudit(ctx context.Context, filter models.AuditFilter) (_ models.AuditListResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuditService.ListAudit")
+	defer func() { tracing.End(span, err) }()
+
+	entries, err := s.repo.ListAuditEntries(ctx, filter)
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("ListAudit: repo error: %v", err)
+		return models.AuditListResponse{}, err
+	}
+
+	resp := models.AuditListResponse{Entries: make([]models.AuditEntryResponse, 0, len(entries))}
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, buildAuditEntryResponse(e))
+	}
+
+	if filter.Limit > 0 && len(entries) == filter.Limit {
+		next := entries[len(entries)-1].ID
+		resp.NextBeforeID = &next
+	}
+
+	return resp, nil
+}
+
+func buildAuditEntryResponse(e models.AuditEntry) models.AuditEntryResponse {
+	return models.AuditEntryResponse{
+		ID:         e.ID,
+		Actor:      e.Actor,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		RequestID:  e.RequestID,
+		Before:     e.Before,
+		After:      e.After,
+		Details:    e.Details,
+		CreatedAt:  e.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+	"ttanalytic/internal/mocks"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestAuditService_ListAudit_NextPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockAuditRepository(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+
+	s := NewAuditService(repo, logger)
+	ctx := tenant.NewContext(context.Background(), 1)
+	at := time.Date(2025, 11, 24, 1, 30, 0, 0, time.UTC)
+
+	filter := models.AuditFilter{Action: models.AuditVideoStopped, Limit: 2}
+	repo.EXPECT().ListAuditEntries(gomock.Any(), filter).Return([]models.AuditEntry{
+		{ID: 9, Actor: "api_key:3", Action: models.AuditVideoStopped, After: json.RawMessage(`{"status":"stopped"}`), CreatedAt: at},
+		{ID: 4, Actor: models.ActorCLI, Action: models.AuditVideoStopped, CreatedAt: at},
+	}, nil)
+
+	resp, err := s.ListAudit(ctx, filter)
+	if err != nil {
+		t.Fatalf("ListAudit: %v", err)
+	}
+
+	if len(resp.Entries) != 2 || resp.Entries[0].CreatedAt != "2025-11-24T01:30:00Z" || string(resp.Entries[0].After) != `{"status":"stopped"}` {
+		t.Fatalf("unexpected entries: %+v", resp.Entries)
+	}
+	if resp.NextBeforeID == nil || *resp.NextBeforeID != 4 {
+		t.Fatalf("expected next page before id 4, got %v", resp.NextBeforeID)
+	}
+
+	// a short page is the last one
+	repo.EXPECT().ListAuditEntries(gomock.Any(), gomock.Any()).Return([]models.AuditEntry{{ID: 2, CreatedAt: at}}, nil)
+
+	resp, err = s.ListAudit(ctx, models.AuditFilter{BeforeID: 4, Limit: 2})
+	if err != nil {
+		t.Fatalf("ListAudit: %v", err)
+	}
+	if resp.NextBeforeID != nil {
+		t.Fatalf("expected no next page, got %d", *resp.NextBeforeID)
+	}
+}
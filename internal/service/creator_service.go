@@ -0,0 +1,207 @@
+//go:generate mockgen -destination=../mocks/creator_mocks.go -package=mocks ttanalytic/internal/service CreatorRepository,CreatorPostsProvider
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tracing"
+)
+
+type CreatorRepository interface {
+	CreateCreator(ctx context.Context, input models.CreateCreatorInput) (*models.Creator, error)
+	GetCreator(ctx context.Context, creatorID int64) (*models.Creator, error)
+	GetCreatorAggregates(ctx context.Context, creatorID int64) (models.CreatorAggregates, error)
+	ListCreatorsForSync(ctx context.Context, minSyncAge time.Duration, limit int) ([]models.Creator, error)
+	MarkCreatorSynced(ctx context.Context, creatorID int64) error
+	SetCreatorSyncError(ctx context.Context, creatorID int64, errText string) error
+	FindVideoByTikTokID(ctx context.Context, tikTokID string) (*models.Video, error)
+	AttachVideoToCreator(ctx context.Context, videoID, creatorID int64, postedAt time.Time) error
+	CreateVideo(ctx context.Context, input models.CreateVideoInput) (*models.Video, error)
+	AppendVideoStats(ctx context.Context, input models.CreateVideoStatsInput) error
+}
+
+type CreatorPostsProvider interface {
+	ListUserPosts(ctx context.Context, username string, since time.Time) ([]models.CreatorPost, error)
+}
+
+type DiscoveryConfig struct {
+	Interval   time.Duration
+	BatchSize  int
+	MinSyncAge time.Duration
+}
+
+type CreatorService struct {
+	repo        CreatorRepository
+	provider    CreatorPostsProvider
+	logger      Logger
+	cfg         DiscoveryConfig
+	earningsCfg EarningsConfig
+	transactor  Transactor
+	events      EventRecorder
+	audit       AuditRecorder
+}
+
+func NewCreatorService(
+	repo CreatorRepository,
+	provider CreatorPostsProvider,
+	logger Logger,
+	cfg DiscoveryConfig,
+	earningsCfg EarningsConfig,
+	transactor Transactor,
+	events EventRecorder,
+	audit AuditRecorder,
+) *CreatorService {
+	return &CreatorService{
+		repo:        repo,
+		provider:    provider,
+		logger:      logger,
+		cfg:         cfg,
+		earningsCfg: earningsCfg,
+		transactor:  transactor,
+		events:      eventsOrNoop(events),
+		audit:       auditOrNoop(audit),
+	}
+}
+
+// RegisterCreator starts tracking a TikTok account; its posts are picked up by the discovery job
+func (s *CreatorService) RegisterCreator(ctx context.Context, req models.RegisterCreatorRequest) (_ models.CreatorResponse, err error) {
+	ctx, span := tracing.Start(ctx, "CreatorService.RegisterCreator")
+	defer func() { tracing.End(span, err) }()
+
+	trackFrom := time.Now().UTC()
+	if req.StartDate != nil {
+		trackFrom = req.StartDate.UTC()
+	}
+
+	var resp models.CreatorResponse
+
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		creator, err := s.repo.CreateCreator(txCtx, models.CreateCreatorInput{
+			Username:  req.Username,
+			TrackFrom: trackFrom,
+		})
+		if err != nil {
+			return err
+		}
+
+		resp = s.buildCreatorResponse(creator, models.CreatorAggregates{})
+
+		return recordChange(txCtx, s.audit, models.AuditCreatorRegistered, models.AuditTargetCreator, creator.ID, nil, resp)
+	})
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("RegisterCreator: CreateCreator(%s) error: %v", req.Username, err)
+		return models.CreatorResponse{}, err
+	}
+
+	return resp, nil
+}
+
+func (s *CreatorService) GetCreator(ctx context.Context, creatorID int64) (_ models.CreatorResponse, err error) {
+	ctx, span := tracing.Start(ctx, "CreatorService.GetCreator")
+	defer func() { tracing.End(span, err) }()
+
+	creator, err := s.repo.GetCreator(ctx, creatorID)
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("CreatorService: GetCreator repo error: %v", err)
+		return models.CreatorResponse{}, err
+	}
+
+	agg, err := s.repo.GetCreatorAggregates(ctx, creatorID)
+	if err != nil {
+		return models.CreatorResponse{}, err
+	}
+
+	return s.buildCreatorResponse(creator, agg), nil
+}
+
+// Run periodically lists posts of registered creators and tracks new ones
+func (s *CreatorService) Run(ctx context.Context) {
+	s.discovery().run(ctx)
+}
+
+func (s *CreatorService) syncCreators(ctx context.Context) error {
+	return s.discovery().syncAll(ctx)
+}
+
+func (s *CreatorService) discovery() discovery[models.Creator] {
+	return discovery[models.Creator]{
+		kind:   "creator",
+		logger: s.logger,
+		cfg:    s.cfg,
+		list:   s.repo.ListCreatorsForSync,
+		describe: func(creator models.Creator) (int64, int64, string) {
+			return creator.ID, creator.WorkspaceID, creator.Username
+		},
+		sync:       s.syncCreator,
+		setError:   s.repo.SetCreatorSyncError,
+		markSynced: s.repo.MarkCreatorSynced,
+	}
+}
+
+// syncCreator tracks every post created after the creator's start date that is not tracked yet
+func (s *CreatorService) syncCreator(ctx context.Context, creator models.Creator) error {
+	posts, err := s.provider.ListUserPosts(ctx, creator.Username, creator.TrackFrom)
+	if err != nil {
+		return err
+	}
+
+	tracked := 0
+
+	for _, post := range posts {
+		if post.PostedAt.Before(creator.TrackFrom) {
+			continue
+		}
+
+		created, err := s.trackPost(ctx, creator.ID, post)
+		if err != nil {
+			return fmt.Errorf("track post %s: %w", post.TikTokID, err)
+		}
+		if created {
+			tracked++
+		}
+	}
+
+	if tracked > 0 {
+		s.logger.Infof("discovery: creator %s: %d new videos tracked", creator.Username, tracked)
+	}
+
+	return nil
+}
+
+// trackPost creates the video with its first stats point; false if it is already tracked. A video tracked
+// without a creator, through POST /api/videos, is attributed to this one.
+func (s *CreatorService) trackPost(ctx context.Context, creatorID int64, post models.CreatorPost) (bool, error) {
+	tracker := postTracker{repo: s.repo, earningsCfg: s.earningsCfg, transactor: s.transactor, events: s.events}
+
+	return tracker.track(ctx, post, &creatorID, func(ctx context.Context, video *models.Video) error {
+		if video.CreatorID != nil {
+			return nil
+		}
+		return s.repo.AttachVideoToCreator(ctx, video.ID, creatorID, post.PostedAt)
+	})
+}
+
+func (s *CreatorService) buildCreatorResponse(creator *models.Creator, agg models.CreatorAggregates) models.CreatorResponse {
+	resp := models.CreatorResponse{
+		CreatorID:     creator.ID,
+		Username:      creator.Username,
+		StartDate:     creator.TrackFrom.UTC().Format(time.RFC3339),
+		Status:        creator.TrackingStatus,
+		VideoCount:    agg.VideoCount,
+		TotalViews:    agg.TotalViews,
+		TotalEarnings: agg.TotalEarnings,
+		Currency:      CurrencyUSD,
+		CreatedAt:     creator.CreatedAt.UTC().Format(time.RFC3339),
+	}
+
+	if creator.LastSyncedAt != nil {
+		syncedAt := creator.LastSyncedAt.UTC().Format(time.RFC3339)
+		resp.LastSyncedAt = &syncedAt
+	}
+
+	return resp
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"ttanalytic/internal/mocks"
+	"ttanalytic/internal/models"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestCreatorService_syncCreators_TracksOnlyNewPostsAfterStartDate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockCreatorRepository(ctrl)
+	provider := mocks.NewMockCreatorPostsProvider(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	transactor := mocks.NewMockTransactor(ctrl)
+
+	logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := DiscoveryConfig{
+		Interval:   time.Second,
+		BatchSize:  10,
+		MinSyncAge: time.Minute,
+	}
+	earningsCfg := EarningsConfig{Per: 1000, Rate: 0.10}
+
+	s := NewCreatorService(repo, provider, logger, cfg, earningsCfg, transactor, nil, nil)
+
+	start := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	creator := models.Creator{ID: 7, Username: "someuser", TrackFrom: start}
+
+	gomock.InOrder(
+		repo.EXPECT().
+			ListCreatorsForSync(gomock.Any(), cfg.MinSyncAge, cfg.BatchSize).
+			Return([]models.Creator{creator}, nil),
+		repo.EXPECT().
+			ListCreatorsForSync(gomock.Any(), cfg.MinSyncAge, cfg.BatchSize).
+			Return([]models.Creator{}, nil),
+	)
+
+	provider.EXPECT().
+		ListUserPosts(gomock.Any(), "someuser", start).
+		Return([]models.CreatorPost{
+			{TikTokID: "new", URL: "url-new", PostedAt: start.Add(time.Hour), Views: 2000},
+			{TikTokID: "known", URL: "url-known", PostedAt: start.Add(time.Hour)},
+			{TikTokID: "manual", URL: "url-manual", PostedAt: start.Add(2 * time.Hour)},
+			{TikTokID: "old", URL: "url-old", PostedAt: start.Add(-time.Hour)},
+		}, nil)
+
+	repo.EXPECT().
+		FindVideoByTikTokID(gomock.Any(), "new").
+		Return(nil, models.ErrNotFound)
+	repo.EXPECT().
+		FindVideoByTikTokID(gomock.Any(), "known").
+		Return(&models.Video{ID: 1, TikTokID: "known", CreatorID: &creator.ID}, nil)
+
+	// tracked through POST /api/videos before the creator was registered
+	repo.EXPECT().
+		FindVideoByTikTokID(gomock.Any(), "manual").
+		Return(&models.Video{ID: 2, TikTokID: "manual"}, nil)
+	repo.EXPECT().
+		AttachVideoToCreator(gomock.Any(), int64(2), creator.ID, start.Add(2*time.Hour)).
+		Return(nil)
+
+	transactor.EXPECT().
+		WithinTransaction(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, fn func(context.Context) error) error {
+			return fn(context.Background())
+		})
+
+	repo.EXPECT().
+		CreateVideo(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, input models.CreateVideoInput) (*models.Video, error) {
+			if input.TikTokID != "new" || input.CreatorID == nil || *input.CreatorID != creator.ID {
+				t.Errorf("unexpected create input: %+v", input)
+			}
+			if input.CurrentEarnings != 0.2 {
+				t.Errorf("expected earnings=0.2, got %v", input.CurrentEarnings)
+			}
+			return &models.Video{ID: 42, TikTokID: input.TikTokID, CreatorID: input.CreatorID}, nil
+		})
+	repo.EXPECT().
+		AppendVideoStats(gomock.Any(), models.CreateVideoStatsInput{VideoID: 42, Views: 2000, Earnings: 0.2}).
+		Return(nil)
+
+	repo.EXPECT().MarkCreatorSynced(gomock.Any(), creator.ID).Return(nil)
+
+	if err := s.syncCreators(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestCreatorService_syncCreators_ProviderErrorRecordedAndNextCreatorSynced(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockCreatorRepository(ctrl)
+	provider := mocks.NewMockCreatorPostsProvider(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+
+	logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := DiscoveryConfig{Interval: time.Second, BatchSize: 10}
+
+	s := NewCreatorService(repo, provider, logger, cfg, EarningsConfig{Per: 1000, Rate: 0.1}, nil, nil, nil)
+
+	creators := []models.Creator{
+		{ID: 1, Username: "broken"},
+		{ID: 2, Username: "fine"},
+	}
+
+	// min_sync_age=0 returns the same creators again; the pass must still end
+	repo.EXPECT().
+		ListCreatorsForSync(gomock.Any(), cfg.MinSyncAge, cfg.BatchSize).
+		Return(creators, nil).
+		Times(2)
+
+	provider.EXPECT().
+		ListUserPosts(gomock.Any(), "broken", gomock.Any()).
+		Return(nil, errors.New("provider down"))
+	provider.EXPECT().
+		ListUserPosts(gomock.Any(), "fine", gomock.Any()).
+		Return(nil, nil)
+
+	repo.EXPECT().SetCreatorSyncError(gomock.Any(), int64(1), "provider down").Return(nil)
+	repo.EXPECT().MarkCreatorSynced(gomock.Any(), int64(2)).Return(nil)
+
+	if err := s.syncCreators(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
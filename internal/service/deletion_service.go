@@ -0,0 +1,265 @@
+//go:generate mockgen -destination=../mocks/deletion_mocks.go -package=mocks ttanalytic/internal/service DeletionRepository
+
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+	"ttanalytic/internal/tracing"
+)
+
+type DeletionRepository interface {
+	FindVideoByID(ctx context.Context, videoID int64) (*models.Video, error)
+	SoftDeleteVideo(ctx context.Context, videoID int64) error
+	ListVideosForPurge(ctx context.Context, deletedBefore time.Time, limit int) ([]models.Video, error)
+	ListVideoIDsByUsername(ctx context.Context, username string) ([]int64, error)
+	DeleteVideos(ctx context.Context, videoIDs []int64) (models.DeletedVideos, error)
+	DeleteCreatorsByUsername(ctx context.Context, username string) (int64, error)
+	RemoveAllowedCreator(ctx context.Context, username string) (int64, error)
+	RedactAuditUsername(ctx context.Context, username string) (int64, error)
+	AppendAuditEntry(ctx context.Context, input models.CreateAuditEntryInput) (int64, error)
+}
+
+type DeletionConfig struct {
+	Retention time.Duration // how long a soft deleted video can still be restored from the database
+	Interval  time.Duration
+	BatchSize int
+}
+
+// DeletionService removes videos: soft delete on request, hard delete by the purge job once the
+// retention window is over, and the immediate purge of everything about a creator (GDPR erasure).
+// Every removal writes an audit entry in the same transaction.
+type DeletionService struct {
+	repo       DeletionRepository
+	transactor Transactor
+	logger     Logger
+	cfg        DeletionConfig
+
+	// seams for tests
+	now        func() time.Time
+	removeFile func(name string) error
+}
+
+func NewDeletionService(repo DeletionRepository, transactor Transactor, logger Logger, cfg DeletionConfig) *DeletionService {
+	return &DeletionService{
+		repo:       repo,
+		transactor: transactor,
+		logger:     logger,
+		cfg:        cfg,
+		now:        time.Now,
+		removeFile: os.Remove,
+	}
+}
+
+// DeleteVideo soft deletes a video of the workspace in ctx: it disappears from the API, the
+// updater and exports at once, and its data is purged after the retention window
+func (s *DeletionService) DeleteVideo(ctx context.Context, videoID int64) (err error) {
+	ctx, span := tracing.Start(ctx, "DeletionService.DeleteVideo")
+	defer func() { tracing.End(span, err) }()
+
+	return s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		video, err := s.repo.FindVideoByID(txCtx, videoID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.repo.SoftDeleteVideo(txCtx, videoID); err != nil {
+			return err
+		}
+
+		return recordChange(txCtx, s.repo, models.AuditVideoDeleted, models.AuditTargetVideo, videoID, buildVideoResponse(video), nil)
+	})
+}
+
+// creatorPurgeDetails is the audit record of an erasure; it names the subject by hash only
+type creatorPurgeDetails struct {
+	UsernameSHA256 string `json:"username_sha256"`
+	Videos         int64  `json:"videos"`
+	Stats          int64  `json:"stats"`
+	Creators       int64  `json:"creators"`
+	Events         int64  `json:"events"`
+	WatchFilters   int64  `json:"watch_filters"`
+	AuditEntries   int64  `json:"audit_entries"`
+}
+
+// PurgeCreator removes right away everything the workspace in ctx holds about a TikTok author:
+// their videos (soft deleted ones too) with stats, events and exports, the creator account,
+// the username in watch filters and in the snapshots of earlier audit entries
+func (s *DeletionService) PurgeCreator(ctx context.Context, req models.PurgeCreatorRequest) (_ models.PurgeResult, err error) {
+	ctx, span := tracing.Start(ctx, "DeletionService.PurgeCreator")
+	defer func() { tracing.End(span, err) }()
+
+	if err := req.Validate(); err != nil {
+		return models.PurgeResult{}, fmt.Errorf("%w: %v", models.ErrInvalidRequest, err)
+	}
+
+	var (
+		result models.PurgeResult
+		files  []string
+	)
+
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		ids, err := s.repo.ListVideoIDsByUsername(txCtx, req.Username)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := s.repo.DeleteVideos(txCtx, ids)
+		if err != nil {
+			return err
+		}
+
+		creators, err := s.repo.DeleteCreatorsByUsername(txCtx, req.Username)
+		if err != nil {
+			return err
+		}
+
+		watches, err := s.repo.RemoveAllowedCreator(txCtx, req.Username)
+		if err != nil {
+			return err
+		}
+
+		redacted, err := s.repo.RedactAuditUsername(txCtx, req.Username)
+		if err != nil {
+			return err
+		}
+
+		result = models.PurgeResult{
+			Videos:       deleted.Videos,
+			Stats:        deleted.Stats,
+			Creators:     creators,
+			Events:       deleted.Events,
+			WatchFilters: watches,
+			AuditEntries: deleted.AuditEntries + redacted,
+		}
+		files = deleted.ExportFiles
+
+		sum := sha256.Sum256([]byte(req.Username))
+
+		result.AuditEntryID, err = s.repo.AppendAuditEntry(txCtx, auditEntry(txCtx, models.AuditCreatorPurged, models.AuditTargetCreator, nil, creatorPurgeDetails{
+			UsernameSHA256: hex.EncodeToString(sum[:]),
+			Videos:         result.Videos,
+			Stats:          result.Stats,
+			Creators:       result.Creators,
+			Events:         result.Events,
+			WatchFilters:   result.WatchFilters,
+			AuditEntries:   result.AuditEntries,
+		}))
+		if err != nil {
+			return fmt.Errorf("audit creator purge: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.PurgeResult{}, err
+	}
+
+	s.removeFiles(ctx, files)
+
+	return result, nil
+}
+
+// Run periodically hard deletes the videos whose retention window is over
+func (s *DeletionService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Infof("Purge: worker shutdown")
+			return
+		case <-ticker.C:
+			if err := s.purge(ctx); err != nil {
+				s.logger.Errorf("Purge: %v", err)
+			}
+		}
+	}
+}
+
+// purge works in batches until no video is due, each batch in its own transaction
+func (s *DeletionService) purge(ctx context.Context) error {
+	for ctx.Err() == nil {
+		n, err := s.purgeBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if n < s.cfg.BatchSize {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (s *DeletionService) purgeBatch(ctx context.Context) (int, error) {
+	var (
+		n     int
+		files []string
+	)
+
+	err := s.transactor.WithinTransaction(WithActor(ctx, models.ActorSystem), func(txCtx context.Context) error {
+		videos, err := s.repo.ListVideosForPurge(txCtx, s.now().Add(-s.cfg.Retention), s.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("list videos for purge: %w", err)
+		}
+		n = len(videos)
+
+		byWorkspace := make(map[int64][]int64)
+		var order []int64
+		for _, v := range videos {
+			if _, ok := byWorkspace[v.WorkspaceID]; !ok {
+				order = append(order, v.WorkspaceID)
+			}
+			byWorkspace[v.WorkspaceID] = append(byWorkspace[v.WorkspaceID], v.ID)
+		}
+
+		for _, workspaceID := range order {
+			wsCtx := tenant.NewContext(txCtx, workspaceID)
+			ids := byWorkspace[workspaceID]
+
+			deleted, err := s.repo.DeleteVideos(wsCtx, ids)
+			if err != nil {
+				return fmt.Errorf("purge videos of workspace %d: %w", workspaceID, err)
+			}
+			files = append(files, deleted.ExportFiles...)
+
+			for _, id := range ids {
+				if err := recordChange(wsCtx, s.repo, models.AuditVideoPurged, models.AuditTargetVideo, id, nil, nil); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if n > 0 {
+		s.logger.Infof("Purge: removed %d videos", n)
+	}
+	s.removeFiles(ctx, files)
+
+	return n, nil
+}
+
+// removeFiles deletes export files of purged jobs; a file left behind is logged, the data
+// is already gone from the database
+func (s *DeletionService) removeFiles(ctx context.Context, files []string) {
+	for _, name := range files {
+		if err := s.removeFile(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logging.From(ctx, s.logger).Warnf("Purge: remove export file %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"ttanalytic/internal/auth"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/mocks"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+
+	"github.com/golang/mock/gomock"
+)
+
+func newTestDeletionService(t *testing.T, cfg DeletionConfig) (*DeletionService, *mocks.MockDeletionRepository, *[]string) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockDeletionRepository(ctrl)
+
+	s := NewDeletionService(repo, newPassthroughTransactor(ctrl), newQuietLogger(ctrl), cfg)
+	s.now = func() time.Time { return testNow }
+
+	var removed []string
+	s.removeFile = func(name string) error {
+		removed = append(removed, name)
+		return nil
+	}
+
+	return s, repo, &removed
+}
+
+func TestDeletionService_DeleteVideo_AuditsCaller(t *testing.T) {
+	s, repo, _ := newTestDeletionService(t, DeletionConfig{})
+
+	ctx := tenant.NewContext(context.Background(), 1)
+	ctx = auth.NewContext(ctx, &models.APIKey{ID: 9, WorkspaceID: 1})
+	ctx = logging.WithRequestID(ctx, "req-1")
+
+	gomock.InOrder(
+		repo.EXPECT().FindVideoByID(gomock.Any(), int64(7)).Return(&models.Video{ID: 7, TikTokID: "7301", TrackingStatus: models.VideoStatusActive}, nil),
+		repo.EXPECT().SoftDeleteVideo(gomock.Any(), int64(7)).Return(nil),
+		repo.EXPECT().AppendAuditEntry(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, in models.CreateAuditEntryInput) (int64, error) {
+				if in.Actor != "api_key:9" || in.Action != models.AuditVideoDeleted || in.RequestID != "req-1" {
+					t.Fatalf("unexpected audit entry: %+v", in)
+				}
+				if in.TargetType != models.AuditTargetVideo || in.TargetID == nil || *in.TargetID != 7 {
+					t.Fatalf("unexpected audit target: %+v", in)
+				}
+				if before, ok := in.Before.(models.TrackVideoResponse); !ok || before.TikTokID != "7301" || in.After != nil {
+					t.Fatalf("expected the video before deletion and no after, got %+v", in)
+				}
+				return 1, nil
+			}),
+	)
+
+	if err := s.DeleteVideo(ctx, 7); err != nil {
+		t.Fatalf("DeleteVideo: %v", err)
+	}
+}
+
+func TestDeletionService_DeleteVideo_NotFoundWritesNoAudit(t *testing.T) {
+	s, repo, _ := newTestDeletionService(t, DeletionConfig{})
+	ctx := tenant.NewContext(context.Background(), 1)
+
+	repo.EXPECT().FindVideoByID(gomock.Any(), int64(7)).Return(nil, models.ErrNotFound)
+
+	if err := s.DeleteVideo(ctx, 7); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestDeletionService_PurgeCreator(t *testing.T) {
+	s, repo, removed := newTestDeletionService(t, DeletionConfig{})
+	ctx := tenant.NewContext(context.Background(), 1)
+
+	gomock.InOrder(
+		repo.EXPECT().ListVideoIDsByUsername(gomock.Any(), "some_user").Return([]int64{3, 4}, nil),
+		repo.EXPECT().DeleteVideos(gomock.Any(), []int64{3, 4}).
+			Return(models.DeletedVideos{Videos: 2, Stats: 40, Events: 5, AuditEntries: 4, ExportFiles: []string{"/exports/3.parquet"}}, nil),
+		repo.EXPECT().DeleteCreatorsByUsername(gomock.Any(), "some_user").Return(int64(1), nil),
+		repo.EXPECT().RemoveAllowedCreator(gomock.Any(), "some_user").Return(int64(2), nil),
+		// the creator.registered and watch.registered snapshots still name the user
+		repo.EXPECT().RedactAuditUsername(gomock.Any(), "some_user").Return(int64(2), nil),
+		repo.EXPECT().AppendAuditEntry(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, in models.CreateAuditEntryInput) (int64, error) {
+				details, ok := in.Details.(creatorPurgeDetails)
+				if !ok {
+					t.Fatalf("unexpected details: %#v", in.Details)
+				}
+
+				sum := sha256.Sum256([]byte("some_user"))
+				if details.UsernameSHA256 != hex.EncodeToString(sum[:]) || details.Videos != 2 || details.WatchFilters != 2 ||
+					details.AuditEntries != 6 {
+					t.Fatalf("unexpected details: %+v", details)
+				}
+				if in.Actor != models.ActorAnonymous || in.Action != models.AuditCreatorPurged || in.TargetID != nil {
+					t.Fatalf("unexpected audit entry: %+v", in)
+				}
+				return 11, nil
+			}),
+	)
+
+	// the request names the user the way TikTok shows it
+	result, err := s.PurgeCreator(ctx, models.PurgeCreatorRequest{Username: "@Some_User"})
+	if err != nil {
+		t.Fatalf("PurgeCreator: %v", err)
+	}
+
+	want := models.PurgeResult{Videos: 2, Stats: 40, Creators: 1, Events: 5, WatchFilters: 2, AuditEntries: 6, AuditEntryID: 11}
+	if result != want {
+		t.Fatalf("expected %+v, got %+v", want, result)
+	}
+	if len(*removed) != 1 || (*removed)[0] != "/exports/3.parquet" {
+		t.Fatalf("expected the export file to be removed, got %v", *removed)
+	}
+}
+
+func TestDeletionService_PurgeCreator_FailureKeepsFiles(t *testing.T) {
+	s, repo, removed := newTestDeletionService(t, DeletionConfig{})
+	ctx := tenant.NewContext(context.Background(), 1)
+
+	repo.EXPECT().ListVideoIDsByUsername(gomock.Any(), "some_user").Return([]int64{3}, nil)
+	repo.EXPECT().DeleteVideos(gomock.Any(), []int64{3}).
+		Return(models.DeletedVideos{Videos: 1, ExportFiles: []string{"/exports/3.parquet"}}, nil)
+	repo.EXPECT().DeleteCreatorsByUsername(gomock.Any(), "some_user").Return(int64(0), nil)
+	repo.EXPECT().RemoveAllowedCreator(gomock.Any(), "some_user").Return(int64(0), nil)
+	repo.EXPECT().RedactAuditUsername(gomock.Any(), "some_user").Return(int64(0), nil)
+	repo.EXPECT().AppendAuditEntry(gomock.Any(), gomock.Any()).Return(int64(0), errors.New("db down"))
+
+	if _, err := s.PurgeCreator(ctx, models.PurgeCreatorRequest{Username: "some_user"}); err == nil {
+		t.Fatal("expected the audit error")
+	}
+
+	// the transaction rolled back, the export still references its file
+	if len(*removed) != 0 {
+		t.Fatalf("expected no file removed, got %v", *removed)
+	}
+}
+
+func TestDeletionService_PurgeCreator_InvalidUsername(t *testing.T) {
+	s, _, _ := newTestDeletionService(t, DeletionConfig{})
+	ctx := tenant.NewContext(context.Background(), 1)
+
+	_, err := s.PurgeCreator(ctx, models.PurgeCreatorRequest{Username: "not a user"})
+	if !errors.Is(err, models.ErrInvalidRequest) {
+		t.Fatalf("expected ErrInvalidRequest, got %v", err)
+	}
+}
+
+func TestDeletionService_purge_GroupsByWorkspace(t *testing.T) {
+	s, repo, removed := newTestDeletionService(t, DeletionConfig{Retention: 24 * time.Hour, BatchSize: 3})
+	cutoff := s.now().Add(-24 * time.Hour)
+
+	gomock.InOrder(
+		repo.EXPECT().ListVideosForPurge(gomock.Any(), cutoff, 3).
+			Return([]models.Video{{ID: 1, WorkspaceID: 1}, {ID: 2, WorkspaceID: 2}, {ID: 3, WorkspaceID: 1}}, nil),
+		repo.EXPECT().ListVideosForPurge(gomock.Any(), cutoff, 3).Return(nil, nil),
+	)
+
+	deleted := map[int64][]int64{}
+	repo.EXPECT().DeleteVideos(gomock.Any(), gomock.Any()).Times(2).
+		DoAndReturn(func(ctx context.Context, ids []int64) (models.DeletedVideos, error) {
+			workspaceID, _ := tenant.FromContext(ctx)
+			deleted[workspaceID] = ids
+			return models.DeletedVideos{Videos: int64(len(ids)), ExportFiles: []string{fmt.Sprintf("/exports/ws%d.parquet", workspaceID)}}, nil
+		})
+
+	audited := map[int64]int64{}
+	repo.EXPECT().AppendAuditEntry(gomock.Any(), gomock.Any()).Times(3).
+		DoAndReturn(func(ctx context.Context, in models.CreateAuditEntryInput) (int64, error) {
+			if in.Actor != models.ActorSystem || in.Action != models.AuditVideoPurged {
+				t.Fatalf("unexpected audit entry: %+v", in)
+			}
+			workspaceID, _ := tenant.FromContext(ctx)
+			audited[*in.TargetID] = workspaceID
+			return 1, nil
+		})
+
+	if err := s.purge(context.Background()); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+
+	if len(deleted[1]) != 2 || deleted[1][0] != 1 || deleted[1][1] != 3 || len(deleted[2]) != 1 || deleted[2][0] != 2 {
+		t.Fatalf("unexpected deletes per workspace: %v", deleted)
+	}
+	if audited[1] != 1 || audited[2] != 2 || audited[3] != 1 {
+		t.Fatalf("audit entries written to the wrong workspace: %v", audited)
+	}
+	if len(*removed) != 2 {
+		t.Fatalf("expected the export files of both workspaces removed, got %v", *removed)
+	}
+}
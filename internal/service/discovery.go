@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+)
+
+// discovery is the loop shared by the creator and watch discovery jobs: every interval it syncs the sources
+// that are due, each in its own workspace, and records the outcome on the source
+type discovery[T any] struct {
+	kind   string // "creator" or "watch", in logs and errors
+	logger Logger
+	cfg    DiscoveryConfig
+
+	list       func(ctx context.Context, minSyncAge time.Duration, limit int) ([]T, error)
+	describe   func(source T) (id, workspaceID int64, label string)
+	sync       func(ctx context.Context, source T) error
+	setError   func(ctx context.Context, id int64, errText string) error
+	markSynced func(ctx context.Context, id int64) error
+}
+
+// run calls syncAll every interval until ctx is done
+func (d discovery[T]) run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.logger.Infof("Discovery: %ss shutdown", d.kind)
+			return
+		case <-ticker.C:
+			if err := d.syncAll(ctx); err != nil {
+				d.logger.Errorf("Discovery: %ss sync error: %v", d.kind, err)
+			}
+		}
+	}
+}
+
+// syncAll syncs the sources due batch by batch. A failed source keeps its error and the pass goes on.
+func (d discovery[T]) syncAll(ctx context.Context) error {
+	// guards against re-listing the same sources when min_sync_age is zero
+	seen := make(map[int64]bool)
+
+	for {
+		sources, err := d.list(ctx, d.cfg.MinSyncAge, d.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("list %ss for sync: %w", d.kind, err)
+		}
+
+		if len(sources) == 0 {
+			return nil
+		}
+
+		for _, source := range sources {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			id, workspaceID, label := d.describe(source)
+			if seen[id] {
+				return nil
+			}
+			seen[id] = true
+
+			// videos found for the source are tracked in its workspace
+			sourceCtx := tenant.NewContext(ctx, workspaceID)
+
+			if err := d.sync(sourceCtx, source); err != nil {
+				d.logger.Errorf("discovery: sync %s %s: %v", d.kind, label, err)
+
+				if setErr := d.setError(sourceCtx, id, err.Error()); setErr != nil {
+					d.logger.Errorf("discovery: failed to set sync error for %s %d: %v", d.kind, id, setErr)
+				}
+				continue
+			}
+
+			if err := d.markSynced(sourceCtx, id); err != nil {
+				return fmt.Errorf("mark %s %d synced: %w", d.kind, id, err)
+			}
+		}
+	}
+}
+
+// discoveredVideoRepository is the part of the creator and watch repositories that tracks discovered posts
+type discoveredVideoRepository interface {
+	FindVideoByTikTokID(ctx context.Context, tikTokID string) (*models.Video, error)
+	CreateVideo(ctx context.Context, input models.CreateVideoInput) (*models.Video, error)
+	AppendVideoStats(ctx context.Context, input models.CreateVideoStatsInput) error
+}
+
+// postTracker starts tracking the videos of discovered posts
+type postTracker struct {
+	repo        discoveredVideoRepository
+	earningsCfg EarningsConfig
+	transactor  Transactor
+	events      EventRecorder
+}
+
+// track creates the video of the post with its first stats point and passes it to attach in the same
+// transaction; a video tracked already, through POST /api/videos or another source, is passed as it is.
+// It returns whether the video is new.
+func (t postTracker) track(ctx context.Context, post models.CreatorPost, creatorID *int64, attach func(ctx context.Context, video *models.Video) error) (bool, error) {
+	existing, err := t.repo.FindVideoByTikTokID(ctx, post.TikTokID)
+	if err != nil && !errors.Is(err, models.ErrNotFound) {
+		return false, err
+	}
+	if existing != nil {
+		return false, attach(ctx, existing)
+	}
+
+	earnings := t.earningsCfg.Calc(post.Views)
+	postedAt := post.PostedAt
+
+	err = t.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		video, err := t.repo.CreateVideo(txCtx, models.CreateVideoInput{
+			TikTokID:        post.TikTokID,
+			URL:             post.URL,
+			CurrentViews:    post.Views,
+			CurrentEarnings: earnings,
+			TrackingStatus:  models.VideoStatusActive,
+			CreatorID:       creatorID,
+			PostedAt:        &postedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("create video %s: %w", post.TikTokID, err)
+		}
+
+		if err := t.repo.AppendVideoStats(txCtx, models.CreateVideoStatsInput{
+			VideoID:  video.ID,
+			Views:    post.Views,
+			Earnings: earnings,
+		}); err != nil {
+			return err
+		}
+
+		if err := recordVideoEvent(txCtx, t.events, models.EventVideoTracked, "", videoEventData(*video)); err != nil {
+			return err
+		}
+
+		return attach(txCtx, video)
+	})
+	if errors.Is(err, models.ErrAlreadyExists) {
+		// tracked concurrently through POST /api/videos or another source
+		if existing, err = t.repo.FindVideoByTikTokID(ctx, post.TikTokID); err != nil {
+			return false, err
+		}
+		return false, attach(ctx, existing)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
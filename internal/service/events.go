@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+)
+
+// EventRecorder writes domain events to the outbox. Callers pass the transaction
+// ctx so an event is committed or rolled back with the state change.
+type EventRecorder interface {
+	RecordEvent(ctx context.Context, event models.Event) error
+}
+
+type noopEvents struct{}
+
+func (noopEvents) RecordEvent(context.Context, models.Event) error { return nil }
+
+func eventsOrNoop(events EventRecorder) EventRecorder {
+	if events == nil {
+		return noopEvents{}
+	}
+	return events
+}
+
+// recordVideoEvent builds the payload for a video and records it.
+// key identifies the state transition within the video (see eventID).
+func recordVideoEvent(ctx context.Context, events EventRecorder, eventType, key string, data models.VideoEventData) error {
+	workspaceID, _ := tenant.FromContext(ctx)
+	id := eventID(workspaceID, eventType, fmt.Sprintf("%d:%s", data.VideoID, key))
+
+	if data.TikTokID != "" {
+		data.Currency = CurrencyUSD
+	}
+
+	payload, err := json.Marshal(models.VideoEventPayload{
+		ID:          id,
+		Type:        eventType,
+		WorkspaceID: workspaceID,
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+		Data:        data,
+	})
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", eventType, err)
+	}
+
+	if err := events.RecordEvent(ctx, models.Event{
+		ID:            id,
+		Type:          eventType,
+		AggregateType: models.AggregateVideo,
+		AggregateID:   data.VideoID,
+		Payload:       payload,
+	}); err != nil {
+		return fmt.Errorf("record %s event for video %d: %w", eventType, data.VideoID, err)
+	}
+
+	return nil
+}
+
+func videoEventData(video models.Video) models.VideoEventData {
+	return models.VideoEventData{
+		VideoID:  video.ID,
+		TikTokID: video.TikTokID,
+		URL:      video.URL,
+		Views:    video.CurrentViews,
+		Earnings: video.CurrentEarnings,
+	}
+}
+
+// eventID derives the idempotency key of an event from the state transition it describes,
+// so replaying the same transition (a retried request, a re-run batch) records nothing new
+func eventID(workspaceID int64, eventType, key string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%s|%s", workspaceID, eventType, key)))
+	return "evt_" + hex.EncodeToString(sum[:12])
+}
+
+// crossed returns the thresholds in (from, to]
+func crossed[T int64 | float64](from, to T, thresholds []T) []T {
+	var result []T
+	for _, t := range thresholds {
+		if from < t && t <= to {
+			result = append(result, t)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,401 @@
+//go:generate mockgen -destination=../mocks/export_mocks.go -package=mocks ttanalytic/internal/service ExportRepository
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+	"ttanalytic/internal/export"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+const expireBatchSize = 100
+
+// exportRow is the Parquet schema of exported stats
+type exportRow struct {
+	VideoID     int64   `parquet:"name=video_id, type=INT64"`
+	TikTokID    string  `parquet:"name=tiktok_id, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	CapturedAt  int64   `parquet:"name=captured_at, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Views       int64   `parquet:"name=views, type=INT64"`
+	Earnings    float64 `parquet:"name=earnings, type=DOUBLE"`
+	Granularity string  `parquet:"name=granularity, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+}
+
+type ExportRepository interface {
+	FindVideoByID(ctx context.Context, videoID int64) (*models.Video, error)
+	CreateExportJob(ctx context.Context, input models.CreateExportJobInput) (*models.ExportJob, error)
+	GetExportJob(ctx context.Context, exportID int64) (*models.ExportJob, error)
+	ClaimExportJob(ctx context.Context, worker string, lease time.Duration, maxAttempts int) (*models.ExportJob, error)
+	FailAbandonedExports(ctx context.Context, maxAttempts int) (int64, error)
+	RenewExportLease(ctx context.Context, exportID int64, worker string, lease time.Duration) error
+	MarkExportDone(ctx context.Context, exportID int64, worker, filePath string, rows, sizeBytes int64, expiresAt time.Time) error
+	MarkExportFailed(ctx context.Context, exportID int64, worker, errText string) error
+	ExpireExports(ctx context.Context, limit int) ([]models.ExportJob, error)
+	StreamStatsForExport(ctx context.Context, granularity string, videoID *int64, from, to time.Time, fn func(models.ExportStatRow) error) error
+	GetRollupState(ctx context.Context) (models.RollupState, error)
+	PinReads(ctx context.Context) context.Context
+}
+
+type ExportConfig struct {
+	Dir          string // shared by all replicas
+	Interval     time.Duration
+	Lease        time.Duration // renewed every Lease/3 while a job runs; taken over once it runs out
+	MaxAttempts  int           // claims of a job before it is failed
+	LinkTTL      time.Duration // how long a finished file can be downloaded
+	SigningKey   []byte
+	PublicURL    string // prefix of download links, relative when empty
+	RowGroupSize int
+}
+
+// ExportService runs async Parquet exports of stats for large date ranges
+type ExportService struct {
+	repo       ExportRepository
+	logger     Logger
+	cfg        ExportConfig
+	transactor Transactor
+	audit      AuditRecorder
+	worker     string // claimed_by of the jobs this replica runs
+
+	// seam for tests
+	now func() time.Time
+}
+
+func NewExportService(repo ExportRepository, logger Logger, cfg ExportConfig, transactor Transactor, audit AuditRecorder) *ExportService {
+	return &ExportService{
+		repo:       repo,
+		logger:     logger,
+		cfg:        cfg,
+		transactor: transactor,
+		audit:      auditOrNoop(audit),
+		worker:     exportWorkerID(),
+		now:        time.Now,
+	}
+}
+
+// exportWorkerID tells replicas, and restarts of one, apart
+func exportWorkerID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return fmt.Sprintf("%s-%d-%d", host, os.Getpid(), time.Now().UnixNano())
+}
+
+// CreateExport queues a job; poll GetExport for the download link
+func (s *ExportService) CreateExport(ctx context.Context, req models.CreateExportRequest) (models.ExportResponse, error) {
+	if req.VideoID != nil {
+		if _, err := s.repo.FindVideoByID(ctx, *req.VideoID); err != nil {
+			return models.ExportResponse{}, err
+		}
+	}
+
+	var resp models.ExportResponse
+
+	err := s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		job, err := s.repo.CreateExportJob(txCtx, models.CreateExportJobInput{
+			VideoID: req.VideoID,
+			From:    time.Unix(req.From, 0).UTC(),
+			To:      time.Unix(req.To, 0).UTC(),
+			Format:  models.ExportFormatParquet,
+		})
+		if err != nil {
+			return err
+		}
+
+		resp = s.buildExportResponse(*job)
+
+		return recordChange(txCtx, s.audit, models.AuditExportCreated, models.AuditTargetExport, job.ID, nil, resp)
+	})
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("CreateExport: repo error: %v", err)
+		return models.ExportResponse{}, err
+	}
+
+	return resp, nil
+}
+
+func (s *ExportService) GetExport(ctx context.Context, exportID int64) (models.ExportResponse, error) {
+	job, err := s.repo.GetExportJob(ctx, exportID)
+	if err != nil {
+		return models.ExportResponse{}, err
+	}
+
+	return s.buildExportResponse(*job), nil
+}
+
+// OpenExportFile checks a signed download link and opens the file; the caller closes it
+func (s *ExportService) OpenExportFile(ctx context.Context, exportID int64, query url.Values) (*os.File, error) {
+	workspaceID, err := export.VerifyLink(s.cfg.SigningKey, exportID, query, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("export %d: %w: %w", exportID, err, models.ErrForbidden)
+	}
+
+	job, err := s.repo.GetExportJob(tenant.NewContext(ctx, workspaceID), exportID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Status != models.ExportDone || job.FilePath == nil {
+		return nil, fmt.Errorf("export %d is %s: %w", exportID, job.Status, models.ErrNotFound)
+	}
+
+	f, err := os.Open(*job.FilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("export %d file is gone: %w", exportID, models.ErrNotFound)
+	}
+
+	return f, err
+}
+
+// Run periodically runs queued jobs and removes expired files
+func (s *ExportService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Infof("Exports: worker shutdown")
+			return
+		case <-ticker.C:
+			if err := s.process(ctx); err != nil {
+				s.logger.Errorf("Exports: %v", err)
+			}
+		}
+	}
+}
+
+func (s *ExportService) process(ctx context.Context) error {
+	failed, err := s.repo.FailAbandonedExports(ctx, s.cfg.MaxAttempts)
+	if err != nil {
+		return fmt.Errorf("fail abandoned exports: %w", err)
+	}
+	if failed > 0 {
+		s.logger.Errorf("exports: %d jobs failed after %d attempts", failed, s.cfg.MaxAttempts)
+	}
+
+	for ctx.Err() == nil {
+		job, err := s.repo.ClaimExportJob(ctx, s.worker, s.cfg.Lease, s.cfg.MaxAttempts)
+		if errors.Is(err, models.ErrNotFound) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("claim export job: %w", err)
+		}
+
+		s.runJob(tenant.NewContext(ctx, job.WorkspaceID), *job)
+	}
+
+	return s.expire(ctx)
+}
+
+func (s *ExportService) runJob(ctx context.Context, job models.ExportJob) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	lost := make(chan bool, 1)
+	go func() {
+		lost <- s.renewLease(jobCtx, cancel, job.ID)
+	}()
+
+	path, rows, size, err := s.writeParquet(jobCtx, job)
+
+	cancel()
+	if <-lost {
+		// the job is another worker's now, whatever this one produced
+		if err == nil {
+			os.Remove(path)
+		}
+		return
+	}
+
+	if err != nil {
+		s.logger.Errorf("exports: job %d failed: %v", job.ID, err)
+
+		if err := s.repo.MarkExportFailed(ctx, job.ID, s.worker, err.Error()); err != nil {
+			s.logger.Errorf("exports: mark job %d failed: %v", job.ID, err)
+		}
+		return
+	}
+
+	if err := s.repo.MarkExportDone(ctx, job.ID, s.worker, path, rows, size, s.now().Add(s.cfg.LinkTTL)); err != nil {
+		s.logger.Errorf("exports: mark job %d done: %v", job.ID, err)
+
+		// nobody links to the file: the job failed meanwhile or was taken over
+		if errors.Is(err, models.ErrNotFound) {
+			os.Remove(path)
+		}
+		return
+	}
+
+	s.logger.Infof("exports: job %d done (%d rows, %d bytes)", job.ID, rows, size)
+}
+
+// renewLease keeps a running job ours until ctx ends; it cancels the job and reports
+// true once the job was taken over
+func (s *ExportService) renewLease(ctx context.Context, cancel context.CancelFunc, exportID int64) bool {
+	ticker := time.NewTicker(s.cfg.Lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			err := s.repo.RenewExportLease(ctx, exportID, s.worker, s.cfg.Lease)
+			if errors.Is(err, models.ErrNotFound) {
+				s.logger.Errorf("exports: lost the lease of job %d", exportID)
+				cancel()
+				return true
+			}
+			if err != nil && ctx.Err() == nil {
+				// the lease lasts three renewals, the next one may succeed
+				s.logger.Errorf("exports: renew lease of job %d: %v", exportID, err)
+			}
+		}
+	}
+}
+
+// writeParquet streams the rows of the job into <dir>/<workspace>/export-<id>-<attempt>.parquet,
+// so a worker that lost the job never overwrites the file of the one that took it over
+func (s *ExportService) writeParquet(ctx context.Context, job models.ExportJob) (path string, rows, size int64, err error) {
+	dir := filepath.Join(s.cfg.Dir, strconv.FormatInt(job.WorkspaceID, 10))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", 0, 0, err
+	}
+
+	tmp, err := os.CreateTemp(dir, fmt.Sprintf("export-%d-*.tmp", job.ID))
+	if err != nil {
+		return "", 0, 0, err
+	}
+	defer func() {
+		tmp.Close()
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	w, err := writer.NewParquetWriterFromWriter(tmp, new(exportRow), 1)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("parquet schema: %w", err)
+	}
+	w.CompressionType = parquet.CompressionCodec_SNAPPY
+
+	err = s.streamStats(ctx, job, func(granularity string, r models.ExportStatRow) error {
+		if err := w.Write(exportRow{
+			VideoID:     r.VideoID,
+			TikTokID:    r.TikTokID,
+			CapturedAt:  r.CapturedAt.UnixMilli(),
+			Views:       r.Views,
+			Earnings:    r.Earnings,
+			Granularity: granularity,
+		}); err != nil {
+			return fmt.Errorf("write parquet: %w", err)
+		}
+
+		rows++
+		// parquet-go sizes row groups in bytes, the config counts rows
+		if s.cfg.RowGroupSize > 0 && rows%int64(s.cfg.RowGroupSize) == 0 {
+			if err := w.Flush(true); err != nil {
+				return fmt.Errorf("write parquet: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("read stats: %w", err)
+	}
+
+	if err := w.WriteStop(); err != nil {
+		return "", 0, 0, fmt.Errorf("write parquet: %w", err)
+	}
+
+	info, err := tmp.Stat()
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	path = filepath.Join(dir, fmt.Sprintf("export-%d-%d.parquet", job.ID, job.Attempts))
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, 0, err
+	}
+
+	return path, rows, info.Size(), nil
+}
+
+// streamStats calls fn for the points in the range of the job, oldest part first, each part at the finest
+// granularity still stored, split like the video history: past the raw retention a point is the last snapshot
+// of its day or week
+func (s *ExportService) streamStats(ctx context.Context, job models.ExportJob, fn func(granularity string, r models.ExportStatRow) error) error {
+	ctx = s.repo.PinReads(ctx)
+
+	st, err := s.repo.GetRollupState(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, seg := range historySegments(st, &job.From, &job.To) {
+		err := s.repo.StreamStatsForExport(ctx, seg.granularity, job.VideoID, *seg.from, *seg.to, func(r models.ExportStatRow) error {
+			return fn(seg.granularity, r)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expire removes the files of jobs past their link lifetime
+func (s *ExportService) expire(ctx context.Context) error {
+	jobs, err := s.repo.ExpireExports(ctx, expireBatchSize)
+	if err != nil {
+		return fmt.Errorf("expire exports: %w", err)
+	}
+
+	for _, job := range jobs {
+		if job.FilePath == nil {
+			continue
+		}
+		if err := os.Remove(*job.FilePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.logger.Errorf("exports: remove %s: %v", *job.FilePath, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *ExportService) buildExportResponse(job models.ExportJob) models.ExportResponse {
+	resp := models.ExportResponse{
+		ExportID:  job.ID,
+		Status:    job.Status,
+		Format:    job.Format,
+		VideoID:   job.VideoID,
+		From:      job.From.UTC().Format(time.RFC3339),
+		To:        job.To.UTC().Format(time.RFC3339),
+		Rows:      job.Rows,
+		SizeBytes: job.SizeBytes,
+		Error:     job.Error,
+		CreatedAt: job.CreatedAt.UTC().Format(time.RFC3339),
+	}
+
+	if job.Status == models.ExportDone && job.ExpiresAt != nil {
+		link := s.cfg.PublicURL + export.SignLink(s.cfg.SigningKey, job.WorkspaceID, job.ID, *job.ExpiresAt)
+		expires := job.ExpiresAt.UTC().Format(time.RFC3339)
+
+		resp.DownloadURL = &link
+		resp.ExpiresAt = &expires
+	}
+
+	return resp
+}
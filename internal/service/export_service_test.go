@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"ttanalytic/internal/mocks"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+
+	"github.com/golang/mock/gomock"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/common"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+// expectRawOnly has the export read a range the retention never reached, from the raw snapshots alone
+func expectRawOnly(repo *mocks.MockExportRepository) {
+	repo.EXPECT().PinReads(gomock.Any()).DoAndReturn(func(ctx context.Context) context.Context { return ctx })
+	repo.EXPECT().GetRollupState(gomock.Any()).Return(models.RollupState{}, nil)
+}
+
+func TestExportService_process_WritesParquetAndLinks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockExportRepository(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := ExportConfig{
+		Dir:         t.TempDir(),
+		Lease:       time.Hour,
+		MaxAttempts: 3,
+		LinkTTL:     24 * time.Hour,
+		SigningKey:  []byte("secret"),
+	}
+	s := NewExportService(repo, logger, cfg, nil, nil)
+
+	now := time.Unix(1700000000, 0)
+	s.now = func() time.Time { return now }
+
+	job := models.ExportJob{ID: 5, WorkspaceID: 3, From: now.Add(-48 * time.Hour), To: now, Status: models.ExportRunning, Attempts: 2}
+	wantPath := filepath.Join(cfg.Dir, "3", "export-5-2.parquet")
+
+	repo.EXPECT().FailAbandonedExports(gomock.Any(), cfg.MaxAttempts).Return(int64(0), nil)
+	gomock.InOrder(
+		repo.EXPECT().ClaimExportJob(gomock.Any(), s.worker, cfg.Lease, cfg.MaxAttempts).Return(&job, nil),
+		repo.EXPECT().ClaimExportJob(gomock.Any(), s.worker, cfg.Lease, cfg.MaxAttempts).Return(nil, models.ErrNotFound),
+	)
+
+	expectRawOnly(repo)
+	repo.EXPECT().StreamStatsForExport(gomock.Any(), models.GranularityHour, job.VideoID, job.From, job.To, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ string, _ *int64, _, _ time.Time, fn func(models.ExportStatRow) error) error {
+			if got, _ := tenant.FromContext(ctx); got != job.WorkspaceID {
+				t.Errorf("expected workspace %d, got %d", job.WorkspaceID, got)
+			}
+			for i := int64(1); i <= 3; i++ {
+				if err := fn(models.ExportStatRow{VideoID: 1, TikTokID: "123", CapturedAt: now, Views: i}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	var size int64
+	repo.EXPECT().MarkExportDone(gomock.Any(), job.ID, s.worker, wantPath, int64(3), gomock.Any(), now.Add(cfg.LinkTTL)).
+		DoAndReturn(func(_ context.Context, _ int64, _, _ string, _, sizeBytes int64, _ time.Time) error {
+			size = sizeBytes
+			return nil
+		})
+
+	repo.EXPECT().ExpireExports(gomock.Any(), expireBatchSize).Return(nil, nil)
+
+	if err := s.process(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	info, err := os.Stat(wantPath)
+	if err != nil {
+		t.Fatalf("export file: %v", err)
+	}
+	if info.Size() != size {
+		t.Fatalf("reported size %d, file has %d", size, info.Size())
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(cfg.Dir, "3", "*.tmp")); len(tmp) != 0 {
+		t.Fatalf("temp files left behind: %v", tmp)
+	}
+
+	// the finished job links to the file it produced
+	expires := now.Add(cfg.LinkTTL)
+	done := job
+	done.Status = models.ExportDone
+	done.FilePath = &wantPath
+	done.ExpiresAt = &expires
+
+	resp := s.buildExportResponse(done)
+	if resp.DownloadURL == nil {
+		t.Fatal("expected a download url")
+	}
+
+	u, err := url.Parse(*resp.DownloadURL)
+	if err != nil {
+		t.Fatalf("parse download url: %v", err)
+	}
+
+	repo.EXPECT().GetExportJob(gomock.Any(), job.ID).Return(&done, nil)
+
+	f, err := s.OpenExportFile(context.Background(), job.ID, u.Query())
+	if err != nil {
+		t.Fatalf("OpenExportFile: %v", err)
+	}
+	f.Close()
+}
+
+// TestExportService_writeParquet_ReadBack checks the file against an independent reader
+func TestExportService_writeParquet_ReadBack(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockExportRepository(ctrl)
+	s := NewExportService(repo, mocks.NewMockLogger(ctrl), ExportConfig{Dir: t.TempDir(), RowGroupSize: 2}, nil, nil)
+
+	at := time.UnixMilli(1700000000123)
+	ids := []string{"7301", "", "7303 ✓"}
+	expectRawOnly(repo)
+	repo.EXPECT().StreamStatsForExport(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ *int64, _, _ time.Time, fn func(models.ExportStatRow) error) error {
+			for i, id := range ids {
+				row := models.ExportStatRow{
+					VideoID:    int64(i + 1),
+					TikTokID:   id,
+					CapturedAt: at.Add(time.Duration(i) * time.Hour),
+					Views:      int64(i * 100),
+					Earnings:   float64(i) + 0.25,
+				}
+				if err := fn(row); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	path, rows, _, err := s.writeParquet(context.Background(), models.ExportJob{ID: 1, WorkspaceID: 3, Attempts: 1})
+	if err != nil {
+		t.Fatalf("writeParquet: %v", err)
+	}
+	if rows != int64(len(ids)) {
+		t.Fatalf("wrote %d rows, want %d", rows, len(ids))
+	}
+
+	pr := openParquet(t, path)
+
+	if n := pr.GetNumRows(); n != int64(len(ids)) {
+		t.Fatalf("parquet-go sees %d rows, want %d", n, len(ids))
+	}
+	if groups := pr.Footer.RowGroups; len(groups) != 2 {
+		t.Fatalf("got %d row groups, want 2", len(groups))
+	}
+	if codec := pr.Footer.RowGroups[0].Columns[0].MetaData.Codec; codec != parquet.CompressionCodec_SNAPPY {
+		t.Fatalf("columns are compressed with %v, want SNAPPY", codec)
+	}
+
+	column := func(name string) []any { return readColumn(t, pr, name, len(ids)) }
+
+	videoIDs, tiktokIDs, captured, views, earnings, granularity := column("video_id"), column("tiktok_id"), column("captured_at"), column("views"), column("earnings"), column("granularity")
+	for i := range ids {
+		if videoIDs[i] != int64(i+1) {
+			t.Errorf("row %d: video_id %v, want %d", i, videoIDs[i], i+1)
+		}
+		if tiktokIDs[i] != ids[i] {
+			t.Errorf("row %d: tiktok_id %q, want %q", i, tiktokIDs[i], ids[i])
+		}
+		if want := at.Add(time.Duration(i) * time.Hour).UnixMilli(); captured[i] != want {
+			t.Errorf("row %d: captured_at %v, want %d", i, captured[i], want)
+		}
+		if views[i] != int64(i*100) {
+			t.Errorf("row %d: views %v, want %d", i, views[i], i*100)
+		}
+		if want := float64(i) + 0.25; earnings[i] != want {
+			t.Errorf("row %d: earnings %v, want %v", i, earnings[i], want)
+		}
+		if granularity[i] != models.GranularityHour {
+			t.Errorf("row %d: granularity %v, want %s", i, granularity[i], models.GranularityHour)
+		}
+	}
+}
+
+func TestExportService_writeParquet_ReadsRollupsPastRetention(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockExportRepository(ctrl)
+	s := NewExportService(repo, mocks.NewMockLogger(ctrl), ExportConfig{Dir: t.TempDir()}, nil, nil)
+
+	dailyFrom := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	rawFrom := time.Date(2025, 11, 20, 0, 0, 0, 0, time.UTC)
+	job := models.ExportJob{
+		ID:          2,
+		WorkspaceID: 3,
+		From:        time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC),
+		To:          time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC),
+		Attempts:    1,
+	}
+
+	// the rows the retention moved out of video_stats are read from the rollups, oldest first
+	stream := func(_ context.Context, _ string, _ *int64, from, _ time.Time, fn func(models.ExportStatRow) error) error {
+		return fn(models.ExportStatRow{VideoID: 1, TikTokID: "123", CapturedAt: from})
+	}
+	repo.EXPECT().PinReads(gomock.Any()).DoAndReturn(func(ctx context.Context) context.Context { return ctx })
+	repo.EXPECT().GetRollupState(gomock.Any()).Return(models.RollupState{RawFrom: &rawFrom, DailyFrom: &dailyFrom}, nil)
+	gomock.InOrder(
+		repo.EXPECT().StreamStatsForExport(gomock.Any(), models.GranularityWeek, job.VideoID, job.From, dailyFrom, gomock.Any()).DoAndReturn(stream),
+		repo.EXPECT().StreamStatsForExport(gomock.Any(), models.GranularityDay, job.VideoID, dailyFrom, rawFrom, gomock.Any()).DoAndReturn(stream),
+		repo.EXPECT().StreamStatsForExport(gomock.Any(), models.GranularityHour, job.VideoID, rawFrom, job.To, gomock.Any()).DoAndReturn(stream),
+	)
+
+	path, rows, _, err := s.writeParquet(context.Background(), job)
+	if err != nil {
+		t.Fatalf("writeParquet: %v", err)
+	}
+	if rows != 3 {
+		t.Fatalf("wrote %d rows, want 3", rows)
+	}
+
+	pr := openParquet(t, path)
+
+	captured, granularity := readColumn(t, pr, "captured_at", 3), readColumn(t, pr, "granularity", 3)
+	want := []struct {
+		at          time.Time
+		granularity string
+	}{
+		{job.From, models.GranularityWeek},
+		{dailyFrom, models.GranularityDay},
+		{rawFrom, models.GranularityHour},
+	}
+	for i, w := range want {
+		if captured[i] != w.at.UnixMilli() || granularity[i] != w.granularity {
+			t.Errorf("row %d: %v at %v, want %s at %d", i, granularity[i], captured[i], w.granularity, w.at.UnixMilli())
+		}
+	}
+}
+
+// openParquet opens an exported file with parquet-go, a reader independent of the one that wrote it
+func openParquet(t *testing.T, path string) *reader.ParquetReader {
+	t.Helper()
+
+	file, err := local.NewLocalFileReader(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	t.Cleanup(func() { file.Close() })
+
+	pr, err := reader.NewParquetColumnReader(file, 1)
+	if err != nil {
+		t.Fatalf("open with parquet-go: %v", err)
+	}
+	t.Cleanup(pr.ReadStop)
+
+	return pr
+}
+
+func readColumn(t *testing.T, pr *reader.ParquetReader, name string, n int) []any {
+	t.Helper()
+
+	values, _, _, err := pr.ReadColumnByPath(common.ReformPathStr(pr.SchemaHandler.GetRootExName()+"."+name), int64(n))
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+	if len(values) != n {
+		t.Fatalf("read %d %s values, want %d", len(values), name, n)
+	}
+	return values
+}
+
+func TestExportService_process_MarksFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockExportRepository(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	logger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := ExportConfig{Dir: t.TempDir(), Lease: time.Hour, MaxAttempts: 3}
+	s := NewExportService(repo, logger, cfg, nil, nil)
+
+	job := models.ExportJob{ID: 6, WorkspaceID: 3, Attempts: 1}
+
+	// a job whose worker died on the last attempt is failed, not claimed again
+	repo.EXPECT().FailAbandonedExports(gomock.Any(), cfg.MaxAttempts).Return(int64(1), nil)
+	gomock.InOrder(
+		repo.EXPECT().ClaimExportJob(gomock.Any(), s.worker, cfg.Lease, cfg.MaxAttempts).Return(&job, nil),
+		repo.EXPECT().ClaimExportJob(gomock.Any(), s.worker, cfg.Lease, cfg.MaxAttempts).Return(nil, models.ErrNotFound),
+	)
+	expectRawOnly(repo)
+	repo.EXPECT().StreamStatsForExport(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("connection reset"))
+	repo.EXPECT().MarkExportFailed(gomock.Any(), job.ID, s.worker, gomock.Any()).Return(nil)
+	repo.EXPECT().MarkExportDone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	repo.EXPECT().ExpireExports(gomock.Any(), expireBatchSize).Return(nil, nil)
+
+	if err := s.process(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if files, _ := os.ReadDir(filepath.Join(cfg.Dir, "3")); len(files) != 0 {
+		t.Fatalf("expected no files, got %d", len(files))
+	}
+}
+
+func TestExportService_runJob_RenewsLeaseAndStopsOnceTakenOver(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockExportRepository(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	logger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := ExportConfig{Dir: t.TempDir(), Lease: 30 * time.Millisecond, MaxAttempts: 3}
+	s := NewExportService(repo, logger, cfg, nil, nil)
+
+	job := models.ExportJob{ID: 7, WorkspaceID: 3, Attempts: 1}
+	ctx := tenant.NewContext(context.Background(), job.WorkspaceID)
+
+	// renewed while the rows stream, until another worker took the job over
+	gomock.InOrder(
+		repo.EXPECT().RenewExportLease(gomock.Any(), job.ID, s.worker, cfg.Lease).Return(nil),
+		repo.EXPECT().RenewExportLease(gomock.Any(), job.ID, s.worker, cfg.Lease).Return(models.ErrNotFound),
+	)
+	expectRawOnly(repo)
+	repo.EXPECT().StreamStatsForExport(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ string, _ *int64, _, _ time.Time, fn func(models.ExportStatRow) error) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	repo.EXPECT().MarkExportFailed(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	repo.EXPECT().MarkExportDone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	s.runJob(ctx, job)
+
+	if files, _ := os.ReadDir(filepath.Join(cfg.Dir, "3")); len(files) != 0 {
+		t.Fatalf("expected no files, got %d", len(files))
+	}
+}
+
+func TestExportService_OpenExportFile_RejectsBadLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockExportRepository(ctrl)
+	s := NewExportService(repo, mocks.NewMockLogger(ctrl), ExportConfig{SigningKey: []byte("secret")}, nil, nil)
+
+	repo.EXPECT().GetExportJob(gomock.Any(), gomock.Any()).Times(0)
+
+	query := url.Values{"workspace": {"3"}, "expires": {"9999999999"}, "signature": {"forged"}}
+	_, err := s.OpenExportFile(context.Background(), 5, query)
+	if !errors.Is(err, models.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestExportService_expire_RemovesFiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockExportRepository(ctrl)
+	s := NewExportService(repo, mocks.NewMockLogger(ctrl), ExportConfig{}, nil, nil)
+
+	path := filepath.Join(t.TempDir(), "export-1.parquet")
+	if err := os.WriteFile(path, []byte("PAR1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(t.TempDir(), "export-2.parquet")
+
+	repo.EXPECT().ExpireExports(gomock.Any(), expireBatchSize).
+		Return([]models.ExportJob{{ID: 1, FilePath: &path}, {ID: 2, FilePath: &missing}, {ID: 3}}, nil)
+
+	if err := s.expire(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected file removed, got %v", err)
+	}
+}
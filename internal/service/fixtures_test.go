@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"time"
+	"ttanalytic/internal/mocks"
+
+	"github.com/golang/mock/gomock"
+)
+
+// testNow is the clock of the service tests that fix it, a Monday noon
+var testNow = time.Date(2025, 11, 24, 12, 0, 0, 0, time.UTC)
+
+// newQuietLogger accepts any Infof and Warnf; an unexpected Errorf still fails the test
+func newQuietLogger(ctrl *gomock.Controller) *mocks.MockLogger {
+	logger := mocks.NewMockLogger(ctrl)
+	logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Warnf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	return logger
+}
+
+// newPassthroughTransactor runs every WithinTransaction callback right away, with the caller's ctx
+func newPassthroughTransactor(ctrl *gomock.Controller) *mocks.MockTransactor {
+	transactor := mocks.NewMockTransactor(ctrl)
+	transactor.EXPECT().
+		WithinTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		}).AnyTimes()
+
+	return transactor
+}
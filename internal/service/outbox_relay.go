@@ -0,0 +1,124 @@
+//go:generate mockgen -destination=../mocks/outbox_mocks.go -package=mocks ttanalytic/internal/service OutboxRepository,EventSink,EventRecorder
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"ttanalytic/internal/backoff"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+)
+
+type OutboxRepository interface {
+	ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error)
+	MarkOutboxEventPublished(ctx context.Context, id int64) error
+	MarkOutboxEventFailed(ctx context.Context, id int64, errText string, nextAttemptAt *time.Time) error
+}
+
+// EventSink is where the relay publishes events, see package eventbus
+type EventSink interface {
+	Publish(ctx context.Context, event models.OutboxEvent) error
+}
+
+type OutboxConfig struct {
+	Interval      time.Duration
+	BatchSize     int
+	RetryDelay    time.Duration // base delay, doubled on every attempt
+	MaxRetryDelay time.Duration
+	Lease         time.Duration // how long a claimed event is hidden from other relays
+	MaxAttempts   int           // then the event is marked failed; 0 retries until it is published
+}
+
+// OutboxRelay publishes recorded events to a sink. Delivery is at-least-once: an event is retried until
+// the sink accepts it or MaxAttempts is reached, and its EventID lets consumers drop duplicates.
+type OutboxRelay struct {
+	repo   OutboxRepository
+	sink   EventSink
+	logger Logger
+	cfg    OutboxConfig
+
+	// seams for tests
+	now    func() time.Time
+	jitter func(d time.Duration) time.Duration
+}
+
+func NewOutboxRelay(repo OutboxRepository, sink EventSink, logger Logger, cfg OutboxConfig) *OutboxRelay {
+	return &OutboxRelay{
+		repo:   repo,
+		sink:   sink,
+		logger: logger,
+		cfg:    cfg,
+		now:    time.Now,
+		jitter: backoff.HalfJitter,
+	}
+}
+
+// Run periodically relays unpublished events
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Infof("Outbox: relay shutdown")
+			return
+		case <-ticker.C:
+			if err := r.relay(ctx); err != nil {
+				r.logger.Errorf("Outbox: relay error: %v", err)
+			}
+		}
+	}
+}
+
+// relay drains due events batch by batch
+func (r *OutboxRelay) relay(ctx context.Context) error {
+	for {
+		events, err := r.repo.ClaimOutboxEvents(ctx, r.cfg.BatchSize, r.cfg.Lease)
+		if err != nil {
+			return fmt.Errorf("claim outbox events: %w", err)
+		}
+
+		for _, e := range events {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			r.publish(tenant.NewContext(ctx, e.WorkspaceID), e)
+		}
+
+		if len(events) < r.cfg.BatchSize {
+			return nil
+		}
+	}
+}
+
+// publish hands one event to the sink and records the outcome
+func (r *OutboxRelay) publish(ctx context.Context, e models.OutboxEvent) {
+	err := r.sink.Publish(ctx, e)
+	if err == nil {
+		if err := r.repo.MarkOutboxEventPublished(ctx, e.ID); err != nil {
+			// the lease expires and the event is published again; consumers dedupe on EventID
+			r.logger.Errorf("outbox: mark event %s published: %v", e.EventID, err)
+		}
+		return
+	}
+
+	var next *time.Time
+	if r.cfg.MaxAttempts <= 0 || e.Attempts < r.cfg.MaxAttempts {
+		at := r.now().Add(r.jitter(backoff.Exponential(r.cfg.RetryDelay, r.cfg.MaxRetryDelay, e.Attempts)))
+		next = &at
+	}
+
+	if next == nil {
+		r.logger.Errorf("outbox: publish %s (%s) failed %d times, giving up: %v", e.EventID, e.EventType, e.Attempts, err)
+	} else {
+		r.logger.Warnf("outbox: publish %s (%s) attempt %d failed: %v", e.EventID, e.EventType, e.Attempts, err)
+	}
+
+	if err := r.repo.MarkOutboxEventFailed(ctx, e.ID, err.Error(), next); err != nil {
+		r.logger.Errorf("outbox: mark event %s failed: %v", e.EventID, err)
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"ttanalytic/internal/eventbus"
+	"ttanalytic/internal/mocks"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestOutboxRelay_relay_PublishesAndMarks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockOutboxRepository(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	bus := eventbus.NewMemoryBus()
+
+	cfg := OutboxConfig{BatchSize: 2, Lease: 30 * time.Second}
+	r := NewOutboxRelay(repo, eventbus.NewNATSSink(bus, ""), logger, cfg)
+
+	events := []models.OutboxEvent{
+		{ID: 1, WorkspaceID: 1, EventID: "evt_a", EventType: models.EventVideoTracked, Payload: []byte(`{}`)},
+		{ID: 2, WorkspaceID: 2, EventID: "evt_b", EventType: models.EventVideoStopped, Payload: []byte(`{}`)},
+	}
+
+	// a full batch means there may be more, so the relay claims again
+	gomock.InOrder(
+		repo.EXPECT().ClaimOutboxEvents(gomock.Any(), 2, cfg.Lease).Return(events, nil),
+		repo.EXPECT().ClaimOutboxEvents(gomock.Any(), 2, cfg.Lease).Return(nil, nil),
+	)
+
+	for _, e := range events {
+		repo.EXPECT().MarkOutboxEventPublished(gomock.Any(), e.ID).
+			DoAndReturn(func(ctx context.Context, _ int64) error {
+				if got, _ := tenant.FromContext(ctx); got != e.WorkspaceID {
+					t.Errorf("event %d: expected workspace %d, got %d", e.ID, e.WorkspaceID, got)
+				}
+				return nil
+			})
+	}
+
+	if err := r.relay(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if msgs := bus.Messages(); len(msgs) != 2 || msgs[1].Subject != models.EventVideoStopped {
+		t.Fatalf("unexpected messages: %+v", msgs)
+	}
+}
+
+func TestOutboxRelay_relay_SchedulesRetryOnSinkError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockOutboxRepository(ctrl)
+	sink := mocks.NewMockEventSink(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	logger.EXPECT().Warnf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := OutboxConfig{BatchSize: 10, RetryDelay: time.Second, MaxRetryDelay: 5 * time.Second}
+	r := NewOutboxRelay(repo, sink, logger, cfg)
+
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	r.jitter = func(d time.Duration) time.Duration { return d }
+
+	event := models.OutboxEvent{ID: 9, WorkspaceID: 1, EventID: "evt_c", Attempts: 4}
+
+	repo.EXPECT().ClaimOutboxEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return([]models.OutboxEvent{event}, nil)
+	sink.EXPECT().Publish(gomock.Any(), event).Return(errors.New("broker down"))
+
+	// 1s doubled three times is 8s, capped at 5s
+	next := now.Add(5 * time.Second)
+	repo.EXPECT().MarkOutboxEventFailed(gomock.Any(), int64(9), gomock.Any(), &next).Return(nil)
+	repo.EXPECT().MarkOutboxEventPublished(gomock.Any(), gomock.Any()).Times(0)
+
+	if err := r.relay(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestOutboxRelay_relay_GivesUpAfterMaxAttempts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockOutboxRepository(ctrl)
+	sink := mocks.NewMockEventSink(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	logger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := OutboxConfig{BatchSize: 10, RetryDelay: time.Second, MaxRetryDelay: 5 * time.Second, MaxAttempts: 4}
+	r := NewOutboxRelay(repo, sink, logger, cfg)
+
+	event := models.OutboxEvent{ID: 9, WorkspaceID: 1, EventID: "evt_c", Attempts: 4}
+
+	repo.EXPECT().ClaimOutboxEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return([]models.OutboxEvent{event}, nil)
+	sink.EXPECT().Publish(gomock.Any(), event).Return(errors.New("broker down"))
+
+	// no next attempt: the event is dead-lettered
+	repo.EXPECT().MarkOutboxEventFailed(gomock.Any(), int64(9), "broker down", nil).Return(nil)
+
+	if err := r.relay(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestRecordVideoEvent_IdempotencyKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	events := mocks.NewMockEventRecorder(ctrl)
+
+	var ids []string
+	events.EXPECT().RecordEvent(gomock.Any(), gomock.Any()).
+		Times(4).
+		DoAndReturn(func(_ context.Context, event models.Event) error {
+			ids = append(ids, event.ID)
+			return nil
+		})
+
+	data := models.VideoEventData{VideoID: 5}
+	ws1 := tenant.NewContext(context.Background(), 1)
+	ws2 := tenant.NewContext(context.Background(), 2)
+
+	for _, call := range []struct {
+		ctx context.Context
+		key string
+	}{
+		{ws1, "1000"},
+		{ws1, "1000"}, // same transition replayed
+		{ws1, "5000"},
+		{ws2, "1000"},
+	} {
+		if err := recordVideoEvent(call.ctx, events, models.EventVideoMilestone, call.key, data); err != nil {
+			t.Fatalf("recordVideoEvent: %v", err)
+		}
+	}
+
+	if ids[0] != ids[1] {
+		t.Errorf("replayed transition got a new id: %s != %s", ids[0], ids[1])
+	}
+	if ids[0] == ids[2] || ids[0] == ids[3] {
+		t.Errorf("distinct transitions share an id: %v", ids)
+	}
+}
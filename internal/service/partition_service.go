@@ -0,0 +1,163 @@
+//go:generate mockgen -destination=../mocks/partition_mocks.go -package=mocks ttanalytic/internal/service PartitionRepository
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tracing"
+)
+
+type PartitionRepository interface {
+	LockPartitionMaintenance(ctx context.Context) (bool, error)
+	CreateStatsPartition(ctx context.Context, month time.Time) (bool, error)
+	ListStatsPartitions(ctx context.Context) ([]models.StatsPartition, error)
+	ArchiveStatsPartition(ctx context.Context, name string) error
+	GetRollupState(ctx context.Context) (models.RollupState, error)
+}
+
+type PartitionConfig struct {
+	Interval     time.Duration
+	Premake      int           // months after the current one kept ready
+	ArchiveAfter int           // months of attached history, older partitions are archived; 0 keeps them all
+	RawRetention time.Duration // of the rollup job, months inside it are never archived
+}
+
+// an archived month is at least this old per month of ArchiveAfter
+const archiveMonth = 30 * 24 * time.Hour
+
+// Validate refuses an ArchiveAfter shorter than the raw retention: archived months leave history reads
+// and the retention policy, so they must be past the raw retention already
+func (c PartitionConfig) Validate() error {
+	if c.ArchiveAfter <= 0 || c.RawRetention <= 0 {
+		return nil
+	}
+
+	if time.Duration(c.ArchiveAfter)*archiveMonth < c.RawRetention {
+		return fmt.Errorf("archive_after of %d months is shorter than the raw retention of %s", c.ArchiveAfter, c.RawRetention)
+	}
+
+	return nil
+}
+
+// PartitionService keeps the monthly partitions of video_stats: the next months exist before their first
+// snapshot, and months older than ArchiveAfter are detached into the archive schema once rolled up
+type PartitionService struct {
+	repo       PartitionRepository
+	transactor Transactor
+	logger     Logger
+	cfg        PartitionConfig
+
+	now func() time.Time
+}
+
+func NewPartitionService(repo PartitionRepository, transactor Transactor, logger Logger, cfg PartitionConfig) *PartitionService {
+	return &PartitionService{
+		repo:       repo,
+		transactor: transactor,
+		logger:     logger,
+		cfg:        cfg,
+		now:        time.Now,
+	}
+}
+
+// Run maintains the partitions at start and then every interval
+func (s *PartitionService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Maintain(ctx); err != nil {
+			s.logger.Errorf("Partitions: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Infof("Partitions: worker shutdown")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Maintain creates the partitions of the current and the next Premake months and archives the ones that
+// ended more than ArchiveAfter months ago, once they are rolled up and past the raw retention. A replica
+// that finds another one at it does nothing.
+func (s *PartitionService) Maintain(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "PartitionService.Maintain")
+	defer func() { tracing.End(span, err) }()
+
+	now := s.now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var created, archived []string
+
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		locked, err := s.repo.LockPartitionMaintenance(txCtx)
+		if err != nil {
+			return fmt.Errorf("lock partition maintenance: %w", err)
+		}
+		if !locked {
+			return nil
+		}
+
+		for i := 0; i <= s.cfg.Premake; i++ {
+			m := month.AddDate(0, i, 0)
+
+			ok, err := s.repo.CreateStatsPartition(txCtx, m)
+			if err != nil {
+				return fmt.Errorf("create partition %s: %w", models.StatsPartitionName(m), err)
+			}
+			if ok {
+				created = append(created, models.StatsPartitionName(m))
+			}
+		}
+
+		if s.cfg.ArchiveAfter <= 0 {
+			return nil
+		}
+
+		partitions, err := s.repo.ListStatsPartitions(txCtx)
+		if err != nil {
+			return fmt.Errorf("list partitions: %w", err)
+		}
+
+		st, err := s.repo.GetRollupState(txCtx)
+		if err != nil {
+			return fmt.Errorf("get rollup state: %w", err)
+		}
+
+		// the rollups and the raw retention only see attached months
+		cutoff := earlierTime(month.AddDate(0, -s.cfg.ArchiveAfter, 0), st.RolledUpTo.UTC())
+		if s.cfg.RawRetention > 0 {
+			cutoff = earlierTime(cutoff, now.Add(-s.cfg.RawRetention))
+		}
+
+		for _, p := range partitions {
+			if p.To.After(cutoff) {
+				continue
+			}
+
+			if err := s.repo.ArchiveStatsPartition(txCtx, p.Name); err != nil {
+				return fmt.Errorf("archive partition %s: %w", p.Name, err)
+			}
+			archived = append(archived, p.Name)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(created) > 0 {
+		s.logger.Infof("Partitions: created %v", created)
+	}
+	if len(archived) > 0 {
+		s.logger.Infof("Partitions: archived %v", archived)
+	}
+
+	return nil
+}
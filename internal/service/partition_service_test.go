@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"ttanalytic/internal/mocks"
+	"ttanalytic/internal/models"
+
+	"github.com/golang/mock/gomock"
+)
+
+func newTestPartitionService(t *testing.T, cfg PartitionConfig) (*PartitionService, *mocks.MockPartitionRepository) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockPartitionRepository(ctrl)
+
+	s := NewPartitionService(repo, newPassthroughTransactor(ctrl), newQuietLogger(ctrl), cfg)
+	s.now = func() time.Time { return testNow }
+
+	return s, repo
+}
+
+func utcMonth(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPartitionService_Maintain(t *testing.T) {
+	s, repo := newTestPartitionService(t, PartitionConfig{Premake: 2, ArchiveAfter: 3})
+
+	gomock.InOrder(
+		repo.EXPECT().LockPartitionMaintenance(gomock.Any()).Return(true, nil),
+		repo.EXPECT().CreateStatsPartition(gomock.Any(), utcMonth(2025, time.November)).Return(false, nil),
+		repo.EXPECT().CreateStatsPartition(gomock.Any(), utcMonth(2025, time.December)).Return(false, nil),
+		repo.EXPECT().CreateStatsPartition(gomock.Any(), utcMonth(2026, time.January)).Return(true, nil),
+		repo.EXPECT().ListStatsPartitions(gomock.Any()).Return([]models.StatsPartition{
+			{Name: "video_stats_2025_07", From: utcMonth(2025, time.July), To: utcMonth(2025, time.August)},
+			{Name: "video_stats_2025_08", From: utcMonth(2025, time.August), To: utcMonth(2025, time.September)},
+			{Name: "video_stats_2025_09", From: utcMonth(2025, time.September), To: utcMonth(2025, time.October)},
+			{Name: "video_stats_2025_11", From: utcMonth(2025, time.November), To: utcMonth(2025, time.December)},
+		}, nil),
+		repo.EXPECT().GetRollupState(gomock.Any()).Return(models.RollupState{RolledUpTo: utcMonth(2025, time.November)}, nil),
+		// three months back from November is August: July ended before it, August did not
+		repo.EXPECT().ArchiveStatsPartition(gomock.Any(), "video_stats_2025_07").Return(nil),
+	)
+
+	if err := s.Maintain(context.Background()); err != nil {
+		t.Fatalf("Maintain: %v", err)
+	}
+}
+
+func TestPartitionService_Maintain_WaitsForRollupsAndRawRetention(t *testing.T) {
+	s, repo := newTestPartitionService(t, PartitionConfig{ArchiveAfter: 1, RawRetention: 60 * 24 * time.Hour})
+
+	gomock.InOrder(
+		repo.EXPECT().LockPartitionMaintenance(gomock.Any()).Return(true, nil),
+		repo.EXPECT().CreateStatsPartition(gomock.Any(), utcMonth(2025, time.November)).Return(false, nil),
+		repo.EXPECT().ListStatsPartitions(gomock.Any()).Return([]models.StatsPartition{
+			{Name: "video_stats_2025_07", From: utcMonth(2025, time.July), To: utcMonth(2025, time.August)},
+			{Name: "video_stats_2025_08", From: utcMonth(2025, time.August), To: utcMonth(2025, time.September)},
+			{Name: "video_stats_2025_09", From: utcMonth(2025, time.September), To: utcMonth(2025, time.October)},
+		}, nil),
+		// rolled up to mid-August: only July is done, September is also inside the 60 days of raw retention
+		repo.EXPECT().GetRollupState(gomock.Any()).Return(models.RollupState{RolledUpTo: time.Date(2025, 8, 15, 0, 0, 0, 0, time.UTC)}, nil),
+		repo.EXPECT().ArchiveStatsPartition(gomock.Any(), "video_stats_2025_07").Return(nil),
+	)
+
+	if err := s.Maintain(context.Background()); err != nil {
+		t.Fatalf("Maintain: %v", err)
+	}
+}
+
+func TestPartitionConfig_Validate(t *testing.T) {
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name    string
+		cfg     PartitionConfig
+		wantErr bool
+	}{
+		{name: "no archive", cfg: PartitionConfig{RawRetention: 400 * day}},
+		{name: "no raw retention", cfg: PartitionConfig{ArchiveAfter: 1}},
+		{name: "archive after the raw retention", cfg: PartitionConfig{ArchiveAfter: 13, RawRetention: 365 * day}},
+		{name: "archive inside the raw retention", cfg: PartitionConfig{ArchiveAfter: 3, RawRetention: 180 * day}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPartitionService_Maintain_KeepsHistoryByDefault(t *testing.T) {
+	s, repo := newTestPartitionService(t, PartitionConfig{})
+
+	repo.EXPECT().LockPartitionMaintenance(gomock.Any()).Return(true, nil)
+	repo.EXPECT().CreateStatsPartition(gomock.Any(), utcMonth(2025, time.November)).Return(false, nil)
+
+	if err := s.Maintain(context.Background()); err != nil {
+		t.Fatalf("Maintain: %v", err)
+	}
+}
+
+func TestPartitionService_Maintain_OtherReplicaHoldsLock(t *testing.T) {
+	s, repo := newTestPartitionService(t, PartitionConfig{Premake: 3, ArchiveAfter: 12})
+
+	repo.EXPECT().LockPartitionMaintenance(gomock.Any()).Return(false, nil)
+
+	if err := s.Maintain(context.Background()); err != nil {
+		t.Fatalf("Maintain: %v", err)
+	}
+}
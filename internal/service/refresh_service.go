@@ -0,0 +1,158 @@
+//go:generate mockgen -destination=../mocks/refresh_mocks.go -package=mocks ttanalytic/internal/service VideoRefresher
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+	"ttanalytic/internal/tracing"
+
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
+)
+
+var (
+	ErrRefreshTooSoon = fmt.Errorf("video was refreshed recently: %w", models.ErrRateLimited)
+	ErrRefreshBudget  = fmt.Errorf("on-demand refresh budget exhausted: %w", models.ErrRateLimited)
+)
+
+// VideoRefresher polls one video and stores the snapshot, see UpdaterService.RefreshVideo
+type VideoRefresher interface {
+	RefreshVideo(ctx context.Context, videoID int64) (*models.Video, string, error)
+}
+
+type RefreshConfig struct {
+	Cooldown      time.Duration // minimum time between two on-demand refreshes of one video
+	RatePerMinute float64       // provider calls per minute spent on on-demand refreshes, 0 means unlimited
+	Burst         int
+}
+
+// RefreshService serves on-demand refreshes: concurrent calls for the same video share one
+// provider call, and every video and the replica as a whole are rate limited so clients
+// cannot burn the Ensemble credits the updater needs
+type RefreshService struct {
+	refresher VideoRefresher
+	logger    Logger
+	cfg       RefreshConfig
+	limiter   *rate.Limiter
+	now       func() time.Time
+
+	group singleflight.Group
+
+	mu   sync.Mutex
+	last map[string]time.Time // successful refresh per workspace/video key
+}
+
+func NewRefreshService(refresher VideoRefresher, logger Logger, cfg RefreshConfig) *RefreshService {
+	limit := rate.Inf
+	if cfg.RatePerMinute > 0 {
+		limit = rate.Limit(cfg.RatePerMinute / 60)
+	}
+
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+
+	return &RefreshService{
+		refresher: refresher,
+		logger:    logger,
+		cfg:       cfg,
+		limiter:   rate.NewLimiter(limit, burst),
+		now:       time.Now,
+		last:      make(map[string]time.Time),
+	}
+}
+
+// RefreshVideo polls the video of the workspace in ctx now. A caller that arrives while a
+// refresh of the same video is running gets its result instead of starting another one.
+func (s *RefreshService) RefreshVideo(ctx context.Context, videoID int64) (_ models.RefreshVideoResponse, err error) {
+	ctx, span := tracing.Start(ctx, "RefreshService.RefreshVideo")
+	defer func() { tracing.End(span, err) }()
+
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return models.RefreshVideoResponse{}, err
+	}
+
+	key := fmt.Sprintf("%d/%d", workspaceID, videoID)
+
+	// the shared call must outlive the caller that started it, the provider call timeout bounds it
+	ch := s.group.DoChan(key, func() (any, error) {
+		return s.refresh(context.WithoutCancel(ctx), key, videoID)
+	})
+
+	select {
+	case <-ctx.Done():
+		return models.RefreshVideoResponse{}, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return models.RefreshVideoResponse{}, res.Err
+		}
+
+		resp := res.Val.(models.RefreshVideoResponse)
+		resp.Shared = res.Shared
+
+		return resp, nil
+	}
+}
+
+func (s *RefreshService) refresh(ctx context.Context, key string, videoID int64) (models.RefreshVideoResponse, error) {
+	now := s.now()
+
+	if wait := s.cooldownLeft(key, now); wait > 0 {
+		return models.RefreshVideoResponse{}, &models.RetryAfterError{Err: ErrRefreshTooSoon, RetryAfter: wait}
+	}
+
+	reservation := s.limiter.ReserveN(now, 1)
+	if wait := reservation.DelayFrom(now); !reservation.OK() || wait > 0 {
+		reservation.CancelAt(now)
+		return models.RefreshVideoResponse{}, &models.RetryAfterError{Err: ErrRefreshBudget, RetryAfter: wait}
+	}
+
+	video, result, err := s.refresher.RefreshVideo(ctx, videoID)
+	if err != nil {
+		logging.From(ctx, s.logger).Warnf("RefreshVideo: video %d: %v", videoID, err)
+		return models.RefreshVideoResponse{}, err
+	}
+
+	s.markRefreshed(key, now)
+
+	return models.RefreshVideoResponse{
+		Video:  buildVideoResponse(video),
+		Result: result,
+	}, nil
+}
+
+func (s *RefreshService) cooldownLeft(key string, now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	last, ok := s.last[key]
+	if !ok {
+		return 0
+	}
+
+	return last.Add(s.cfg.Cooldown).Sub(now)
+}
+
+// markRefreshed also forgets the videos whose cooldown is over so the map stays small
+func (s *RefreshService) markRefreshed(key string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, last := range s.last {
+		if now.Sub(last) >= s.cfg.Cooldown {
+			delete(s.last, k)
+		}
+	}
+
+	if s.cfg.Cooldown > 0 {
+		s.last[key] = now
+	}
+}
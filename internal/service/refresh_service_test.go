@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"ttanalytic/internal/mocks"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+
+	"github.com/golang/mock/gomock"
+)
+
+func newTestRefreshService(t *testing.T, cfg RefreshConfig) (*RefreshService, *mocks.MockVideoRefresher, *time.Time) {
+	ctrl := gomock.NewController(t)
+	refresher := mocks.NewMockVideoRefresher(ctrl)
+
+	now := testNow
+
+	s := NewRefreshService(refresher, newQuietLogger(ctrl), cfg)
+	s.now = func() time.Time { return now }
+
+	return s, refresher, &now
+}
+
+func TestRefreshService_RefreshVideo(t *testing.T) {
+	s, refresher, _ := newTestRefreshService(t, RefreshConfig{Cooldown: time.Minute})
+	ctx := tenant.NewContext(context.Background(), 1)
+
+	refresher.EXPECT().RefreshVideo(gomock.Any(), int64(7)).
+		Return(&models.Video{ID: 7, TikTokID: "123", CurrentViews: 1500, TrackingStatus: models.VideoStatusActive}, VideoResultUpdated, nil)
+
+	resp, err := s.RefreshVideo(ctx, 7)
+	if err != nil {
+		t.Fatalf("RefreshVideo: %v", err)
+	}
+	if resp.Result != VideoResultUpdated || resp.Video.VideoID != 7 || resp.Video.CurrentViews != 1500 || resp.Shared {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestRefreshService_RefreshVideo_Cooldown(t *testing.T) {
+	s, refresher, now := newTestRefreshService(t, RefreshConfig{Cooldown: time.Minute})
+	ctx := tenant.NewContext(context.Background(), 1)
+
+	refresher.EXPECT().RefreshVideo(gomock.Any(), int64(7)).
+		Return(&models.Video{ID: 7}, VideoResultUnchanged, nil).Times(2)
+	refresher.EXPECT().RefreshVideo(gomock.Any(), int64(8)).
+		Return(&models.Video{ID: 8}, VideoResultUnchanged, nil)
+
+	if _, err := s.RefreshVideo(ctx, 7); err != nil {
+		t.Fatalf("first refresh: %v", err)
+	}
+
+	*now = now.Add(20 * time.Second)
+
+	_, err := s.RefreshVideo(ctx, 7)
+	if !errors.Is(err, ErrRefreshTooSoon) || !errors.Is(err, models.ErrRateLimited) {
+		t.Fatalf("expected ErrRefreshTooSoon, got %v", err)
+	}
+	var retry *models.RetryAfterError
+	if !errors.As(err, &retry) || retry.RetryAfter != 40*time.Second {
+		t.Fatalf("expected retry after 40s, got %+v", retry)
+	}
+
+	// other videos and other workspaces are not affected
+	if _, err := s.RefreshVideo(ctx, 8); err != nil {
+		t.Fatalf("other video: %v", err)
+	}
+
+	*now = now.Add(40 * time.Second)
+
+	if _, err := s.RefreshVideo(ctx, 7); err != nil {
+		t.Fatalf("refresh after cooldown: %v", err)
+	}
+}
+
+func TestRefreshService_RefreshVideo_FailureDoesNotStartCooldown(t *testing.T) {
+	s, refresher, _ := newTestRefreshService(t, RefreshConfig{Cooldown: time.Minute})
+	ctx := tenant.NewContext(context.Background(), 1)
+
+	gomock.InOrder(
+		refresher.EXPECT().RefreshVideo(gomock.Any(), int64(7)).Return(nil, VideoResultFailed, errors.New("provider down")),
+		refresher.EXPECT().RefreshVideo(gomock.Any(), int64(7)).Return(&models.Video{ID: 7}, VideoResultUpdated, nil),
+	)
+
+	if _, err := s.RefreshVideo(ctx, 7); err == nil {
+		t.Fatal("expected the provider error")
+	}
+	if _, err := s.RefreshVideo(ctx, 7); err != nil {
+		t.Fatalf("retry after failure: %v", err)
+	}
+}
+
+func TestRefreshService_RefreshVideo_Budget(t *testing.T) {
+	s, refresher, now := newTestRefreshService(t, RefreshConfig{RatePerMinute: 6, Burst: 2})
+	ctx := tenant.NewContext(context.Background(), 1)
+
+	refresher.EXPECT().RefreshVideo(gomock.Any(), gomock.Any()).
+		Return(&models.Video{ID: 7}, VideoResultUnchanged, nil).Times(3)
+
+	for id := int64(1); id <= 2; id++ {
+		if _, err := s.RefreshVideo(ctx, id); err != nil {
+			t.Fatalf("refresh %d within burst: %v", id, err)
+		}
+	}
+
+	_, err := s.RefreshVideo(ctx, 3)
+	var retry *models.RetryAfterError
+	if !errors.Is(err, ErrRefreshBudget) || !errors.As(err, &retry) || retry.RetryAfter != 10*time.Second {
+		t.Fatalf("expected ErrRefreshBudget with retry after 10s, got %v", err)
+	}
+
+	// a refused call does not spend the budget
+	*now = now.Add(10 * time.Second)
+
+	if _, err := s.RefreshVideo(ctx, 3); err != nil {
+		t.Fatalf("refresh after refill: %v", err)
+	}
+}
+
+func TestRefreshService_RefreshVideo_DeduplicatesConcurrentCalls(t *testing.T) {
+	s, refresher, _ := newTestRefreshService(t, RefreshConfig{Cooldown: time.Minute})
+	ctx := tenant.NewContext(context.Background(), 1)
+
+	const callers = 5
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	refresher.EXPECT().RefreshVideo(gomock.Any(), int64(7)).
+		DoAndReturn(func(context.Context, int64) (*models.Video, string, error) {
+			close(started)
+			<-release
+			return &models.Video{ID: 7, CurrentViews: 42}, VideoResultUpdated, nil
+		})
+
+	results := make(chan models.RefreshVideoResponse, callers)
+	errs := make(chan error, callers)
+
+	var wg sync.WaitGroup
+	call := func() {
+		defer wg.Done()
+		resp, err := s.RefreshVideo(ctx, 7)
+		if err != nil {
+			errs <- err
+			return
+		}
+		results <- resp
+	}
+
+	wg.Add(1)
+	go call()
+	<-started
+
+	for i := 1; i < callers; i++ {
+		wg.Add(1)
+		go call()
+	}
+
+	// give the joiners time to attach to the running call
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("RefreshVideo: %v", err)
+	}
+
+	n := 0
+	for resp := range results {
+		n++
+		if resp.Video.CurrentViews != 42 || !resp.Shared {
+			t.Fatalf("unexpected response: %+v", resp)
+		}
+	}
+	if n != callers {
+		t.Fatalf("expected %d responses, got %d", callers, n)
+	}
+}
+
+func TestRefreshService_RefreshVideo_CallerCancelDoesNotAbortSharedCall(t *testing.T) {
+	s, refresher, _ := newTestRefreshService(t, RefreshConfig{})
+	base := tenant.NewContext(context.Background(), 1)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	refresher.EXPECT().RefreshVideo(gomock.Any(), int64(7)).
+		DoAndReturn(func(ctx context.Context, _ int64) (*models.Video, string, error) {
+			close(started)
+			<-release
+			if ctx.Err() != nil {
+				return nil, VideoResultFailed, ctx.Err()
+			}
+			return &models.Video{ID: 7}, VideoResultUpdated, nil
+		})
+
+	ctx, cancel := context.WithCancel(base)
+	first := make(chan error, 1)
+	go func() {
+		_, err := s.RefreshVideo(ctx, 7)
+		first <- err
+	}()
+	<-started
+
+	second := make(chan error, 1)
+	go func() {
+		_, err := s.RefreshVideo(base, 7)
+		second <- err
+	}()
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the canceled caller to give up, got %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	if err := <-second; err != nil {
+		t.Fatalf("second caller: %v", err)
+	}
+}
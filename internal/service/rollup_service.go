@@ -0,0 +1,317 @@
+//go:generate mockgen -destination=../mocks/rollup_mocks.go -package=mocks ttanalytic/internal/service RollupRepository
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tracing"
+)
+
+const (
+	rollupChunk  = 24 * time.Hour // snapshots rolled up per transaction while catching up
+	rollupSettle = time.Hour      // a day is rolled up again after it ended, for snapshots committed late
+)
+
+type RollupRepository interface {
+	LockRollups(ctx context.Context) (bool, error)
+	GetRollupState(ctx context.Context) (models.RollupState, error)
+	UpdateRollupState(ctx context.Context, st models.RollupState) error
+	RollupDailyStats(ctx context.Context, from, to time.Time) (int64, error)
+	RollupWeeklyStats(ctx context.Context, from, to time.Time) (int64, error)
+	DeleteRawStatsBefore(ctx context.Context, before time.Time, limit int) (int64, error)
+	DeleteDailyStatsBefore(ctx context.Context, before time.Time, limit int) (int64, error)
+	LockPartitionMaintenance(ctx context.Context) (bool, error)
+	ListStatsPartitions(ctx context.Context) ([]models.StatsPartition, error)
+	DropStatsPartition(ctx context.Context, name string) error
+}
+
+type RollupConfig struct {
+	Interval       time.Duration
+	RawRetention   time.Duration // hourly snapshots older than this are removed once rolled up, 0 keeps them
+	DailyRetention time.Duration // daily rows older than this are removed, weekly ones are kept; 0 keeps them
+	BatchSize      int           // rows removed per statement
+}
+
+// RollupService maintains the daily and weekly rollups of video_stats incrementally and applies the
+// retention policy: raw snapshots and daily rows past their retention are removed once rolled up
+type RollupService struct {
+	repo       RollupRepository
+	transactor Transactor
+	logger     Logger
+	cfg        RollupConfig
+
+	now func() time.Time
+}
+
+func NewRollupService(repo RollupRepository, transactor Transactor, logger Logger, cfg RollupConfig) *RollupService {
+	return &RollupService{
+		repo:       repo,
+		transactor: transactor,
+		logger:     logger,
+		cfg:        cfg,
+		now:        time.Now,
+	}
+}
+
+// Run rolls up the new snapshots and applies the retention every interval
+func (s *RollupService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Infof("Rollups: worker shutdown")
+			return
+		case <-ticker.C:
+			if err := s.Rollup(ctx); err != nil {
+				s.logger.Errorf("Rollups: %v", err)
+				continue
+			}
+			if err := s.ApplyRetention(ctx); err != nil {
+				s.logger.Errorf("Rollups: retention: %v", err)
+			}
+		}
+	}
+}
+
+// Rollup rebuilds the buckets from the watermark up to now, a day per transaction. The current day stays
+// past the watermark and is rebuilt on every run until it has ended.
+func (s *RollupService) Rollup(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "RollupService.Rollup")
+	defer func() { tracing.End(span, err) }()
+
+	now := s.now().UTC()
+
+	for ctx.Err() == nil {
+		done, err := s.rollupStep(ctx, now)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+
+	return ctx.Err()
+}
+
+func (s *RollupService) rollupStep(ctx context.Context, now time.Time) (bool, error) {
+	done := false
+
+	err := s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		locked, err := s.repo.LockRollups(txCtx)
+		if err != nil {
+			return fmt.Errorf("lock rollups: %w", err)
+		}
+		if !locked {
+			done = true
+			return nil
+		}
+
+		st, err := s.repo.GetRollupState(txCtx)
+		if err != nil {
+			return fmt.Errorf("get rollup state: %w", err)
+		}
+
+		from := st.RolledUpTo.UTC()
+		to := from.Add(rollupChunk)
+		if !to.Before(now) {
+			to = now
+			done = true
+		}
+		if !from.Before(to) {
+			done = true
+			return nil
+		}
+
+		if _, err := s.repo.RollupDailyStats(txCtx, from, to); err != nil {
+			return fmt.Errorf("daily rollup from %s: %w", from.Format(time.DateOnly), err)
+		}
+
+		// the week of from is rebuilt from its daily rows, the days before from included
+		if _, err := s.repo.RollupWeeklyStats(txCtx, weekStart(from), to); err != nil {
+			return fmt.Errorf("weekly rollup from %s: %w", from.Format(time.DateOnly), err)
+		}
+
+		st.RolledUpTo = to
+		if done {
+			st.RolledUpTo = laterTime(from, dayStart(now.Add(-rollupSettle)))
+		}
+
+		return s.repo.UpdateRollupState(txCtx, st)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return done, nil
+}
+
+// ApplyRetention moves the raw and daily horizons forward, never past the rollup watermark, then removes
+// the rows before them. History reads switch to the rollups when the horizon moves, before any row is gone.
+func (s *RollupService) ApplyRetention(ctx context.Context) (err error) {
+	if s.cfg.RawRetention <= 0 && s.cfg.DailyRetention <= 0 {
+		return nil
+	}
+
+	ctx, span := tracing.Start(ctx, "RollupService.ApplyRetention")
+	defer func() { tracing.End(span, err) }()
+
+	now := s.now().UTC()
+
+	var (
+		rawFrom, dailyFrom *time.Time
+		dropped            []string
+	)
+
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		locked, err := s.repo.LockRollups(txCtx)
+		if err != nil {
+			return fmt.Errorf("lock rollups: %w", err)
+		}
+		if !locked {
+			return nil
+		}
+
+		st, err := s.repo.GetRollupState(txCtx)
+		if err != nil {
+			return fmt.Errorf("get rollup state: %w", err)
+		}
+
+		if s.cfg.RawRetention > 0 {
+			cutoff := earlierTime(dayStart(now.Add(-s.cfg.RawRetention)), st.RolledUpTo.UTC())
+			if st.RawFrom == nil || cutoff.After(*st.RawFrom) {
+				st.RawFrom = &cutoff
+			}
+			rawFrom = st.RawFrom
+		}
+
+		if s.cfg.DailyRetention > 0 {
+			// whole weeks only, the weekly rollup of the horizon week still needs its days
+			cutoff := earlierTime(weekStart(now.Add(-s.cfg.DailyRetention)), weekStart(st.RolledUpTo.UTC()))
+			if st.DailyFrom == nil || cutoff.After(*st.DailyFrom) {
+				st.DailyFrom = &cutoff
+			}
+			dailyFrom = st.DailyFrom
+		}
+
+		if err := s.repo.UpdateRollupState(txCtx, st); err != nil {
+			return fmt.Errorf("update rollup state: %w", err)
+		}
+
+		if rawFrom == nil {
+			return nil
+		}
+
+		dropped, err = s.dropExpiredPartitions(txCtx, *rawFrom)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(dropped) > 0 {
+		s.logger.Infof("Rollups: dropped partitions %v", dropped)
+	}
+
+	if rawFrom != nil {
+		n, err := s.deleteBefore(ctx, s.repo.DeleteRawStatsBefore, *rawFrom)
+		if err != nil {
+			return fmt.Errorf("delete raw stats: %w", err)
+		}
+		if n > 0 {
+			s.logger.Infof("Rollups: removed %d raw snapshots before %s", n, rawFrom.Format(time.DateOnly))
+		}
+	}
+
+	if dailyFrom != nil {
+		n, err := s.deleteBefore(ctx, s.repo.DeleteDailyStatsBefore, *dailyFrom)
+		if err != nil {
+			return fmt.Errorf("delete daily stats: %w", err)
+		}
+		if n > 0 {
+			s.logger.Infof("Rollups: removed %d daily rows before %s", n, dailyFrom.Format(time.DateOnly))
+		}
+	}
+
+	return nil
+}
+
+// dropExpiredPartitions drops the months of video_stats that ended before the raw horizon;
+// skipped while the partition job runs on another replica, the rows are then deleted one by one
+func (s *RollupService) dropExpiredPartitions(ctx context.Context, rawFrom time.Time) ([]string, error) {
+	locked, err := s.repo.LockPartitionMaintenance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("lock partition maintenance: %w", err)
+	}
+	if !locked {
+		return nil, nil
+	}
+
+	partitions, err := s.repo.ListStatsPartitions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list partitions: %w", err)
+	}
+
+	var dropped []string
+	for _, p := range partitions {
+		if p.To.After(rawFrom) {
+			continue
+		}
+
+		if err := s.repo.DropStatsPartition(ctx, p.Name); err != nil {
+			return nil, fmt.Errorf("drop partition %s: %w", p.Name, err)
+		}
+		dropped = append(dropped, p.Name)
+	}
+
+	return dropped, nil
+}
+
+// deleteBefore removes rows in batches, each statement committed on its own
+func (s *RollupService) deleteBefore(ctx context.Context, del func(context.Context, time.Time, int) (int64, error), before time.Time) (int64, error) {
+	var total int64
+
+	for ctx.Err() == nil {
+		n, err := del(ctx, before, s.cfg.BatchSize)
+		if err != nil {
+			return total, err
+		}
+		total += n
+
+		if n < int64(s.cfg.BatchSize) {
+			return total, nil
+		}
+	}
+
+	return total, ctx.Err()
+}
+
+func dayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// weekStart is the Monday of the ISO week of t, as date_trunc('week') in UTC
+func weekStart(t time.Time) time.Time {
+	d := dayStart(t)
+	return d.AddDate(0, 0, -(int(d.Weekday())+6)%7)
+}
+
+func earlierTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func laterTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
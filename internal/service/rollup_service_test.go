@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+	"ttanalytic/internal/mocks"
+	"ttanalytic/internal/models"
+
+	"github.com/golang/mock/gomock"
+)
+
+func newTestRollupService(t *testing.T, cfg RollupConfig) (*RollupService, *mocks.MockRollupRepository) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockRollupRepository(ctrl)
+
+	s := NewRollupService(repo, newPassthroughTransactor(ctrl), newQuietLogger(ctrl), cfg)
+	s.now = func() time.Time { return testNow }
+
+	return s, repo
+}
+
+func utcDay(month time.Month, day int) time.Time {
+	return time.Date(2025, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRollupService_Rollup_CatchesUpDayByDay(t *testing.T) {
+	s, repo := newTestRollupService(t, RollupConfig{})
+	now := s.now()
+
+	repo.EXPECT().LockRollups(gomock.Any()).Return(true, nil).Times(2)
+
+	gomock.InOrder(
+		repo.EXPECT().GetRollupState(gomock.Any()).Return(models.RollupState{RolledUpTo: utcDay(time.November, 23)}, nil),
+		repo.EXPECT().RollupDailyStats(gomock.Any(), utcDay(time.November, 23), utcDay(time.November, 24)).Return(int64(10), nil),
+		// Sunday closes the week that began on Monday the 17th
+		repo.EXPECT().RollupWeeklyStats(gomock.Any(), utcDay(time.November, 17), utcDay(time.November, 24)).Return(int64(10), nil),
+		repo.EXPECT().UpdateRollupState(gomock.Any(), models.RollupState{RolledUpTo: utcDay(time.November, 24)}).Return(nil),
+
+		// the current day is rolled up so far and stays past the watermark
+		repo.EXPECT().GetRollupState(gomock.Any()).Return(models.RollupState{RolledUpTo: utcDay(time.November, 24)}, nil),
+		repo.EXPECT().RollupDailyStats(gomock.Any(), utcDay(time.November, 24), now).Return(int64(10), nil),
+		repo.EXPECT().RollupWeeklyStats(gomock.Any(), utcDay(time.November, 24), now).Return(int64(10), nil),
+		repo.EXPECT().UpdateRollupState(gomock.Any(), models.RollupState{RolledUpTo: utcDay(time.November, 24)}).Return(nil),
+	)
+
+	if err := s.Rollup(context.Background()); err != nil {
+		t.Fatalf("Rollup: %v", err)
+	}
+}
+
+func TestRollupService_Rollup_OtherReplicaHoldsLock(t *testing.T) {
+	s, repo := newTestRollupService(t, RollupConfig{})
+
+	repo.EXPECT().LockRollups(gomock.Any()).Return(false, nil)
+
+	if err := s.Rollup(context.Background()); err != nil {
+		t.Fatalf("Rollup: %v", err)
+	}
+}
+
+func TestRollupService_ApplyRetention(t *testing.T) {
+	s, repo := newTestRollupService(t, RollupConfig{RawRetention: 30 * 24 * time.Hour, BatchSize: 2})
+	rawFrom := utcDay(time.October, 25)
+
+	gomock.InOrder(
+		repo.EXPECT().LockRollups(gomock.Any()).Return(true, nil),
+		repo.EXPECT().GetRollupState(gomock.Any()).Return(models.RollupState{RolledUpTo: utcDay(time.November, 24)}, nil),
+		// reads move to the daily rollup before any row is removed
+		repo.EXPECT().UpdateRollupState(gomock.Any(), models.RollupState{RolledUpTo: utcDay(time.November, 24), RawFrom: &rawFrom}).Return(nil),
+		repo.EXPECT().LockPartitionMaintenance(gomock.Any()).Return(true, nil),
+		repo.EXPECT().ListStatsPartitions(gomock.Any()).Return([]models.StatsPartition{
+			{Name: "video_stats_2025_09", From: utcDay(time.September, 1), To: utcDay(time.October, 1)},
+			{Name: "video_stats_2025_10", From: utcDay(time.October, 1), To: utcDay(time.November, 1)},
+		}, nil),
+		repo.EXPECT().DropStatsPartition(gomock.Any(), "video_stats_2025_09").Return(nil),
+		// October is only partly expired, its rows go in batches
+		repo.EXPECT().DeleteRawStatsBefore(gomock.Any(), rawFrom, 2).Return(int64(2), nil),
+		repo.EXPECT().DeleteRawStatsBefore(gomock.Any(), rawFrom, 2).Return(int64(1), nil),
+	)
+
+	if err := s.ApplyRetention(context.Background()); err != nil {
+		t.Fatalf("ApplyRetention: %v", err)
+	}
+}
+
+func TestRollupService_ApplyRetention_StopsAtWatermark(t *testing.T) {
+	s, repo := newTestRollupService(t, RollupConfig{RawRetention: 24 * time.Hour, DailyRetention: 30 * 24 * time.Hour, BatchSize: 100})
+
+	// the rollups lag behind: nothing past 1 October may be removed yet
+	watermark := utcDay(time.October, 1)
+	dailyFrom := utcDay(time.September, 29) // Monday of the week of the watermark
+
+	gomock.InOrder(
+		repo.EXPECT().LockRollups(gomock.Any()).Return(true, nil),
+		repo.EXPECT().GetRollupState(gomock.Any()).Return(models.RollupState{RolledUpTo: watermark}, nil),
+		repo.EXPECT().UpdateRollupState(gomock.Any(), models.RollupState{RolledUpTo: watermark, RawFrom: &watermark, DailyFrom: &dailyFrom}).Return(nil),
+		repo.EXPECT().LockPartitionMaintenance(gomock.Any()).Return(false, nil),
+		repo.EXPECT().DeleteRawStatsBefore(gomock.Any(), watermark, 100).Return(int64(0), nil),
+		repo.EXPECT().DeleteDailyStatsBefore(gomock.Any(), dailyFrom, 100).Return(int64(0), nil),
+	)
+
+	if err := s.ApplyRetention(context.Background()); err != nil {
+		t.Fatalf("ApplyRetention: %v", err)
+	}
+}
+
+func TestHistorySegments(t *testing.T) {
+	rawFrom := utcDay(time.October, 25)
+	dailyFrom := utcDay(time.March, 3)
+	from := utcDay(time.January, 1)
+	to := utcDay(time.November, 1)
+	inRaw := utcDay(time.November, 10)
+
+	tests := []struct {
+		name     string
+		st       models.RollupState
+		from, to *time.Time
+		want     string
+	}{
+		{name: "nothing removed", st: models.RollupState{}, from: &from, to: &to, want: "[hour 2025-01-01..2025-11-01]"},
+		{name: "open range without retention", st: models.RollupState{}, want: "[hour ..]"},
+		{name: "raw retention", st: models.RollupState{RawFrom: &rawFrom}, from: &from, to: &to,
+			want: "[day 2025-01-01..2025-10-25 hour 2025-10-25..2025-11-01]"},
+		{name: "raw and daily retention", st: models.RollupState{RawFrom: &rawFrom, DailyFrom: &dailyFrom},
+			want: "[week ..2025-03-03 day 2025-03-03..2025-10-25 hour 2025-10-25..]"},
+		{name: "range within raw", st: models.RollupState{RawFrom: &rawFrom, DailyFrom: &dailyFrom}, from: &inRaw,
+			want: "[hour 2025-11-10..]"},
+		{name: "daily horizon past the raw one", st: models.RollupState{RawFrom: &dailyFrom, DailyFrom: &rawFrom}, to: &to,
+			want: "[week ..2025-03-03 hour 2025-03-03..2025-11-01]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fmt.Sprint(formatSegments(historySegments(tt.st, tt.from, tt.to))); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func formatSegments(segs []historySegment) []string {
+	day := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.DateOnly)
+	}
+
+	var out []string
+	for _, seg := range segs {
+		out = append(out, seg.granularity, day(seg.from)+".."+day(seg.to))
+	}
+	return out
+}
@@ -0,0 +1,183 @@
+//go:generate mockgen -destination=../mocks/stream_mocks.go -package=mocks ttanalytic/internal/service StreamRepository
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+)
+
+var ErrStreamClosed = errors.New("stats stream closed")
+
+type StreamRepository interface {
+	FindVideoByID(ctx context.Context, videoID int64) (*models.Video, error)
+	ListVideoStatsSince(ctx context.Context, videoID *int64, after models.StatsEventID, window time.Duration, limit int) ([]models.VideoStatsEvent, error)
+}
+
+type StreamConfig struct {
+	Heartbeat    time.Duration
+	BufferSize   int           // live events buffered per client before it is dropped
+	ReplayLimit  int           // events replayed at most from Last-Event-ID
+	ReplayWindow time.Duration // snapshots captured this long before Last-Event-ID are replayed again, for late commits
+}
+
+// StreamService fans out video_stats notifications (see pglisten.Handler) to SSE clients
+type StreamService struct {
+	repo   StreamRepository
+	logger Logger
+	cfg    StreamConfig
+
+	mu        sync.Mutex
+	subs      map[*statsSubscriber]struct{}
+	listening bool
+	closed    bool
+}
+
+type statsSubscriber struct {
+	workspaceID int64
+	videoID     *int64
+	ch          chan models.VideoStatsEvent
+}
+
+func NewStreamService(repo StreamRepository, logger Logger, cfg StreamConfig) *StreamService {
+	return &StreamService{
+		repo:   repo,
+		logger: logger,
+		cfg:    cfg,
+		subs:   make(map[*statsSubscriber]struct{}),
+	}
+}
+
+func (s *StreamService) Heartbeat() time.Duration {
+	return s.cfg.Heartbeat
+}
+
+// OpenStatsStream subscribes to new snapshots of the workspace in ctx, of one video when videoID is set.
+// With a lastEventID the snapshots appended after it are returned as replay, with the ones of the replay
+// window before it; a replay cut at the limit is flagged Truncated.
+func (s *StreamService) OpenStatsStream(ctx context.Context, videoID *int64, lastEventID *models.StatsEventID) (models.StatsStream, error) {
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return models.StatsStream{}, err
+	}
+
+	if videoID != nil {
+		if _, err := s.repo.FindVideoByID(ctx, *videoID); err != nil {
+			return models.StatsStream{}, err
+		}
+	}
+
+	// subscribe before reading the replay so nothing appended in between is lost;
+	// the consumer skips live events already replayed
+	sub, err := s.subscribe(workspaceID, videoID)
+	if err != nil {
+		return models.StatsStream{}, err
+	}
+
+	var replay []models.VideoStatsEvent
+	if lastEventID != nil {
+		replay, err = s.repo.ListVideoStatsSince(ctx, videoID, *lastEventID, s.cfg.ReplayWindow, s.cfg.ReplayLimit)
+		if err != nil {
+			s.unsubscribe(sub)
+			logging.From(ctx, s.logger).Errorf("OpenStatsStream: replay after %s: %v", lastEventID, err)
+			return models.StatsStream{}, err
+		}
+	}
+
+	return models.StatsStream{
+		Replay:    replay,
+		Truncated: len(replay) >= s.cfg.ReplayLimit,
+		Events:    sub.ch,
+		Close:     func() { s.unsubscribe(sub) },
+	}, nil
+}
+
+// OnListen drops every subscriber when LISTEN is re-established: notifications sent while
+// the connection was down are lost, so clients reconnect and replay from Last-Event-ID
+func (s *StreamService) OnListen() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listening {
+		s.logger.Warnf("stream: listener reconnected, dropping %d subscribers", len(s.subs))
+		s.dropAll()
+	}
+	s.listening = true
+}
+
+// OnNotification delivers one video_stats payload to the matching subscribers
+func (s *StreamService) OnNotification(payload string) {
+	var event models.VideoStatsEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		s.logger.Errorf("stream: bad notification %q: %v", payload, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sub := range s.subs {
+		if sub.workspaceID != event.WorkspaceID || (sub.videoID != nil && *sub.videoID != event.VideoID) {
+			continue
+		}
+
+		select {
+		case sub.ch <- event:
+		default:
+			// a slow client must not hold up the others; it resumes with Last-Event-ID
+			delete(s.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Close ends every open stream, call it on shutdown
+func (s *StreamService) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	s.dropAll()
+}
+
+func (s *StreamService) subscribe(workspaceID int64, videoID *int64) (*statsSubscriber, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrStreamClosed
+	}
+
+	sub := &statsSubscriber{
+		workspaceID: workspaceID,
+		videoID:     videoID,
+		ch:          make(chan models.VideoStatsEvent, s.cfg.BufferSize),
+	}
+	s.subs[sub] = struct{}{}
+
+	return sub, nil
+}
+
+func (s *StreamService) unsubscribe(sub *statsSubscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[sub]; ok {
+		delete(s.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// dropAll expects s.mu to be held
+func (s *StreamService) dropAll() {
+	for sub := range s.subs {
+		delete(s.subs, sub)
+		close(sub.ch)
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"ttanalytic/internal/mocks"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+
+	"github.com/golang/mock/gomock"
+)
+
+func newTestStreamService(t *testing.T, bufferSize int) (*StreamService, *mocks.MockStreamRepository) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockStreamRepository(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	logger.EXPECT().Warnf(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	s := NewStreamService(repo, logger, StreamConfig{Heartbeat: time.Second, BufferSize: bufferSize, ReplayLimit: 100, ReplayWindow: time.Minute})
+	s.OnListen()
+
+	return s, repo
+}
+
+func receive(t *testing.T, ch <-chan models.VideoStatsEvent) (models.VideoStatsEvent, bool) {
+	t.Helper()
+	select {
+	case e, ok := <-ch:
+		return e, ok
+	default:
+		t.Fatalf("expected an event or a closed stream")
+		return models.VideoStatsEvent{}, false
+	}
+}
+
+func TestStreamService_OnNotification_FiltersByWorkspaceAndVideo(t *testing.T) {
+	s, repo := newTestStreamService(t, 8)
+	ctx := tenant.NewContext(context.Background(), 1)
+
+	videoID := int64(7)
+	repo.EXPECT().FindVideoByID(gomock.Any(), videoID).Return(&models.Video{ID: videoID, WorkspaceID: 1}, nil)
+
+	video, err := s.OpenStatsStream(ctx, &videoID, nil)
+	if err != nil {
+		t.Fatalf("open video stream: %v", err)
+	}
+	workspace, err := s.OpenStatsStream(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("open workspace stream: %v", err)
+	}
+
+	s.OnNotification(`{"id":1,"video_id":7,"workspace_id":1,"views":100}`)
+	s.OnNotification(`{"id":2,"video_id":8,"workspace_id":1,"views":200}`)
+	s.OnNotification(`{"id":3,"video_id":7,"workspace_id":2,"views":300}`) // other tenant
+
+	if e, _ := receive(t, video.Events); e.ID != 1 {
+		t.Fatalf("video stream: expected event 1, got %d", e.ID)
+	}
+	if len(video.Events) != 0 {
+		t.Fatalf("video stream: unexpected events left: %d", len(video.Events))
+	}
+
+	for _, want := range []int64{1, 2} {
+		if e, _ := receive(t, workspace.Events); e.ID != want {
+			t.Fatalf("workspace stream: expected event %d, got %d", want, e.ID)
+		}
+	}
+	if len(workspace.Events) != 0 {
+		t.Fatalf("workspace stream: unexpected events left: %d", len(workspace.Events))
+	}
+}
+
+func TestStreamService_OpenStatsStream_ReplaysFromLastEventID(t *testing.T) {
+	s, repo := newTestStreamService(t, 8)
+	ctx := tenant.NewContext(context.Background(), 1)
+
+	last := models.StatsEventID{ID: 10, CapturedAt: time.Date(2025, 11, 20, 12, 0, 0, 0, time.UTC)}
+	missed := []models.VideoStatsEvent{{ID: 11}, {ID: 12}}
+	repo.EXPECT().ListVideoStatsSince(gomock.Any(), nil, last, time.Minute, 100).Return(missed, nil)
+
+	stream, err := s.OpenStatsStream(ctx, nil, &last)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer stream.Close()
+
+	if len(stream.Replay) != 2 || stream.Replay[0].ID != 11 || stream.Truncated {
+		t.Fatalf("unexpected replay: %+v (truncated %v)", stream.Replay, stream.Truncated)
+	}
+}
+
+func TestStreamService_OpenStatsStream_FlagsTruncatedReplay(t *testing.T) {
+	s, repo := newTestStreamService(t, 8)
+	ctx := tenant.NewContext(context.Background(), 1)
+
+	missed := make([]models.VideoStatsEvent, 100)
+	for i := range missed {
+		missed[i].ID = int64(11 + i)
+	}
+	last := models.StatsEventID{ID: 10}
+	repo.EXPECT().ListVideoStatsSince(gomock.Any(), nil, last, time.Minute, 100).Return(missed, nil)
+
+	stream, err := s.OpenStatsStream(ctx, nil, &last)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer stream.Close()
+
+	if !stream.Truncated {
+		t.Fatal("expected a replay cut at the limit to be flagged")
+	}
+}
+
+func TestStreamService_OpenStatsStream_UnknownVideo(t *testing.T) {
+	s, repo := newTestStreamService(t, 8)
+	ctx := tenant.NewContext(context.Background(), 1)
+
+	videoID := int64(99)
+	repo.EXPECT().FindVideoByID(gomock.Any(), videoID).Return(nil, models.ErrNotFound)
+
+	if _, err := s.OpenStatsStream(ctx, &videoID, nil); err != models.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestStreamService_DropsSlowSubscribersAndOnReconnect(t *testing.T) {
+	s, _ := newTestStreamService(t, 1)
+	ctx := tenant.NewContext(context.Background(), 1)
+
+	slow, _ := s.OpenStatsStream(ctx, nil, nil)
+
+	s.OnNotification(`{"id":1,"video_id":7,"workspace_id":1}`)
+	s.OnNotification(`{"id":2,"video_id":7,"workspace_id":1}`) // buffer full
+
+	if e, ok := receive(t, slow.Events); !ok || e.ID != 1 {
+		t.Fatalf("expected buffered event 1, got %d (%v)", e.ID, ok)
+	}
+	if _, ok := receive(t, slow.Events); ok {
+		t.Fatalf("expected slow stream to be closed")
+	}
+
+	// events sent while LISTEN was down are lost, so every stream is ended
+	live, _ := s.OpenStatsStream(ctx, nil, nil)
+	s.OnListen()
+
+	if _, ok := receive(t, live.Events); ok {
+		t.Fatalf("expected stream to be closed on reconnect")
+	}
+
+	// closing an already dropped stream is a no-op
+	slow.Close()
+	live.Close()
+}
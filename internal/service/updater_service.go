@@ -1,14 +1,21 @@
-//go:generate mockgen -destination=../mocks/updater_mocks.go -package=mocks ttanalytic/internal/service UpdaterRepository,TikTokProvider,Logger,Transactor
+//go:generate mockgen -destination=../mocks/updater_mocks.go -package=mocks ttanalytic/internal/service UpdaterRepository,TikTokProvider,BatchTikTokProvider,Logger,Transactor,UpdaterMetrics
 
 package service
 
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+	"ttanalytic/internal/tracing"
 
 	"github.com/gammazero/workerpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type UpdaterRepository interface {
@@ -16,13 +23,63 @@ type UpdaterRepository interface {
 	AppendVideoStats(ctx context.Context, input models.CreateVideoStatsInput) error
 	UpdateVideoAggregates(ctx context.Context, input models.UpdateVideoAggregatesInput) error
 	SetVideoErrorStatus(ctx context.Context, videoID int64, errText string) error
+	FindVideoByID(ctx context.Context, videoID int64) (*models.Video, error)
+	UpdateVideoAggregatesBulk(ctx context.Context, writes []models.VideoStatsWrite) ([]int64, error)
+	CopyVideoStats(ctx context.Context, writes []models.VideoStatsWrite) (int64, error)
 }
 
+// BatchTikTokProvider is implemented by providers that can poll many videos per request.
+// Results must be in the order of videoURLs; per-item failures go into VideoStatsResult.Err.
+type BatchTikTokProvider interface {
+	TikTokProvider
+	GetVideoStatsBatch(ctx context.Context, videoURLs []string) ([]models.VideoStatsResult, error)
+}
+
+// outcomes of one video in an updater pass
+const (
+	VideoResultUpdated   = "updated"
+	VideoResultUnchanged = "unchanged"
+	VideoResultFailed    = "failed"
+)
+
+// UpdaterMetrics observes updater passes and the outcome of every video
+type UpdaterMetrics interface {
+	ObserveUpdaterTick(duration time.Duration, err error)
+	ObserveUpdaterVideo(result string)
+}
+
+type noopUpdaterMetrics struct{}
+
+func (noopUpdaterMetrics) ObserveUpdaterTick(time.Duration, error) {}
+func (noopUpdaterMetrics) ObserveUpdaterVideo(string)              {}
+
 type UpdaterConfig struct {
 	Interval       time.Duration
 	BatchSize      int
 	MinUpdateAge   time.Duration
 	MaxConcurrency int
+
+	// videos written per bulk flush (COPY of the snapshots, one UPDATE of the aggregates);
+	// 0 writes every video in its own transaction. The buffer is flushed at the end of each
+	// batch, before the next one is listed, so a flush never holds more than BatchSize videos.
+	FlushSize int
+
+	// crossing one of these emits video.milestone_reached / earnings.threshold
+	ViewMilestones     []int64
+	EarningsThresholds []float64
+}
+
+// maxFlushSize keeps the aggregates UPDATE, 4 parameters per video, under the 65535 bind parameters of Postgres
+const maxFlushSize = 10000
+
+// Validate refuses a FlushSize the updater can never reach: videos left in the buffer would be
+// listed again by the next batch, so it is flushed per batch
+func (c UpdaterConfig) Validate() error {
+	if c.FlushSize > c.BatchSize {
+		return fmt.Errorf("flush_size %d is larger than batch_size %d, flushes never exceed a batch", c.FlushSize, c.BatchSize)
+	}
+
+	return nil
 }
 
 type UpdaterService struct {
@@ -32,6 +89,11 @@ type UpdaterService struct {
 	cfg         UpdaterConfig
 	earningsCfg EarningsConfig
 	transactor  Transactor
+	events      EventRecorder
+	audit       AuditRecorder
+	metrics     UpdaterMetrics
+
+	heartbeat atomic.Int64 // unix nanos of the last sign of life of Run
 }
 
 func NewUpdaterService(
@@ -41,7 +103,15 @@ func NewUpdaterService(
 	cfg UpdaterConfig,
 	earningsCfg EarningsConfig,
 	transactor Transactor,
+	events EventRecorder,
+	audit AuditRecorder,
+	metrics UpdaterMetrics,
 ) *UpdaterService {
+	if metrics == nil {
+		metrics = noopUpdaterMetrics{}
+	}
+	cfg.FlushSize = min(cfg.FlushSize, maxFlushSize)
+
 	return &UpdaterService{
 		repo:        repo,
 		provider:    provider,
@@ -49,19 +119,47 @@ func NewUpdaterService(
 		cfg:         cfg,
 		earningsCfg: earningsCfg,
 		transactor:  transactor,
+		events:      eventsOrNoop(events),
+		audit:       auditOrNoop(audit),
+		metrics:     metrics,
 	}
 }
+
+// Heartbeat is when Run last showed progress: started, ticked or finished a batch.
+// It is zero until Run starts.
+func (u *UpdaterService) Heartbeat() time.Time {
+	nanos := u.heartbeat.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, nanos)
+}
+
+func (u *UpdaterService) beat() {
+	u.heartbeat.Store(time.Now().UnixNano())
+}
+
 func (u *UpdaterService) Run(ctx context.Context) {
 	ticker := time.NewTicker(u.cfg.Interval)
 	defer ticker.Stop()
 
+	u.beat()
+
 	for {
 		select {
 		case <-ctx.Done():
 			u.logger.Infof("Updater: shutdown")
 			return
 		case <-ticker.C:
-			if err := u.processBatch(ctx); err != nil {
+			u.beat()
+			started := time.Now()
+			tickCtx, span := tracing.Start(ctx, "updater.tick")
+			err := u.processBatch(tickCtx)
+			tracing.End(span, err)
+			u.metrics.ObserveUpdaterTick(time.Since(started), err)
+
+			if err != nil {
 				u.logger.Errorf("Updater: batch error: %v", err)
 			}
 		}
@@ -69,6 +167,7 @@ func (u *UpdaterService) Run(ctx context.Context) {
 }
 func (u *UpdaterService) processBatch(ctx context.Context) error {
 	for {
+		u.beat()
 
 		videos, err := u.repo.ListVideosForUpdate(ctx, u.cfg.MinUpdateAge, u.cfg.BatchSize)
 		if err != nil {
@@ -80,56 +179,70 @@ func (u *UpdaterService) processBatch(ctx context.Context) error {
 			break
 		}
 
+		prefetched, err := u.fetchBatchStats(ctx, videos)
+		if err != nil {
+			return fmt.Errorf("get batch stats: %w", err)
+		}
+
 		wp := workerpool.New(u.cfg.MaxConcurrency)
+		buf := &statsBuffer{}
 
-		for _, v := range videos {
+		for i, v := range videos {
 			if ctx.Err() != nil {
 				wp.Stop()
 				return ctx.Err()
 			}
 
 			video := v
+			var result *models.VideoStatsResult
+			if prefetched != nil {
+				result = &prefetched[i]
+			}
+
 			wp.Submit(func() {
 				if ctx.Err() != nil {
 					return
 				}
 
+				// writes are scoped to the workspace owning the video
+				videoCtx, span := tracing.Start(tenant.NewContext(ctx, video.WorkspaceID), "updater.video",
+					trace.WithAttributes(attribute.Int64("video.id", video.ID)))
+				var videoErr error
+				defer func() { tracing.End(span, videoErr) }()
+
 				//provider
-				info, err := u.provider.GetVideoStats(ctx, video.URL)
+				info, err := u.videoStats(videoCtx, video, result)
 				if err != nil {
+					videoErr = err
 					u.logger.Errorf("updater: get info for video ID=%s URL=%s: %v", video.TikTokID, video.URL, err)
+					u.metrics.ObserveUpdaterVideo(VideoResultFailed)
 
-					if setErr := u.repo.SetVideoErrorStatus(ctx, video.ID, err.Error()); setErr != nil {
+					if setErr := u.markVideoError(videoCtx, video, err); setErr != nil {
 						u.logger.Errorf("updater: failed to set error status for video %d: %v", video.ID, setErr)
 					}
 					return
 				}
 
-				//calculate
-				statInput, aggInput, ok := u.prepareVideoUpdate(video, info)
-				if !ok {
+				if u.cfg.FlushSize > 0 {
+					u.bufferStats(ctx, buf, video, info)
 					return
 				}
 
-				//transaction
-				if txErr := u.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
-					if err := u.repo.AppendVideoStats(txCtx, statInput); err != nil {
-						return fmt.Errorf("append video stats for video %d: %w", video.ID, err)
-					}
-
-					if err := u.repo.UpdateVideoAggregates(txCtx, aggInput); err != nil {
-						return fmt.Errorf("update aggregates for video %d: %w", video.ID, err)
-					}
-
-					return nil
-				}); txErr != nil {
-					u.logger.Errorf("updater: transaction failed: %v", txErr)
+				outcome, err := u.applyStats(videoCtx, video, info, nil)
+				if err != nil {
+					videoErr = err
+					u.logger.Errorf("updater: transaction failed: %v", err)
 				}
+				u.metrics.ObserveUpdaterVideo(outcome)
 
 			})
 		}
 		wp.StopWait()
 
+		if pending := buf.take(0); len(pending) > 0 {
+			u.flushStats(ctx, pending)
+		}
+
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
@@ -137,6 +250,309 @@ func (u *UpdaterService) processBatch(ctx context.Context) error {
 	}
 	return nil
 }
+
+// RefreshVideo polls one video of the workspace in ctx right away and writes the result like a tick does.
+// Provider errors are returned without flagging the video, the caller decides what to do with them.
+func (u *UpdaterService) RefreshVideo(ctx context.Context, videoID int64) (_ *models.Video, result string, err error) {
+	ctx, span := tracing.Start(ctx, "updater.refresh", trace.WithAttributes(attribute.Int64("video.id", videoID)))
+	defer func() { tracing.End(span, err) }()
+
+	video, err := u.repo.FindVideoByID(ctx, videoID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	stats, err := u.provider.GetVideoStats(ctx, video.URL)
+	if err != nil {
+		return nil, VideoResultFailed, fmt.Errorf("get stats for video %d: %w", video.ID, err)
+	}
+
+	// an on-demand poll is a change made by the caller, the scheduled ones are not audited
+	result, err = u.applyStats(ctx, *video, stats, func(txCtx context.Context) error {
+		after, err := u.repo.FindVideoByID(txCtx, videoID)
+		if err != nil {
+			return err
+		}
+
+		return recordChange(txCtx, u.audit, models.AuditVideoRefreshed, models.AuditTargetVideo, videoID, buildVideoResponse(video), buildVideoResponse(after))
+	})
+	if err != nil {
+		return nil, result, err
+	}
+
+	if result == VideoResultUnchanged {
+		return video, result, nil
+	}
+
+	video, err = u.repo.FindVideoByID(ctx, videoID)
+	if err != nil {
+		return nil, result, err
+	}
+
+	return video, result, nil
+}
+
+// applyStats appends the snapshot and updates the aggregates of the video in one transaction
+// when its views grew, and returns the outcome as a VideoResult* value. audit, when set, runs
+// last in that transaction.
+func (u *UpdaterService) applyStats(ctx context.Context, video models.Video, stats *models.VideoStats, audit func(txCtx context.Context) error) (string, error) {
+	statInput, aggInput, ok := u.prepareVideoUpdate(video, stats)
+	if !ok {
+		return VideoResultUnchanged, nil
+	}
+
+	err := u.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := u.repo.AppendVideoStats(txCtx, statInput); err != nil {
+			return fmt.Errorf("append video stats for video %d: %w", video.ID, err)
+		}
+
+		if err := u.repo.UpdateVideoAggregates(txCtx, aggInput); err != nil {
+			return fmt.Errorf("update aggregates for video %d: %w", video.ID, err)
+		}
+
+		if err := u.recordThresholdEvents(txCtx, video, aggInput); err != nil {
+			return err
+		}
+
+		if audit != nil {
+			return audit(txCtx)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return VideoResultFailed, err
+	}
+
+	return VideoResultUpdated, nil
+}
+
+// pendingStats is a polled video waiting in the buffer for a bulk flush
+type pendingStats struct {
+	video models.Video
+	stats *models.VideoStats
+	write models.VideoStatsWrite
+}
+
+// statsBuffer collects the results of the workers of one batch; the videos in it are still due,
+// so it is emptied before the next batch is listed
+type statsBuffer struct {
+	mu      sync.Mutex
+	pending []pendingStats
+}
+
+func (b *statsBuffer) add(p pendingStats) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pending = append(b.pending, p)
+}
+
+// take empties the buffer once it holds at least n videos, nil otherwise
+func (b *statsBuffer) take(n int) []pendingStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.pending) == 0 || len(b.pending) < n {
+		return nil
+	}
+
+	pending := b.pending
+	b.pending = nil
+
+	return pending
+}
+
+// bufferStats queues the update of a video whose views grew and flushes the buffer once it is full;
+// the worker that fills it does the flush
+func (u *UpdaterService) bufferStats(ctx context.Context, buf *statsBuffer, video models.Video, stats *models.VideoStats) {
+	_, aggInput, ok := u.prepareVideoUpdate(video, stats)
+	if !ok {
+		u.metrics.ObserveUpdaterVideo(VideoResultUnchanged)
+		return
+	}
+
+	buf.add(pendingStats{
+		video: video,
+		stats: stats,
+		write: models.VideoStatsWrite{
+			WorkspaceID: video.WorkspaceID,
+			VideoID:     aggInput.VideoID,
+			Views:       aggInput.Views,
+			Earnings:    aggInput.Earnings,
+		},
+	})
+
+	if pending := buf.take(u.cfg.FlushSize); len(pending) > 0 {
+		u.flushStats(ctx, pending)
+	}
+}
+
+// flushStats writes the buffered videos in one transaction. When it fails every video is written again
+// in its own transaction, so one bad row does not hold back the others and each video keeps its snapshot
+// and aggregates together, as without the buffer.
+func (u *UpdaterService) flushStats(ctx context.Context, pending []pendingStats) {
+	ctx, span := tracing.Start(ctx, "updater.flush", trace.WithAttributes(attribute.Int("videos", len(pending))))
+	written, err := u.writeStatsBulk(ctx, pending)
+	tracing.End(span, err)
+
+	if err != nil {
+		u.logger.Errorf("updater: bulk write of %d videos failed, writing them one by one: %v", len(pending), err)
+
+		for _, p := range pending {
+			outcome, err := u.applyStats(tenant.NewContext(ctx, p.video.WorkspaceID), p.video, p.stats, nil)
+			if err != nil {
+				u.logger.Errorf("updater: transaction failed: %v", err)
+			}
+			u.metrics.ObserveUpdaterVideo(outcome)
+		}
+		return
+	}
+
+	for _, p := range pending {
+		if !written[p.video.ID] {
+			// deleted while it was polled, as models.ErrNotFound on the single-video path
+			u.logger.Errorf("updater: video %d is gone, its stats were not written", p.video.ID)
+			u.metrics.ObserveUpdaterVideo(VideoResultFailed)
+			continue
+		}
+		u.metrics.ObserveUpdaterVideo(VideoResultUpdated)
+	}
+}
+
+// writeStatsBulk updates the aggregates first: the returned ids are the videos still there, locked until
+// commit, and only their snapshots are copied. It returns the written video ids.
+func (u *UpdaterService) writeStatsBulk(ctx context.Context, pending []pendingStats) (map[int64]bool, error) {
+	writes := make([]models.VideoStatsWrite, 0, len(pending))
+	for _, p := range pending {
+		writes = append(writes, p.write)
+	}
+
+	var written map[int64]bool
+
+	err := u.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		ids, err := u.repo.UpdateVideoAggregatesBulk(txCtx, writes)
+		if err != nil {
+			return fmt.Errorf("update aggregates: %w", err)
+		}
+
+		written = make(map[int64]bool, len(ids))
+		for _, id := range ids {
+			written[id] = true
+		}
+
+		kept := writes[:0]
+		for _, w := range writes {
+			if written[w.VideoID] {
+				kept = append(kept, w)
+			}
+		}
+
+		if _, err := u.repo.CopyVideoStats(txCtx, kept); err != nil {
+			return fmt.Errorf("copy video stats: %w", err)
+		}
+
+		for _, p := range pending {
+			if !written[p.video.ID] {
+				continue
+			}
+
+			update := models.UpdateVideoAggregatesInput{VideoID: p.write.VideoID, Views: p.write.Views, Earnings: p.write.Earnings}
+			if err := u.recordThresholdEvents(tenant.NewContext(txCtx, p.video.WorkspaceID), p.video, update); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return written, nil
+}
+
+// fetchBatchStats polls the whole batch in one go when the provider supports it.
+// It returns nil when the provider only supports per-video calls.
+func (u *UpdaterService) fetchBatchStats(ctx context.Context, videos []models.Video) ([]models.VideoStatsResult, error) {
+	batchProvider, ok := u.provider.(BatchTikTokProvider)
+	if !ok {
+		return nil, nil
+	}
+
+	urls := make([]string, 0, len(videos))
+	for _, v := range videos {
+		urls = append(urls, v.URL)
+	}
+
+	results, err := batchProvider.GetVideoStatsBatch(ctx, urls)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) != len(videos) {
+		return nil, fmt.Errorf("provider returned %d results for %d videos", len(results), len(videos))
+	}
+
+	return results, nil
+}
+
+// videoStats returns the prefetched batch result or falls back to a single provider call, for a video the
+// batch could not poll too
+func (u *UpdaterService) videoStats(ctx context.Context, video models.Video, prefetched *models.VideoStatsResult) (*models.VideoStats, error) {
+	if prefetched == nil || prefetched.Unresolved {
+		return u.provider.GetVideoStats(ctx, video.URL)
+	}
+
+	if prefetched.Err != nil {
+		return nil, prefetched.Err
+	}
+
+	return prefetched.Stats, nil
+}
+
+// markVideoError flags the video and queues video.error in one transaction
+func (u *UpdaterService) markVideoError(ctx context.Context, video models.Video, cause error) error {
+	return u.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := u.repo.SetVideoErrorStatus(txCtx, video.ID, cause.Error()); err != nil {
+			return err
+		}
+
+		data := videoEventData(video)
+		data.Error = cause.Error()
+
+		// one error event per failed poll of the video
+		key := strconv.FormatInt(video.UpdatedAt.UnixNano(), 10)
+
+		return recordVideoEvent(txCtx, u.events, models.EventVideoError, key, data)
+	})
+}
+
+// recordThresholdEvents queues one event per view milestone and earnings threshold crossed by the update
+func (u *UpdaterService) recordThresholdEvents(ctx context.Context, video models.Video, update models.UpdateVideoAggregatesInput) error {
+	data := videoEventData(video)
+	data.Views = update.Views
+	data.Earnings = update.Earnings
+
+	for _, milestone := range crossed(video.CurrentViews, update.Views, u.cfg.ViewMilestones) {
+		event := data
+		event.Milestone = &milestone
+		if err := recordVideoEvent(ctx, u.events, models.EventVideoMilestone, strconv.FormatInt(milestone, 10), event); err != nil {
+			return err
+		}
+	}
+
+	for _, threshold := range crossed(video.CurrentEarnings, update.Earnings, u.cfg.EarningsThresholds) {
+		event := data
+		event.Threshold = &threshold
+		if err := recordVideoEvent(ctx, u.events, models.EventEarningsThreshold, strconv.FormatFloat(threshold, 'f', -1, 64), event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (u *UpdaterService) prepareVideoUpdate(video models.Video, stats *models.VideoStats) (statInput models.CreateVideoStatsInput, aggInput models.UpdateVideoAggregatesInput, ok bool) {
 	oldViews := video.CurrentViews
 	newViews := stats.Views
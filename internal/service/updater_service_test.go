@@ -2,12 +2,15 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 	"ttanalytic/internal/mocks"
 	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
 
 	"github.com/golang/mock/gomock"
 )
@@ -38,6 +41,9 @@ func TestUpdaterService_processBatch_NoVideos(t *testing.T) {
 		cfg,
 		earningsCfg,
 		nil,
+		nil,
+		nil,
+		nil,
 	)
 
 	ctx := context.Background()
@@ -73,7 +79,7 @@ func TestUpdaterService_processBatch_SingleBatchTwoVideos(t *testing.T) {
 	}
 	earningsCfg := EarningsConfig{Per: 1000, Rate: 0.10}
 
-	u := NewUpdaterService(repo, provider, logger, cfg, earningsCfg, transactor)
+	u := NewUpdaterService(repo, provider, logger, cfg, earningsCfg, transactor, nil, nil, nil)
 
 	ctx := context.Background()
 
@@ -149,7 +155,7 @@ func TestUpdaterService_processBatch_MultipleBatches(t *testing.T) {
 		Rate: 0.10,
 	}
 
-	u := NewUpdaterService(repo, provider, logger, cfg, earningsCfg, transactor)
+	u := NewUpdaterService(repo, provider, logger, cfg, earningsCfg, transactor, nil, nil, nil)
 	ctx := context.Background()
 
 	//create 21 video in db
@@ -245,7 +251,7 @@ func TestUpdaterService_processBatch_RespectsMaxConcurrency(t *testing.T) {
 		Rate: 0.10,
 	}
 
-	u := NewUpdaterService(repo, provider, logger, cfg, earningsCfg, transactor)
+	u := NewUpdaterService(repo, provider, logger, cfg, earningsCfg, transactor, nil, nil, nil)
 
 	ctx := context.Background()
 
@@ -337,3 +343,588 @@ func TestUpdaterService_processBatch_RespectsMaxConcurrency(t *testing.T) {
 		t.Fatalf("max observed concurrency=%d > MaxConcurrency=%d", max, cfg.MaxConcurrency)
 	}
 }
+func TestUpdaterService_processBatch_UsesBatchProviderWithPartialFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockUpdaterRepository(ctrl)
+	provider := mocks.NewMockBatchTikTokProvider(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	transactor := mocks.NewMockTransactor(ctrl)
+
+	logger.EXPECT().Info(gomock.Any()).AnyTimes()
+	logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Warnf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := UpdaterConfig{
+		Interval:       time.Second,
+		BatchSize:      10,
+		MinUpdateAge:   0,
+		MaxConcurrency: 2,
+	}
+	earningsCfg := EarningsConfig{Per: 1000, Rate: 0.10}
+
+	u := NewUpdaterService(repo, provider, logger, cfg, earningsCfg, transactor, nil, nil, nil)
+
+	videos := []models.Video{
+		{ID: 1, URL: "url1", TikTokID: "t1"},
+		{ID: 2, URL: "url2", TikTokID: "t2"},
+		{ID: 3, URL: "url3", TikTokID: "t3"},
+	}
+
+	gomock.InOrder(
+		repo.EXPECT().
+			ListVideosForUpdate(gomock.Any(), cfg.MinUpdateAge, cfg.BatchSize).
+			Return(videos, nil),
+		repo.EXPECT().
+			ListVideosForUpdate(gomock.Any(), cfg.MinUpdateAge, cfg.BatchSize).
+			Return([]models.Video{}, nil),
+	)
+
+	//one request for the whole batch, never per-video calls
+	provider.EXPECT().
+		GetVideoStatsBatch(gomock.Any(), []string{"url1", "url2", "url3"}).
+		Times(1).
+		Return([]models.VideoStatsResult{
+			{URL: "url1", Stats: &models.VideoStats{Views: 100}},
+			{URL: "url2", Err: models.ErrNotFound},
+			{URL: "url3", Stats: &models.VideoStats{Views: 300}},
+		}, nil)
+	provider.EXPECT().GetVideoStats(gomock.Any(), gomock.Any()).Times(0)
+
+	//only the failed item is marked as error
+	repo.EXPECT().
+		SetVideoErrorStatus(gomock.Any(), int64(2), models.ErrNotFound.Error()).
+		Times(1).
+		Return(nil)
+
+	repo.EXPECT().
+		AppendVideoStats(gomock.Any(), gomock.Any()).
+		Times(2).
+		Return(nil)
+	repo.EXPECT().
+		UpdateVideoAggregates(gomock.Any(), gomock.Any()).
+		Times(2).
+		Return(nil)
+
+	transactor.EXPECT().
+		WithinTransaction(gomock.Any(), gomock.Any()).
+		Times(3). // two updates + one error status
+		DoAndReturn(func(_ context.Context, fn func(context.Context) error) error {
+			return fn(context.Background())
+		})
+
+	if err := u.processBatch(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+// a video the batch cannot poll is polled on its own by the worker pool
+func TestUpdaterService_processBatch_PollsUnresolvedVideosOneByOne(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockUpdaterRepository(ctrl)
+	provider := mocks.NewMockBatchTikTokProvider(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	transactor := mocks.NewMockTransactor(ctrl)
+
+	logger.EXPECT().Info(gomock.Any()).AnyTimes()
+	logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := UpdaterConfig{Interval: time.Second, BatchSize: 10, MaxConcurrency: 2}
+	u := NewUpdaterService(repo, provider, logger, cfg, EarningsConfig{Per: 1000, Rate: 0.10}, transactor, nil, nil, nil)
+
+	videos := []models.Video{
+		{ID: 1, URL: "url1", TikTokID: "t1"},
+		{ID: 2, URL: "short", TikTokID: "t2"},
+	}
+
+	gomock.InOrder(
+		repo.EXPECT().ListVideosForUpdate(gomock.Any(), cfg.MinUpdateAge, cfg.BatchSize).Return(videos, nil),
+		repo.EXPECT().ListVideosForUpdate(gomock.Any(), cfg.MinUpdateAge, cfg.BatchSize).Return([]models.Video{}, nil),
+	)
+
+	provider.EXPECT().
+		GetVideoStatsBatch(gomock.Any(), []string{"url1", "short"}).
+		Return([]models.VideoStatsResult{
+			{URL: "url1", Stats: &models.VideoStats{Views: 100}},
+			{URL: "short", Unresolved: true},
+		}, nil)
+	provider.EXPECT().GetVideoStats(gomock.Any(), "short").Return(&models.VideoStats{Views: 200}, nil)
+
+	repo.EXPECT().AppendVideoStats(gomock.Any(), gomock.Any()).Times(2).Return(nil)
+	repo.EXPECT().UpdateVideoAggregates(gomock.Any(), gomock.Any()).Times(2).Return(nil)
+
+	transactor.EXPECT().
+		WithinTransaction(gomock.Any(), gomock.Any()).
+		Times(2).
+		DoAndReturn(func(_ context.Context, fn func(context.Context) error) error {
+			return fn(context.Background())
+		})
+
+	if err := u.processBatch(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestUpdaterService_processBatch_BatchProviderFailureAbortsTick(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockUpdaterRepository(ctrl)
+	provider := mocks.NewMockBatchTikTokProvider(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+
+	cfg := UpdaterConfig{
+		Interval:       time.Second,
+		BatchSize:      10,
+		MaxConcurrency: 1,
+	}
+	earningsCfg := EarningsConfig{Per: 1000, Rate: 0.10}
+
+	u := NewUpdaterService(repo, provider, logger, cfg, earningsCfg, nil, nil, nil, nil)
+
+	repo.EXPECT().
+		ListVideosForUpdate(gomock.Any(), cfg.MinUpdateAge, cfg.BatchSize).
+		Return([]models.Video{{ID: 1, URL: "url1"}}, nil)
+
+	provider.EXPECT().
+		GetVideoStatsBatch(gomock.Any(), []string{"url1"}).
+		Return(nil, fmt.Errorf("provider down"))
+
+	//a whole-request failure must not flag every video as errored
+	repo.EXPECT().SetVideoErrorStatus(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	if err := u.processBatch(context.Background()); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func TestUpdaterService_processBatch_ScopesWritesToVideoWorkspace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockUpdaterRepository(ctrl)
+	provider := mocks.NewMockTikTokProvider(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	transactor := mocks.NewMockTransactor(ctrl)
+
+	logger.EXPECT().Info(gomock.Any()).AnyTimes()
+	logger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := UpdaterConfig{Interval: time.Second, BatchSize: 10, MaxConcurrency: 2}
+	u := NewUpdaterService(repo, provider, logger, cfg, EarningsConfig{Per: 1000, Rate: 0.10}, transactor, nil, nil, nil)
+
+	videos := []models.Video{
+		{ID: 1, WorkspaceID: 1, URL: "url1", TikTokID: "t1"},
+		{ID: 2, WorkspaceID: 2, URL: "url2", TikTokID: "t2"},
+	}
+
+	gomock.InOrder(
+		repo.EXPECT().ListVideosForUpdate(gomock.Any(), cfg.MinUpdateAge, cfg.BatchSize).Return(videos, nil),
+		repo.EXPECT().ListVideosForUpdate(gomock.Any(), cfg.MinUpdateAge, cfg.BatchSize).Return([]models.Video{}, nil),
+	)
+
+	provider.EXPECT().GetVideoStats(gomock.Any(), "url1").Return(&models.VideoStats{Views: 100}, nil)
+	provider.EXPECT().GetVideoStats(gomock.Any(), "url2").Return(nil, fmt.Errorf("gone"))
+
+	// each write must carry the workspace of the video it touches
+	wantWorkspace := func(videoID, workspaceID int64) func(ctx context.Context) {
+		return func(ctx context.Context) {
+			if got, ok := tenant.FromContext(ctx); !ok || got != workspaceID {
+				t.Errorf("video %d: expected workspace %d, got %d (%v)", videoID, workspaceID, got, ok)
+			}
+		}
+	}
+
+	transactor.EXPECT().
+		WithinTransaction(gomock.Any(), gomock.Any()).
+		Times(2).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+	repo.EXPECT().AppendVideoStats(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ models.CreateVideoStatsInput) error {
+			wantWorkspace(1, 1)(ctx)
+			return nil
+		})
+	repo.EXPECT().UpdateVideoAggregates(gomock.Any(), gomock.Any()).Return(nil)
+	repo.EXPECT().SetVideoErrorStatus(gomock.Any(), int64(2), "gone").
+		DoAndReturn(func(ctx context.Context, _ int64, _ string) error {
+			wantWorkspace(2, 2)(ctx)
+			return nil
+		})
+
+	if err := u.processBatch(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestUpdaterService_processBatch_RecordsCrossedMilestones(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockUpdaterRepository(ctrl)
+	provider := mocks.NewMockTikTokProvider(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	transactor := mocks.NewMockTransactor(ctrl)
+	events := mocks.NewMockEventRecorder(ctrl)
+
+	logger.EXPECT().Info(gomock.Any()).AnyTimes()
+
+	cfg := UpdaterConfig{
+		Interval:           time.Second,
+		BatchSize:          10,
+		MaxConcurrency:     1,
+		ViewMilestones:     []int64{1000, 5000, 10000},
+		EarningsThresholds: []float64{1},
+	}
+	u := NewUpdaterService(repo, provider, logger, cfg, EarningsConfig{Per: 1000, Rate: 0.10}, transactor, events, nil, nil)
+
+	video := models.Video{ID: 1, WorkspaceID: 1, URL: "url1", TikTokID: "t1", CurrentViews: 900, CurrentEarnings: 0.09}
+
+	gomock.InOrder(
+		repo.EXPECT().ListVideosForUpdate(gomock.Any(), cfg.MinUpdateAge, cfg.BatchSize).Return([]models.Video{video}, nil),
+		repo.EXPECT().ListVideosForUpdate(gomock.Any(), cfg.MinUpdateAge, cfg.BatchSize).Return([]models.Video{}, nil),
+	)
+
+	provider.EXPECT().GetVideoStats(gomock.Any(), "url1").Return(&models.VideoStats{Views: 6000}, nil)
+
+	transactor.EXPECT().
+		WithinTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+	repo.EXPECT().AppendVideoStats(gomock.Any(), gomock.Any()).Return(nil)
+	repo.EXPECT().UpdateVideoAggregates(gomock.Any(), gomock.Any()).Return(nil)
+
+	// 900 -> 6000 views crosses 1000 and 5000; 0.09 -> 0.60 earnings crosses nothing
+	var got []string
+	events.EXPECT().RecordEvent(gomock.Any(), gomock.Any()).
+		Times(2).
+		DoAndReturn(func(_ context.Context, event models.Event) error {
+			got = append(got, event.Type)
+			return nil
+		})
+
+	if err := u.processBatch(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, eventType := range got {
+		if eventType != models.EventVideoMilestone {
+			t.Errorf("expected %s, got %s", models.EventVideoMilestone, eventType)
+		}
+	}
+}
+
+func TestUpdaterService_processBatch_ObservesVideoResults(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockUpdaterRepository(ctrl)
+	provider := mocks.NewMockTikTokProvider(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	transactor := mocks.NewMockTransactor(ctrl)
+	metrics := mocks.NewMockUpdaterMetrics(ctrl)
+
+	logger.EXPECT().Info(gomock.Any()).AnyTimes()
+	logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := UpdaterConfig{Interval: time.Second, BatchSize: 10, MaxConcurrency: 1}
+	u := NewUpdaterService(repo, provider, logger, cfg, EarningsConfig{Per: 1000, Rate: 0.10}, transactor, nil, nil, metrics)
+
+	videos := []models.Video{
+		{ID: 1, URL: "url-1", CurrentViews: 10},
+		{ID: 2, URL: "url-2", CurrentViews: 10},
+		{ID: 3, URL: "url-3", CurrentViews: 10},
+	}
+
+	gomock.InOrder(
+		repo.EXPECT().ListVideosForUpdate(gomock.Any(), gomock.Any(), gomock.Any()).Return(videos, nil),
+		repo.EXPECT().ListVideosForUpdate(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil),
+	)
+
+	provider.EXPECT().GetVideoStats(gomock.Any(), "url-1").Return(&models.VideoStats{Views: 20}, nil)
+	provider.EXPECT().GetVideoStats(gomock.Any(), "url-2").Return(&models.VideoStats{Views: 10}, nil)
+	provider.EXPECT().GetVideoStats(gomock.Any(), "url-3").Return(nil, errors.New("provider down"))
+
+	transactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).Times(2).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+	repo.EXPECT().AppendVideoStats(gomock.Any(), gomock.Any()).Return(nil)
+	repo.EXPECT().UpdateVideoAggregates(gomock.Any(), gomock.Any()).Return(nil)
+	repo.EXPECT().SetVideoErrorStatus(gomock.Any(), int64(3), gomock.Any()).Return(nil)
+
+	gomock.InOrder(
+		metrics.EXPECT().ObserveUpdaterVideo(VideoResultUpdated),
+		metrics.EXPECT().ObserveUpdaterVideo(VideoResultUnchanged),
+		metrics.EXPECT().ObserveUpdaterVideo(VideoResultFailed),
+	)
+
+	if err := u.processBatch(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestUpdaterService_processBatch_FlushesInBulk(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockUpdaterRepository(ctrl)
+	provider := mocks.NewMockTikTokProvider(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	transactor := mocks.NewMockTransactor(ctrl)
+	metrics := mocks.NewMockUpdaterMetrics(ctrl)
+
+	logger.EXPECT().Info(gomock.Any()).AnyTimes()
+	logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := UpdaterConfig{Interval: time.Second, BatchSize: 10, MaxConcurrency: 1, FlushSize: 2}
+	u := NewUpdaterService(repo, provider, logger, cfg, EarningsConfig{Per: 1000, Rate: 0.10}, transactor, nil, nil, metrics)
+
+	videos := []models.Video{
+		{ID: 1, WorkspaceID: 1, URL: "url-1", CurrentViews: 10},
+		{ID: 2, WorkspaceID: 2, URL: "url-2", CurrentViews: 10},
+		{ID: 3, WorkspaceID: 1, URL: "url-3", CurrentViews: 10},
+		{ID: 4, WorkspaceID: 1, URL: "url-4", CurrentViews: 10},
+	}
+
+	gomock.InOrder(
+		repo.EXPECT().ListVideosForUpdate(gomock.Any(), gomock.Any(), gomock.Any()).Return(videos, nil),
+		repo.EXPECT().ListVideosForUpdate(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil),
+	)
+
+	provider.EXPECT().GetVideoStats(gomock.Any(), "url-1").Return(&models.VideoStats{Views: 1000}, nil)
+	provider.EXPECT().GetVideoStats(gomock.Any(), "url-2").Return(&models.VideoStats{Views: 2000}, nil)
+	provider.EXPECT().GetVideoStats(gomock.Any(), "url-3").Return(&models.VideoStats{Views: 10}, nil)
+	provider.EXPECT().GetVideoStats(gomock.Any(), "url-4").Return(&models.VideoStats{Views: 3000}, nil)
+
+	// videos 1 and 2 fill the first flush, video 4 goes out with the remainder after the pool drains
+	transactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).Times(2).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+	writeIDs := func(writes []models.VideoStatsWrite) string {
+		var ids []string
+		for _, w := range writes {
+			ids = append(ids, fmt.Sprintf("%d/%d:%d", w.WorkspaceID, w.VideoID, w.Views))
+		}
+		return strings.Join(ids, " ")
+	}
+	expectWrites := func(want string) func(context.Context, []models.VideoStatsWrite) {
+		return func(_ context.Context, writes []models.VideoStatsWrite) {
+			if got := writeIDs(writes); got != want {
+				t.Errorf("expected writes %q, got %q", want, got)
+			}
+		}
+	}
+	gomock.InOrder(
+		repo.EXPECT().UpdateVideoAggregatesBulk(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, writes []models.VideoStatsWrite) ([]int64, error) {
+				expectWrites("1/1:1000 2/2:2000")(ctx, writes)
+				return []int64{1}, nil // video 2 was deleted meanwhile
+			}),
+		repo.EXPECT().CopyVideoStats(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, writes []models.VideoStatsWrite) (int64, error) {
+				expectWrites("1/1:1000")(ctx, writes)
+				return int64(len(writes)), nil
+			}),
+		repo.EXPECT().UpdateVideoAggregatesBulk(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, writes []models.VideoStatsWrite) ([]int64, error) {
+				expectWrites("1/4:3000")(ctx, writes)
+				return []int64{4}, nil
+			}),
+		repo.EXPECT().CopyVideoStats(gomock.Any(), gomock.Len(1)).Return(int64(1), nil),
+	)
+
+	gomock.InOrder(
+		metrics.EXPECT().ObserveUpdaterVideo(VideoResultUpdated),
+		metrics.EXPECT().ObserveUpdaterVideo(VideoResultFailed),
+		metrics.EXPECT().ObserveUpdaterVideo(VideoResultUnchanged),
+		metrics.EXPECT().ObserveUpdaterVideo(VideoResultUpdated),
+	)
+
+	if err := u.processBatch(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestUpdaterService_processBatch_BulkFailureFallsBackPerVideo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockUpdaterRepository(ctrl)
+	provider := mocks.NewMockTikTokProvider(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	transactor := mocks.NewMockTransactor(ctrl)
+	metrics := mocks.NewMockUpdaterMetrics(ctrl)
+
+	logger.EXPECT().Info(gomock.Any()).AnyTimes()
+	logger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := UpdaterConfig{Interval: time.Second, BatchSize: 10, MaxConcurrency: 1, FlushSize: 10}
+	u := NewUpdaterService(repo, provider, logger, cfg, EarningsConfig{Per: 1000, Rate: 0.10}, transactor, nil, nil, metrics)
+
+	videos := []models.Video{
+		{ID: 1, WorkspaceID: 1, URL: "url-1", CurrentViews: 10},
+		{ID: 2, WorkspaceID: 2, URL: "url-2", CurrentViews: 10},
+	}
+
+	gomock.InOrder(
+		repo.EXPECT().ListVideosForUpdate(gomock.Any(), gomock.Any(), gomock.Any()).Return(videos, nil),
+		repo.EXPECT().ListVideosForUpdate(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil),
+	)
+
+	provider.EXPECT().GetVideoStats(gomock.Any(), "url-1").Return(&models.VideoStats{Views: 1000}, nil)
+	provider.EXPECT().GetVideoStats(gomock.Any(), "url-2").Return(&models.VideoStats{Views: 2000}, nil)
+
+	// the bulk transaction, then one per video
+	transactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).Times(3).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+	repo.EXPECT().UpdateVideoAggregatesBulk(gomock.Any(), gomock.Len(2)).Return([]int64{1, 2}, nil)
+	repo.EXPECT().CopyVideoStats(gomock.Any(), gomock.Len(2)).Return(int64(0), errors.New("copy failed"))
+
+	repo.EXPECT().AppendVideoStats(gomock.Any(), gomock.Any()).Times(2).
+		DoAndReturn(func(ctx context.Context, input models.CreateVideoStatsInput) error {
+			if ws, _ := tenant.FromContext(ctx); ws != input.VideoID {
+				t.Errorf("video %d: expected workspace %d, got %d", input.VideoID, input.VideoID, ws)
+			}
+			return nil
+		})
+	repo.EXPECT().UpdateVideoAggregates(gomock.Any(), gomock.Any()).Times(2).Return(nil)
+
+	metrics.EXPECT().ObserveUpdaterVideo(VideoResultUpdated).Times(2)
+
+	if err := u.processBatch(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestUpdaterConfig_Validate(t *testing.T) {
+	if err := (UpdaterConfig{BatchSize: 500, FlushSize: 500}).Validate(); err != nil {
+		t.Fatalf("expected a flush per batch to be accepted, got %v", err)
+	}
+	if err := (UpdaterConfig{BatchSize: 50}).Validate(); err != nil {
+		t.Fatalf("expected per-video writes to be accepted, got %v", err)
+	}
+	if err := (UpdaterConfig{BatchSize: 50, FlushSize: 500}).Validate(); err == nil {
+		t.Fatal("expected a flush_size above batch_size to be refused")
+	}
+}
+
+func TestUpdaterService_processBatch_Heartbeat(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockUpdaterRepository(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	logger.EXPECT().Info(gomock.Any()).AnyTimes()
+
+	u := NewUpdaterService(repo, nil, logger, UpdaterConfig{BatchSize: 10, MaxConcurrency: 1}, EarningsConfig{}, nil, nil, nil, nil)
+
+	if !u.Heartbeat().IsZero() {
+		t.Fatalf("expected no heartbeat before the first pass, got %v", u.Heartbeat())
+	}
+
+	repo.EXPECT().
+		ListVideosForUpdate(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, nil)
+
+	before := time.Now()
+	if err := u.processBatch(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if u.Heartbeat().Before(before) {
+		t.Fatalf("heartbeat %v not refreshed by the pass started at %v", u.Heartbeat(), before)
+	}
+}
+
+func TestUpdaterService_RefreshVideo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockUpdaterRepository(ctrl)
+	provider := mocks.NewMockTikTokProvider(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	transactor := mocks.NewMockTransactor(ctrl)
+
+	logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	audit := mocks.NewMockAuditRecorder(ctrl)
+
+	u := NewUpdaterService(repo, provider, logger, UpdaterConfig{}, EarningsConfig{Per: 1000, Rate: 0.10}, transactor, nil, audit, nil)
+
+	ctx := tenant.NewContext(context.Background(), 1)
+	video := &models.Video{ID: 7, WorkspaceID: 1, URL: "url7", CurrentViews: 1000, CurrentEarnings: 0.1}
+	refreshed := &models.Video{ID: 7, WorkspaceID: 1, URL: "url7", CurrentViews: 3000, CurrentEarnings: 0.3}
+
+	gomock.InOrder(
+		repo.EXPECT().FindVideoByID(gomock.Any(), int64(7)).Return(video, nil),
+		// the audit entry reads the video inside the transaction, the response after it
+		repo.EXPECT().FindVideoByID(gomock.Any(), int64(7)).Return(refreshed, nil).Times(2),
+	)
+	audit.EXPECT().AppendAuditEntry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, in models.CreateAuditEntryInput) (int64, error) {
+			before, after := in.Before.(models.TrackVideoResponse), in.After.(models.TrackVideoResponse)
+			if in.Action != models.AuditVideoRefreshed || before.CurrentViews != 1000 || after.CurrentViews != 3000 {
+				t.Fatalf("unexpected audit entry: %+v", in)
+			}
+			return 1, nil
+		})
+	provider.EXPECT().GetVideoStats(gomock.Any(), "url7").Return(&models.VideoStats{Views: 3000}, nil)
+
+	// 2000 new views at 0.10 per 1000 on top of the earnings so far
+	wantEarnings := video.CurrentEarnings + float64(2000)/1000*0.10
+
+	transactor.EXPECT().
+		WithinTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+	repo.EXPECT().
+		AppendVideoStats(gomock.Any(), models.CreateVideoStatsInput{VideoID: 7, Views: 3000, Earnings: wantEarnings}).
+		Return(nil)
+	repo.EXPECT().
+		UpdateVideoAggregates(gomock.Any(), models.UpdateVideoAggregatesInput{VideoID: 7, Views: 3000, Earnings: wantEarnings}).
+		Return(nil)
+
+	got, result, err := u.RefreshVideo(ctx, 7)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result != VideoResultUpdated || got != refreshed {
+		t.Fatalf("got %s %+v, want updated %+v", result, got, refreshed)
+	}
+}
+
+func TestUpdaterService_RefreshVideo_ProviderErrorLeavesVideoAlone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockUpdaterRepository(ctrl)
+	provider := mocks.NewMockTikTokProvider(ctrl)
+
+	u := NewUpdaterService(repo, provider, mocks.NewMockLogger(ctrl), UpdaterConfig{}, EarningsConfig{Per: 1000}, nil, nil, nil, nil)
+
+	ctx := tenant.NewContext(context.Background(), 1)
+	providerErr := errors.New("ensemble down")
+
+	repo.EXPECT().FindVideoByID(gomock.Any(), int64(7)).Return(&models.Video{ID: 7, URL: "url7"}, nil)
+	provider.EXPECT().GetVideoStats(gomock.Any(), "url7").Return(nil, providerErr)
+	// no SetVideoErrorStatus: a manual refresh does not flag the video
+
+	_, result, err := u.RefreshVideo(ctx, 7)
+	if !errors.Is(err, providerErr) || result != VideoResultFailed {
+		t.Fatalf("got %s %v, want failed %v", result, err, providerErr)
+	}
+}
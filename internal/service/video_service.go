@@ -4,8 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
+	"ttanalytic/internal/logging"
 	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+	"ttanalytic/internal/tracing"
 )
 
 const (
@@ -14,11 +18,22 @@ const (
 
 type Repository interface {
 	FindVideoByTikTokID(ctx context.Context, tikTokID string) (*models.Video, error)
+	FindVideoByID(ctx context.Context, videoID int64) (*models.Video, error)
 	CreateVideo(ctx context.Context, input models.CreateVideoInput) (*models.Video, error)
 	AppendVideoStats(ctx context.Context, input models.CreateVideoStatsInput) error
-	GetVideoHistory(ctx context.Context, videoID int64, from, to *time.Time) ([]*models.VideoStatPoint, error)
 	SetVideoErrorStatus(ctx context.Context, videoID int64, errText string) error
 	SetVideoStoppedStatus(ctx context.Context, videoID int64) error
+	StreamVideos(ctx context.Context, filter models.VideoListFilter, fn func(models.Video) error) error
+	StreamVideoHistory(ctx context.Context, videoID int64, from, to *time.Time, fn func(models.VideoStatPoint) error) error
+	StreamVideoRollups(ctx context.Context, granularity string, videoID int64, from, to *time.Time, fn func(models.VideoStatPoint) error) error
+	GetVideoHistory(ctx context.Context, videoID int64, from, to *time.Time) ([]models.VideoStatPoint, error)
+	GetVideoRollups(ctx context.Context, granularity string, videoID int64, from, to *time.Time) ([]models.VideoStatPoint, error)
+	GetRollupState(ctx context.Context) (models.RollupState, error)
+	PinReads(ctx context.Context) context.Context
+	SetVideoActiveStatus(ctx context.Context, videoID int64) error
+	RecomputeEarnings(ctx context.Context, rate float64, per int64, history bool) (int64, error)
+	PortfolioSummary(ctx context.Context) (*models.PortfolioSummary, error)
+	TopVideos(ctx context.Context, limit int) ([]models.Video, error)
 }
 type TikTokProvider interface {
 	GetVideoStats(ctx context.Context, videoURL string) (*models.VideoStats, error)
@@ -54,36 +69,43 @@ type Service struct {
 	earningsCfg EarningsConfig
 	logger      Logger
 	transactor  Transactor
+	events      EventRecorder
+	audit       AuditRecorder
 }
 
-func NewService(repo Repository, prov TikTokProvider, earningsCfg EarningsConfig, logger Logger, transactor Transactor) *Service {
+func NewService(repo Repository, prov TikTokProvider, earningsCfg EarningsConfig, logger Logger, transactor Transactor, events EventRecorder, audit AuditRecorder) *Service {
 	return &Service{
 		repo:        repo,
 		provider:    prov,
 		earningsCfg: earningsCfg,
 		logger:      logger,
 		transactor:  transactor,
+		events:      eventsOrNoop(events),
+		audit:       auditOrNoop(audit),
 	}
 }
 
-func (s *Service) TrackVideo(ctx context.Context, req models.TrackVideoRequest) (models.TrackVideoResponse, error) {
+func (s *Service) TrackVideo(ctx context.Context, req models.TrackVideoRequest) (_ models.TrackVideoResponse, err error) {
+	ctx, span := tracing.Start(ctx, "Service.TrackVideo")
+	defer func() { tracing.End(span, err) }()
+
 	//try to find existing video
 	video, err := s.repo.FindVideoByTikTokID(ctx, req.TikTokID)
 	if err != nil && !errors.Is(err, models.ErrNotFound) {
-		s.logger.Errorf("TrackVideo: FindVideoByTikTokID(%s) error: %v", req.TikTokID, err)
+		logging.From(ctx, s.logger).Errorf("TrackVideo: FindVideoByTikTokID(%s) error: %v", req.TikTokID, err)
 		return models.TrackVideoResponse{}, err
 	}
 
 	//video already exists
 	if video != nil {
-		s.logger.Infof("TrackVideo: video %s found in DB, not calling provider", req.TikTokID)
-		return s.buildTrackVideoResponse(video), nil
+		logging.From(ctx, s.logger).Infof("TrackVideo: video %s found in DB, not calling provider", req.TikTokID)
+		return buildVideoResponse(video), nil
 	}
 
 	//call the provider
 	stats, err := s.provider.GetVideoStats(ctx, req.URL)
 	if err != nil {
-		s.logger.Errorf("TrackVideo: provider error for %s: %v", req.URL, err)
+		logging.From(ctx, s.logger).Errorf("TrackVideo: provider error for %s: %v", req.URL, err)
 		return models.TrackVideoResponse{}, err
 	}
 
@@ -119,36 +141,69 @@ func (s *Service) TrackVideo(ctx context.Context, req models.TrackVideoRequest)
 		if err := s.repo.AppendVideoStats(txCtx, statInput); err != nil {
 			return fmt.Errorf("append stats for video_id=%d: %w", video.ID, err)
 		}
-		return nil
+
+		if err := recordChange(txCtx, s.audit, models.AuditVideoTracked, models.AuditTargetVideo, video.ID, nil, buildVideoResponse(video)); err != nil {
+			return err
+		}
+
+		return recordVideoEvent(txCtx, s.events, models.EventVideoTracked, "", videoEventData(*video))
 	})
 	if err != nil {
 		return models.TrackVideoResponse{}, err
 	}
 
 	//build response
-	return s.buildTrackVideoResponse(createdVideo), nil
+	return buildVideoResponse(createdVideo), nil
 }
 
-func (s *Service) GetVideo(ctx context.Context, tikTokID string) (models.TrackVideoResponse, error) {
+func (s *Service) GetVideo(ctx context.Context, tikTokID string) (_ models.TrackVideoResponse, err error) {
+	ctx, span := tracing.Start(ctx, "Service.GetVideo")
+	defer func() { tracing.End(span, err) }()
+
 	video, err := s.repo.FindVideoByTikTokID(ctx, tikTokID)
 	if err != nil {
-		s.logger.Errorf("Service: GetVideo repo error: %v", err)
+		logging.From(ctx, s.logger).Errorf("Service: GetVideo repo error: %v", err)
 		return models.TrackVideoResponse{}, err
 	}
 
 	//build response
-	return s.buildTrackVideoResponse(video), nil
+	return buildVideoResponse(video), nil
 }
-func (s *Service) GetVideoHistory(ctx context.Context, videoID int64, from, to *time.Time) (models.VideoHistoryResponse, error) {
-	points, err := s.repo.GetVideoHistory(ctx, videoID, from, to)
+
+// GetVideoByRef finds the video by its internal id or its TikTok id, whichever the ref holds
+func (s *Service) GetVideoByRef(ctx context.Context, ref models.VideoRef) (_ models.TrackVideoResponse, err error) {
+	ctx, span := tracing.Start(ctx, "Service.GetVideoByRef")
+	defer func() { tracing.End(span, err) }()
+
+	var video *models.Video
+	if ref.TikTokID != "" {
+		video, err = s.repo.FindVideoByTikTokID(ctx, ref.TikTokID)
+	} else {
+		video, err = s.repo.FindVideoByID(ctx, ref.ID)
+	}
 	if err != nil {
-		s.logger.Errorf("Service: GetVideoHistory repo error: %v", err)
-		return models.VideoHistoryResponse{}, err
+		if !errors.Is(err, models.ErrNotFound) {
+			logging.From(ctx, s.logger).Errorf("Service: GetVideoByRef(%s) repo error: %v", ref, err)
+		}
+		return models.TrackVideoResponse{}, err
 	}
 
-	historyVideo := make([]models.VideoStatPoint, 0, len(points))
-	for _, p := range points {
-		historyVideo = append(historyVideo, *p)
+	return buildVideoResponse(video), nil
+}
+
+func (s *Service) GetVideoHistory(ctx context.Context, videoID int64, from, to *time.Time) (_ models.VideoHistoryResponse, err error) {
+	ctx, span := tracing.Start(ctx, "Service.GetVideoHistory")
+	defer func() { tracing.End(span, err) }()
+
+	historyVideo := make([]models.VideoStatPoint, 0)
+
+	err = s.history(ctx, videoID, from, to, true, func(p models.VideoStatPoint) error {
+		historyVideo = append(historyVideo, p)
+		return nil
+	})
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("Service: GetVideoHistory repo error: %v", err)
+		return models.VideoHistoryResponse{}, err
 	}
 
 	return models.VideoHistoryResponse{
@@ -156,15 +211,302 @@ func (s *Service) GetVideoHistory(ctx context.Context, videoID int64, from, to *
 		HistoryVideo: historyVideo,
 	}, nil
 }
-func (s *Service) StopTracking(ctx context.Context, videoID int64) error {
-	if err := s.repo.SetVideoStoppedStatus(ctx, videoID); err != nil {
-		s.logger.Errorf("StopTracking: SetVideoStoppedStatus(%d) error: %v", videoID, err)
+
+// ListVideos returns one page of the videos of the workspace, by id
+func (s *Service) ListVideos(ctx context.Context, filter models.VideoListFilter) (_ models.VideoListResponse, err error) {
+	ctx, span := tracing.Start(ctx, "Service.ListVideos")
+	defer func() { tracing.End(span, err) }()
+
+	resp := models.VideoListResponse{Videos: make([]models.TrackVideoResponse, 0, filter.Limit)}
+
+	err = s.repo.StreamVideos(ctx, filter, func(v models.Video) error {
+		resp.Videos = append(resp.Videos, buildVideoResponse(&v))
+		return nil
+	})
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("Service: ListVideos repo error: %v", err)
+		return models.VideoListResponse{}, err
+	}
+
+	if filter.Limit > 0 && len(resp.Videos) == filter.Limit {
+		next := resp.Videos[len(resp.Videos)-1].VideoID
+		resp.NextAfterID = &next
+	}
+
+	return resp, nil
+}
+
+// StreamVideos calls fn for every video matching the filter without loading the list in memory
+func (s *Service) StreamVideos(ctx context.Context, filter models.VideoListFilter, fn func(models.TrackVideoResponse) error) (err error) {
+	ctx, span := tracing.Start(ctx, "Service.StreamVideos")
+	defer func() { tracing.End(span, err) }()
+
+	return s.repo.StreamVideos(ctx, filter, func(v models.Video) error {
+		return fn(buildVideoResponse(&v))
+	})
+}
+
+// StreamVideoHistory calls fn for every point of the video history in [from, to), like GetVideoHistory
+func (s *Service) StreamVideoHistory(ctx context.Context, videoID int64, from, to *time.Time, fn func(models.VideoStatPoint) error) (err error) {
+	ctx, span := tracing.Start(ctx, "Service.StreamVideoHistory")
+	defer func() { tracing.End(span, err) }()
+
+	return s.history(ctx, videoID, from, to, false, fn)
+}
+
+// historySegment is a part of a history range read at one granularity; nil bounds are open
+type historySegment struct {
+	granularity string
+	from, to    *time.Time
+}
+
+// history calls fn for the points of the video in [from, to), oldest first. Each part of the range is read
+// at the finest granularity still stored: raw snapshots, daily rollups past the raw retention, weekly
+// rollups past the daily one. Buffered reads are bounded by the query timeout; streamed ones only by ctx,
+// the client that disconnects cancels them. The rollup state and the rows come from the same server, a lagging
+// replica would otherwise be asked for rows the primary already moved to a rollup.
+func (s *Service) history(ctx context.Context, videoID int64, from, to *time.Time, buffered bool, fn func(models.VideoStatPoint) error) error {
+	ctx = s.repo.PinReads(ctx)
+
+	st, err := s.repo.GetRollupState(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, seg := range historySegments(st, from, to) {
+		emit := func(p models.VideoStatPoint) error {
+			p.Granularity = seg.granularity
+			return fn(p)
+		}
+
+		if err := s.readSegment(ctx, seg, videoID, buffered, emit); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) readSegment(ctx context.Context, seg historySegment, videoID int64, buffered bool, fn func(models.VideoStatPoint) error) error {
+	if !buffered {
+		if seg.granularity == models.GranularityHour {
+			return s.repo.StreamVideoHistory(ctx, videoID, seg.from, seg.to, fn)
+		}
+		return s.repo.StreamVideoRollups(ctx, seg.granularity, videoID, seg.from, seg.to, fn)
+	}
+
+	var points []models.VideoStatPoint
+	var err error
+
+	if seg.granularity == models.GranularityHour {
+		points, err = s.repo.GetVideoHistory(ctx, videoID, seg.from, seg.to)
+	} else {
+		points, err = s.repo.GetVideoRollups(ctx, seg.granularity, videoID, seg.from, seg.to)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, p := range points {
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// historySegments splits [from, to) at the retention horizons of st, oldest part first
+func historySegments(st models.RollupState, from, to *time.Time) []historySegment {
+	if st.RawFrom == nil {
+		return []historySegment{{granularity: models.GranularityHour, from: from, to: to}}
+	}
+
+	rawFrom := *st.RawFrom
+	var all []historySegment
+
+	// daily rows are gone before DailyFrom, raw ones before RawFrom, whichever is later
+	dailyFrom := st.DailyFrom
+	if dailyFrom != nil {
+		weeklyTo := earlierTime(*dailyFrom, rawFrom)
+		all = append(all, historySegment{granularity: models.GranularityWeek, to: &weeklyTo})
+	}
+	if dailyFrom == nil || dailyFrom.Before(rawFrom) {
+		all = append(all, historySegment{granularity: models.GranularityDay, from: dailyFrom, to: &rawFrom})
+	}
+	all = append(all, historySegment{granularity: models.GranularityHour, from: &rawFrom})
+
+	var result []historySegment
+	for _, seg := range all {
+		if from != nil && (seg.from == nil || seg.from.Before(*from)) {
+			seg.from = from
+		}
+		if to != nil && (seg.to == nil || seg.to.After(*to)) {
+			seg.to = to
+		}
+		if seg.from != nil && seg.to != nil && !seg.from.Before(*seg.to) {
+			continue
+		}
+		result = append(result, seg)
+	}
+
+	return result
+}
+
+func (s *Service) StopTracking(ctx context.Context, videoID int64) (err error) {
+	ctx, span := tracing.Start(ctx, "Service.StopTracking")
+	defer func() { tracing.End(span, err) }()
+
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		before, after, err := s.changeVideo(txCtx, videoID, models.AuditVideoStopped, func() error {
+			return s.repo.SetVideoStoppedStatus(txCtx, videoID)
+		})
+		if err != nil {
+			return err
+		}
+		if before.TrackingStatus == models.VideoStatusStopped {
+			return nil
+		}
+
+		// one event per stop: a video stopped again after a resume gets a new event id
+		key := strconv.FormatInt(after.UpdatedAt.UnixNano(), 10)
+
+		return recordVideoEvent(txCtx, s.events, models.EventVideoStopped, key, models.VideoEventData{VideoID: videoID})
+	})
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("StopTracking: SetVideoStoppedStatus(%d) error: %v", videoID, err)
+		return err
+	}
+
+	return nil
+}
+
+// ResumeTracking puts a stopped or errored video back in the updater queue
+func (s *Service) ResumeTracking(ctx context.Context, videoID int64) (err error) {
+	ctx, span := tracing.Start(ctx, "Service.ResumeTracking")
+	defer func() { tracing.End(span, err) }()
+
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		_, _, err := s.changeVideo(txCtx, videoID, models.AuditVideoResumed, func() error {
+			return s.repo.SetVideoActiveStatus(txCtx, videoID)
+		})
+		return err
+	})
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("ResumeTracking: SetVideoActiveStatus(%d) error: %v", videoID, err)
 		return err
 	}
 
 	return nil
 }
 
+// changeVideo applies a status change to a video and audits it with the video as the API
+// shows it before and after, which it returns. Call it inside a transaction.
+func (s *Service) changeVideo(txCtx context.Context, videoID int64, action string, change func() error) (before, after *models.Video, err error) {
+	before, err = s.repo.FindVideoByID(txCtx, videoID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := change(); err != nil {
+		return nil, nil, err
+	}
+
+	after, err = s.repo.FindVideoByID(txCtx, videoID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = recordChange(txCtx, s.audit, action, models.AuditTargetVideo, videoID, buildVideoResponse(before), buildVideoResponse(after))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return before, after, nil
+}
+
+// earningsRecomputedDetails is the audit record of a recompute: the rate it applied
+type earningsRecomputedDetails struct {
+	Rate    float64 `json:"rate"`
+	Per     int64   `json:"per"`
+	History bool    `json:"history"`
+	Videos  int64   `json:"videos"`
+}
+
+// RecomputeEarnings rewrites earnings from views at the configured rate, e.g. after the rate changed;
+// history also rewrites every snapshot. It returns the number of videos updated.
+func (s *Service) RecomputeEarnings(ctx context.Context, history bool) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "Service.RecomputeEarnings")
+	defer func() { tracing.End(span, err) }()
+
+	if s.earningsCfg.Per <= 0 {
+		return 0, fmt.Errorf("earnings per must be positive: %w", models.ErrInvalidRequest)
+	}
+
+	workspaceID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		n, err = s.repo.RecomputeEarnings(txCtx, s.earningsCfg.Rate, s.earningsCfg.Per, history)
+		if err != nil {
+			return err
+		}
+
+		entry := auditEntry(txCtx, models.AuditEarningsRecomputed, models.AuditTargetWorkspace, &workspaceID, earningsRecomputedDetails{
+			Rate:    s.earningsCfg.Rate,
+			Per:     s.earningsCfg.Per,
+			History: history,
+			Videos:  n,
+		})
+		if _, err := s.audit.AppendAuditEntry(txCtx, entry); err != nil {
+			return fmt.Errorf("audit earnings recompute: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("RecomputeEarnings: repo error: %v", err)
+		return 0, err
+	}
+
+	logging.From(ctx, s.logger).Infof("RecomputeEarnings: %d videos at %.4f per %d views (history=%t)",
+		n, s.earningsCfg.Rate, s.earningsCfg.Per, history)
+
+	return n, nil
+}
+
+// PortfolioSummary totals the videos of the workspace with its top videos by earnings
+func (s *Service) PortfolioSummary(ctx context.Context, top int) (_ models.PortfolioSummary, err error) {
+	ctx, span := tracing.Start(ctx, "Service.PortfolioSummary")
+	defer func() { tracing.End(span, err) }()
+
+	summary, err := s.repo.PortfolioSummary(ctx)
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("PortfolioSummary: repo error: %v", err)
+		return models.PortfolioSummary{}, err
+	}
+	summary.Currency = CurrencyUSD
+	summary.Top = []models.TrackVideoResponse{}
+
+	if top > 0 {
+		videos, err := s.repo.TopVideos(ctx, top)
+		if err != nil {
+			logging.From(ctx, s.logger).Errorf("PortfolioSummary: TopVideos error: %v", err)
+			return models.PortfolioSummary{}, err
+		}
+
+		for i := range videos {
+			summary.Top = append(summary.Top, buildVideoResponse(&videos[i]))
+		}
+	}
+
+	return *summary, nil
+}
+
 // helpers
 func (s *Service) calculateEarnings(views int64) float64 {
 	return s.earningsCfg.Calc(views)
@@ -180,7 +522,7 @@ func (s *Service) calculateInitialVideoState(stats *models.VideoStats) initialVi
 	}
 }
 
-func (s *Service) buildTrackVideoResponse(video *models.Video) models.TrackVideoResponse {
+func buildVideoResponse(video *models.Video) models.TrackVideoResponse {
 	return models.TrackVideoResponse{
 		VideoID:         video.ID,
 		TikTokID:        video.TikTokID,
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"ttanalytic/internal/mocks"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+
+	"github.com/golang/mock/gomock"
+)
+
+// statusRepository keeps one video and implements the status changes of Repository like the database does
+type statusRepository struct {
+	Repository
+
+	video models.Video
+	clock time.Time
+}
+
+func (r *statusRepository) FindVideoByID(context.Context, int64) (*models.Video, error) {
+	v := r.video
+	return &v, nil
+}
+
+func (r *statusRepository) SetVideoStoppedStatus(context.Context, int64) error {
+	r.clock = r.clock.Add(time.Minute)
+	r.video.TrackingStatus = models.VideoStatusStopped
+	r.video.UpdatedAt = r.clock
+	return nil
+}
+
+func (r *statusRepository) SetVideoActiveStatus(context.Context, int64) error {
+	r.video.TrackingStatus = models.VideoStatusActive
+	return nil
+}
+
+func TestService_StopTracking_OneEventPerStop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := &statusRepository{
+		video: models.Video{ID: 7, WorkspaceID: 1, TrackingStatus: models.VideoStatusActive},
+		clock: time.Date(2025, 11, 24, 12, 0, 0, 0, time.UTC),
+	}
+	transactor := mocks.NewMockTransactor(ctrl)
+	events := mocks.NewMockEventRecorder(ctrl)
+
+	transactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+
+	var ids []string
+	events.EXPECT().RecordEvent(gomock.Any(), gomock.Any()).Times(2).
+		DoAndReturn(func(_ context.Context, event models.Event) error {
+			if event.Type != models.EventVideoStopped {
+				t.Errorf("expected %s, got %s", models.EventVideoStopped, event.Type)
+			}
+			ids = append(ids, event.ID)
+			return nil
+		})
+
+	s := NewService(repo, nil, EarningsConfig{}, nil, transactor, events, nil)
+	ctx := tenant.NewContext(context.Background(), 1)
+
+	// the second stop changes nothing and emits nothing
+	for _, step := range []func(context.Context, int64) error{s.StopTracking, s.StopTracking, s.ResumeTracking, s.StopTracking} {
+		if err := step(ctx, 7); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if len(ids) != 2 || ids[0] == ids[1] {
+		t.Fatalf("expected two distinct event ids, got %v", ids)
+	}
+}
@@ -0,0 +1,231 @@
+//go:generate mockgen -destination=../mocks/watch_mocks.go -package=mocks ttanalytic/internal/service WatchRepository,WatchPostsProvider
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tracing"
+)
+
+type WatchRepository interface {
+	CreateWatch(ctx context.Context, input models.CreateWatchInput) (*models.Watch, error)
+	GetWatch(ctx context.Context, watchID int64) (*models.Watch, error)
+	GetWatchAggregates(ctx context.Context, watchID int64) (models.WatchAggregates, error)
+	ListWatchesForSync(ctx context.Context, minSyncAge time.Duration, limit int) ([]models.Watch, error)
+	LinkWatchVideo(ctx context.Context, watchID, videoID int64) (bool, error)
+	MarkWatchSynced(ctx context.Context, watchID int64) error
+	SetWatchSyncError(ctx context.Context, watchID int64, errText string) error
+	FindVideoByTikTokID(ctx context.Context, tikTokID string) (*models.Video, error)
+	CreateVideo(ctx context.Context, input models.CreateVideoInput) (*models.Video, error)
+	AppendVideoStats(ctx context.Context, input models.CreateVideoStatsInput) error
+}
+
+type WatchPostsProvider interface {
+	ListHashtagPosts(ctx context.Context, hashtag string, since time.Time, fn func([]models.CreatorPost) (bool, error)) error
+	ListMusicPosts(ctx context.Context, musicID string, since time.Time, fn func([]models.CreatorPost) (bool, error)) error
+}
+
+type WatchService struct {
+	repo        WatchRepository
+	provider    WatchPostsProvider
+	logger      Logger
+	cfg         DiscoveryConfig
+	earningsCfg EarningsConfig
+	transactor  Transactor
+	events      EventRecorder
+	audit       AuditRecorder
+}
+
+func NewWatchService(
+	repo WatchRepository,
+	provider WatchPostsProvider,
+	logger Logger,
+	cfg DiscoveryConfig,
+	earningsCfg EarningsConfig,
+	transactor Transactor,
+	events EventRecorder,
+	audit AuditRecorder,
+) *WatchService {
+	return &WatchService{
+		repo:        repo,
+		provider:    provider,
+		logger:      logger,
+		cfg:         cfg,
+		earningsCfg: earningsCfg,
+		transactor:  transactor,
+		events:      eventsOrNoop(events),
+		audit:       auditOrNoop(audit),
+	}
+}
+
+// RegisterWatch starts watching a hashtag or sound; matching posts are picked up by the discovery job
+func (s *WatchService) RegisterWatch(ctx context.Context, req models.RegisterWatchRequest) (_ models.WatchResponse, err error) {
+	ctx, span := tracing.Start(ctx, "WatchService.RegisterWatch")
+	defer func() { tracing.End(span, err) }()
+
+	trackFrom := time.Now().UTC()
+	if req.StartDate != nil {
+		trackFrom = req.StartDate.UTC()
+	}
+
+	var resp models.WatchResponse
+
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		watch, err := s.repo.CreateWatch(txCtx, models.CreateWatchInput{
+			Kind:            req.Kind,
+			Value:           req.Value,
+			TrackFrom:       trackFrom,
+			AllowedCreators: req.AllowedCreators,
+		})
+		if err != nil {
+			return err
+		}
+
+		resp = s.buildWatchResponse(watch, models.WatchAggregates{})
+
+		return recordChange(txCtx, s.audit, models.AuditWatchRegistered, models.AuditTargetWatch, watch.ID, nil, resp)
+	})
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("RegisterWatch: CreateWatch(%s %s) error: %v", req.Kind, req.Value, err)
+		return models.WatchResponse{}, err
+	}
+
+	return resp, nil
+}
+
+func (s *WatchService) GetWatch(ctx context.Context, watchID int64) (_ models.WatchResponse, err error) {
+	ctx, span := tracing.Start(ctx, "WatchService.GetWatch")
+	defer func() { tracing.End(span, err) }()
+
+	watch, err := s.repo.GetWatch(ctx, watchID)
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("WatchService: GetWatch repo error: %v", err)
+		return models.WatchResponse{}, err
+	}
+
+	agg, err := s.repo.GetWatchAggregates(ctx, watchID)
+	if err != nil {
+		return models.WatchResponse{}, err
+	}
+
+	return s.buildWatchResponse(watch, agg), nil
+}
+
+// Run periodically queries hashtag and sound feeds and tracks matching posts
+func (s *WatchService) Run(ctx context.Context) {
+	s.discovery().run(ctx)
+}
+
+func (s *WatchService) syncWatches(ctx context.Context) error {
+	return s.discovery().syncAll(ctx)
+}
+
+func (s *WatchService) discovery() discovery[models.Watch] {
+	return discovery[models.Watch]{
+		kind:   "watch",
+		logger: s.logger,
+		cfg:    s.cfg,
+		list:   s.repo.ListWatchesForSync,
+		describe: func(watch models.Watch) (int64, int64, string) {
+			return watch.ID, watch.WorkspaceID, watch.Kind + " " + watch.Value
+		},
+		sync:       s.syncWatch,
+		setError:   s.repo.SetWatchSyncError,
+		markSynced: s.repo.MarkWatchSynced,
+	}
+}
+
+// syncWatch tracks and links every matching post created after the watch's start date. The feeds list the
+// posts an earlier pass saw again: the first page whose matching posts are all linked already ends the pass.
+func (s *WatchService) syncWatch(ctx context.Context, watch models.Watch) error {
+	var list func(ctx context.Context, value string, since time.Time, fn func([]models.CreatorPost) (bool, error)) error
+
+	switch watch.Kind {
+	case models.WatchKindHashtag:
+		list = s.provider.ListHashtagPosts
+	case models.WatchKindSound:
+		list = s.provider.ListMusicPosts
+	default:
+		return fmt.Errorf("unknown watch kind %q", watch.Kind)
+	}
+
+	linked := 0
+
+	err := list(ctx, watch.Value, watch.TrackFrom, func(posts []models.CreatorPost) (bool, error) {
+		matching, linkedOnPage := 0, 0
+
+		for _, post := range posts {
+			if post.PostedAt.Before(watch.TrackFrom) || !watch.Allows(post.Username) {
+				continue
+			}
+			matching++
+
+			isNew, err := s.trackPost(ctx, watch.ID, post)
+			if err != nil {
+				return false, fmt.Errorf("track post %s: %w", post.TikTokID, err)
+			}
+			if isNew {
+				linkedOnPage++
+			}
+		}
+
+		linked += linkedOnPage
+
+		return matching == 0 || linkedOnPage > 0, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if linked > 0 {
+		s.logger.Infof("discovery: %s %s: %d matching videos", watch.Kind, watch.Value, linked)
+	}
+
+	return nil
+}
+
+// trackPost starts tracking the post if needed and links it to the watch, in one transaction for a new video;
+// false if it was linked already
+func (s *WatchService) trackPost(ctx context.Context, watchID int64, post models.CreatorPost) (bool, error) {
+	tracker := postTracker{repo: s.repo, earningsCfg: s.earningsCfg, transactor: s.transactor, events: s.events}
+	isNew := false
+
+	_, err := tracker.track(ctx, post, nil, func(ctx context.Context, video *models.Video) error {
+		var err error
+		isNew, err = s.repo.LinkWatchVideo(ctx, watchID, video.ID)
+		return err
+	})
+
+	return isNew, err
+}
+
+func (s *WatchService) buildWatchResponse(watch *models.Watch, agg models.WatchAggregates) models.WatchResponse {
+	resp := models.WatchResponse{
+		WatchID:         watch.ID,
+		Kind:            watch.Kind,
+		Value:           watch.Value,
+		StartDate:       watch.TrackFrom.UTC().Format(time.RFC3339),
+		AllowedCreators: watch.AllowedCreators,
+		Status:          watch.TrackingStatus,
+		VideoCount:      agg.VideoCount,
+		TotalViews:      agg.TotalViews,
+		TotalEarnings:   agg.TotalEarnings,
+		Currency:        CurrencyUSD,
+		CreatedAt:       watch.CreatedAt.UTC().Format(time.RFC3339),
+	}
+
+	if resp.AllowedCreators == nil {
+		resp.AllowedCreators = []string{}
+	}
+
+	if watch.LastSyncedAt != nil {
+		syncedAt := watch.LastSyncedAt.UTC().Format(time.RFC3339)
+		resp.LastSyncedAt = &syncedAt
+	}
+
+	return resp
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"ttanalytic/internal/mocks"
+	"ttanalytic/internal/models"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestWatchService_syncWatches_HashtagWithAllowList(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockWatchRepository(ctrl)
+	provider := mocks.NewMockWatchPostsProvider(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	transactor := mocks.NewMockTransactor(ctrl)
+
+	logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := DiscoveryConfig{
+		Interval:   time.Second,
+		BatchSize:  10,
+		MinSyncAge: time.Minute,
+	}
+	earningsCfg := EarningsConfig{Per: 1000, Rate: 0.10}
+
+	s := NewWatchService(repo, provider, logger, cfg, earningsCfg, transactor, nil, nil)
+
+	start := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	watch := models.Watch{
+		ID:              3,
+		Kind:            models.WatchKindHashtag,
+		Value:           "brandtag",
+		TrackFrom:       start,
+		AllowedCreators: []string{"partner"},
+	}
+
+	gomock.InOrder(
+		repo.EXPECT().
+			ListWatchesForSync(gomock.Any(), cfg.MinSyncAge, cfg.BatchSize).
+			Return([]models.Watch{watch}, nil),
+		repo.EXPECT().
+			ListWatchesForSync(gomock.Any(), cfg.MinSyncAge, cfg.BatchSize).
+			Return([]models.Watch{}, nil),
+	)
+
+	provider.EXPECT().
+		ListHashtagPosts(gomock.Any(), "brandtag", start, gomock.Any()).
+		DoAndReturn(feedPages(t, [][]models.CreatorPost{{
+			{TikTokID: "new", Username: "Partner", URL: "url-new", PostedAt: start.Add(time.Hour), Views: 1000},
+			{TikTokID: "known", Username: "partner", URL: "url-known", PostedAt: start.Add(time.Hour)},
+			{TikTokID: "stranger", Username: "someone", URL: "url-stranger", PostedAt: start.Add(time.Hour)},
+			{TikTokID: "old", Username: "partner", URL: "url-old", PostedAt: start.Add(-time.Hour)},
+		}}, 1))
+	provider.EXPECT().ListMusicPosts(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	repo.EXPECT().
+		FindVideoByTikTokID(gomock.Any(), "new").
+		Return(nil, models.ErrNotFound)
+	repo.EXPECT().
+		FindVideoByTikTokID(gomock.Any(), "known").
+		Return(&models.Video{ID: 11, TikTokID: "known"}, nil)
+
+	transactor.EXPECT().
+		WithinTransaction(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, fn func(context.Context) error) error {
+			return fn(context.Background())
+		})
+
+	repo.EXPECT().
+		CreateVideo(gomock.Any(), gomock.Any()).
+		Return(&models.Video{ID: 10, TikTokID: "new"}, nil)
+	repo.EXPECT().
+		AppendVideoStats(gomock.Any(), models.CreateVideoStatsInput{VideoID: 10, Views: 1000, Earnings: 0.1}).
+		Return(nil)
+
+	//new and already tracked videos are both attributed to the hashtag
+	repo.EXPECT().LinkWatchVideo(gomock.Any(), watch.ID, int64(10)).Return(true, nil)
+	repo.EXPECT().LinkWatchVideo(gomock.Any(), watch.ID, int64(11)).Return(true, nil)
+
+	repo.EXPECT().MarkWatchSynced(gomock.Any(), watch.ID).Return(nil)
+
+	if err := s.syncWatches(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestWatchService_syncWatches_SoundUsesMusicFeed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockWatchRepository(ctrl)
+	provider := mocks.NewMockWatchPostsProvider(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+
+	logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := DiscoveryConfig{Interval: time.Second, BatchSize: 10, MinSyncAge: time.Minute}
+
+	s := NewWatchService(repo, provider, logger, cfg, EarningsConfig{Per: 1000, Rate: 0.1}, nil, nil, nil)
+
+	watch := models.Watch{ID: 4, Kind: models.WatchKindSound, Value: "7000000000000000001"}
+
+	gomock.InOrder(
+		repo.EXPECT().
+			ListWatchesForSync(gomock.Any(), cfg.MinSyncAge, cfg.BatchSize).
+			Return([]models.Watch{watch}, nil),
+		repo.EXPECT().
+			ListWatchesForSync(gomock.Any(), cfg.MinSyncAge, cfg.BatchSize).
+			Return(nil, nil),
+	)
+
+	provider.EXPECT().
+		ListMusicPosts(gomock.Any(), watch.Value, watch.TrackFrom, gomock.Any()).
+		DoAndReturn(feedPages(t, [][]models.CreatorPost{{}}, 1))
+
+	repo.EXPECT().MarkWatchSynced(gomock.Any(), watch.ID).Return(nil)
+
+	if err := s.syncWatches(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+// feedPages serves pages to the callback of a feed listing until it stops, and checks it stopped after want pages
+func feedPages(t *testing.T, pages [][]models.CreatorPost, want int) func(context.Context, string, time.Time, func([]models.CreatorPost) (bool, error)) error {
+	return func(_ context.Context, _ string, _ time.Time, fn func([]models.CreatorPost) (bool, error)) error {
+		served := 0
+		for _, page := range pages {
+			served++
+			more, err := fn(page)
+			if err != nil {
+				return err
+			}
+			if !more {
+				break
+			}
+		}
+
+		if served != want {
+			t.Errorf("expected the listing to stop after %d pages, it read %d", want, served)
+		}
+		return nil
+	}
+}
+
+func TestWatchService_syncWatches_StopsAtFirstLinkedPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockWatchRepository(ctrl)
+	provider := mocks.NewMockWatchPostsProvider(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+
+	logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := DiscoveryConfig{Interval: time.Second, BatchSize: 10, MinSyncAge: time.Minute}
+
+	s := NewWatchService(repo, provider, logger, cfg, EarningsConfig{Per: 1000, Rate: 0.1}, nil, nil, nil)
+
+	start := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	watch := models.Watch{ID: 5, Kind: models.WatchKindHashtag, Value: "brandtag", TrackFrom: start}
+	post := func(id string) models.CreatorPost {
+		return models.CreatorPost{TikTokID: id, Username: "someone", PostedAt: start.Add(time.Hour)}
+	}
+
+	gomock.InOrder(
+		repo.EXPECT().
+			ListWatchesForSync(gomock.Any(), cfg.MinSyncAge, cfg.BatchSize).
+			Return([]models.Watch{watch}, nil),
+		repo.EXPECT().
+			ListWatchesForSync(gomock.Any(), cfg.MinSyncAge, cfg.BatchSize).
+			Return(nil, nil),
+	)
+
+	// a page linking something new goes on, one without a matching post too, the first one all linked ends the pass
+	provider.EXPECT().
+		ListHashtagPosts(gomock.Any(), watch.Value, start, gomock.Any()).
+		DoAndReturn(feedPages(t, [][]models.CreatorPost{
+			{post("1"), post("2")},
+			{{TikTokID: "old", PostedAt: start.Add(-time.Hour)}},
+			{post("3"), post("4")},
+			{post("5")},
+		}, 3))
+
+	for i, id := range []string{"1", "2", "3", "4"} {
+		videoID := int64(i + 1)
+		repo.EXPECT().FindVideoByTikTokID(gomock.Any(), id).Return(&models.Video{ID: videoID, TikTokID: id}, nil)
+		repo.EXPECT().LinkWatchVideo(gomock.Any(), watch.ID, videoID).Return(id == "2", nil)
+	}
+
+	repo.EXPECT().MarkWatchSynced(gomock.Any(), watch.ID).Return(nil)
+
+	if err := s.syncWatches(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
@@ -0,0 +1,297 @@
+//go:generate mockgen -destination=../mocks/webhook_mocks.go -package=mocks ttanalytic/internal/service WebhookRepository
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"ttanalytic/internal/backoff"
+	"ttanalytic/internal/logging"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/tenant"
+	"ttanalytic/internal/webhook"
+)
+
+const maxDeliveryErrorBody = 256
+
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, input models.CreateWebhookInput) (*models.Webhook, error)
+	GetWebhook(ctx context.Context, webhookID int64) (*models.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID int64) error
+	ListWebhookDeliveries(ctx context.Context, webhookID int64, limit int) ([]models.WebhookDelivery, error)
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	MarkDeliverySucceeded(ctx context.Context, deliveryID int64, statusCode int) error
+	MarkDeliveryFailed(ctx context.Context, deliveryID int64, statusCode int, errText string, nextAttemptAt *time.Time) error
+}
+
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type WebhookConfig struct {
+	Interval      time.Duration
+	BatchSize     int
+	MaxAttempts   int
+	RetryDelay    time.Duration // base delay, doubled on every attempt
+	MaxRetryDelay time.Duration
+	Timeout       time.Duration // per delivery, also the claim lease
+}
+
+type WebhookService struct {
+	repo       WebhookRepository
+	client     HTTPDoer
+	logger     Logger
+	cfg        WebhookConfig
+	transactor Transactor
+	audit      AuditRecorder
+
+	// seams for tests
+	now    func() time.Time
+	jitter func(d time.Duration) time.Duration
+}
+
+func NewWebhookService(repo WebhookRepository, client HTTPDoer, logger Logger, cfg WebhookConfig, transactor Transactor, audit AuditRecorder) *WebhookService {
+	return &WebhookService{
+		repo:       repo,
+		client:     client,
+		logger:     logger,
+		cfg:        cfg,
+		transactor: transactor,
+		audit:      auditOrNoop(audit),
+		now:        time.Now,
+		jitter:     backoff.HalfJitter,
+	}
+}
+
+// CreateWebhook subscribes a url; the signing secret is only returned here
+func (s *WebhookService) CreateWebhook(ctx context.Context, req models.CreateWebhookRequest) (models.WebhookResponse, error) {
+	secret, err := webhook.GenerateSecret()
+	if err != nil {
+		return models.WebhookResponse{}, err
+	}
+
+	var resp models.WebhookResponse
+
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		w, err := s.repo.CreateWebhook(txCtx, models.CreateWebhookInput{
+			URL:    req.URL,
+			Secret: secret,
+			Events: req.Events,
+		})
+		if err != nil {
+			return err
+		}
+
+		resp = buildWebhookResponse(*w)
+
+		return recordChange(txCtx, s.audit, models.AuditWebhookCreated, models.AuditTargetWebhook, w.ID, nil, resp)
+	})
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("CreateWebhook: repo error: %v", err)
+		return models.WebhookResponse{}, err
+	}
+
+	resp.Secret = secret
+
+	return resp, nil
+}
+
+func (s *WebhookService) ListWebhooks(ctx context.Context) ([]models.WebhookResponse, error) {
+	hooks, err := s.repo.ListWebhooks(ctx)
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("ListWebhooks: repo error: %v", err)
+		return nil, err
+	}
+
+	resp := make([]models.WebhookResponse, 0, len(hooks))
+	for _, w := range hooks {
+		resp = append(resp, buildWebhookResponse(w))
+	}
+
+	return resp, nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, webhookID int64) error {
+	err := s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		w, err := s.repo.GetWebhook(txCtx, webhookID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.repo.DeleteWebhook(txCtx, webhookID); err != nil {
+			return err
+		}
+
+		return recordChange(txCtx, s.audit, models.AuditWebhookDeleted, models.AuditTargetWebhook, webhookID, buildWebhookResponse(*w), nil)
+	})
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("DeleteWebhook: webhook %d: %v", webhookID, err)
+		return err
+	}
+
+	return nil
+}
+
+// ListDeliveries returns the delivery log; ErrNotFound if the webhook is not in the workspace
+func (s *WebhookService) ListDeliveries(ctx context.Context, webhookID int64, limit int) ([]models.WebhookDeliveryResponse, error) {
+	if _, err := s.repo.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.repo.ListWebhookDeliveries(ctx, webhookID, limit)
+	if err != nil {
+		logging.From(ctx, s.logger).Errorf("ListDeliveries: webhook %d: %v", webhookID, err)
+		return nil, err
+	}
+
+	resp := make([]models.WebhookDeliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		resp = append(resp, buildDeliveryResponse(d))
+	}
+
+	return resp, nil
+}
+
+// Run periodically delivers due events from the outbox
+func (s *WebhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Infof("Webhooks: dispatcher shutdown")
+			return
+		case <-ticker.C:
+			if err := s.dispatch(ctx); err != nil {
+				s.logger.Errorf("Webhooks: dispatch error: %v", err)
+			}
+		}
+	}
+}
+
+// dispatch drains due deliveries batch by batch
+func (s *WebhookService) dispatch(ctx context.Context) error {
+	for {
+		deliveries, err := s.repo.ClaimDueDeliveries(ctx, s.cfg.BatchSize, s.cfg.Timeout)
+		if err != nil {
+			return fmt.Errorf("claim due deliveries: %w", err)
+		}
+
+		for _, d := range deliveries {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			s.deliver(tenant.NewContext(ctx, d.WorkspaceID), d)
+		}
+
+		if len(deliveries) < s.cfg.BatchSize {
+			return nil
+		}
+	}
+}
+
+// deliver POSTs one signed payload and records the outcome
+func (s *WebhookService) deliver(ctx context.Context, d models.WebhookDelivery) {
+	status, err := s.post(ctx, d)
+	if err == nil {
+		if err := s.repo.MarkDeliverySucceeded(ctx, d.ID, status); err != nil {
+			s.logger.Errorf("webhooks: mark delivery %d delivered: %v", d.ID, err)
+		}
+		return
+	}
+
+	var next *time.Time
+	if d.Attempts < s.cfg.MaxAttempts {
+		at := s.now().Add(s.retryDelay(d.Attempts))
+		next = &at
+	}
+
+	s.logger.Warnf("webhooks: delivery %d (%s) to webhook %d attempt %d failed: %v",
+		d.ID, d.EventType, d.WebhookID, d.Attempts, err)
+
+	if err := s.repo.MarkDeliveryFailed(ctx, d.ID, status, err.Error(), next); err != nil {
+		s.logger.Errorf("webhooks: mark delivery %d failed: %v", d.ID, err)
+	}
+}
+
+// post returns the response status, an error for anything but 2xx
+func (s *WebhookService) post(ctx context.Context, d models.WebhookDelivery) (int, error) {
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ttanalytic-webhooks/1")
+	req.Header.Set(webhook.HeaderEvent, d.EventType)
+	req.Header.Set(webhook.HeaderEventID, d.EventID)
+	req.Header.Set(webhook.HeaderDelivery, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(d.Secret, s.now(), d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("send: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxDeliveryErrorBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver returned %d: %s", resp.StatusCode, body)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// retryDelay doubles the base delay per attempt, capped and jittered
+func (s *WebhookService) retryDelay(attempt int) time.Duration {
+	return s.jitter(backoff.Exponential(s.cfg.RetryDelay, s.cfg.MaxRetryDelay, attempt))
+}
+
+func buildWebhookResponse(w models.Webhook) models.WebhookResponse {
+	return models.WebhookResponse{
+		WebhookID: w.ID,
+		URL:       w.URL,
+		Events:    w.Events,
+		CreatedAt: w.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func buildDeliveryResponse(d models.WebhookDelivery) models.WebhookDeliveryResponse {
+	resp := models.WebhookDeliveryResponse{
+		DeliveryID:     d.ID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt.UTC().Format(time.RFC3339),
+	}
+
+	if d.NextAttemptAt != nil && d.Status == models.DeliveryPending {
+		next := d.NextAttemptAt.UTC().Format(time.RFC3339)
+		resp.NextAttemptAt = &next
+	}
+
+	if d.DeliveredAt != nil {
+		delivered := d.DeliveredAt.UTC().Format(time.RFC3339)
+		resp.DeliveredAt = &delivered
+	}
+
+	return resp
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"ttanalytic/internal/mocks"
+	"ttanalytic/internal/models"
+	"ttanalytic/internal/webhook"
+
+	"github.com/golang/mock/gomock"
+)
+
+func newTestWebhookService(t *testing.T, repo WebhookRepository, now time.Time) *WebhookService {
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+	logger.EXPECT().Warnf(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	s := NewWebhookService(repo, http.DefaultClient, logger, WebhookConfig{
+		BatchSize:     10,
+		MaxAttempts:   3,
+		RetryDelay:    time.Minute,
+		MaxRetryDelay: time.Hour,
+		Timeout:       5 * time.Second,
+	}, nil, nil)
+	s.now = func() time.Time { return now }
+	s.jitter = func(d time.Duration) time.Duration { return d }
+
+	return s
+}
+
+func TestWebhookService_dispatch_SignsAndMarksDelivered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	secret := "whsec_test"
+	payload := []byte(`{"type":"video.tracked"}`)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := webhook.Verify(secret, r.Header.Get(webhook.HeaderSignature), body, time.Minute, now); err != nil {
+			t.Errorf("receiver: %v", err)
+		}
+		if got := r.Header.Get(webhook.HeaderEvent); got != models.EventVideoTracked {
+			t.Errorf("receiver: event header %q", got)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	repo := mocks.NewMockWebhookRepository(ctrl)
+	s := newTestWebhookService(t, repo, now)
+
+	repo.EXPECT().ClaimDueDeliveries(gomock.Any(), 10, 5*time.Second).Return([]models.WebhookDelivery{{
+		ID:          7,
+		WorkspaceID: 1,
+		EventID:     "evt_1",
+		EventType:   models.EventVideoTracked,
+		Payload:     payload,
+		Attempts:    1,
+		URL:         receiver.URL,
+		Secret:      secret,
+	}}, nil)
+	repo.EXPECT().MarkDeliverySucceeded(gomock.Any(), int64(7), http.StatusNoContent).Return(nil)
+
+	if err := s.dispatch(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestWebhookService_dispatch_RetriesWithBackoffThenGivesUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	repo := mocks.NewMockWebhookRepository(ctrl)
+	s := newTestWebhookService(t, repo, now)
+
+	delivery := func(id int64, attempts int) models.WebhookDelivery {
+		return models.WebhookDelivery{ID: id, WorkspaceID: 1, Payload: []byte(`{}`), Attempts: attempts, URL: receiver.URL}
+	}
+
+	repo.EXPECT().ClaimDueDeliveries(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return([]models.WebhookDelivery{delivery(1, 2), delivery(2, 3)}, nil)
+
+	// attempt 2 of 3 is rescheduled after 2x the base delay
+	repo.EXPECT().MarkDeliveryFailed(gomock.Any(), int64(1), http.StatusInternalServerError, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, _ int, _ string, next *time.Time) error {
+			if next == nil || !next.Equal(now.Add(2*time.Minute)) {
+				t.Errorf("expected retry at %v, got %v", now.Add(2*time.Minute), next)
+			}
+			return nil
+		})
+
+	// the last attempt marks the delivery failed for good
+	repo.EXPECT().MarkDeliveryFailed(gomock.Any(), int64(2), http.StatusInternalServerError, gomock.Any(), nil).Return(nil)
+
+	if err := s.dispatch(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
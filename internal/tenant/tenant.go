@@ -0,0 +1,30 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"ttanalytic/internal/models"
+)
+
+type ctxKey struct{}
+
+// NewContext scopes every repository call made with ctx to the workspace
+func NewContext(ctx context.Context, workspaceID int64) context.Context {
+	return context.WithValue(ctx, ctxKey{}, workspaceID)
+}
+
+// FromContext returns the workspace ctx is scoped to, if any
+func FromContext(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(ctxKey{}).(int64)
+	return id, ok && id > 0
+}
+
+// MustFromContext fails closed: a query without a workspace is never run unscoped
+func MustFromContext(ctx context.Context) (int64, error) {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return 0, fmt.Errorf("no workspace in context: %w", models.ErrForbidden)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,136 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	return rec
+}
+
+func TestLogFields(t *testing.T) {
+	if fields := LogFields(context.Background()); fields != nil {
+		t.Fatalf("no span: got %v", fields)
+	}
+
+	recordSpans(t)
+	ctx, span := Start(context.Background(), "op")
+	defer span.End()
+
+	fields := LogFields(ctx)
+	if len(fields) != 4 || fields[0] != "trace_id" || fields[2] != "span_id" {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+	if fields[1] != span.SpanContext().TraceID().String() {
+		t.Fatalf("trace_id = %v, want %s", fields[1], span.SpanContext().TraceID())
+	}
+}
+
+func TestEnd_CanceledIsNotAnError(t *testing.T) {
+	rec := recordSpans(t)
+
+	_, failed := Start(context.Background(), "failed")
+	End(failed, errors.New("boom"))
+	_, canceled := Start(context.Background(), "canceled")
+	End(canceled, context.Canceled)
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	if spans[0].Status().Code != codes.Error {
+		t.Fatalf("failed span status = %v, want Error", spans[0].Status().Code)
+	}
+	if spans[1].Status().Code != codes.Unset {
+		t.Fatalf("canceled span status = %v, want Unset", spans[1].Status().Code)
+	}
+}
+
+func TestQueryTracer(t *testing.T) {
+	rec := recordSpans(t)
+
+	parentCtx, parent := Start(context.Background(), "parent")
+
+	var tracer QueryTracer
+	ctx := tracer.TraceQueryStart(parentCtx, nil, pgx.TraceQueryStartData{SQL: "\n\tselect id from videos where id = $1"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+	parent.End()
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+
+	query := spans[0]
+	if query.Name() != "db SELECT" {
+		t.Fatalf("span name = %q, want %q", query.Name(), "db SELECT")
+	}
+	if query.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatal("query span is not a child of the caller span")
+	}
+
+	attrs := map[string]any{}
+	for _, kv := range query.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	if attrs["db.operation.name"] != "SELECT" || attrs["db.rows_affected"] != int64(1) {
+		t.Fatalf("unexpected attributes %v", attrs)
+	}
+}
+
+func TestQueryTracer_CopyFrom(t *testing.T) {
+	rec := recordSpans(t)
+
+	parentCtx, parent := Start(context.Background(), "parent")
+
+	var tracer pgx.CopyFromTracer = QueryTracer{}
+	ctx := tracer.TraceCopyFromStart(parentCtx, nil, pgx.TraceCopyFromStartData{
+		TableName:   pgx.Identifier{"video_stats"},
+		ColumnNames: []string{"video_id", "views", "earnings"},
+	})
+	tracer.TraceCopyFromEnd(ctx, nil, pgx.TraceCopyFromEndData{CommandTag: pgconn.NewCommandTag("COPY 500")})
+	parent.End()
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+
+	copySpan := spans[0]
+	if copySpan.Name() != "db COPY video_stats" {
+		t.Fatalf("span name = %q, want %q", copySpan.Name(), "db COPY video_stats")
+	}
+	if copySpan.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatal("copy span is not a child of the caller span")
+	}
+
+	attrs := map[string]any{}
+	for _, kv := range copySpan.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	if attrs["db.operation.name"] != "COPY" || attrs["db.collection.name"] != "video_stats" || attrs["db.rows_affected"] != int64(500) {
+		t.Fatalf("unexpected attributes %v", attrs)
+	}
+}
+
+func TestQueryTracer_EndWithoutStart(t *testing.T) {
+	// must not panic when the start hook did not run
+	QueryTracer{}.TraceQueryEnd(context.Background(), nil, pgx.TraceQueryEndData{})
+	QueryTracer{}.TraceCopyFromEnd(context.Background(), nil, pgx.TraceCopyFromEndData{})
+}
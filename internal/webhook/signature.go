@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// headers set on every delivery
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderEventID   = "X-Webhook-Event-Id"
+	HeaderDelivery  = "X-Webhook-Delivery"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// GenerateSecret returns a random signing secret for a new subscription
+func GenerateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// Sign returns the signature header value "t=<unix>,v1=<hex hmac-sha256>".
+// The signed message is "<unix>.<body>" so a captured payload cannot be replayed later.
+func Sign(secret string, ts time.Time, body []byte) string {
+	unix := strconv.FormatInt(ts.Unix(), 10)
+	return "t=" + unix + ",v1=" + mac(secret, unix, body)
+}
+
+// Verify checks a signature header produced by Sign; receivers should use a tolerance of a few minutes
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var unix, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			unix = v
+		case "v1":
+			sig = v
+		}
+	}
+
+	ts, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || sig == "" {
+		return fmt.Errorf("malformed header: %w", ErrInvalidSignature)
+	}
+
+	if age := now.Sub(time.Unix(ts, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("timestamp outside tolerance: %w", ErrInvalidSignature)
+	}
+
+	if !hmac.Equal([]byte(sig), []byte(mac(secret, unix, body))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func mac(secret, unix string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(unix))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
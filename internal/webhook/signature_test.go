@@ -0,0 +1,42 @@
+package webhook
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	secret := "whsec_test"
+	body := []byte(`{"type":"video.tracked"}`)
+	ts := time.Unix(1700000000, 0)
+	header := Sign(secret, ts, body)
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  string
+		body    []byte
+		now     time.Time
+		wantErr bool
+	}{
+		{name: "valid", secret: secret, header: header, body: body, now: ts.Add(time.Minute)},
+		{name: "wrong secret", secret: "whsec_other", header: header, body: body, now: ts, wantErr: true},
+		{name: "tampered body", secret: secret, header: header, body: []byte(`{"type":"video.error"}`), now: ts, wantErr: true},
+		{name: "replayed later", secret: secret, header: header, body: body, now: ts.Add(time.Hour), wantErr: true},
+		{name: "malformed header", secret: secret, header: "v1=abc", body: body, now: ts, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.header, tt.body, 5*time.Minute, tt.now)
+
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSignature) {
+				t.Fatalf("expected ErrInvalidSignature, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_videos_creator_id;
+
+ALTER TABLE videos
+    DROP COLUMN IF EXISTS creator_id,
+    DROP COLUMN IF EXISTS posted_at;
+
+DROP TABLE IF EXISTS creators CASCADE;
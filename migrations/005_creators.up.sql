@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS creators (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL UNIQUE,
+    track_from TIMESTAMPTZ NOT NULL,
+    tracking_status video_tracking_status NOT NULL DEFAULT 'active',
+    last_synced_at TIMESTAMPTZ,
+    last_error TEXT,
+    last_error_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE videos
+    ADD COLUMN creator_id INTEGER REFERENCES creators(id) ON DELETE SET NULL,
+    ADD COLUMN posted_at  TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_videos_creator_id
+    ON videos(creator_id);
+
+CREATE INDEX IF NOT EXISTS idx_creators_last_synced_at
+    ON creators(last_synced_at NULLS FIRST);
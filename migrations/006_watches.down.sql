@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS watch_videos CASCADE;
+DROP TABLE IF EXISTS watches CASCADE;
+DROP TYPE IF EXISTS watch_kind;
@@ -0,0 +1,30 @@
+CREATE TYPE watch_kind AS ENUM ('hashtag', 'sound');
+
+CREATE TABLE IF NOT EXISTS watches (
+    id SERIAL PRIMARY KEY,
+    kind watch_kind NOT NULL,
+    value TEXT NOT NULL,
+    track_from TIMESTAMPTZ NOT NULL,
+    allowed_creators TEXT[] NOT NULL DEFAULT '{}',
+    tracking_status video_tracking_status NOT NULL DEFAULT 'active',
+    last_synced_at TIMESTAMPTZ,
+    last_error TEXT,
+    last_error_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE (kind, value)
+);
+
+-- a video can match several hashtags and a sound at once
+CREATE TABLE IF NOT EXISTS watch_videos (
+    watch_id INTEGER NOT NULL REFERENCES watches(id) ON DELETE CASCADE,
+    video_id INTEGER NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (watch_id, video_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_watch_videos_video_id
+    ON watch_videos(video_id);
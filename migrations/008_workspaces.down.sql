@@ -0,0 +1,17 @@
+-- fails if two workspaces track the same video, account or watch
+ALTER TABLE watches  DROP CONSTRAINT watches_workspace_kind_value_key;
+ALTER TABLE creators DROP CONSTRAINT creators_workspace_username_key;
+ALTER TABLE videos   DROP CONSTRAINT videos_workspace_tiktok_id_key;
+
+ALTER TABLE watches  ADD CONSTRAINT watches_kind_value_key UNIQUE (kind, value);
+ALTER TABLE creators ADD CONSTRAINT creators_username_key UNIQUE (username);
+ALTER TABLE videos   ADD CONSTRAINT videos_tiktok_id_key UNIQUE (tiktok_id);
+
+DROP INDEX IF EXISTS idx_api_keys_workspace_id;
+
+ALTER TABLE api_keys DROP COLUMN workspace_id;
+ALTER TABLE watches  DROP COLUMN workspace_id;
+ALTER TABLE creators DROP COLUMN workspace_id;
+ALTER TABLE videos   DROP COLUMN workspace_id;
+
+DROP TABLE IF EXISTS workspaces;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS workspaces (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- existing single-tenant data moves into the default workspace
+INSERT INTO workspaces (id, name) VALUES (1, 'default');
+SELECT setval(pg_get_serial_sequence('workspaces', 'id'), 1);
+
+ALTER TABLE videos   ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id);
+ALTER TABLE creators ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id);
+ALTER TABLE watches  ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id);
+ALTER TABLE api_keys ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id);
+
+-- new rows must name their workspace explicitly
+ALTER TABLE videos   ALTER COLUMN workspace_id DROP DEFAULT;
+ALTER TABLE creators ALTER COLUMN workspace_id DROP DEFAULT;
+ALTER TABLE watches  ALTER COLUMN workspace_id DROP DEFAULT;
+ALTER TABLE api_keys ALTER COLUMN workspace_id DROP DEFAULT;
+
+-- uniqueness is per workspace: two clients may track the same video or account
+ALTER TABLE videos   DROP CONSTRAINT videos_tiktok_id_key;
+ALTER TABLE creators DROP CONSTRAINT creators_username_key;
+ALTER TABLE watches  DROP CONSTRAINT watches_kind_value_key;
+
+ALTER TABLE videos   ADD CONSTRAINT videos_workspace_tiktok_id_key UNIQUE (workspace_id, tiktok_id);
+ALTER TABLE creators ADD CONSTRAINT creators_workspace_username_key UNIQUE (workspace_id, username);
+ALTER TABLE watches  ADD CONSTRAINT watches_workspace_kind_value_key UNIQUE (workspace_id, kind, value);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_workspace_id
+    ON api_keys(workspace_id);
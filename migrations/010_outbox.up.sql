@@ -0,0 +1,23 @@
+-- transactional outbox: every domain event, written in the transaction of the state change
+-- and published by the relay; event_id is the idempotency key
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(id),
+    event_id TEXT NOT NULL UNIQUE,
+    event_type TEXT NOT NULL,
+    aggregate_type TEXT NOT NULL,
+    aggregate_id BIGINT NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    published_at TIMESTAMPTZ,
+    -- set once the relay gave up after outbox.max_attempts; the event stays here for inspection
+    failed_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_unpublished
+    ON outbox_events(next_attempt_at, id)
+    WHERE published_at IS NULL AND failed_at IS NULL;
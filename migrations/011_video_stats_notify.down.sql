@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS video_stats_notify ON video_stats;
+DROP FUNCTION IF EXISTS notify_video_stats();
@@ -0,0 +1,20 @@
+-- every appended snapshot is broadcast on channel video_stats (delivered on commit)
+-- and fanned out to SSE clients by each replica
+CREATE OR REPLACE FUNCTION notify_video_stats() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('video_stats', json_build_object(
+        'id', NEW.id,
+        'video_id', NEW.video_id,
+        'workspace_id', (SELECT workspace_id FROM videos WHERE id = NEW.video_id),
+        'views', NEW.views,
+        'earnings', NEW.earnings,
+        'captured_at', NEW.captured_at
+    )::text);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER video_stats_notify
+    AFTER INSERT ON video_stats
+    FOR EACH ROW EXECUTE FUNCTION notify_video_stats();
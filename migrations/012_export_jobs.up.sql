@@ -0,0 +1,32 @@
+CREATE TYPE export_status AS ENUM ('pending', 'running', 'done', 'failed', 'expired');
+
+CREATE TABLE IF NOT EXISTS export_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(id),
+    video_id INTEGER REFERENCES videos(id) ON DELETE CASCADE,
+    range_from TIMESTAMPTZ NOT NULL,
+    range_to TIMESTAMPTZ NOT NULL,
+    format TEXT NOT NULL,
+    status export_status NOT NULL DEFAULT 'pending',
+    file_path TEXT,
+    rows BIGINT NOT NULL DEFAULT 0,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    -- a running job belongs to claimed_by until lease_until, which its worker keeps pushing back while it
+    -- streams; a job whose worker stopped renewing is taken over, and failed once it was claimed max_attempts times
+    claimed_by TEXT,
+    lease_until TIMESTAMPTZ,
+    attempts INTEGER NOT NULL DEFAULT 0,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_export_jobs_workspace_id
+    ON export_jobs(workspace_id);
+
+CREATE INDEX IF NOT EXISTS idx_export_jobs_queue
+    ON export_jobs(status, id)
+    WHERE status IN ('pending', 'running');
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS audit_log;
+
+DROP INDEX IF EXISTS idx_videos_deleted_at;
+
+-- deleted videos would come back without the column, and clash with the ones tracked again
+DELETE FROM videos WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS videos_workspace_tiktok_id_key;
+ALTER TABLE videos ADD CONSTRAINT videos_workspace_tiktok_id_key UNIQUE (workspace_id, tiktok_id);
+
+ALTER TABLE videos DROP COLUMN IF EXISTS deleted_at;
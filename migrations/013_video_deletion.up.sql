@@ -0,0 +1,30 @@
+-- soft delete: the row is hidden from every query and hard-deleted by the purge job after the retention window
+ALTER TABLE videos ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_videos_deleted_at
+    ON videos(deleted_at)
+    WHERE deleted_at IS NOT NULL;
+
+-- a video deleted but not purged yet must not keep it from being tracked again
+ALTER TABLE videos DROP CONSTRAINT videos_workspace_tiktok_id_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS videos_workspace_tiktok_id_key
+    ON videos(workspace_id, tiktok_id)
+    WHERE deleted_at IS NULL;
+
+-- who removed what; entries outlive the data they describe and never hold the removed personal data
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(id),
+    actor TEXT NOT NULL,
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL,
+    target_id BIGINT,
+    request_id TEXT,
+    details JSONB NOT NULL DEFAULT '{}',
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_workspace_id
+    ON audit_log(workspace_id, id);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_audit_log_actor;
+
+DROP INDEX IF EXISTS idx_audit_log_target;
+
+ALTER TABLE audit_log
+    DROP COLUMN IF EXISTS after,
+    DROP COLUMN IF EXISTS before;
@@ -0,0 +1,10 @@
+-- every user-initiated change: the target as the API showed it before and after
+ALTER TABLE audit_log
+    ADD COLUMN before JSONB,
+    ADD COLUMN after JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_target
+    ON audit_log(workspace_id, target_type, target_id, id);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor
+    ON audit_log(workspace_id, actor, id);
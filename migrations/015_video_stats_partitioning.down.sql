@@ -0,0 +1,37 @@
+-- fails while archived months are left in video_stats_archive: attach them back or drop them first,
+-- and once video ids outgrew INTEGER
+ALTER TABLE video_stats RENAME TO video_stats_partitioned;
+ALTER TABLE video_stats_partitioned RENAME CONSTRAINT video_stats_pkey TO video_stats_partitioned_pkey;
+ALTER INDEX idx_video_stats_video_id_captured_at RENAME TO idx_video_stats_partitioned_video_id_captured_at;
+ALTER SEQUENCE video_stats_id_seq RENAME TO video_stats_partitioned_id_seq;
+DROP TRIGGER IF EXISTS video_stats_notify ON video_stats_partitioned;
+
+CREATE TABLE video_stats (
+    id SERIAL PRIMARY KEY,
+    video_id INTEGER NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    captured_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    views BIGINT NOT NULL,
+    earnings NUMERIC(12, 4) NOT NULL
+);
+
+INSERT INTO video_stats (id, video_id, captured_at, views, earnings)
+SELECT id, video_id, captured_at, views, earnings
+FROM video_stats_partitioned;
+
+SELECT setval(pg_get_serial_sequence('video_stats', 'id'), COALESCE((SELECT MAX(id) FROM video_stats), 0) + 1, false);
+
+CREATE INDEX IF NOT EXISTS idx_video_stats_video_id_captured_at
+    ON video_stats(video_id, captured_at);
+
+DROP SCHEMA IF EXISTS video_stats_archive;
+DROP TABLE video_stats_partitioned CASCADE;
+DROP FUNCTION IF EXISTS create_video_stats_partition(TIMESTAMPTZ);
+
+ALTER TABLE export_jobs ALTER COLUMN video_id TYPE INTEGER;
+ALTER TABLE watch_videos ALTER COLUMN video_id TYPE INTEGER;
+ALTER SEQUENCE videos_id_seq AS INTEGER;
+ALTER TABLE videos ALTER COLUMN id TYPE INTEGER;
+
+CREATE TRIGGER video_stats_notify
+    AFTER INSERT ON video_stats
+    FOR EACH ROW EXECUTE FUNCTION notify_video_stats();
@@ -0,0 +1,79 @@
+-- video_stats is range partitioned by month of captured_at (UTC) so history reads only touch the months they ask
+-- for and old months can be detached whole. Partitions are named video_stats_YYYY_MM; the default partition only
+-- catches rows of a month the maintenance job has not created yet, create_video_stats_partition moves them out.
+ALTER TABLE video_stats RENAME TO video_stats_legacy;
+ALTER TABLE video_stats_legacy RENAME CONSTRAINT video_stats_pkey TO video_stats_legacy_pkey;
+ALTER INDEX idx_video_stats_video_id_captured_at RENAME TO idx_video_stats_legacy_video_id_captured_at;
+ALTER SEQUENCE video_stats_id_seq RENAME TO video_stats_legacy_id_seq;
+DROP TRIGGER IF EXISTS video_stats_notify ON video_stats_legacy;
+
+CREATE TABLE video_stats (
+    id BIGSERIAL,
+    video_id BIGINT NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    captured_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    views BIGINT NOT NULL,
+    earnings NUMERIC(12, 4) NOT NULL,
+
+    -- the partition key is part of every unique constraint of a partitioned table
+    PRIMARY KEY (id, captured_at)
+) PARTITION BY RANGE (captured_at);
+
+CREATE INDEX IF NOT EXISTS idx_video_stats_video_id_captured_at
+    ON video_stats(video_id, captured_at);
+
+CREATE TABLE video_stats_default PARTITION OF video_stats DEFAULT;
+
+-- detached months are moved here, out of every query, until they are dumped and dropped
+CREATE SCHEMA IF NOT EXISTS video_stats_archive;
+
+-- create_video_stats_partition creates the partition of the month holding month_start and returns its name,
+-- NULL when it exists already
+CREATE OR REPLACE FUNCTION create_video_stats_partition(month_start TIMESTAMPTZ) RETURNS TEXT AS $$
+DECLARE
+    lower_bound    TIMESTAMPTZ := date_trunc('month', month_start AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+    upper_bound    TIMESTAMPTZ := (date_trunc('month', month_start AT TIME ZONE 'UTC') + INTERVAL '1 month') AT TIME ZONE 'UTC';
+    partition_name TEXT := 'video_stats_' || to_char(month_start AT TIME ZONE 'UTC', 'YYYY_MM');
+BEGIN
+    IF to_regclass('public.' || partition_name) IS NOT NULL THEN
+        RETURN NULL;
+    END IF;
+
+    EXECUTE format('CREATE TABLE public.%I (LIKE video_stats INCLUDING DEFAULTS)', partition_name);
+    EXECUTE format(
+        'WITH moved AS (DELETE FROM video_stats_default WHERE captured_at >= %L AND captured_at < %L RETURNING *)
+         INSERT INTO public.%I SELECT * FROM moved',
+        lower_bound, upper_bound, partition_name);
+    EXECUTE format('ALTER TABLE video_stats ATTACH PARTITION public.%I FOR VALUES FROM (%L) TO (%L)',
+        partition_name, lower_bound, upper_bound);
+
+    RETURN partition_name;
+END;
+$$ LANGUAGE plpgsql;
+
+-- every month with data and the next three are created before the rows are copied
+SELECT create_video_stats_partition(month AT TIME ZONE 'UTC')
+FROM generate_series(
+    date_trunc('month', COALESCE((SELECT MIN(captured_at) FROM video_stats_legacy), NOW()) AT TIME ZONE 'UTC'),
+    date_trunc('month', GREATEST(COALESCE((SELECT MAX(captured_at) FROM video_stats_legacy), NOW()), NOW()) AT TIME ZONE 'UTC')
+        + INTERVAL '3 months',
+    INTERVAL '1 month'
+) AS month;
+
+INSERT INTO video_stats (id, video_id, captured_at, views, earnings)
+SELECT id, video_id, captured_at, views, earnings
+FROM video_stats_legacy;
+
+SELECT setval(pg_get_serial_sequence('video_stats', 'id'), COALESCE((SELECT MAX(id) FROM video_stats), 0) + 1, false);
+
+DROP TABLE video_stats_legacy;
+
+-- video ids are BIGINT wherever they are stored, like the keys of the snapshots
+ALTER TABLE videos ALTER COLUMN id TYPE BIGINT;
+ALTER SEQUENCE videos_id_seq AS BIGINT;
+ALTER TABLE watch_videos ALTER COLUMN video_id TYPE BIGINT;
+ALTER TABLE export_jobs ALTER COLUMN video_id TYPE BIGINT;
+
+-- the trigger is created after the copy, existing rows are not broadcast again
+CREATE TRIGGER video_stats_notify
+    AFTER INSERT ON video_stats
+    FOR EACH ROW EXECUTE FUNCTION notify_video_stats();
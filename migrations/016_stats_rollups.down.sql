@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS stats_rollup_state;
+DROP TABLE IF EXISTS video_stats_weekly;
+DROP TABLE IF EXISTS video_stats_daily;
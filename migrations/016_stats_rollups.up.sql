@@ -0,0 +1,37 @@
+-- rollups of video_stats: one row per video and UTC day / ISO week holding the last snapshot of the bucket,
+-- views and earnings being running totals. Weekly rows are built from daily ones so they outlive raw retention.
+CREATE TABLE IF NOT EXISTS video_stats_daily (
+    video_id BIGINT NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    bucket TIMESTAMPTZ NOT NULL,
+    captured_at TIMESTAMPTZ NOT NULL,
+    views BIGINT NOT NULL,
+    earnings NUMERIC(12, 4) NOT NULL,
+    samples INTEGER NOT NULL,
+
+    PRIMARY KEY (video_id, bucket)
+);
+
+CREATE TABLE IF NOT EXISTS video_stats_weekly (
+    video_id BIGINT NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    bucket TIMESTAMPTZ NOT NULL,
+    captured_at TIMESTAMPTZ NOT NULL,
+    views BIGINT NOT NULL,
+    earnings NUMERIC(12, 4) NOT NULL,
+    samples INTEGER NOT NULL,
+
+    PRIMARY KEY (video_id, bucket)
+);
+
+-- single row. Buckets before rolled_up_to are complete; rows of video_stats before raw_from and of
+-- video_stats_daily before daily_from have been removed by the retention policy (NULL: nothing removed)
+CREATE TABLE IF NOT EXISTS stats_rollup_state (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    rolled_up_to TIMESTAMPTZ NOT NULL,
+    raw_from TIMESTAMPTZ,
+    daily_from TIMESTAMPTZ
+);
+
+-- the rollup job starts from the first day with data
+INSERT INTO stats_rollup_state (rolled_up_to)
+SELECT date_trunc('day', COALESCE(MIN(captured_at), NOW()) AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'
+FROM video_stats;
@@ -0,0 +1,120 @@
+// Package migrations embeds the SQL migrations so the binary knows the schema version it was built for
+// and the migrator does not depend on its working directory.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+// Migration is one NNN_name.up.sql file
+type Migration struct {
+	Version uint
+	Name    string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%03d_%s", m.Version, m.Name)
+}
+
+// List returns the up migrations of fsys by version
+func List(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Migration, 0, len(files))
+	for _, file := range files {
+		prefix, name, ok := strings.Cut(strings.TrimSuffix(file, ".up.sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: no version prefix", file)
+		}
+
+		v, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", file, err)
+		}
+
+		list = append(list, Migration{Version: uint(v), Name: name})
+	}
+
+	slices.SortFunc(list, func(a, b Migration) int { return int(a.Version) - int(b.Version) })
+
+	return list, nil
+}
+
+// Latest returns the version of the newest embedded up migration
+func Latest() (uint, error) {
+	list, err := List(FS)
+	if err != nil {
+		return 0, err
+	}
+	if len(list) == 0 {
+		return 0, fmt.Errorf("no migrations embedded")
+	}
+
+	return list[len(list)-1].Version, nil
+}
+
+// Step is one migration run up or down
+type Step struct {
+	Migration
+	Up bool
+}
+
+func (s Step) String() string {
+	if s.Up {
+		return "up " + s.Migration.String()
+	}
+
+	return "down " + s.Migration.String()
+}
+
+// Plan lists the steps that move the schema from version current to target, in order.
+// Version 0 is the empty schema.
+func Plan(all []Migration, current, target uint) []Step {
+	var steps []Step
+
+	if target >= current {
+		for _, m := range all {
+			if m.Version > current && m.Version <= target {
+				steps = append(steps, Step{Migration: m, Up: true})
+			}
+		}
+		return steps
+	}
+
+	for i := len(all) - 1; i >= 0; i-- {
+		if m := all[i]; m.Version <= current && m.Version > target {
+			steps = append(steps, Step{Migration: m})
+		}
+	}
+
+	return steps
+}
+
+// Target returns the version reached from current after n steps, negative n going down;
+// it stops at the first and the last migration
+func Target(all []Migration, current uint, n int) uint {
+	pos := -1 // index of current in all, -1 for the empty schema
+	for i, m := range all {
+		if m.Version <= current {
+			pos = i
+		}
+	}
+
+	pos = min(max(pos+n, -1), len(all)-1)
+	if pos < 0 {
+		return 0
+	}
+
+	return all[pos].Version
+}
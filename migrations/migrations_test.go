@@ -0,0 +1,79 @@
+package migrations
+
+import (
+	"fmt"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLatest(t *testing.T) {
+	v, err := Latest()
+	if err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+
+	ups, err := fs.Glob(FS, "*.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// versions are numbered without gaps
+	if v != uint(len(ups)) {
+		t.Fatalf("latest version %d, but %d up migrations are embedded", v, len(ups))
+	}
+}
+
+func TestList(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_outbox.up.sql":      {},
+		"010_outbox.down.sql":    {},
+		"002_video_stats.up.sql": {},
+		"001_videos.up.sql":      {},
+		"README.md":              {},
+	}
+
+	list, err := List(fsys)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+
+	if got := fmt.Sprint(list); got != "[001_videos 002_video_stats 010_outbox]" {
+		t.Fatalf("got %s", got)
+	}
+
+	if _, err := List(fstest.MapFS{"videos.up.sql": {}}); err == nil {
+		t.Fatal("expected an error for a file without version")
+	}
+}
+
+func TestPlanAndTarget(t *testing.T) {
+	all := []Migration{{1, "a"}, {2, "b"}, {3, "c"}, {4, "d"}}
+
+	tests := []struct {
+		name    string
+		current uint
+		n       int
+		target  uint
+		steps   string
+	}{
+		{name: "up all from empty", current: 0, n: 10, target: 4, steps: "[up 001_a up 002_b up 003_c up 004_d]"},
+		{name: "up one", current: 2, n: 1, target: 3, steps: "[up 003_c]"},
+		{name: "down two", current: 4, n: -2, target: 2, steps: "[down 004_d down 003_c]"},
+		{name: "down past the first", current: 2, n: -5, target: 0, steps: "[down 002_b down 001_a]"},
+		{name: "nothing pending", current: 4, n: 1, target: 4, steps: "[]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := Target(all, tt.current, tt.n)
+			if target != tt.target {
+				t.Fatalf("Target = %d, want %d", target, tt.target)
+			}
+
+			if got := fmt.Sprint(Plan(all, tt.current, target)); got != tt.steps {
+				t.Fatalf("Plan = %s, want %s", got, tt.steps)
+			}
+		})
+	}
+}